@@ -2,8 +2,13 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type BybitAccount struct {
@@ -21,9 +26,17 @@ type BybitAccount struct {
 	WebhookURLExecutions          string
 	MarkEveryoneExecution         bool
 	SheetURLGoogleSheetsExecutions string
-	Platform                      string // "bybit" ou "okx"
-	Metadata                      string // JSON; OKX: {"passphrase":"..."}
-	NotificationDelaySeconds      int    // 0 = desligado; 3-20 = segundos para agrupar notificações
+	Platform                       string          // "bybit" ou "okx"
+	Metadata                       string          // JSON; OKX: {"passphrase":"..."}
+	NotificationDelaySeconds       int             // 0 = desligado; 3-20 = segundos para agrupar notificações
+	Timezone                       string          // fuso IANA (ex.: "Europe/Lisbon"); vazio = horário de Brasília
+	Notes                          string          // observações livres (responsável, estratégia, validade da key...)
+	Settings                       AccountSettings // preferências da conta (coluna settings, JSON)
+	SecretsInKeyring               bool            // API Secret/passphrase guardados no keyring do sistema; o banco guarda só a referência
+	secretErr                      error           // erro ao resolver os segredos (ex.: keyring indisponível)
+	apiKeyRef                      string          // referência env: original da API Key (vazio se o valor está no banco)
+	apiSecretRef                   string          // referência env: original do API Secret
+	metadataRef                    string          // metadata original quando o passphrase é uma referência env:
 }
 
 type AccountManager struct {
@@ -44,8 +57,8 @@ func (am *AccountManager) AddAccount(account *BybitAccount) error {
 		metadata = "{}"
 	}
 
-	query := `INSERT INTO bybit_accounts (name, api_key, api_secret, webhook_url, active, mark_everyone_order, mark_everyone_wallet, one_way_mode, webhook_url_google_sheets, sheet_url_google_sheets, webhook_url_executions, mark_everyone_execution, sheet_url_google_sheets_executions, platform, metadata, notification_delay_seconds) 
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO bybit_accounts (name, api_key, api_secret, webhook_url, active, mark_everyone_order, mark_everyone_wallet, one_way_mode, webhook_url_google_sheets, sheet_url_google_sheets, webhook_url_executions, mark_everyone_execution, sheet_url_google_sheets_executions, platform, metadata, notification_delay_seconds, timezone, notes, settings) 
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
 	markEveryoneOrder := 0
 	if account.MarkEveryoneOrder {
@@ -69,14 +82,44 @@ func (am *AccountManager) AddAccount(account *BybitAccount) error {
 	if delaySec < 0 || delaySec > 20 || (delaySec != 0 && delaySec < 3) {
 		delaySec = 0
 	}
-	_, err := am.db.GetDB().Exec(query, account.Name, account.APIKey, account.APISecret,
+	// Com keyring, o segredo só é gravado depois do INSERT (o nome no keyring usa o ID da conta);
+	// até lá o banco fica com o segredo vazio para o valor real nunca passar pelo SQLite
+	apiSecret := account.APISecret
+	dbMetadata := metadata
+	if account.SecretsInKeyring {
+		apiSecret = ""
+		if metadataPassphrase(metadata) != "" {
+			dbMetadata = "{}"
+		}
+	}
+	result, err := am.db.GetDB().Exec(query, account.Name, account.APIKey, apiSecret,
 		account.WebhookURL, active, markEveryoneOrder, markEveryoneWallet, oneWayMode,
 		account.WebhookURLGoogleSheets, account.SheetURLGoogleSheets,
 		account.WebhookURLExecutions, markEveryoneExecution, account.SheetURLGoogleSheetsExecutions,
-		platform, metadata, delaySec)
+		platform, dbMetadata, delaySec, strings.TrimSpace(account.Timezone), strings.TrimSpace(account.Notes), account.Settings.String())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	account.ID = id
+	if !account.SecretsInKeyring {
+		return nil
+	}
+
+	secretRef, metadataRef, err := storeAccountSecretsInKeyring(id, account.APISecret, metadata)
+	if err != nil {
+		am.db.GetDB().Exec("DELETE FROM bybit_accounts WHERE id = ?", id)
+		return err
+	}
+	_, err = am.db.GetDB().Exec(`UPDATE bybit_accounts SET api_secret = ?, metadata = ? WHERE id = ?`, secretRef, metadataRef, id)
 	return err
 }
 
+// RemoveAccount remove a conta de forma lógica (deleted_at): ela some das listagens e do monitoramento,
+// mas credenciais, histórico e estatísticas continuam no banco até um PurgeAccount.
 func (am *AccountManager) RemoveAccount(id int64) error {
 	// Remove também a conexão ativa se existir
 	_, err := am.db.GetDB().Exec("DELETE FROM active_connections WHERE account_id = ?", id)
@@ -84,13 +127,89 @@ func (am *AccountManager) RemoveAccount(id int64) error {
 		return err
 	}
 
-	_, err = am.db.GetDB().Exec("DELETE FROM bybit_accounts WHERE id = ?", id)
+	_, err = am.db.GetDB().Exec("UPDATE bybit_accounts SET deleted_at = CURRENT_TIMESTAMP, active = 0 WHERE id = ? AND deleted_at IS NULL", id)
+	return err
+}
+
+// RemovedAccount é uma conta removida que ainda pode ser restaurada.
+type RemovedAccount struct {
+	ID        int64
+	Name      string
+	Platform  string
+	DeletedAt time.Time
+}
+
+// ListRemovedAccounts retorna as contas removidas, da remoção mais recente para a mais antiga.
+func (am *AccountManager) ListRemovedAccounts() ([]RemovedAccount, error) {
+	rows, err := am.db.GetDB().Query(`SELECT id, name, platform, deleted_at FROM bybit_accounts WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []RemovedAccount
+	for rows.Next() {
+		var acc RemovedAccount
+		if err := rows.Scan(&acc.ID, &acc.Name, &acc.Platform, &acc.DeletedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, rows.Err()
+}
+
+// RestoreAccount desfaz a remoção. A conta volta desativada, para não iniciar monitoramento sem o usuário pedir.
+func (am *AccountManager) RestoreAccount(id int64) error {
+	var name string
+	if err := am.db.GetDB().QueryRow(`SELECT name FROM bybit_accounts WHERE id = ? AND deleted_at IS NOT NULL`, id).Scan(&name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("conta removida não encontrada")
+		}
+		return err
+	}
+	var count int
+	if err := am.db.GetDB().QueryRow(`SELECT COUNT(*) FROM bybit_accounts WHERE name = ? AND deleted_at IS NULL`, name).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("já existe uma conta ativa com o nome '%s'; renomeie-a antes de restaurar", name)
+	}
+	_, err := am.db.GetDB().Exec(`UPDATE bybit_accounts SET deleted_at = NULL WHERE id = ?`, id)
+	return err
+}
+
+// PurgeAccount apaga a conta definitivamente, com estatísticas e segredos no keyring. Só vale para contas já removidas.
+func (am *AccountManager) PurgeAccount(id int64) error {
+	var apiSecret string
+	if err := am.db.GetDB().QueryRow(`SELECT api_secret FROM bybit_accounts WHERE id = ? AND deleted_at IS NOT NULL`, id).Scan(&apiSecret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("conta removida não encontrada (remova a conta antes de apagá-la definitivamente)")
+		}
+		return err
+	}
+
+	// Remove também as estatísticas e os históricos da conta (as foreign keys não são aplicadas pelo SQLite por padrão)
+	if err := am.db.ResetAccountStats(id); err != nil {
+		return err
+	}
+	for _, table := range []string{"orders", "order_events", "executions", "positions_history", "notifications", "last_message_snapshots", "stream_checkpoints", "raw_messages", "auth_failures"} {
+		if _, err := am.db.GetDB().Exec(`DELETE FROM `+table+` WHERE account_id = ?`, id); err != nil {
+			return err
+		}
+	}
+
+	// Remove também os segredos do keyring, se a conta os usava
+	if isKeyringRef(apiSecret) {
+		deleteAccountSecretsFromKeyring(id)
+	}
+
+	_, err := am.db.GetDB().Exec("DELETE FROM bybit_accounts WHERE id = ?", id)
 	return err
 }
 
 func (am *AccountManager) ListAccounts() ([]*BybitAccount, error) {
-	query := `SELECT id, name, api_key, api_secret, webhook_url, active, mark_everyone_order, mark_everyone_wallet, one_way_mode, webhook_url_google_sheets, sheet_url_google_sheets, webhook_url_executions, mark_everyone_execution, sheet_url_google_sheets_executions, platform, metadata, notification_delay_seconds 
-	          FROM bybit_accounts ORDER BY id`
+	query := `SELECT id, name, api_key, api_secret, webhook_url, active, mark_everyone_order, mark_everyone_wallet, one_way_mode, webhook_url_google_sheets, sheet_url_google_sheets, webhook_url_executions, mark_everyone_execution, sheet_url_google_sheets_executions, platform, metadata, notification_delay_seconds, timezone, notes, settings 
+	          FROM bybit_accounts WHERE deleted_at IS NULL ORDER BY id`
 	
 	rows, err := am.db.GetDB().Query(query)
 	if err != nil {
@@ -102,11 +221,12 @@ func (am *AccountManager) ListAccounts() ([]*BybitAccount, error) {
 	for rows.Next() {
 		acc := &BybitAccount{}
 		var active, markEveryoneOrder, markEveryoneWallet, markEveryoneExecution, oneWayMode int
+		var settings string
 		err := rows.Scan(&acc.ID, &acc.Name, &acc.APIKey, &acc.APISecret,
 			&acc.WebhookURL, &active, &markEveryoneOrder, &markEveryoneWallet, &oneWayMode,
 			&acc.WebhookURLGoogleSheets, &acc.SheetURLGoogleSheets,
 			&acc.WebhookURLExecutions, &markEveryoneExecution, &acc.SheetURLGoogleSheetsExecutions,
-			&acc.Platform, &acc.Metadata, &acc.NotificationDelaySeconds)
+			&acc.Platform, &acc.Metadata, &acc.NotificationDelaySeconds, &acc.Timezone, &acc.Notes, &settings)
 		if err != nil {
 			return nil, err
 		}
@@ -118,6 +238,9 @@ func (am *AccountManager) ListAccounts() ([]*BybitAccount, error) {
 		if acc.Platform == "" {
 			acc.Platform = "bybit"
 		}
+		// settings inválido (editado à mão) não impede o uso da conta: valem os padrões
+		acc.Settings, _ = parseAccountSettings(settings)
+		resolveAccountSecrets(acc)
 		accounts = append(accounts, acc)
 	}
 
@@ -125,17 +248,18 @@ func (am *AccountManager) ListAccounts() ([]*BybitAccount, error) {
 }
 
 func (am *AccountManager) GetAccount(id int64) (*BybitAccount, error) {
-	query := `SELECT id, name, api_key, api_secret, webhook_url, active, mark_everyone_order, mark_everyone_wallet, one_way_mode, webhook_url_google_sheets, sheet_url_google_sheets, webhook_url_executions, mark_everyone_execution, sheet_url_google_sheets_executions, platform, metadata, notification_delay_seconds 
-	          FROM bybit_accounts WHERE id = ?`
+	query := `SELECT id, name, api_key, api_secret, webhook_url, active, mark_everyone_order, mark_everyone_wallet, one_way_mode, webhook_url_google_sheets, sheet_url_google_sheets, webhook_url_executions, mark_everyone_execution, sheet_url_google_sheets_executions, platform, metadata, notification_delay_seconds, timezone, notes, settings 
+	          FROM bybit_accounts WHERE id = ? AND deleted_at IS NULL`
 	
 	acc := &BybitAccount{}
 	var active, markEveryoneOrder, markEveryoneWallet, markEveryoneExecution, oneWayMode int
+	var settings string
 	err := am.db.GetDB().QueryRow(query, id).Scan(
 		&acc.ID, &acc.Name, &acc.APIKey, &acc.APISecret,
 		&acc.WebhookURL, &active, &markEveryoneOrder, &markEveryoneWallet, &oneWayMode,
 		&acc.WebhookURLGoogleSheets, &acc.SheetURLGoogleSheets,
 		&acc.WebhookURLExecutions, &markEveryoneExecution, &acc.SheetURLGoogleSheetsExecutions,
-		&acc.Platform, &acc.Metadata, &acc.NotificationDelaySeconds)
+		&acc.Platform, &acc.Metadata, &acc.NotificationDelaySeconds, &acc.Timezone, &acc.Notes, &settings)
 	
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -152,13 +276,40 @@ func (am *AccountManager) GetAccount(id int64) (*BybitAccount, error) {
 	if acc.Platform == "" {
 		acc.Platform = "bybit"
 	}
+	acc.Settings, _ = parseAccountSettings(settings)
+	resolveAccountSecrets(acc)
 	return acc, nil
 }
 
+// UpdateAccountSettings aplica update às preferências da conta e grava o resultado (leitura e escrita na mesma transação).
+func (am *AccountManager) UpdateAccountSettings(id int64, update func(AccountSettings)) error {
+	tx, err := am.db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var data string
+	if err := tx.QueryRow(`SELECT settings FROM bybit_accounts WHERE id = ? AND deleted_at IS NULL`, id).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("conta não encontrada")
+		}
+		return err
+	}
+	settings, err := parseAccountSettings(data)
+	if err != nil {
+		return err
+	}
+	update(settings)
+	if _, err := tx.Exec(`UPDATE bybit_accounts SET settings = ? WHERE id = ?`, settings.String(), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (am *AccountManager) SetConnectionActive(accountID int64, active bool) error {
 	if active {
-		query := `INSERT OR REPLACE INTO active_connections (account_id, connected, updated_at) 
-		          VALUES (?, 1, CURRENT_TIMESTAMP)`
+		query := `INSERT OR REPLACE INTO active_connections (account_id, connected, updated_at, last_heartbeat_at) 
+		          VALUES (?, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 		_, err := am.db.GetDB().Exec(query, accountID)
 		return err
 	} else {
@@ -167,6 +318,87 @@ func (am *AccountManager) SetConnectionActive(accountID int64, active bool) erro
 	}
 }
 
+// SetAccountActive ativa ou desativa a conta. Contas inativas são ignoradas por StartAllConnections e RestoreConnections.
+func (am *AccountManager) SetAccountActive(id int64, active bool) error {
+	value := 0
+	if active {
+		value = 1
+	}
+	_, err := am.db.GetDB().Exec(`UPDATE bybit_accounts SET active = ? WHERE id = ?`, value, id)
+	return err
+}
+
+// TouchConnectionHeartbeat atualiza o heartbeat das conexões monitoradas por este processo.
+func (am *AccountManager) TouchConnectionHeartbeat(accountIDs []int64) error {
+	for _, id := range accountIDs {
+		if _, err := am.db.GetDB().Exec(`UPDATE active_connections SET last_heartbeat_at = CURRENT_TIMESTAMP WHERE account_id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetConnectionHeartbeats retorna o último heartbeat de cada conexão registrada (zero se nunca gravado).
+func (am *AccountManager) GetConnectionHeartbeats() (map[int64]time.Time, error) {
+	rows, err := am.db.GetDB().Query(`SELECT account_id, last_heartbeat_at FROM active_connections WHERE connected = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heartbeats := make(map[int64]time.Time)
+	for rows.Next() {
+		var id int64
+		var heartbeat sql.NullTime
+		if err := rows.Scan(&id, &heartbeat); err != nil {
+			return nil, err
+		}
+		heartbeats[id] = heartbeat.Time
+	}
+	return heartbeats, rows.Err()
+}
+
+// AuthFailure é a marcação de uma conta parada pelo circuit breaker de autenticação (authbreaker.go).
+type AuthFailure struct {
+	Reason   string
+	Attempts int
+	FailedAt time.Time
+}
+
+// SetAuthFailure marca a conta como "autenticação recusada", com o motivo informado pela corretora.
+func (am *AccountManager) SetAuthFailure(accountID int64, reason string, attempts int) error {
+	_, err := am.db.GetDB().Exec(`INSERT OR REPLACE INTO auth_failures (account_id, reason, attempts, failed_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		accountID, reason, attempts)
+	return err
+}
+
+func (am *AccountManager) ClearAuthFailure(accountID int64) error {
+	_, err := am.db.GetDB().Exec(`DELETE FROM auth_failures WHERE account_id = ?`, accountID)
+	return err
+}
+
+// GetAuthFailures retorna as contas marcadas com autenticação recusada.
+func (am *AccountManager) GetAuthFailures() (map[int64]AuthFailure, error) {
+	rows, err := am.db.GetDB().Query(`SELECT account_id, reason, attempts, failed_at FROM auth_failures`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := make(map[int64]AuthFailure)
+	for rows.Next() {
+		var id int64
+		var failure AuthFailure
+		var failedAt sql.NullTime
+		if err := rows.Scan(&id, &failure.Reason, &failure.Attempts, &failedAt); err != nil {
+			return nil, err
+		}
+		failure.FailedAt = failedAt.Time
+		failures[id] = failure
+	}
+	return failures, rows.Err()
+}
+
 func (am *AccountManager) GetActiveConnections() ([]int64, error) {
 	query := `SELECT account_id FROM active_connections WHERE connected = 1`
 	rows, err := am.db.GetDB().Query(query)
@@ -187,8 +419,8 @@ func (am *AccountManager) GetActiveConnections() ([]int64, error) {
 	return accountIDs, rows.Err()
 }
 
-// UpdateAccount atualiza a conta. platform não é alterado. apiKey e apiSecret são persistidos; metadata pode ser passado para atualizar (ex.: passphrase OKX); use "" para manter o atual. notificationDelaySeconds: 0 ou 3-20.
-func (am *AccountManager) UpdateAccount(id int64, name, apiKey, apiSecret, webhookURL string, markEveryoneOrder, markEveryoneWallet bool, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, sheetURLGoogleSheetsExecutions string, markEveryoneExecution bool, metadata string, notificationDelaySeconds int) error {
+// UpdateAccount atualiza a conta. platform não é alterado. apiKey e apiSecret são persistidos; metadata pode ser passado para atualizar (ex.: passphrase OKX); use "" para manter o atual. notificationDelaySeconds: 0 ou 3-20. timezone: fuso IANA ou "" para Brasília. notes: observações livres.
+func (am *AccountManager) UpdateAccount(id int64, name, apiKey, apiSecret, webhookURL string, markEveryoneOrder, markEveryoneWallet bool, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, sheetURLGoogleSheetsExecutions string, markEveryoneExecution bool, metadata string, notificationDelaySeconds int, timezone string, notes string) error {
 	markEveryoneOrderInt := 0
 	if markEveryoneOrder {
 		markEveryoneOrderInt = 1
@@ -206,21 +438,63 @@ func (am *AccountManager) UpdateAccount(id int64, name, apiKey, apiSecret, webho
 		delaySec = 0
 	}
 
+	// Conta com segredos no keyring: atualizar o keyring e manter só as referências no banco
+	if current, err := am.GetAccount(id); err == nil && current.SecretsInKeyring {
+		var err error
+		apiSecret, metadata, err = storeAccountSecretsInKeyring(id, apiSecret, metadata)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Se metadata foi passado (não é o sentinel "keep"), atualizar; senão fazer UPDATE sem metadata
 	if metadata != "" {
-		query := `UPDATE bybit_accounts SET name = ?, api_key = ?, api_secret = ?, webhook_url = ?, mark_everyone_order = ?, mark_everyone_wallet = ?, webhook_url_google_sheets = ?, sheet_url_google_sheets = ?, webhook_url_executions = ?, mark_everyone_execution = ?, sheet_url_google_sheets_executions = ?, metadata = ?, notification_delay_seconds = ? WHERE id = ?`
-		_, err := am.db.GetDB().Exec(query, name, apiKey, apiSecret, webhookURL, markEveryoneOrderInt, markEveryoneWalletInt, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, markEveryoneExecutionInt, sheetURLGoogleSheetsExecutions, metadata, delaySec, id)
+		query := `UPDATE bybit_accounts SET name = ?, api_key = ?, api_secret = ?, webhook_url = ?, mark_everyone_order = ?, mark_everyone_wallet = ?, webhook_url_google_sheets = ?, sheet_url_google_sheets = ?, webhook_url_executions = ?, mark_everyone_execution = ?, sheet_url_google_sheets_executions = ?, metadata = ?, notification_delay_seconds = ?, timezone = ?, notes = ? WHERE id = ?`
+		_, err := am.db.GetDB().Exec(query, name, apiKey, apiSecret, webhookURL, markEveryoneOrderInt, markEveryoneWalletInt, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, markEveryoneExecutionInt, sheetURLGoogleSheetsExecutions, metadata, delaySec, strings.TrimSpace(timezone), strings.TrimSpace(notes), id)
 		return err
 	}
-	query := `UPDATE bybit_accounts SET name = ?, api_key = ?, api_secret = ?, webhook_url = ?, mark_everyone_order = ?, mark_everyone_wallet = ?, webhook_url_google_sheets = ?, sheet_url_google_sheets = ?, webhook_url_executions = ?, mark_everyone_execution = ?, sheet_url_google_sheets_executions = ?, notification_delay_seconds = ? WHERE id = ?`
-	_, err := am.db.GetDB().Exec(query, name, apiKey, apiSecret, webhookURL, markEveryoneOrderInt, markEveryoneWalletInt, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, markEveryoneExecutionInt, sheetURLGoogleSheetsExecutions, delaySec, id)
+	query := `UPDATE bybit_accounts SET name = ?, api_key = ?, api_secret = ?, webhook_url = ?, mark_everyone_order = ?, mark_everyone_wallet = ?, webhook_url_google_sheets = ?, sheet_url_google_sheets = ?, webhook_url_executions = ?, mark_everyone_execution = ?, sheet_url_google_sheets_executions = ?, notification_delay_seconds = ?, timezone = ?, notes = ? WHERE id = ?`
+	_, err := am.db.GetDB().Exec(query, name, apiKey, apiSecret, webhookURL, markEveryoneOrderInt, markEveryoneWalletInt, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, markEveryoneExecutionInt, sheetURLGoogleSheetsExecutions, delaySec, strings.TrimSpace(timezone), strings.TrimSpace(notes), id)
 	return err
 }
 
+// SetSecretsInKeyring move os segredos da conta para o keyring do sistema (enable=true) ou de volta para o banco.
+func (am *AccountManager) SetSecretsInKeyring(id int64, enable bool) error {
+	account, err := am.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	if account.secretErr != nil {
+		return account.secretErr
+	}
+	if account.SecretsInKeyring == enable {
+		return nil
+	}
+
+	if enable {
+		secretRef, metadataRef, err := storeAccountSecretsInKeyring(id, account.StoredAPISecret(), account.StoredMetadata())
+		if err != nil {
+			return err
+		}
+		_, err = am.db.GetDB().Exec(`UPDATE bybit_accounts SET api_secret = ?, metadata = ? WHERE id = ?`, secretRef, metadataRef, id)
+		return err
+	}
+
+	// account já vem com os valores resolvidos (referências env: são mantidas)
+	if _, err := am.db.GetDB().Exec(`UPDATE bybit_accounts SET api_secret = ?, metadata = ? WHERE id = ?`, account.StoredAPISecret(), account.StoredMetadata(), id); err != nil {
+		return err
+	}
+	return deleteAccountSecretsFromKeyring(id)
+}
+
 // Métodos para gerenciar ordens
-func (am *AccountManager) SaveOrder(orderID string, accountID int64, orderDataJSON string) error {
-	query := `INSERT OR REPLACE INTO orders (order_id, account_id, order_data) VALUES (?, ?, ?)`
-	_, err := am.db.GetDB().Exec(query, orderID, accountID, orderDataJSON)
+func (am *AccountManager) SaveOrder(accountID int64, order OrderData) error {
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	query := `INSERT OR REPLACE INTO orders (order_id, account_id, order_data, symbol, status, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = am.db.GetDB().Exec(query, order.OrderID, accountID, string(orderJSON), order.Symbol, order.OrderStatus, parseMillisTimestamp(order.UpdatedTime))
 	return err
 }
 
@@ -240,6 +514,409 @@ func (am *AccountManager) DeleteOrder(orderID string) error {
 	return err
 }
 
+// OrderEvent é uma atualização de ordem guardada no histórico (order_events).
+type OrderEvent struct {
+	ID             int64
+	OrderID        string
+	Symbol         string
+	Side           string
+	OrderType      string
+	StopOrderType  string
+	PreviousStatus string
+	Status         string
+	Price          string
+	Qty            string
+	EventTime      time.Time
+}
+
+// HistoryFilter filtra os históricos (ordens, execuções, notificações). Campos vazios/zero não filtram.
+type HistoryFilter struct {
+	Symbol string
+	Status string    // só notificações (notificationStatusSent ou notificationStatusFailed)
+	From   time.Time // inclusivo
+	To     time.Time // exclusivo
+	Limit  int
+}
+
+// parseMillisTimestamp converte timestamp em ms (string) para time.Time em UTC. Se inválido, usa o horário atual.
+func parseMillisTimestamp(ms string) time.Time {
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil || v <= 0 {
+		return time.Now().UTC()
+	}
+	return time.UnixMilli(v).UTC()
+}
+
+// RecordOrderEvent grava uma atualização de ordem no histórico, junto com o status anterior da mesma ordem.
+// Reenvios idênticos (mesmo status e mesmo updatedTime) são ignorados.
+func (am *AccountManager) RecordOrderEvent(accountID int64, order OrderData) error {
+	eventTime := parseMillisTimestamp(order.UpdatedTime)
+	var previousStatus string
+	var previousTime time.Time
+	err := am.db.GetDB().QueryRow(
+		`SELECT status, event_time FROM order_events WHERE account_id = ? AND order_id = ? ORDER BY id DESC LIMIT 1`,
+		accountID, order.OrderID,
+	).Scan(&previousStatus, &previousTime)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && previousStatus == order.OrderStatus && previousTime.Equal(eventTime) {
+		return nil
+	}
+
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	_, err = am.db.GetDB().Exec(
+		`INSERT INTO order_events (account_id, order_id, symbol, side, order_type, stop_order_type, previous_status, status, price, qty, order_data, event_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountID, order.OrderID, order.Symbol, order.Side, order.OrderType, order.StopOrderType, previousStatus, order.OrderStatus,
+		getDisplayPrice(order), order.Qty, string(orderJSON), eventTime,
+	)
+	return err
+}
+
+// ListOrderEvents retorna o histórico de ordens da conta, do mais recente para o mais antigo.
+func (am *AccountManager) ListOrderEvents(accountID int64, filter HistoryFilter) ([]OrderEvent, error) {
+	query := `SELECT id, order_id, symbol, side, order_type, stop_order_type, previous_status, status, price, qty, event_time
+		FROM order_events WHERE account_id = ?`
+	args := []interface{}{accountID}
+	if filter.Symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, strings.ToUpper(filter.Symbol))
+	}
+	if !filter.From.IsZero() {
+		query += ` AND event_time >= ?`
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query += ` AND event_time < ?`
+		args = append(args, filter.To.UTC())
+	}
+	query += ` ORDER BY event_time DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := am.db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []OrderEvent
+	for rows.Next() {
+		var e OrderEvent
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.Symbol, &e.Side, &e.OrderType, &e.StopOrderType, &e.PreviousStatus, &e.Status, &e.Price, &e.Qty, &e.EventTime); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// ExecutionRecord é uma execução (Trade) guardada no histórico.
+type ExecutionRecord struct {
+	ID        int64
+	ExecID    string
+	OrderID   string
+	Symbol    string
+	Side      string
+	OrderType string
+	Price     string
+	Qty       string
+	Value     string
+	Fee       string
+	FeeRate   string
+	IsMaker   bool
+	ExecTime  time.Time
+}
+
+// RecordExecution grava uma execução no histórico. Execuções repetidas (mesmo execId) são ignoradas.
+func (am *AccountManager) RecordExecution(accountID int64, exec ExecutionData) error {
+	execID := exec.ExecID
+	if execID == "" {
+		// Sem execId (não deveria acontecer): usa ordem + horário para não duplicar em reenvios
+		execID = exec.OrderID + "@" + exec.ExecTime
+	}
+	_, err := am.db.GetDB().Exec(
+		`INSERT OR IGNORE INTO executions (account_id, exec_id, order_id, symbol, side, order_type, price, qty, value, fee, fee_rate, is_maker, exec_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountID, execID, exec.OrderID, exec.Symbol, exec.Side, exec.OrderType, exec.ExecPrice, exec.ExecQty, exec.ExecValue,
+		exec.ExecFee, exec.FeeRate, exec.IsMaker, parseMillisTimestamp(exec.ExecTime),
+	)
+	return err
+}
+
+// HasExecution informa se a execução (execId) já está no histórico da conta.
+func (am *AccountManager) HasExecution(accountID int64, execID string) (bool, error) {
+	var exists bool
+	err := am.db.GetDB().QueryRow(`SELECT EXISTS(SELECT 1 FROM executions WHERE account_id = ? AND exec_id = ?)`, accountID, execID).Scan(&exists)
+	return exists, err
+}
+
+// ListExecutions retorna as execuções da conta, da mais recente para a mais antiga.
+func (am *AccountManager) ListExecutions(accountID int64, filter HistoryFilter) ([]ExecutionRecord, error) {
+	query := `SELECT id, exec_id, order_id, symbol, side, order_type, price, qty, value, fee, fee_rate, is_maker, exec_time
+		FROM executions WHERE account_id = ?`
+	args := []interface{}{accountID}
+	if filter.Symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, strings.ToUpper(filter.Symbol))
+	}
+	if !filter.From.IsZero() {
+		query += ` AND exec_time >= ?`
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query += ` AND exec_time < ?`
+		args = append(args, filter.To.UTC())
+	}
+	query += ` ORDER BY exec_time DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := am.db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []ExecutionRecord
+	for rows.Next() {
+		var r ExecutionRecord
+		if err := rows.Scan(&r.ID, &r.ExecID, &r.OrderID, &r.Symbol, &r.Side, &r.OrderType, &r.Price, &r.Qty, &r.Value, &r.Fee, &r.FeeRate, &r.IsMaker, &r.ExecTime); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// PositionRecord é uma posição do histórico (aberta enquanto ClosedAt for zero).
+type PositionRecord struct {
+	ID          int64
+	Symbol      string
+	PositionIdx int
+	Side        string
+	Size        string // maior tamanho atingido enquanto aberta
+	EntryPrice  string
+	ExitPrice   string
+	RealizedPnl string
+	OpenedAt    time.Time
+	ClosedAt    time.Time
+}
+
+// Duration é o tempo em que a posição ficou aberta (até agora, se ainda estiver aberta).
+func (p PositionRecord) Duration() time.Duration {
+	if p.ClosedAt.IsZero() {
+		return time.Since(p.OpenedAt)
+	}
+	return p.ClosedAt.Sub(p.OpenedAt)
+}
+
+// TrackPosition atualiza o histórico de posições a partir de uma mensagem de position e retorna as posições abertas
+// ou fechadas por ela, na ordem (a virada de lado sem passar por tamanho 0 fecha uma e abre outra).
+// Retorna a posição quando ela foi aberta ou fechada nesta mensagem (nil nas demais atualizações).
+func (am *AccountManager) TrackPosition(accountID int64, pos PositionData) ([]PositionRecord, error) {
+	size, _ := strconv.ParseFloat(pos.Size, 64)
+	eventTime := parseMillisTimestamp(pos.UpdatedTime)
+
+	var open PositionRecord
+	var openedSize, pnlBaseline string
+	err := am.db.GetDB().QueryRow(
+		`SELECT id, side, size, entry_price, pnl_baseline, opened_at FROM positions_history
+		WHERE account_id = ? AND symbol = ? AND position_idx = ? AND closed_at IS NULL ORDER BY id DESC LIMIT 1`,
+		accountID, pos.Symbol, pos.PositionIdx,
+	).Scan(&open.ID, &open.Side, &openedSize, &open.EntryPrice, &pnlBaseline, &open.OpenedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	hasOpen := err == nil
+	open.Symbol, open.PositionIdx, open.Size = pos.Symbol, pos.PositionIdx, openedSize
+
+	switch {
+	case size > 0 && !hasOpen:
+		record, err := am.openPositionRecord(accountID, pos, eventTime)
+		if err != nil {
+			return nil, err
+		}
+		return []PositionRecord{*record}, nil
+
+	case size > 0 && hasOpen && pos.Side != "" && pos.Side != open.Side:
+		// Virada de lado (ex.: Buy -> Sell numa só execução): fecha a posição anterior e abre a nova. Sem as execuções
+		// do fechamento no banco, a saída é o preço de entrada da nova posição, que é o da execução que virou o lado.
+		if err := am.closePositionRecord(accountID, &open, eventTime, flipRealizedPnl(pos, pnlBaseline), pos.EntryPrice); err != nil {
+			return nil, err
+		}
+		record, err := am.openPositionRecord(accountID, pos, eventTime)
+		if err != nil {
+			return nil, err
+		}
+		return []PositionRecord{open, *record}, nil
+
+	case size > 0 && hasOpen:
+		maxSize := openedSize
+		if prev, _ := strconv.ParseFloat(openedSize, 64); size > prev {
+			maxSize = pos.Size
+		}
+		_, err := am.db.GetDB().Exec(`UPDATE positions_history SET size = ?, entry_price = ? WHERE id = ?`, maxSize, pos.EntryPrice, open.ID)
+		return nil, err
+
+	case size == 0 && hasOpen:
+		if err := am.closePositionRecord(accountID, &open, eventTime, positionRealizedPnl(pos, pnlBaseline), pos.MarkPrice); err != nil {
+			return nil, err
+		}
+		return []PositionRecord{open}, nil
+	}
+	return nil, nil
+}
+
+// openPositionRecord grava a abertura da posição no histórico.
+func (am *AccountManager) openPositionRecord(accountID int64, pos PositionData, openedAt time.Time) (*PositionRecord, error) {
+	// Baseline do PnL: acumulado antes desta posição (Bybit: cumRealisedPnl - curRealisedPnl, que já inclui a taxa de abertura)
+	cum, cumErr := strconv.ParseFloat(pos.CumRealisedPnl, 64)
+	cur, _ := strconv.ParseFloat(pos.CurRealisedPnl, 64)
+	baseline := ""
+	if cumErr == nil {
+		baseline = strconv.FormatFloat(cum-cur, 'f', -1, 64)
+	}
+	record := &PositionRecord{Symbol: pos.Symbol, PositionIdx: pos.PositionIdx, Side: pos.Side, Size: pos.Size, EntryPrice: pos.EntryPrice, OpenedAt: openedAt}
+	res, err := am.db.GetDB().Exec(
+		`INSERT INTO positions_history (account_id, symbol, position_idx, side, size, entry_price, pnl_baseline, opened_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountID, pos.Symbol, pos.PositionIdx, pos.Side, pos.Size, pos.EntryPrice, baseline, openedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	record.ID, _ = res.LastInsertId()
+	return record, nil
+}
+
+// closePositionRecord grava o fechamento da posição. A saída é o preço médio das execuções do fechamento
+// (closingPrice) ou, sem elas, fallbackPrice, vindo da própria mensagem de position.
+func (am *AccountManager) closePositionRecord(accountID int64, open *PositionRecord, closedAt time.Time, realizedPnl, fallbackPrice string) error {
+	open.ClosedAt, open.RealizedPnl = closedAt, realizedPnl
+	exitPrice, err := am.closingPrice(accountID, *open)
+	if err != nil {
+		return err
+	}
+	if exitPrice == "" {
+		exitPrice = fallbackPrice
+	}
+	open.ExitPrice = exitPrice
+	_, err = am.db.GetDB().Exec(
+		`UPDATE positions_history SET exit_price = ?, realized_pnl = ?, closed_at = ? WHERE id = ?`,
+		open.ExitPrice, open.RealizedPnl, open.ClosedAt, open.ID,
+	)
+	return err
+}
+
+// positionRealizedPnl calcula o PnL realizado da posição fechada. Com cumRealisedPnl (Bybit) usa a diferença
+// para o acumulado antes da abertura; sem ele (OKX) usa o PnL realizado informado pela corretora.
+func positionRealizedPnl(pos PositionData, baseline string) string {
+	cum, err := strconv.ParseFloat(pos.CumRealisedPnl, 64)
+	base, baseErr := strconv.ParseFloat(baseline, 64)
+	if err == nil && baseErr == nil {
+		return strconv.FormatFloat(cum-base, 'f', 8, 64)
+	}
+	return pos.CurRealisedPnl
+}
+
+// flipRealizedPnl calcula o PnL realizado da posição fechada pela virada de lado: o acumulado até antes da nova
+// posição (cumRealisedPnl - curRealisedPnl) menos o de antes da abertura. Vazio sem cumRealisedPnl (OKX), porque o
+// PnL informado já é o da nova posição.
+func flipRealizedPnl(pos PositionData, baseline string) string {
+	cum, err := strconv.ParseFloat(pos.CumRealisedPnl, 64)
+	cur, curErr := strconv.ParseFloat(pos.CurRealisedPnl, 64)
+	base, baseErr := strconv.ParseFloat(baseline, 64)
+	if err != nil || curErr != nil || baseErr != nil {
+		return ""
+	}
+	return strconv.FormatFloat(cum-cur-base, 'f', 8, 64)
+}
+
+// closingPrice calcula o preço médio de saída a partir das execuções do lado oposto desde a abertura
+// (média ponderada de contratos inverse: soma(qty) / soma(qty/preço)). Vazio se não houver execuções.
+func (am *AccountManager) closingPrice(accountID int64, position PositionRecord) (string, error) {
+	closingSide := "Sell"
+	if position.Side == "Sell" {
+		closingSide = "Buy"
+	}
+	rows, err := am.db.GetDB().Query(
+		`SELECT price, qty FROM executions WHERE account_id = ? AND symbol = ? AND side = ? AND exec_time >= ? AND exec_time <= ?`,
+		accountID, position.Symbol, closingSide, position.OpenedAt.UTC(), position.ClosedAt.UTC(),
+	)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var totalQty, coinQty float64
+	for rows.Next() {
+		var priceStr, qtyStr string
+		if err := rows.Scan(&priceStr, &qtyStr); err != nil {
+			return "", err
+		}
+		price, _ := strconv.ParseFloat(priceStr, 64)
+		qty, _ := strconv.ParseFloat(qtyStr, 64)
+		if price <= 0 || qty <= 0 {
+			continue
+		}
+		totalQty += qty
+		coinQty += qty / price
+	}
+	if err := rows.Err(); err != nil || coinQty == 0 {
+		return "", err
+	}
+	return strconv.FormatFloat(math.Round(totalQty/coinQty*1e8)/1e8, 'f', -1, 64), nil
+}
+
+// ListPositionHistory retorna as posições da conta (abertas e fechadas), da abertura mais recente para a mais antiga.
+// O filtro de período considera a data de abertura.
+func (am *AccountManager) ListPositionHistory(accountID int64, filter HistoryFilter) ([]PositionRecord, error) {
+	query := `SELECT id, symbol, position_idx, side, size, entry_price, exit_price, realized_pnl, opened_at, closed_at
+		FROM positions_history WHERE account_id = ?`
+	args := []interface{}{accountID}
+	if filter.Symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, strings.ToUpper(filter.Symbol))
+	}
+	if !filter.From.IsZero() {
+		query += ` AND opened_at >= ?`
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query += ` AND opened_at < ?`
+		args = append(args, filter.To.UTC())
+	}
+	query += ` ORDER BY opened_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := am.db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []PositionRecord
+	for rows.Next() {
+		var r PositionRecord
+		var closedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.Symbol, &r.PositionIdx, &r.Side, &r.Size, &r.EntryPrice, &r.ExitPrice, &r.RealizedPnl, &r.OpenedAt, &closedAt); err != nil {
+			return nil, err
+		}
+		if closedAt.Valid {
+			r.ClosedAt = closedAt.Time
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
 func (am *AccountManager) UpdateOneWayMode(accountID int64, oneWayMode bool) error {
 	value := 1
 	if !oneWayMode {
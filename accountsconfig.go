@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Contas definidas fora do banco, para execuções efêmeras (--in-memory) e testes de integração:
+// ACCOUNTS_FILE (ou --accounts-file) aponta para um arquivo JSON; ACCOUNTS_JSON traz o mesmo conteúdo direto na variável.
+const (
+	accountsFileEnv = "ACCOUNTS_FILE"
+	accountsJSONEnv = "ACCOUNTS_JSON"
+)
+
+// accountsFileOverride vem de --accounts-file e tem prioridade sobre ACCOUNTS_FILE.
+var accountsFileOverride string
+
+// configAccount é uma conta no arquivo de configuração. Segredos aceitam referências env: (ex.: "env:BYBIT_API_SECRET").
+type configAccount struct {
+	Name                           string          `json:"name"`
+	Platform                       string          `json:"platform"`
+	APIKey                         string          `json:"api_key"`
+	APISecret                      string          `json:"api_secret"`
+	Passphrase                     string          `json:"passphrase"` // só OKX
+	WebhookURL                     string          `json:"webhook_url"`
+	WebhookURLExecutions           string          `json:"webhook_url_executions"`
+	WebhookURLGoogleSheets         string          `json:"webhook_url_google_sheets"`
+	SheetURLGoogleSheets           string          `json:"sheet_url_google_sheets"`
+	SheetURLGoogleSheetsExecutions string          `json:"sheet_url_google_sheets_executions"`
+	MarkEveryoneOrder              bool            `json:"mark_everyone_order"`
+	MarkEveryoneWallet             bool            `json:"mark_everyone_wallet"`
+	MarkEveryoneExecution          bool            `json:"mark_everyone_execution"`
+	NotificationDelaySeconds       int             `json:"notification_delay_seconds"`
+	Timezone                       string          `json:"timezone"`
+	Notes                          string          `json:"notes"`
+	Active                         *bool           `json:"active"`   // padrão: true (monitorada ao iniciar)
+	Settings                       AccountSettings `json:"settings"` // preferências da conta (mesmas chaves do comando settings)
+}
+
+// readAccountsConfig lê as contas configuradas. Sem configuração retorna nil.
+func readAccountsConfig() ([]configAccount, string, error) {
+	var data []byte
+	var source string
+	path := accountsFileOverride
+	if path == "" {
+		path = os.Getenv(accountsFileEnv)
+	}
+	switch {
+	case path != "":
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, path, err
+		}
+		data, source = content, path
+	case os.Getenv(accountsJSONEnv) != "":
+		data, source = []byte(os.Getenv(accountsJSONEnv)), accountsJSONEnv
+	default:
+		return nil, "", nil
+	}
+
+	var accounts []configAccount
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, source, fmt.Errorf("JSON inválido: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i, acc := range accounts {
+		name := strings.TrimSpace(acc.Name)
+		if name == "" {
+			return nil, source, fmt.Errorf("conta %d sem nome", i+1)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, source, fmt.Errorf("conta '%s' definida mais de uma vez", name)
+		}
+		seen[strings.ToLower(name)] = true
+		if acc.APIKey == "" || acc.APISecret == "" {
+			return nil, source, fmt.Errorf("conta '%s' sem api_key ou api_secret", name)
+		}
+		if platform := strings.ToLower(strings.TrimSpace(acc.Platform)); platform != "" && platform != "bybit" && platform != "okx" {
+			return nil, source, fmt.Errorf("conta '%s': plataforma desconhecida %s (use bybit ou okx)", name, acc.Platform)
+		}
+	}
+	return accounts, source, nil
+}
+
+// loadConfiguredAccounts cadastra as contas da configuração que ainda não existem no banco (pelo nome) e marca
+// as ativas para monitoramento, de modo que RestoreConnections as inicie. Retorna quantas foram cadastradas.
+func loadConfiguredAccounts(manager Repository) (int, error) {
+	configured, source, err := readAccountsConfig()
+	if err != nil {
+		return 0, fmt.Errorf("contas de %s: %w", source, err)
+	}
+	if len(configured) == 0 {
+		return 0, nil
+	}
+
+	existing, err := manager.ListAccounts()
+	if err != nil {
+		return 0, err
+	}
+	names := make(map[string]bool, len(existing))
+	for _, acc := range existing {
+		names[strings.ToLower(acc.Name)] = true
+	}
+
+	added := 0
+	for _, cfg := range configured {
+		name := strings.TrimSpace(cfg.Name)
+		if names[strings.ToLower(name)] {
+			continue
+		}
+		account := cfg.toAccount()
+		if err := manager.AddAccount(account); err != nil {
+			return added, fmt.Errorf("erro ao cadastrar a conta '%s': %w", name, err)
+		}
+		if account.Active {
+			if err := manager.SetConnectionActive(account.ID, true); err != nil {
+				return added, err
+			}
+		}
+		added++
+	}
+	return added, nil
+}
+
+func (cfg configAccount) toAccount() *BybitAccount {
+	platform := strings.ToLower(strings.TrimSpace(cfg.Platform))
+	if platform == "" {
+		platform = "bybit"
+	}
+	metadata := ""
+	if platform == "okx" {
+		metadataJSON, _ := json.Marshal(map[string]string{"passphrase": cfg.Passphrase})
+		metadata = string(metadataJSON)
+	}
+	return &BybitAccount{
+		Name:                           strings.TrimSpace(cfg.Name),
+		APIKey:                         cfg.APIKey,
+		APISecret:                      cfg.APISecret,
+		WebhookURL:                     cfg.WebhookURL,
+		Active:                         cfg.Active == nil || *cfg.Active,
+		MarkEveryoneOrder:              cfg.MarkEveryoneOrder,
+		MarkEveryoneWallet:             cfg.MarkEveryoneWallet,
+		OneWayMode:                     true,
+		WebhookURLGoogleSheets:         cfg.WebhookURLGoogleSheets,
+		SheetURLGoogleSheets:           cfg.SheetURLGoogleSheets,
+		WebhookURLExecutions:           cfg.WebhookURLExecutions,
+		MarkEveryoneExecution:          cfg.MarkEveryoneExecution,
+		SheetURLGoogleSheetsExecutions: cfg.SheetURLGoogleSheetsExecutions,
+		Platform:                       platform,
+		Metadata:                       metadata,
+		NotificationDelaySeconds:       cfg.NotificationDelaySeconds,
+		Timezone:                       cfg.Timezone,
+		Notes:                          cfg.Notes,
+		Settings:                       cfg.Settings,
+	}
+}
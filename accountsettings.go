@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AccountSettings são as preferências da conta guardadas na coluna settings (objeto JSON).
+// Cada recurso lê a sua chave com o valor padrão dele; chave ausente = padrão.
+type AccountSettings map[string]json.RawMessage
+
+func parseAccountSettings(data string) (AccountSettings, error) {
+	settings := AccountSettings{}
+	if strings.TrimSpace(data) == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return AccountSettings{}, fmt.Errorf("settings inválido: %w", err)
+	}
+	return settings, nil
+}
+
+func (s AccountSettings) String() string {
+	if len(s) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// Decode lê a chave em v. Retorna false se a chave não existe ou não tem o tipo esperado.
+func (s AccountSettings) Decode(key string, v interface{}) bool {
+	raw, exists := s[key]
+	if !exists {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+func (s AccountSettings) GetString(key, def string) string {
+	var value string
+	if s.Decode(key, &value) {
+		return value
+	}
+	return def
+}
+
+func (s AccountSettings) GetInt(key string, def int) int {
+	var value int
+	if s.Decode(key, &value) {
+		return value
+	}
+	return def
+}
+
+func (s AccountSettings) GetBool(key string, def bool) bool {
+	var value bool
+	if s.Decode(key, &value) {
+		return value
+	}
+	return def
+}
+
+// Set grava o valor (qualquer tipo serializável em JSON) na chave.
+func (s AccountSettings) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s[key] = data
+	return nil
+}
+
+// parseSettingValue interpreta o valor digitado: JSON válido (número, true/false, lista, objeto, "texto") ou texto puro.
+func parseSettingValue(input string) json.RawMessage {
+	input = strings.TrimSpace(input)
+	if json.Valid([]byte(input)) {
+		return json.RawMessage(input)
+	}
+	quoted, _ := json.Marshal(input)
+	return quoted
+}
+
+// accountSettingValidators valida as preferências conhecidas antes de gravar pelo comando settings.
+// Chaves sem validador são gravadas como vierem.
+var accountSettingValidators = map[string]func(value json.RawMessage) error{
+	logLevelSettingKey: func(value json.RawMessage) error {
+		var name string
+		if json.Unmarshal(value, &name) != nil {
+			return errors.New("use debug, info, warning ou error")
+		}
+		if _, ok := parseLogLevel(name); !ok {
+			return fmt.Errorf("nível de log inválido: %s (use debug, info, warning ou error)", name)
+		}
+		return nil
+	},
+	reconnectSettingKey:               validateReconnectSetting,
+	connectionAlertsWebhookSettingKey: validateConnectionAlertsWebhookSetting,
+	heartbeatURLSettingKey:            validateHeartbeatURLSetting,
+	apiKeyTypeSettingKey:              validateAPIKeyTypeSetting,
+	scheduledReconnectSettingKey:      validateScheduledReconnectSetting,
+	templatesSettingKey:               validateTemplatesSetting,
+	severityRoutesSettingKey:          validateSeverityRoutesSetting,
+	severityLevelsSettingKey:          validateSeverityLevelsSetting,
+	mentionSettingKey:                 validateMentionSetting,
+	notificationRulesSettingKey:       validateNotificationRulesSetting,
+	iconsSettingKey:                   validateIconsSetting,
+	summaryChartSettingKey:            validateSummaryChartSetting,
+	rateLimitSettingKey:               validateRateLimitSetting,
+	genericWebhookSettingKey:          validateGenericWebhookSetting,
+	sharedWebhooksSettingKey:          validateSharedWebhooksSetting,
+	orderMessageEditsSettingKey:       validateOrderMessageEditsSetting,
+	orderLinkNamesSettingKey:          validateOrderLinkNamesSetting,
+	logOnlySettingKey:                 validateLogOnlySetting,
+	discordBotSettingKey:              validateDiscordBotSetting,
+}
+
+const settingsUsage = "uso: settings <conta> [<chave> [<valor>|--unset]]"
+
+// runSettingsCommand mostra ou altera as preferências da conta.
+func runSettingsCommand(db *Database, args []string) error {
+	if len(args) == 0 || len(args) > 3 {
+		return errors.New(settingsUsage)
+	}
+	manager := NewAccountManager(db)
+	account, err := findAccountByNameOrID(manager, args[0])
+	if err != nil {
+		return err
+	}
+
+	switch len(args) {
+	case 1:
+		if len(account.Settings) == 0 {
+			fmt.Println("Nenhuma preferência definida (todas com o valor padrão).")
+			return nil
+		}
+		keys := make([]string, 0, len(account.Settings))
+		for key := range account.Settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%-28s %s\n", key, account.Settings[key])
+		}
+		return nil
+	case 2:
+		value, exists := account.Settings[args[1]]
+		if !exists {
+			return fmt.Errorf("preferência '%s' não definida", args[1])
+		}
+		fmt.Println(string(value))
+		return nil
+	}
+
+	key := strings.TrimSpace(args[1])
+	if key == "" {
+		return errors.New("a chave não pode ser vazia")
+	}
+	if args[2] == "--unset" {
+		if err := manager.UpdateAccountSettings(account.ID, func(s AccountSettings) { delete(s, key) }); err != nil {
+			return err
+		}
+		fmt.Printf("%s: volta ao valor padrão.\n", key)
+		return nil
+	}
+	value := parseSettingValue(args[2])
+	if validate, ok := accountSettingValidators[key]; ok {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if err := manager.UpdateAccountSettings(account.ID, func(s AccountSettings) { s[key] = value }); err != nil {
+		return err
+	}
+	fmt.Printf("%s = %s\n", key, value)
+	fmt.Println("Contas já monitoradas usam o novo valor após reiniciar o monitoramento.")
+	return nil
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminAPIAddrEnv habilita a API HTTP de controle no endereço informado, ex.: 127.0.0.1:8787.
+// Sem a variável a API não é iniciada.
+const adminAPIAddrEnv = "ADMIN_API_ADDR"
+
+// Papéis dos tokens da API: viewer só consulta (status e histórico); admin também inicia, para e remove contas e reenvia notificações.
+const (
+	apiRoleViewer = "viewer"
+	apiRoleAdmin  = "admin"
+)
+
+// apiTokenPrefix identifica os tokens gerados por este aplicativo (facilita achá-los em configs e logs).
+const apiTokenPrefix = "bn_"
+
+func validAPIRole(role string) bool {
+	return role == apiRoleViewer || role == apiRoleAdmin
+}
+
+// roleAllows indica se o papel do token cobre o papel exigido pela rota (admin pode tudo).
+func roleAllows(tokenRole, required string) bool {
+	return tokenRole == apiRoleAdmin || tokenRole == required
+}
+
+// generateAPIToken cria um token aleatório e retorna o valor (exibido uma única vez) e o hash a ser guardado.
+func generateAPIToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := apiTokenPrefix + hex.EncodeToString(buf)
+	return token, hashAPIToken(token), nil
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// adminAPI é a API HTTP de controle, usada por dashboards e scripts.
+type adminAPI struct {
+	db        *Database
+	manager   Repository
+	wsManager *WebSocketManager
+}
+
+// startAdminAPI inicia a API em background se ADMIN_API_ADDR estiver definido.
+func startAdminAPI(db *Database, manager Repository, wsManager *WebSocketManager) {
+	addr := strings.TrimSpace(os.Getenv(adminAPIAddrEnv))
+	if addr == "" {
+		return
+	}
+	api := &adminAPI{db: db, manager: manager, wsManager: wsManager}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", api.requireRole(apiRoleViewer, api.handleStatus))
+	mux.HandleFunc("/api/accounts/", api.requireRole(apiRoleViewer, api.handleAccountAction))
+	mux.HandleFunc("/api/reload", api.requireRole(apiRoleAdmin, api.handleReload))
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Erro na API de controle (%s): %v\n", addr, err)
+		}
+	}()
+}
+
+// requireRole autentica o token Bearer e confere se o papel dele cobre o exigido pela rota.
+func (api *adminAPI) requireRole(required string, next func(w http.ResponseWriter, r *http.Request, token *APIToken)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			writeAPIError(w, http.StatusUnauthorized, "token ausente (use o header Authorization: Bearer <token>)")
+			return
+		}
+		token, err := api.db.GetAPITokenByHash(hashAPIToken(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeAPIError(w, http.StatusUnauthorized, "token inválido")
+			} else {
+				writeAPIError(w, http.StatusInternalServerError, "erro ao validar token")
+			}
+			return
+		}
+		if !roleAllows(token.Role, required) {
+			writeAPIError(w, http.StatusForbidden, "o token não tem permissão para esta operação")
+			return
+		}
+		api.db.TouchAPIToken(token.ID)
+		next(w, r, token)
+	}
+}
+
+type apiAccountStatus struct {
+	ID             int64                `json:"id"`
+	Name           string               `json:"name"`
+	Platform       string               `json:"platform"`
+	Active         bool                 `json:"active"`
+	Monitoring     bool                 `json:"monitoring"`
+	Connected      bool                 `json:"connected"`
+	Stale          bool                 `json:"stale"`
+	Paused         bool                 `json:"paused"`
+	PausedUntil    *time.Time           `json:"paused_until,omitempty"` // retomada automática da pausa
+	Mutes          []notificationMute   `json:"mutes,omitempty"`        // silêncios ativos (mute.go)
+	ConnectedAt    *time.Time           `json:"connected_at,omitempty"`
+	LastMessageAt  *time.Time           `json:"last_message_at,omitempty"`
+	ReconnectCount int                  `json:"reconnect_count"`
+	HeartbeatAt    *time.Time           `json:"heartbeat_at,omitempty"`
+	UnackedTopics  []string             `json:"unacked_topics,omitempty"`
+	Endpoint       string               `json:"endpoint,omitempty"`     // endpoint alternativo em uso (failover)
+	Resources      *ConnectionResources `json:"resources,omitempty"`    // goroutines, timers e buffers da conexão
+	Stats          *ConnectionStats     `json:"stats,omitempty"`        // mensagens por tópico, bytes e último erro
+	AuthFailure    string               `json:"auth_failure,omitempty"` // motivo da recusa, se a conta foi parada pelo circuit breaker
+}
+
+// handleStatus: GET /api/status (viewer) - versão e estado de cada conta, sem credenciais nem webhooks.
+func (api *adminAPI) handleStatus(w http.ResponseWriter, r *http.Request, token *APIToken) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "método não permitido")
+		return
+	}
+	accounts, err := api.manager.ListAccounts()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "erro ao listar contas")
+		return
+	}
+	heartbeats, _ := api.manager.GetConnectionHeartbeats()
+	authFailures, _ := api.manager.GetAuthFailures()
+	statuses := make([]apiAccountStatus, 0, len(accounts))
+	for _, acc := range accounts {
+		status := apiAccountStatus{ID: acc.ID, Name: acc.Name, Platform: acc.Platform, Active: acc.Active, Mutes: activeMutes(acc.ID)}
+		if failure, failed := authFailures[acc.ID]; failed {
+			status.AuthFailure = failure.Reason
+		}
+		if heartbeat := heartbeats[acc.ID]; !heartbeat.IsZero() {
+			status.HeartbeatAt = &heartbeat
+		}
+		if health, ok := api.wsManager.GetConnectionHealth(acc.ID); ok {
+			status.Monitoring = true
+			status.Connected = health.Connected
+			status.Stale = health.Stale
+			status.Paused = health.Paused
+			status.PausedUntil = health.PausedUntil
+			status.ReconnectCount = health.ReconnectCount
+			status.UnackedTopics = health.UnackedTopics
+			status.Endpoint = health.Endpoint
+			if !health.ConnectedAt.IsZero() {
+				connectedAt := health.ConnectedAt
+				status.ConnectedAt = &connectedAt
+			}
+			if !health.LastMessageAt.IsZero() {
+				lastMessageAt := health.LastMessageAt
+				status.LastMessageAt = &lastMessageAt
+			}
+		}
+		if resources, ok := api.wsManager.GetConnectionResources(acc.ID); ok {
+			status.Resources = &resources
+		}
+		if stats, ok := api.wsManager.GetConnectionStats(acc.ID); ok {
+			status.Stats = &stats
+		}
+		statuses = append(statuses, status)
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{
+		"version":               projectVersion,
+		"goroutines":            runtime.NumGoroutine(),
+		"public_stream":         api.wsManager.PublicStreams().Status(),
+		"bybit_clock_offset_ms": bybitClockOffset().Milliseconds(),
+		"accounts":              statuses,
+	})
+}
+
+// handleReload: POST /api/reload (admin) - relê SETTINGS_FILE e o cadastro das contas monitoradas, como o SIGHUP.
+func (api *adminAPI) handleReload(w http.ResponseWriter, r *http.Request, token *APIToken) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, "método não permitido")
+		return
+	}
+	if err := api.wsManager.ReloadSettings("API"); err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{"ok": true})
+}
+
+// handleAccountAction trata as rotas de conta:
+//
+//	GET    /api/accounts/{id}/notifications?limit=N        (viewer)
+//	POST   /api/accounts/{id}/notifications/{nid}/resend   (admin)
+//	POST   /api/accounts/{id}/start                        (admin)
+//	POST   /api/accounts/{id}/stop                         (admin)
+//	POST   /api/accounts/{id}/pause?for=15m                (admin; for é opcional)
+//	POST   /api/accounts/{id}/resume                       (admin)
+//	POST   /api/accounts/{id}/mute?symbol=BTC&for=2h       (admin; ou event=cancel no lugar de symbol)
+//	POST   /api/accounts/{id}/unmute?symbol=BTC            (admin; ou event=cancel)
+//	DELETE /api/accounts/{id}                              (admin)
+func (api *adminAPI) handleAccountAction(w http.ResponseWriter, r *http.Request, token *APIToken) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/accounts/"), "/"), "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "conta não encontrada")
+		return
+	}
+	account, err := api.manager.GetAccount(id)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "conta não encontrada")
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	if action == "notifications" {
+		api.handleAccountNotifications(w, r, token, account, parts[2:])
+		return
+	}
+	if !roleAllows(token.Role, apiRoleAdmin) {
+		writeAPIError(w, http.StatusForbidden, "o token não tem permissão para esta operação")
+		return
+	}
+	switch {
+	case action == "start" && r.Method == http.MethodPost:
+		if err := api.wsManager.StartConnection(account.ID); err != nil {
+			writeAPIError(w, http.StatusConflict, err.Error())
+			return
+		}
+	case action == "stop" && r.Method == http.MethodPost:
+		api.wsManager.StopConnection(account.ID)
+	case action == "pause" && r.Method == http.MethodPost:
+		var d time.Duration
+		if raw := r.URL.Query().Get("for"); raw != "" {
+			if d, err = time.ParseDuration(raw); err != nil || d <= 0 {
+				writeAPIError(w, http.StatusBadRequest, "duração inválida em for (ex.: 15m)")
+				return
+			}
+		}
+		if err := api.wsManager.PauseConnection(account.ID, d); err != nil {
+			writeAPIError(w, http.StatusConflict, err.Error())
+			return
+		}
+	case action == "resume" && r.Method == http.MethodPost:
+		if err := api.wsManager.ResumeConnection(account.ID); err != nil {
+			writeAPIError(w, http.StatusConflict, err.Error())
+			return
+		}
+	case action == "mute" && r.Method == http.MethodPost:
+		kind, value := muteTarget(r)
+		d, err := time.ParseDuration(r.URL.Query().Get("for"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "duração inválida em for (ex.: 2h)")
+			return
+		}
+		until, err := muteNotifications(account, kind, value, d)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "until": until})
+		return
+	case action == "unmute" && r.Method == http.MethodPost:
+		kind, value := muteTarget(r)
+		if !unmuteNotifications(account, kind, value) {
+			writeAPIError(w, http.StatusConflict, "não há silêncio ativo para "+muteLabel(kind, value))
+			return
+		}
+	case action == "" && r.Method == http.MethodDelete:
+		// Mesma regra do menu: não remove conta em monitoramento
+		if api.wsManager.IsConnectionActive(account.ID) {
+			writeAPIError(w, http.StatusConflict, "pare o monitoramento da conta antes de removê-la")
+			return
+		}
+		if err := api.manager.RemoveAccount(account.ID); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "erro ao remover conta")
+			return
+		}
+	default:
+		writeAPIError(w, http.StatusNotFound, "rota não encontrada")
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{"ok": true})
+}
+
+type apiNotification struct {
+	ID             int64     `json:"id"`
+	Channel        string    `json:"channel"`
+	Message        string    `json:"message"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	HTTPStatus     int       `json:"http_status,omitempty"`
+	Attempts       int       `json:"attempts"`
+	CorrelationIDs []string  `json:"correlation_ids,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type apiQueuedNotification struct {
+	QueueID        int64     `json:"queue_id"`
+	Channel        string    `json:"channel"`
+	Message        string    `json:"message"`
+	Status         string    `json:"status"`
+	CorrelationIDs []string  `json:"correlation_ids,omitempty"`
+	QueuedAt       time.Time `json:"queued_at"`
+}
+
+// handleAccountNotifications lista o histórico de notificações da conta (com as que estão na fila de envio, em
+// queued) ou reenvia uma delas. status=queued|sent|failed|logged filtra a listagem.
+func (api *adminAPI) handleAccountNotifications(w http.ResponseWriter, r *http.Request, token *APIToken, account *BybitAccount, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		limit := 50
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				writeAPIError(w, http.StatusBadRequest, "limit inválido")
+				return
+			}
+			limit = n
+		}
+		status, err := parseNotificationStatusFilter(r.URL.Query().Get("status"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		result := make(map[string]interface{})
+		if status == "" || status == notificationStatusQueued {
+			pending, _ := api.wsManager.GetQueuedNotifications(account.ID)
+			queued := make([]apiQueuedNotification, 0, len(pending))
+			for _, q := range pending {
+				queued = append(queued, apiQueuedNotification{QueueID: q.ID, Channel: q.Channel, Message: q.Message, Status: q.Status,
+					CorrelationIDs: q.CorrelationIDs, QueuedAt: q.QueuedAt})
+			}
+			result["queued"] = queued
+		}
+		if status != notificationStatusQueued {
+			records, err := api.db.ListNotifications(account.ID, HistoryFilter{Status: status, Limit: limit})
+			if err != nil {
+				writeAPIError(w, http.StatusInternalServerError, "erro ao ler o histórico")
+				return
+			}
+			notifications := make([]apiNotification, 0, len(records))
+			for _, rec := range records {
+				notifications = append(notifications, apiNotification{ID: rec.ID, Channel: rec.Channel, Message: rec.Message, Status: rec.Status, Error: rec.Error,
+					HTTPStatus: rec.HTTPStatus, Attempts: rec.Attempts, CorrelationIDs: rec.CorrelationIDs, CreatedAt: rec.CreatedAt})
+			}
+			result["notifications"] = notifications
+		}
+		writeAPIJSON(w, http.StatusOK, result)
+	case len(rest) == 2 && rest[1] == "resend" && r.Method == http.MethodPost:
+		if !roleAllows(token.Role, apiRoleAdmin) {
+			writeAPIError(w, http.StatusForbidden, "o token não tem permissão para esta operação")
+			return
+		}
+		id, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, "notificação não encontrada")
+			return
+		}
+		record, err := api.db.GetNotification(id)
+		if err != nil || record.AccountID != account.ID {
+			writeAPIError(w, http.StatusNotFound, "notificação não encontrada")
+			return
+		}
+		if err := resendNotification(account, record); err != nil {
+			writeAPIError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, map[string]interface{}{"ok": true})
+	default:
+		writeAPIError(w, http.StatusNotFound, "rota não encontrada")
+	}
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, map[string]string{"error": redactSecrets(message)})
+}
+
+// muteTarget lê o alvo do silêncio da query: symbol ou event.
+func muteTarget(r *http.Request) (kind, value string) {
+	if event := r.URL.Query().Get("event"); event != "" {
+		return muteEvent, event
+	}
+	return muteSymbol, r.URL.Query().Get("symbol")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const bybitRESTURL = "https://api.bybit.com"
+const okxRESTURL = "https://www.okx.com"
+const apiKeyValidationTimeout = 10 * time.Second
+
+// apiKeyPolicyEnv define o que fazer no cadastro quando a key tem permissão de negociar ou sacar:
+// "warn" (padrão) avisa e pede confirmação, "refuse" recusa o cadastro.
+const apiKeyPolicyEnv = "API_KEY_PERMISSION_POLICY"
+
+// Trechos que identificam permissões de escrita (ordens, posições, transferências e saques)
+var riskyPermissionKeywords = []string{"trade", "order", "position", "transfer", "withdraw"}
+
+// APIKeyInfo resume o que a corretora informa sobre a API key.
+type APIKeyInfo struct {
+	ReadOnly    bool
+	Permissions []string // ex.: "ContractTrade:Order", "Wallet:AccountTransfer" (Bybit) ou "trade", "withdraw" (OKX)
+	IPs         []string // IPs liberados; vazio quando a key não tem restrição
+}
+
+// validateAPICredentials chama um endpoint autenticado e barato da corretora para confirmar
+// que a key/secret funcionam e retorna as permissões da key.
+func validateAPICredentials(account *BybitAccount) (*APIKeyInfo, error) {
+	client := &http.Client{Timeout: apiKeyValidationTimeout}
+	if account.Platform == "okx" {
+		passphrase, err := getOKXPassphrase(account.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		return validateOKXAPIKey(client, account, passphrase)
+	}
+	return validateBybitAPIKey(client, account)
+}
+
+// validateBybitAPIKey consulta GET /v5/user/query-api, que retorna as permissões da própria key.
+func validateBybitAPIKey(client *http.Client, account *BybitAccount) (*APIKeyInfo, error) {
+	req, err := newBybitSignedGet(context.Background(), account, "/v5/user/query-api", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := doAPIKeyRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			ReadOnly    int                 `json:"readOnly"`
+			IPs         []string            `json:"ips"`
+			Permissions map[string][]string `json:"permissions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("resposta inesperada da Bybit: %s", truncateForError(body))
+	}
+	if resp.RetCode != 0 {
+		return nil, fmt.Errorf("Bybit recusou a API key: %s (código %d)%s", resp.RetMsg, resp.RetCode, bybitAuthErrorHint(resp.RetCode))
+	}
+
+	info := &APIKeyInfo{ReadOnly: resp.Result.ReadOnly == 1}
+	for group, perms := range resp.Result.Permissions {
+		for _, p := range perms {
+			info.Permissions = append(info.Permissions, group+":"+p)
+		}
+	}
+	sort.Strings(info.Permissions)
+	for _, ip := range resp.Result.IPs {
+		// "*" significa sem restrição de IP
+		if ip != "" && ip != "*" {
+			info.IPs = append(info.IPs, ip)
+		}
+	}
+	return info, nil
+}
+
+// validateOKXAPIKey consulta GET /api/v5/account/config, que inclui as permissões da key (campo perm).
+func validateOKXAPIKey(client *http.Client, account *BybitAccount, passphrase string) (*APIKeyInfo, error) {
+	apiKey := strings.TrimSpace(account.APIKey)
+	apiSecret := strings.TrimSpace(account.APISecret)
+	requestPath := "/api/v5/account/config"
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+	mac := hmac.New(sha256.New, []byte(apiSecret))
+	mac.Write([]byte(timestamp + http.MethodGet + requestPath))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequest(http.MethodGet, okxRESTURL+requestPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("OK-ACCESS-KEY", apiKey)
+	req.Header.Set("OK-ACCESS-SIGN", signature)
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", passphrase)
+
+	body, err := doAPIKeyRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Perm string `json:"perm"`
+			IP   string `json:"ip"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("resposta inesperada da OKX: %s", truncateForError(body))
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("OKX recusou a API key: %s (código %s)", resp.Msg, resp.Code)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("resposta da OKX sem dados da conta")
+	}
+
+	info := &APIKeyInfo{}
+	for _, p := range strings.Split(resp.Data[0].Perm, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || p == "read_only" {
+			continue
+		}
+		info.Permissions = append(info.Permissions, p)
+	}
+	info.ReadOnly = len(info.Permissions) == 0
+	for _, ip := range strings.Split(resp.Data[0].IP, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			info.IPs = append(info.IPs, ip)
+		}
+	}
+	return info, nil
+}
+
+// newBybitSignedGet monta um GET autenticado na API REST da Bybit (assinatura HMAC ou RSA de
+// timestamp + key + recvWindow + query string, conforme o tipo da key).
+func newBybitSignedGet(ctx context.Context, account *BybitAccount, path string, query url.Values) (*http.Request, error) {
+	apiKey := strings.TrimSpace(account.APIKey)
+	timestamp := strconv.FormatInt(bybitNow().UnixMilli(), 10)
+	recvWindow := "5000"
+	queryString := query.Encode()
+
+	signature, err := bybitSign(account, timestamp+apiKey+recvWindow+queryString)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := bybitRESTURL + path
+	if queryString != "" {
+		endpoint += "?" + queryString
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-BAPI-API-KEY", apiKey)
+	req.Header.Set("X-BAPI-SIGN", signature)
+	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+	req.Header.Set("X-BAPI-RECV-WINDOW", recvWindow)
+	return req, nil
+}
+
+func doAPIKeyRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contatar a corretora: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta da corretora: %w", err)
+	}
+	// Erros de autenticação também vêm com corpo JSON (às vezes com status 401), então só falha aqui se não houver corpo
+	if resp.StatusCode != http.StatusOK && len(body) == 0 {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// bybitAuthErrorHint traduz os códigos de erro de autenticação mais comuns da Bybit.
+func bybitAuthErrorHint(retCode int) string {
+	switch retCode {
+	case 10003:
+		return " - API key inválida"
+	case 10004:
+		return " - assinatura inválida, confira o API Secret"
+	case 10005:
+		return " - a key não tem permissão para este recurso"
+	case 10010:
+		return " - IP não liberado para esta key"
+	}
+	return ""
+}
+
+func truncateForError(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
+}
+
+// formatAPIKeyInfo monta as linhas exibidas ao usuário com as permissões da key.
+func formatAPIKeyInfo(info *APIKeyInfo) []string {
+	var lines []string
+	if info.ReadOnly {
+		lines = append(lines, "Tipo: somente leitura")
+	} else {
+		lines = append(lines, "Tipo: leitura e escrita")
+	}
+	if len(info.Permissions) > 0 {
+		lines = append(lines, "Permissões: "+strings.Join(info.Permissions, ", "))
+	}
+	if len(info.IPs) > 0 {
+		lines = append(lines, "IPs liberados: "+strings.Join(info.IPs, ", "))
+	} else {
+		lines = append(lines, "IPs liberados: sem restrição")
+	}
+	return lines
+}
+
+// riskyPermissions retorna as permissões da key que vão além de leitura (negociar, transferir ou sacar).
+// O notificador só precisa ler ordens, execuções e carteira.
+func (info *APIKeyInfo) riskyPermissions() []string {
+	if info.ReadOnly {
+		return nil
+	}
+	var risky []string
+	for _, p := range info.Permissions {
+		lower := strings.ToLower(p)
+		for _, keyword := range riskyPermissionKeywords {
+			if strings.Contains(lower, keyword) {
+				risky = append(risky, p)
+				break
+			}
+		}
+	}
+	return risky
+}
+
+// refuseRiskyAPIKeys indica se keys com permissão de escrita devem ser recusadas no cadastro.
+func refuseRiskyAPIKeys() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(apiKeyPolicyEnv)), "refuse")
+}
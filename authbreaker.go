@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Circuit breaker de autenticação: quando a corretora recusa as credenciais AUTH_FAILURE_LIMIT vezes seguidas
+// (padrão 5) com o mesmo motivo, o monitoramento da conta é parado, a conta fica marcada no banco (auth_failures)
+// e o operador é avisado, em vez de tentar indefinidamente com uma key revogada. Erros de rede ou timeout na
+// autenticação não contam; iniciar o monitoramento da conta de novo remove a marcação.
+const (
+	authFailureLimitEnv     = "AUTH_FAILURE_LIMIT"
+	authFailureLimitDefault = 5
+)
+
+func authFailureLimit() int {
+	limit, err := strconv.Atoi(strings.TrimSpace(os.Getenv(authFailureLimitEnv)))
+	if err != nil || limit < 1 {
+		return authFailureLimitDefault
+	}
+	return limit
+}
+
+// authRejectedError é a resposta da corretora recusando a autenticação (credenciais inválidas, key revogada...).
+type authRejectedError struct {
+	Reason string // motivo informado pela corretora, usado para comparar tentativas seguidas
+	msg    string
+}
+
+func (e *authRejectedError) Error() string {
+	return e.msg
+}
+
+// authBreaker conta as recusas de autenticação seguidas com o mesmo motivo.
+type authBreaker struct {
+	reason string
+	count  int
+}
+
+// record registra a falha de uma tentativa e retorna true quando o limite de recusas iguais foi atingido.
+// Falhas que não são recusa de autenticação não mudam a contagem.
+func (b *authBreaker) record(err error) bool {
+	var rejected *authRejectedError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	if rejected.Reason != b.reason {
+		b.reason, b.count = rejected.Reason, 0
+	}
+	b.count++
+	return b.count >= authFailureLimit()
+}
+
+func (b *authBreaker) reset() {
+	b.reason, b.count = "", 0
+}
+
+// stopOnAuthFailure para o monitoramento da conta, marca a conta no banco e avisa o operador com o motivo.
+func (wsm *WebSocketManager) stopOnAuthFailure(wsConn *WebSocketConnection, breaker *authBreaker) {
+	reason := redactSecrets(breaker.reason)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	if logger != nil {
+		logger.Log("❌ Autenticação recusada %d vezes seguidas (%s); monitoramento parado até a conta ser corrigida", breaker.count, reason)
+	}
+	if err := wsm.accountManager.SetAuthFailure(wsConn.AccountID, reason, breaker.count); err != nil && logger != nil {
+		logger.Log("Erro ao marcar a falha de autenticação no banco: %v", err)
+	}
+	wsm.sendOperatorAlert(wsConn, eventMonitoringStopped, fmt.Sprintf("🔐 **%s**: a corretora recusou a autenticação %d vezes seguidas: %s\n"+
+		"O monitoramento foi parado. Verifique se a API key foi revogada, expirou ou perdeu permissões, corrija a conta e inicie o monitoramento novamente.",
+		wsConn.Account().Name, breaker.count, reason))
+	wsConn.requestStop()
+}
+
+// authFailureText descreve a marcação para exibição (ex.: "autenticação recusada em 16/10/2026 12:00: API key is invalid").
+func authFailureText(failure AuthFailure, tz *time.Location) string {
+	return fmt.Sprintf("autenticação recusada em %s: %s", failure.FailedAt.In(tz).Format("02/01/2006 15:04"), failure.Reason)
+}
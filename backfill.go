@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Recuperação de lacunas: ao reconectar (ou restaurar a conexão ao abrir o aplicativo), as execuções feitas
+// enquanto a conta estava sem conexão são buscadas na API REST da Bybit e notificadas como se tivessem chegado
+// pelo WebSocket. A lacuna começa no último sinal de vida da conexão (último frame recebido, ou o último evento
+// processado de qualquer tópico quando o aplicativo estava fechado) menos backfillMargin, e nunca antes da última
+// execução processada (checkpoint do tópico execution). Execuções que já estão no histórico são ignoradas.
+const (
+	backfillMargin         = time.Minute
+	backfillMaxWindow      = 7 * 24 * time.Hour // maior intervalo aceito pela Bybit em /v5/execution/list
+	backfillPageLimit      = 100
+	backfillMaxPages       = 50
+	backfillRequestTimeout = 15 * time.Second
+)
+
+// executionGapStart retorna o início da lacuna a recuperar depois da próxima conexão (zero = nada a recuperar:
+// primeira conexão de um início manual ou conta OKX). Chamado antes de cada tentativa de conexão.
+func executionGapStart(wsConn *WebSocketConnection) time.Time {
+	if wsConn.Account().Platform == "okx" {
+		return time.Time{}
+	}
+	wsConn.mu.Lock()
+	reconnecting, restored, lastAlive := wsConn.connectedOnce, wsConn.restored, wsConn.LastMessageAt
+	wsConn.mu.Unlock()
+
+	if !reconnecting {
+		if !restored {
+			return time.Time{}
+		}
+		// Aplicativo reaberto: o último evento processado antes de fechar é o último sinal de vida
+		for _, at := range getStreamCheckpoints(wsConn.AccountID) {
+			if at.After(lastAlive) {
+				lastAlive = at
+			}
+		}
+	}
+	if lastAlive.IsZero() {
+		return time.Time{}
+	}
+	start := lastAlive.Add(-backfillMargin)
+	if checkpoint := getStreamCheckpoint(wsConn.AccountID, "execution"); checkpoint.After(start) {
+		start = checkpoint
+	}
+	return start
+}
+
+// backfillExecutions busca as execuções entre since e until e processa as que ainda não foram notificadas.
+func (wsm *WebSocketManager) backfillExecutions(wsConn *WebSocketConnection, since, until time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "[PANIC] backfillExecutions para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "backfillExecutions", r)
+		}
+	}()
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	tz := loadTimezone(wsConn.Account().Timezone)
+
+	if until.Sub(since) > backfillMaxWindow {
+		if logger != nil {
+			logger.Log("⚠️ Lacuna maior que %s; execuções anteriores a %s não serão recuperadas", formatElapsed(backfillMaxWindow), until.Add(-backfillMaxWindow).In(tz).Format("02/01/2006 15:04:05"))
+		}
+		since = until.Add(-backfillMaxWindow)
+	}
+	if logger != nil {
+		logger.Log("[DEBUG] Lacuna de %s em execution desde %s; buscando execuções na API REST", formatElapsed(until.Sub(since)), since.In(tz).Format("02/01/2006 15:04:05"))
+	}
+
+	client := &http.Client{Timeout: backfillRequestTimeout}
+	executions, err := fetchBybitExecutions(wsConn.ctx, client, wsConn.Account(), since, until)
+	if err != nil {
+		if !wsConn.running() {
+			return
+		}
+		if logger != nil {
+			logger.Log("❌ Erro ao recuperar as execuções da lacuna: %v", err)
+		}
+		wsm.sendOperatorAlert(wsConn, eventAlert, fmt.Sprintf("⚠️ Conta **%s**: não foi possível recuperar as execuções feitas entre %s e %s, enquanto a conta estava sem conexão (%v).\n"+
+			"Confira o histórico da corretora: execuções desse período podem não ter sido notificadas.",
+			wsConn.Account().Name, since.In(tz).Format("02/01/2006 15:04:05"), until.In(tz).Format("02/01/2006 15:04:05"), err))
+		return
+	}
+
+	var missing []ExecutionData
+	var lastExecTime int64
+	for _, exec := range executions {
+		if known, err := wsm.accountManager.HasExecution(wsConn.AccountID, exec.ExecID); err == nil && known {
+			continue
+		}
+		missing = append(missing, exec)
+		if ms, err := strconv.ParseInt(exec.ExecTime, 10, 64); err == nil && ms > lastExecTime {
+			lastExecTime = ms
+		}
+	}
+	if len(missing) == 0 {
+		if logger != nil {
+			logger.Log("[DEBUG] Nenhuma execução perdida na lacuna (%d consultada(s))", len(executions))
+		}
+		return
+	}
+
+	if !wsConn.running() {
+		return
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		return parseMillisTimestamp(missing[i].ExecTime).Before(parseMillisTimestamp(missing[j].ExecTime))
+	})
+	correlationID := newCorrelationID()
+	if logger != nil {
+		logger.Log("✅ %s %d execução(ões) recuperada(s) da lacuna pela API REST", correlationTag(correlationID), len(missing))
+	}
+	wsm.handleExecutionMessage(wsConn, BybitExecutionMessage{
+		Topic:         "execution",
+		CreationTime:  time.Now().UnixMilli(),
+		Data:          missing,
+		CorrelationID: correlationID,
+	})
+	acceptStreamEvent(wsConn.AccountID, "execution", lastExecTime)
+}
+
+// fetchBybitExecutions consulta GET /v5/execution/list (inverse) entre since e until, seguindo a paginação.
+func fetchBybitExecutions(ctx context.Context, client *http.Client, account *BybitAccount, since, until time.Time) ([]ExecutionData, error) {
+	var executions []ExecutionData
+	cursor := ""
+	for page := 0; page < backfillMaxPages; page++ {
+		query := url.Values{}
+		query.Set("category", "inverse")
+		query.Set("startTime", strconv.FormatInt(since.UnixMilli(), 10))
+		query.Set("endTime", strconv.FormatInt(until.UnixMilli(), 10))
+		query.Set("limit", strconv.Itoa(backfillPageLimit))
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		req, err := newBybitSignedGet(ctx, account, "/v5/execution/list", query)
+		if err != nil {
+			return nil, err
+		}
+		body, err := doAPIKeyRequest(client, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp struct {
+			RetCode int    `json:"retCode"`
+			RetMsg  string `json:"retMsg"`
+			Result  struct {
+				NextPageCursor string          `json:"nextPageCursor"`
+				List           []ExecutionData `json:"list"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("resposta inesperada da Bybit: %s", truncateForError(body))
+		}
+		if resp.RetCode != 0 {
+			return nil, fmt.Errorf("Bybit recusou a consulta de execuções: %s (código %d)%s", resp.RetMsg, resp.RetCode, bybitAuthErrorHint(resp.RetCode))
+		}
+		for _, exec := range resp.Result.List {
+			// A lista da API REST não repete a categoria em cada item
+			if exec.Category == "" {
+				exec.Category = "inverse"
+			}
+			executions = append(executions, exec)
+		}
+		if resp.Result.NextPageCursor == "" || len(resp.Result.List) == 0 {
+			return executions, nil
+		}
+		cursor = resp.Result.NextPageCursor
+	}
+	return nil, fmt.Errorf("mais de %d execuções na lacuna", backfillMaxPages*backfillPageLimit)
+}
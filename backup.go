@@ -0,0 +1,324 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Formato do arquivo de backup:
+//
+//	magic (8 bytes) | salt (16) | iterações PBKDF2 (uint32 big endian) | nonce (12) | tar.gz cifrado com AES-256-GCM
+//
+// O cabeçalho entra como dado autenticado do GCM, então qualquer alteração no arquivo é detectada no restore.
+const (
+	backupMagic      = "BNBAK001"
+	backupSaltSize   = 16
+	backupIterations = 600000
+	backupFileExt    = ".bnbak"
+)
+
+// backupMaxIterations é o maior número de iterações aceito no cabeçalho: um arquivo corrompido ou adulterado não pode
+// fazer o restore derivar a chave por bilhões de rodadas (parecendo travado).
+const backupMaxIterations = 4 * backupIterations
+
+// backupPasswordEnv permite informar a senha sem prompt (ex.: backup agendado no cron).
+const backupPasswordEnv = "BACKUP_PASSWORD"
+
+// Nomes das entradas dentro do arquivo
+const (
+	backupDBEntry   = databaseFileName
+	backupLogsEntry = "logs/"
+)
+
+func runBackupCommand(db *Database, args []string) error {
+	if len(args) > 1 {
+		return errors.New("uso: backup [arquivo]")
+	}
+	outPath := fmt.Sprintf("bybit-notifier-backup-%s%s", time.Now().Format("20060102-150405"), backupFileExt)
+	if len(args) == 1 {
+		outPath = args[0]
+	}
+	if _, err := os.Stat(outPath); err == nil {
+		return fmt.Errorf("o arquivo %s já existe", outPath)
+	}
+	if err := requireMasterPasswordCLI(db); err != nil {
+		return err
+	}
+
+	password, err := readBackupPassword(true)
+	if err != nil {
+		return err
+	}
+
+	// VACUUM INTO gera uma cópia consistente mesmo com o aplicativo rodando e gravando no banco
+	tmpDir, err := os.MkdirTemp("", "bybit-notifier-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	dbCopy := filepath.Join(tmpDir, databaseFileName)
+	if _, err := db.GetDB().Exec(`VACUUM INTO ?`, dbCopy); err != nil {
+		return fmt.Errorf("erro ao copiar o banco: %w", err)
+	}
+
+	var archive bytes.Buffer
+	logCount, err := writeBackupArchive(&archive, dbCopy, getLogsDir())
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptBackup(archive.Bytes(), password)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, encrypted, 0600); err != nil {
+		return fmt.Errorf("erro ao gravar backup: %w", err)
+	}
+
+	fmt.Printf("Backup criado: %s (banco + %d arquivo(s) de log)\n", outPath, logCount)
+	fmt.Println(colorYellow("Segredos guardados no keyring do sistema não fazem parte do backup."))
+	return nil
+}
+
+func runRestoreCommand(db *Database, args []string) error {
+	if len(args) != 1 {
+		return errors.New("uso: restore <arquivo>")
+	}
+	// O aplicativo precisa estar parado: o lock garante que nenhuma instância está usando o banco
+	lock, err := acquireInstanceLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	password, err := readBackupPassword(false)
+	if err != nil {
+		return err
+	}
+	archive, err := decryptBackup(data, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("O banco atual (%s) será substituído; uma cópia dele ficará com a extensão .antes-do-restore.\n", getDatabasePath())
+	fmt.Print("Continuar? (sim/s ou não/n): ")
+	scanner := cliStdinScanner
+	scanner.Scan()
+	confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	if confirmation != "sim" && confirmation != "s" {
+		fmt.Println("Restore cancelado.")
+		return nil
+	}
+
+	logCount, err := extractBackupArchive(archive, getDatabasePath(), getLogsDir())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backup restaurado: banco + %d arquivo(s) de log.\n", logCount)
+	return nil
+}
+
+// readBackupPassword lê a senha do BACKUP_PASSWORD ou do terminal (com confirmação ao criar o backup).
+func readBackupPassword(confirm bool) (string, error) {
+	if password := os.Getenv(backupPasswordEnv); password != "" {
+		return password, nil
+	}
+	scanner := cliStdinScanner
+	fmt.Print("Senha do backup: ")
+	password := readSecretLine(scanner)
+	if password == "" {
+		return "", errors.New("a senha não pode ser vazia")
+	}
+	if confirm {
+		fmt.Print("Confirme a senha: ")
+		if readSecretLine(scanner) != password {
+			return "", errors.New("as senhas não conferem")
+		}
+	}
+	return password, nil
+}
+
+// writeBackupArchive grava em w um tar.gz com a cópia do banco e os arquivos de log. Retorna a quantidade de logs.
+func writeBackupArchive(w io.Writer, dbPath, logsDir string) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := addFileToTar(tw, dbPath, backupDBEntry); err != nil {
+		return 0, fmt.Errorf("erro ao adicionar o banco ao backup: %w", err)
+	}
+
+	logCount := 0
+	entries, err := os.ReadDir(logsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := addFileToTar(tw, filepath.Join(logsDir, entry.Name()), backupLogsEntry+entry.Name()); err != nil {
+			return 0, fmt.Errorf("erro ao adicionar %s ao backup: %w", entry.Name(), err)
+		}
+		logCount++
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return logCount, gz.Close()
+}
+
+func addFileToTar(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractBackupArchive restaura o banco e os logs. Só aceita as entradas que o backup gera,
+// para que um arquivo adulterado não escreva fora dos diretórios de dados.
+func extractBackupArchive(archive []byte, dbPath, logsDir string) (int, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return 0, fmt.Errorf("backup corrompido: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	logCount := 0
+	restoredDB := false
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return logCount, fmt.Errorf("backup corrompido: %w", err)
+		}
+
+		switch {
+		case header.Name == backupDBEntry:
+			if _, err := os.Stat(dbPath); err == nil {
+				if err := os.Rename(dbPath, dbPath+".antes-do-restore"); err != nil {
+					return logCount, fmt.Errorf("erro ao preservar o banco atual: %w", err)
+				}
+			}
+			if err := writeFileFromReader(dbPath, tr); err != nil {
+				return logCount, err
+			}
+			restoredDB = true
+		case strings.HasPrefix(header.Name, backupLogsEntry):
+			name := strings.TrimPrefix(header.Name, backupLogsEntry)
+			if name == "" || name != filepath.Base(name) || name == ".." {
+				continue
+			}
+			if err := os.MkdirAll(logsDir, 0755); err != nil {
+				return logCount, err
+			}
+			if err := writeFileFromReader(filepath.Join(logsDir, name), tr); err != nil {
+				return logCount, err
+			}
+			logCount++
+		}
+	}
+	if !restoredDB {
+		return logCount, errors.New("o backup não contém o banco de dados")
+	}
+	return logCount, nil
+}
+
+func writeFileFromReader(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func encryptBackup(plaintext []byte, password string) ([]byte, error) {
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newBackupCipher(password, salt, backupIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(backupMagic)+backupSaltSize+4+len(nonce))
+	header = append(header, backupMagic...)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, backupIterations)
+	header = append(header, nonce...)
+	return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+func decryptBackup(data []byte, password string) ([]byte, error) {
+	headerSize := len(backupMagic) + backupSaltSize + 4 + 12
+	if len(data) < headerSize || string(data[:len(backupMagic)]) != backupMagic {
+		return nil, errors.New("arquivo não é um backup deste aplicativo")
+	}
+	salt := data[len(backupMagic) : len(backupMagic)+backupSaltSize]
+	iterations := binary.BigEndian.Uint32(data[len(backupMagic)+backupSaltSize:])
+	nonce := data[headerSize-12 : headerSize]
+	if iterations == 0 || iterations > backupMaxIterations {
+		return nil, fmt.Errorf("backup corrompido: número de iterações inválido (%d)", iterations)
+	}
+
+	gcm, err := newBackupCipher(password, salt, int(iterations))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, errors.New("senha incorreta ou arquivo corrompido")
+	}
+	return plaintext, nil
+}
+
+func newBackupCipher(password string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(password), salt, iterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
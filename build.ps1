@@ -7,5 +7,10 @@ Write-Host "Building for Windows..."
 $env:CGO_ENABLED = "1"
 go build -o bin/bybit-notifier-windows.exe -ldflags="-s -w" .
 
+Write-Host "Gerando checksums..."
+Get-ChildItem bin/bybit-notifier-* | ForEach-Object {
+    "$((Get-FileHash $_.FullName -Algorithm SHA256).Hash.ToLower())  $($_.Name)"
+} | Set-Content -Encoding ascii bin/checksums.txt
+
 Write-Host "Build concluído! Arquivo em ./bin/"
 
@@ -8,5 +8,8 @@ mkdir -p bin
 echo "Building for Linux..."
 CGO_ENABLED=1 GOOS=linux GOARCH=amd64 go build -o bin/bybit-notifier-linux -ldflags="-s -w" .
 
+echo "Gerando checksums..."
+(cd bin && sha256sum bybit-notifier-* > checksums.txt)
+
 echo "Builds concluídos! Arquivos em ./bin/"
 
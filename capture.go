@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A captura crua grava cada payload recebido do WebSocket (comprimido) na tabela raw_messages, por uma janela
+// limitada. Fica desligada por padrão; o fim da janela de cada conta fica em app_settings (rawCaptureSettingPrefix + ID).
+const (
+	rawCaptureSettingPrefix = "raw_capture_until."
+	rawCaptureDefaultWindow = 24 * time.Hour
+	rawCaptureMaxWindow     = 7 * 24 * time.Hour
+	// rawCaptureRefreshInterval: o comando capture roda em outro processo; o menu relê as janelas nesse intervalo
+	rawCaptureRefreshInterval = 30 * time.Second
+)
+
+// Origem do payload (conexão que o recebeu)
+const (
+	rawStreamBybitPrivate = "bybit-private"
+	rawStreamOKXPrivate   = "okx-private"
+	rawStreamOKXBusiness  = "okx-business"
+)
+
+var rawCapture = struct {
+	mu       sync.Mutex
+	db       *Database
+	until    map[int64]time.Time
+	loadedAt time.Time
+}{
+	until: make(map[int64]time.Time),
+}
+
+// enableRawCapture passa a gravar os payloads das contas com captura ligada.
+func enableRawCapture(db *Database) {
+	rawCapture.mu.Lock()
+	rawCapture.db = db
+	rawCapture.loadedAt = time.Time{}
+	rawCapture.mu.Unlock()
+}
+
+// rawCaptureDB retorna o banco se a captura está ligada para a conta agora, ou nil.
+func rawCaptureDB(accountID int64) *Database {
+	rawCapture.mu.Lock()
+	defer rawCapture.mu.Unlock()
+	if rawCapture.db == nil {
+		return nil
+	}
+	if time.Since(rawCapture.loadedAt) > rawCaptureRefreshInterval {
+		windows, err := loadRawCaptureWindows(rawCapture.db)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao carregar a configuração de captura: %v\n", err)
+		} else {
+			rawCapture.until = windows
+		}
+		rawCapture.loadedAt = time.Now()
+	}
+	if time.Now().Before(rawCapture.until[accountID]) {
+		return rawCapture.db
+	}
+	return nil
+}
+
+func loadRawCaptureWindows(db *Database) (map[int64]time.Time, error) {
+	settings, err := db.ListSettingsByPrefix(rawCaptureSettingPrefix)
+	if err != nil {
+		return nil, err
+	}
+	windows := make(map[int64]time.Time, len(settings))
+	for key, value := range settings {
+		accountID, err := strconv.ParseInt(strings.TrimPrefix(key, rawCaptureSettingPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		if until, err := time.Parse(time.RFC3339, value); err == nil {
+			windows[accountID] = until
+		}
+	}
+	return windows, nil
+}
+
+// captureRawMessage grava o payload, com o ID de correlação da mensagem, se a captura estiver ligada para a conta.
+func captureRawMessage(accountID int64, stream, correlationID string, message []byte) {
+	db := rawCaptureDB(accountID)
+	if db == nil {
+		return
+	}
+	if err := db.AddRawMessage(accountID, stream, correlationID, message, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao gravar mensagem crua da conta %d: %v\n", accountID, err)
+	}
+}
+
+func compressPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressPayload(data []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+const captureUsage = "uso: capture <conta> [on [horas]|off|dump [arquivo]]"
+
+// runCaptureCommand liga/desliga a captura crua da conta, mostra o estado ou exporta o que foi capturado.
+func runCaptureCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New(captureUsage)
+	}
+	manager := NewAccountManager(db)
+	account, err := findAccountByNameOrID(manager, args[0])
+	if err != nil {
+		return err
+	}
+	settingKey := rawCaptureSettingPrefix + strconv.FormatInt(account.ID, 10)
+
+	action := "status"
+	if len(args) > 1 {
+		action = args[1]
+	}
+	switch action {
+	case "status":
+		if len(args) > 2 {
+			return errors.New(captureUsage)
+		}
+		count, err := db.CountRawMessages(account.ID)
+		if err != nil {
+			return err
+		}
+		value, _, err := db.GetSetting(settingKey)
+		if err != nil {
+			return err
+		}
+		until, parseErr := time.Parse(time.RFC3339, value)
+		if parseErr == nil && time.Now().Before(until) {
+			fmt.Printf("Captura ligada até %s.\n", until.In(loadTimezone(account.Timezone)).Format("02/01/2006 15:04"))
+		} else {
+			fmt.Println("Captura desligada.")
+		}
+		fmt.Printf("%d mensagem(ns) capturada(s).\n", count)
+		return nil
+	case "on":
+		window := rawCaptureDefaultWindow
+		if len(args) > 3 {
+			return errors.New(captureUsage)
+		}
+		if len(args) == 3 {
+			hours, err := strconv.Atoi(args[2])
+			if err != nil || hours < 1 || time.Duration(hours)*time.Hour > rawCaptureMaxWindow {
+				return fmt.Errorf("quantidade de horas inválida: %s (de 1 a %d)", args[2], int(rawCaptureMaxWindow.Hours()))
+			}
+			window = time.Duration(hours) * time.Hour
+		}
+		until := time.Now().Add(window).UTC()
+		if err := db.SetSetting(settingKey, until.Format(time.RFC3339)); err != nil {
+			return err
+		}
+		fmt.Printf("Captura ligada até %s (o aplicativo aberto passa a gravar em até %d segundos).\n",
+			until.In(loadTimezone(account.Timezone)).Format("02/01/2006 15:04"), int(rawCaptureRefreshInterval.Seconds()))
+		fmt.Println(colorYellow("Os payloads contêm dados da conta (ordens, saldos) e ficam guardados conforme a retenção 'raw' (padrão: 3 dias)."))
+		return nil
+	case "off":
+		if len(args) > 2 {
+			return errors.New(captureUsage)
+		}
+		if err := db.DeleteSetting(settingKey); err != nil {
+			return err
+		}
+		fmt.Println("Captura desligada. As mensagens já capturadas seguem a retenção 'raw'.")
+		return nil
+	case "dump":
+		if len(args) > 3 {
+			return errors.New(captureUsage)
+		}
+		var out io.Writer = os.Stdout
+		if len(args) == 3 {
+			f, err := os.OpenFile(args[2], os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		count, err := dumpRawMessages(out, db, account.ID)
+		if err != nil {
+			return err
+		}
+		if len(args) == 3 {
+			fmt.Printf("%d mensagem(ns) exportada(s) para %s\n", count, args[2])
+		}
+		return nil
+	default:
+		return errors.New(captureUsage)
+	}
+}
+
+// rawMessageDump é uma linha do dump (JSON Lines), em ordem cronológica, no formato lido pela reprodução.
+type rawMessageDump struct {
+	ReceivedAt    time.Time       `json:"received_at"`
+	Stream        string          `json:"stream"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func dumpRawMessages(out io.Writer, db *Database, accountID int64) (int, error) {
+	records, err := db.ListRawMessages(accountID)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(out)
+	for _, record := range records {
+		payload := json.RawMessage(record.Payload)
+		if !json.Valid(payload) {
+			quoted, _ := json.Marshal(string(record.Payload))
+			payload = quoted
+		}
+		if err := enc.Encode(rawMessageDump{ReceivedAt: record.ReceivedAt, Stream: record.Stream, CorrelationID: record.CorrelationID, Payload: payload}); err != nil {
+			return 0, err
+		}
+	}
+	return len(records), nil
+}
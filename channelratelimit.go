@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limite de notificações por canal: a preferência rate_limit da conta limita quantas notificações cada canal
+// (Discord ou webhook genérico) recebe por minuto, ex.: settings "Bot Grid" rate_limit '{"discord": 10}'. Passado o
+// limite, as notificações do canal deixam de sair uma a uma e são acumuladas; quando a janela de um minuto libera
+// uma vaga, saem todas numa única mensagem de resumo com a primeira linha de cada uma. Protege canais compartilhados
+// de uma conta que gera muitas notificações (bot de grid, por exemplo) sem perder o registro do que aconteceu.
+const rateLimitSettingKey = "rate_limit"
+
+const (
+	rateLimitWindow       = time.Minute
+	rateLimitSummaryLines = 15 // linhas de notificações agrupadas no resumo; o resto vira "... e mais N"
+)
+
+func validateRateLimitSetting(value json.RawMessage) error {
+	var limits map[string]int
+	if json.Unmarshal(value, &limits) != nil {
+		return errors.New(`use um objeto {"canal": notificações por minuto}, ex.: {"discord": 10, "execucoes": 20}`)
+	}
+	for channel, limit := range limits {
+		if !isRoutableChannel(channel) {
+			return fmt.Errorf("canal desconhecido: %s (use %s)", channel, strings.Join(routableChannels, ", "))
+		}
+		if limit < 1 {
+			return fmt.Errorf("%s: limite inválido: %d (mínimo 1 por minuto)", channel, limit)
+		}
+	}
+	return nil
+}
+
+// channelRateLimit retorna o limite por minuto do canal na conta (0 = sem limite).
+func channelRateLimit(account *BybitAccount, channel string) int {
+	var limits map[string]int
+	if !account.Settings.Decode(rateLimitSettingKey, &limits) {
+		return 0
+	}
+	// O bot posta o canal principal: sem limite próprio, vale o do discord
+	if _, own := limits[channel]; !own && channel == notifyChannelDiscordBot {
+		channel = notifyChannelDiscord
+	}
+	if limits[channel] < 1 {
+		return 0
+	}
+	return limits[channel]
+}
+
+// rateLimitedChannel é a janela de um canal: os envios do último minuto e as notificações acumuladas até o resumo.
+type rateLimitedChannel struct {
+	sent           []time.Time
+	folded         []string // primeira linha de cada notificação acumulada
+	correlationIDs []string
+	timer          *connTimer // envio do resumo quando a janela liberar
+}
+
+// channelRateLimiter guarda as janelas dos canais da conexão.
+type channelRateLimiter struct {
+	mu       sync.Mutex
+	channels map[string]*rateLimitedChannel
+}
+
+// admitNotification informa se a notificação pode ser enviada agora ao canal; false = acumulada para o resumo, que é
+// agendado para quando a janela liberar uma vaga.
+func (wsm *WebSocketManager) admitNotification(wsConn *WebSocketConnection, channel, messageText string, correlationIDs []string) bool {
+	limit := channelRateLimit(wsConn.Account(), channel)
+	if limit == 0 {
+		return true
+	}
+	l := &wsConn.rateLimiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.channels == nil {
+		l.channels = make(map[string]*rateLimitedChannel)
+	}
+	state := l.channels[channel]
+	if state == nil {
+		state = &rateLimitedChannel{}
+		l.channels[channel] = state
+	}
+	now := time.Now()
+	for len(state.sent) > 0 && now.Sub(state.sent[0]) >= rateLimitWindow {
+		state.sent = state.sent[1:]
+	}
+	// Com notificações já acumuladas, as novas também esperam o resumo, para não saírem fora de ordem
+	if len(state.folded) == 0 && len(state.sent) < limit {
+		state.sent = append(state.sent, now)
+		return true
+	}
+
+	state.folded = append(state.folded, notificationSummary(messageText))
+	state.correlationIDs = append(state.correlationIDs, correlationIDs...)
+	if state.timer == nil {
+		state.timer = wsConn.afterFunc(timerRateLimit, state.sent[0].Add(rateLimitWindow).Sub(now), func() {
+			wsm.sendRateLimitSummary(wsConn, channel)
+		})
+		if logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name); logger != nil {
+			logger.Log("⏳ Limite de %d notificações por minuto atingido (%s); as próximas saem agrupadas num resumo", limit, notifyChannelLabel(channel))
+		}
+	}
+	return false
+}
+
+// sendRateLimitSummary envia ao canal o resumo das notificações acumuladas; o resumo conta como um envio da janela.
+func (wsm *WebSocketManager) sendRateLimitSummary(wsConn *WebSocketConnection, channel string) {
+	l := &wsConn.rateLimiter
+	l.mu.Lock()
+	state := l.channels[channel]
+	if state == nil || len(state.folded) == 0 {
+		l.mu.Unlock()
+		return
+	}
+	folded, correlationIDs := state.folded, state.correlationIDs
+	state.folded, state.correlationIDs, state.timer = nil, nil, nil
+	state.sent = append(state.sent, time.Now())
+	l.mu.Unlock()
+
+	lines := folded
+	if len(lines) > rateLimitSummaryLines {
+		lines = lines[:rateLimitSummaryLines]
+	}
+	text := fmt.Sprintf("⏳ %d notificações agrupadas pelo limite de %d por minuto deste canal:\n• %s",
+		len(folded), channelRateLimit(wsConn.Account(), channel), strings.Join(lines, "\n• "))
+	if rest := len(folded) - len(lines); rest > 0 {
+		text += fmt.Sprintf("\n... e mais %d", rest)
+	}
+	if channelWebhookURL(wsConn.Account(), channel) == "" {
+		return
+	}
+	discordMsg := buildDiscordMessage(wsConn.Account(), text, false, false)
+	account := wsConn.Account()
+	wsConn.enqueueDelivery(channel, discordMsg, correlationIDs, func() {
+		err := deliverNotification(account.ID, channel, discordMsg, correlationIDs, channelSender(account, channel, discordMsg, "", correlationIDs))
+		if err != nil {
+			if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+				logger.Log("%s Erro ao enviar o resumo de %d notificações agrupadas (%s): %v", correlationTag(correlationIDs...), len(folded), notifyChannelLabel(channel), err)
+			}
+		}
+	})
+}
+
+// flushRateLimits envia na hora os resumos pendentes da conexão (parada do monitoramento).
+func (wsm *WebSocketManager) flushRateLimits(wsConn *WebSocketConnection) {
+	l := &wsConn.rateLimiter
+	l.mu.Lock()
+	var pending []string
+	for channel, state := range l.channels {
+		if state.timer != nil && state.timer.Stop() {
+			pending = append(pending, channel)
+		}
+	}
+	l.mu.Unlock()
+	for _, channel := range pending {
+		wsm.sendRateLimitSummary(wsConn, channel)
+	}
+}
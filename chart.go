@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// Gráfico no resumo de carteira: com a preferência summary_chart da conta, o resumo de posições enviado ao Discord
+// leva um gráfico de barras por moeda (total, protegido, long e exposto em USD). A imagem é gerada pelo QuickChart a
+// partir da configuração do gráfico na própria URL e anexada como imagem do embed: quem busca a imagem é o Discord,
+// o aplicativo não faz chamadas extras. Use true para o quickchart.io ou a URL de uma instância própria, ex.:
+// settings "Minha Conta" summary_chart '"https://quickchart.minha-rede.local/chart"'.
+const summaryChartSettingKey = "summary_chart"
+
+const defaultQuickChartURL = "https://quickchart.io/chart"
+
+// summaryChartMaxURL é o maior tamanho de URL de imagem aceito pelo Discord no embed.
+const summaryChartMaxURL = 2048
+
+func validateSummaryChartSetting(value json.RawMessage) error {
+	var enabled bool
+	if json.Unmarshal(value, &enabled) == nil {
+		return nil
+	}
+	var raw string
+	if json.Unmarshal(value, &raw) != nil || !validHeartbeatURL(raw) {
+		return errors.New(`use true, false ou a URL http(s) do QuickChart, ex.: "https://quickchart.io/chart"`)
+	}
+	return nil
+}
+
+// summaryChartBaseURL retorna a URL do QuickChart da conta ("" = gráfico desligado).
+func summaryChartBaseURL(account *BybitAccount) string {
+	raw, ok := account.Settings[summaryChartSettingKey]
+	if !ok {
+		return ""
+	}
+	var enabled bool
+	if json.Unmarshal(raw, &enabled) == nil {
+		if enabled {
+			return defaultQuickChartURL
+		}
+		return ""
+	}
+	var base string
+	if json.Unmarshal(raw, &base) == nil && validHeartbeatURL(base) {
+		return strings.TrimSpace(base)
+	}
+	return ""
+}
+
+// walletSummaryChartURL monta a URL da imagem do gráfico do resumo ("" = sem gráfico: desligado, sem moedas ou
+// URL longa demais para o Discord).
+func walletSummaryChartURL(account *BybitAccount, data walletSummaryTemplateData) string {
+	base := summaryChartBaseURL(account)
+	if base == "" || len(data.Coins) == 0 {
+		return ""
+	}
+	labels := make([]string, 0, len(data.Coins))
+	total := make([]float64, 0, len(data.Coins))
+	protected := make([]float64, 0, len(data.Coins))
+	long := make([]float64, 0, len(data.Coins))
+	exposed := make([]float64, 0, len(data.Coins))
+	for _, coin := range data.Coins {
+		labels = append(labels, coin.Coin)
+		total = append(total, chartValue(coin.Total))
+		protected = append(protected, chartValue(coin.Protected))
+		long = append(long, chartValue(coin.Long))
+		exposed = append(exposed, chartValue(coin.Exposed))
+	}
+	dataset := func(label, color string, values []float64) map[string]interface{} {
+		return map[string]interface{}{"label": label, "backgroundColor": color, "data": values}
+	}
+	chart := map[string]interface{}{
+		"type": "bar",
+		"data": map[string]interface{}{
+			"labels": labels,
+			"datasets": []interface{}{
+				dataset("Total", "#607d8b", total),
+				dataset("Protegido", "#2e7d32", protected),
+				dataset("Long", "#1565c0", long),
+				dataset("Exposto", "#c62828", exposed),
+			},
+		},
+		"options": map[string]interface{}{
+			"title": map[string]interface{}{
+				"display": true,
+				"text":    fmt.Sprintf("Carteira $%s USD - %s%% protegida", formatPriceCoin(data.Total), formatPriceCoin(data.ProtectedPct)),
+			},
+		},
+	}
+	config, err := json.Marshal(chart)
+	if err != nil {
+		return ""
+	}
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	chartURL := base + separator + "w=600&h=300&bkg=white&c=" + url.QueryEscape(string(config))
+	if len(chartURL) > summaryChartMaxURL {
+		return ""
+	}
+	return chartURL
+}
+
+// chartValue arredonda o valor em USD para 2 casas, para a URL do gráfico não crescer à toa.
+func chartValue(v float64) float64 {
+	return math.Round(v*100) / 100
+}
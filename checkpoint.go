@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// streamCheckpoints guarda o creationTime (ms) da última mensagem processada por conta e tópico.
+// Fica em memória e vai para o banco junto com as estatísticas (flushStats), para não gravar a cada mensagem.
+var streamCheckpoints = struct {
+	mu    sync.Mutex
+	db    *Database
+	last  map[int64]map[string]int64
+	dirty map[int64]map[string]bool
+}{
+	last:  make(map[int64]map[string]int64),
+	dirty: make(map[int64]map[string]bool),
+}
+
+// enableStreamCheckpoints carrega os checkpoints gravados e passa a persistir os novos.
+func enableStreamCheckpoints(db *Database) {
+	checkpoints, err := db.ListStreamCheckpoints()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao carregar checkpoints das conexões: %v\n", err)
+	}
+	streamCheckpoints.mu.Lock()
+	defer streamCheckpoints.mu.Unlock()
+	streamCheckpoints.db = db
+	for accountID, topics := range checkpoints {
+		streamCheckpoints.last[accountID] = topics
+	}
+}
+
+// acceptStreamEvent registra o creationTime de uma mensagem recebida. Retorna false se a mensagem é
+// anterior ao último evento já processado do tópico (reenvio após reconexão/reinício), que deve ser descartada.
+func acceptStreamEvent(accountID int64, topic string, creationTime int64) bool {
+	if creationTime <= 0 {
+		return true
+	}
+	streamCheckpoints.mu.Lock()
+	defer streamCheckpoints.mu.Unlock()
+	topics, exists := streamCheckpoints.last[accountID]
+	if !exists {
+		topics = make(map[string]int64)
+		streamCheckpoints.last[accountID] = topics
+	}
+	if creationTime < topics[topic] {
+		return false
+	}
+	if creationTime > topics[topic] {
+		topics[topic] = creationTime
+		if streamCheckpoints.dirty[accountID] == nil {
+			streamCheckpoints.dirty[accountID] = make(map[string]bool)
+		}
+		streamCheckpoints.dirty[accountID][topic] = true
+	}
+	return true
+}
+
+// getStreamCheckpoint retorna o horário do último evento processado do tópico (zero se nunca recebeu).
+func getStreamCheckpoint(accountID int64, topic string) time.Time {
+	streamCheckpoints.mu.Lock()
+	defer streamCheckpoints.mu.Unlock()
+	if ms := streamCheckpoints.last[accountID][topic]; ms > 0 {
+		return time.UnixMilli(ms)
+	}
+	return time.Time{}
+}
+
+// getStreamCheckpoints retorna os checkpoints da conta (tópico -> horário do último evento).
+func getStreamCheckpoints(accountID int64) map[string]time.Time {
+	streamCheckpoints.mu.Lock()
+	defer streamCheckpoints.mu.Unlock()
+	result := make(map[string]time.Time)
+	for topic, ms := range streamCheckpoints.last[accountID] {
+		result[topic] = time.UnixMilli(ms)
+	}
+	return result
+}
+
+// flushStreamCheckpoints grava no banco os checkpoints alterados desde a última gravação.
+func flushStreamCheckpoints() {
+	streamCheckpoints.mu.Lock()
+	db := streamCheckpoints.db
+	pending := make(map[int64]map[string]int64)
+	for accountID, topics := range streamCheckpoints.dirty {
+		pending[accountID] = make(map[string]int64)
+		for topic := range topics {
+			pending[accountID][topic] = streamCheckpoints.last[accountID][topic]
+		}
+	}
+	streamCheckpoints.dirty = make(map[int64]map[string]bool)
+	streamCheckpoints.mu.Unlock()
+
+	if db == nil {
+		return
+	}
+	for accountID, topics := range pending {
+		for topic, creationTime := range topics {
+			if err := db.SaveStreamCheckpoint(accountID, topic, creationTime); err != nil {
+				fmt.Fprintf(os.Stderr, "Erro ao gravar checkpoint da conta %d (%s): %v\n", accountID, topic, err)
+			}
+		}
+	}
+}
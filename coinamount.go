@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Valores em moeda: nos contratos inversos a quantidade vem em USD, mas a margem e o resultado são na moeda. As
+// mensagens de ordens, stops e execuções mostram também o equivalente na moeda ao preço do próprio evento (preço da
+// ordem, trigger do stop, preço da execução), ex.: "Qty: 10000 USD ≈ 0.105 BTC"; o resumo de carteira usa a cotação
+// do saldo da moeda.
+
+// coinAmountDigits é o número de algarismos significativos do valor em moeda.
+const coinAmountDigits = 4
+
+// coinAmount converte a quantidade em USD para a moeda ao preço informado (false se algum valor for inválido).
+func coinAmount(qtyUSD, price float64) (float64, bool) {
+	if qtyUSD <= 0 || price <= 0 || math.IsInf(qtyUSD/price, 0) {
+		return 0, false
+	}
+	return qtyUSD / price, true
+}
+
+// formatCoinAmount formata o valor em moeda com coinAmountDigits algarismos significativos (até 8 casas, o satoshi).
+func formatCoinAmount(v float64) string {
+	decimals := coinAmountDigits - 1 - int(math.Floor(math.Log10(math.Abs(v))))
+	if decimals < 0 {
+		decimals = 0
+	} else if decimals > 8 {
+		decimals = 8
+	}
+	s := fmt.Sprintf("%.*f", decimals, v)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// coinEquivalent retorna o sufixo com o valor na moeda do símbolo (ex: " ≈ 0.105 BTC") ou "" se não der para calcular.
+func coinEquivalent(symbol string, qtyUSD, price float64) string {
+	amount, ok := coinAmount(qtyUSD, price)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" ≈ %s %s", formatCoinAmount(amount), symbolToCoin(symbol))
+}
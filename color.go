@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiDim     = "\033[2m"
+	ansiReverse = "\033[7m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiCyan    = "\033[36m"
+)
+
+var (
+	colorOnce    sync.Once
+	colorEnabled bool
+)
+
+// colorsEnabled indica se a saída deve usar cores ANSI.
+// Desliga automaticamente quando stdout não é um terminal ou quando NO_COLOR está definido.
+func colorsEnabled() bool {
+	colorOnce.Do(func() {
+		colorEnabled = detectColorSupport()
+	})
+	return colorEnabled
+}
+
+func detectColorSupport() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if os.Getenv("FORCE_COLOR") != "" {
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// No Windows, só o Windows Terminal e terminais com TERM definido interpretam ANSI por padrão
+	if runtime.GOOS == "windows" {
+		return os.Getenv("WT_SESSION") != "" || os.Getenv("TERM") != "" || os.Getenv("ANSICON") != ""
+	}
+	return true
+}
+
+func colorize(code, s string) string {
+	if !colorsEnabled() || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func colorRed(s string) string    { return colorize(ansiRed, s) }
+func colorGreen(s string) string  { return colorize(ansiGreen, s) }
+func colorYellow(s string) string { return colorize(ansiYellow, s) }
+func colorCyan(s string) string   { return colorize(ansiCyan, s) }
+func colorDim(s string) string    { return colorize(ansiDim, s) }
+func colorBold(s string) string   { return colorize(ansiBold, s) }
+
+// colorStatus pinta o texto de verde quando ok e de vermelho caso contrário.
+func colorStatus(text string, ok bool) string {
+	if ok {
+		return colorGreen(text)
+	}
+	return colorRed(text)
+}
+
+var logTimestampRe = regexp.MustCompile(`^\[\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\]`)
+
+// colorLogTimestamp destaca o timestamp no início de uma linha de log.
+func colorLogTimestamp(line string) string {
+	if !colorsEnabled() {
+		return line
+	}
+	loc := logTimestampRe.FindStringIndex(line)
+	if loc == nil {
+		return line
+	}
+	return colorCyan(line[:loc[1]]) + line[loc[1]:]
+}
+
+// colorLogLine pinta a linha de log conforme o conteúdo, para o tail de uma conta movimentada ficar legível:
+// erros em vermelho, avisos em amarelo, notificações enviadas em verde e [DEBUG] esmaecido.
+func colorLogLine(line string) string {
+	if !colorsEnabled() {
+		return line
+	}
+	timestamp, body := "", line
+	if loc := logTimestampRe.FindStringIndex(line); loc != nil {
+		timestamp, body = line[:loc[1]], line[loc[1]:]
+	}
+	switch logLineSeverity(body) {
+	case severityDebug:
+		return colorDim(line)
+	case severityError:
+		body = colorRed(body)
+	case severityWarning:
+		body = colorYellow(body)
+	default:
+		if isNotificationLogLine(body) {
+			body = colorGreen(body)
+		}
+	}
+	return colorCyan(timestamp) + body
+}
+
+// isNotificationLogLine indica se a linha registra uma notificação enviada (ou reenviada).
+func isNotificationLogLine(line string) bool {
+	lower := strings.ToLower(line)
+	return strings.Contains(lower, "✅") || ((strings.Contains(lower, "notificação") || strings.Contains(lower, "resumo")) &&
+		(strings.Contains(lower, "enviad") || strings.Contains(lower, "reenviad")))
+}
+
+// printErrorf imprime uma mensagem de erro em vermelho (sem cor quando desabilitado).
+func printErrorf(format string, args ...interface{}) {
+	fmt.Print(colorizeLines(ansiRed, redactSecrets(fmt.Sprintf(format, args...))))
+}
+
+// printWarningf imprime um aviso em amarelo (sem cor quando desabilitado).
+func printWarningf(format string, args ...interface{}) {
+	fmt.Print(colorizeLines(ansiYellow, redactSecrets(fmt.Sprintf(format, args...))))
+}
+
+// colorizeLines aplica a cor a cada linha, preservando as quebras de linha fora dos códigos ANSI.
+func colorizeLines(code, s string) string {
+	if !colorsEnabled() {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = colorize(code, line)
+	}
+	return strings.Join(lines, "\n")
+}
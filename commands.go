@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cliCommand descreve um subcomando de linha de comando (modo não interativo).
+type cliCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	AccountArg  bool // primeiro argumento é o nome (ou ID) de uma conta; usado pela completion
+	Hidden      bool // não aparece na ajuda nem na completion
+	NeedsDB     bool
+	Run         func(db *Database, args []string) error
+}
+
+// cliStdinScanner é compartilhado pelos subcomandos que leem do stdin (senhas, confirmações), para que
+// com entrada redirecionada uma leitura não consuma as linhas destinadas à próxima.
+var cliStdinScanner = bufio.NewScanner(os.Stdin)
+
+// getCLICommands retorna os subcomandos disponíveis.
+func getCLICommands() []cliCommand {
+	return []cliCommand{
+		{Name: "help", Usage: "help", Description: "Exibe esta ajuda", Run: runHelpCommand},
+		{Name: "version", Usage: "version", Description: "Exibe a versão", Run: runVersionCommand},
+		{Name: "list", Usage: "list", Description: "Lista as contas cadastradas", NeedsDB: true, Run: runListCommand},
+		{Name: "activate", Usage: "activate <conta>", Description: "Ativa a conta (entra em \"Todas as contas\" e na restauração)", AccountArg: true, NeedsDB: true, Run: runActivateCommand},
+		{Name: "deactivate", Usage: "deactivate <conta>", Description: "Desativa a conta sem removê-la", AccountArg: true, NeedsDB: true, Run: runDeactivateCommand},
+		{Name: "test-notify", Usage: "test-notify <conta>", Description: "Envia uma notificação de teste para todos os webhooks da conta", AccountArg: true, NeedsDB: true, Run: runTestNotifyCommand},
+		{Name: "summary", Usage: "summary <conta>", Description: "Envia agora o resumo de carteira/posições da conta", AccountArg: true, NeedsDB: true, Run: runSummaryCommand},
+		{Name: "self-update", Usage: "self-update [--check|--force]", Description: "Baixa, verifica (SHA-256) e instala a última versão publicada", Run: runSelfUpdateCommand},
+		{Name: "backup", Usage: "backup [arquivo]", Description: "Cria um backup cifrado (senha) do banco e dos logs", NeedsDB: true, Run: runBackupCommand},
+		{Name: "restore", Usage: "restore <arquivo>", Description: "Restaura um backup criado com 'backup' (com o aplicativo parado)", Run: runRestoreCommand},
+		{Name: "master-password", Usage: "master-password <set|remove>", Description: "Define ou remove a senha mestra pedida para exibir, editar e exportar contas", NeedsDB: true, Run: runMasterPasswordCommand},
+		{Name: "db-encrypt", Usage: "db-encrypt", Description: "Cifra o banco com SQLCipher (requer executável compilado com -tags sqlcipher)", NeedsDB: true, Run: runDBEncryptCommand},
+		{Name: "migrate", Usage: "migrate <status|down <versão>>", Description: "Mostra as migrations do banco ou desfaz as posteriores à versão informada", NeedsDB: true, Run: runMigrateCommand},
+		{Name: "export", Usage: "export <conta> <tipo> ...", Description: "Exporta em CSV as execuções ou posições fechadas (tipo executions|positions; --from/--to DD/MM/AAAA, --symbol, --out)", AccountArg: true, NeedsDB: true, Run: runExportCommand},
+		{Name: "notifications", Usage: "notifications <conta> [--date DD/MM/AAAA] ...", Description: "Lista as notificações enviadas pela conta no dia ou período (--from/--to DD/MM/AAAA, --limit, --status sent|failed|logged)", AccountArg: true, NeedsDB: true, Run: runNotificationsCommand},
+		{Name: "tail", Usage: "tail <conta> [regex]", Description: "Acompanha o log da conta (até o Ctrl+C), opcionalmente só as linhas que casam com a regex", AccountArg: true, NeedsDB: true, Run: runTailCommand},
+		{Name: "settings", Usage: "settings <conta> [<chave> [<valor>|--unset]]", Description: "Mostra ou altera as preferências da conta (valores em JSON ou texto)", AccountArg: true, NeedsDB: true, Run: runSettingsCommand},
+		{Name: "capture", Usage: "capture <conta> [on [horas]|off|dump [arquivo]]", Description: "Liga/desliga a captura dos payloads crus do WebSocket (depuração) ou exporta o capturado em JSON Lines", AccountArg: true, NeedsDB: true, Run: runCaptureCommand},
+		{Name: "replay", Usage: "replay <conta> <arquivo> ...", Description: "Reproduz um dump da captura no processamento das notificações (--speed <N>|max, --webhook <url>)", AccountArg: true, NeedsDB: true, Run: runReplayCommand},
+		{Name: "purge", Usage: "purge <conta removida>", Description: "Apaga definitivamente uma conta removida (credenciais, estatísticas e keyring)", NeedsDB: true, Run: runPurgeCommand},
+		{Name: "retention", Usage: "retention [<histórico> <dias>]", Description: "Mostra ou define quantos dias manter de cada histórico (0 = para sempre)", NeedsDB: true, Run: runRetentionCommand},
+		{Name: "prune", Usage: "prune [--dry-run]", Description: "Apaga dos históricos os registros mais antigos que a retenção e compacta o banco", NeedsDB: true, Run: runPruneCommand},
+		{Name: "shared-webhook", Usage: "shared-webhook [add <nome> <url>|remove <nome>]", Description: "Lista, cadastra ou remove os webhooks do Discord compartilhados entre contas (preferência shared_webhooks)", NeedsDB: true, Run: runSharedWebhookCommand},
+		{Name: "api-token", Usage: "api-token <create|list|revoke> ...", Description: "Gerencia os tokens da API de controle (create <nome> <viewer|admin>, revoke <id>)", NeedsDB: true, Run: runAPITokenCommand},
+		{Name: "completion", Usage: "completion <bash|zsh|fish>", Description: "Gera o script de autocompletar para o shell", Run: runCompletionCommand},
+		{Name: "__complete", Hidden: true, NeedsDB: true, Run: runCompleteCommand},
+	}
+}
+
+// runCLI executa um subcomando e retorna o código de saída do processo.
+func runCLI(args []string) int {
+	name := args[0]
+	var cmd *cliCommand
+	commands := getCLICommands()
+	for i := range commands {
+		if commands[i].Name == name {
+			cmd = &commands[i]
+			break
+		}
+	}
+	if cmd == nil {
+		printErrorf("Comando desconhecido: %s\n", name)
+		fmt.Fprintf(os.Stderr, "Use '%s help' para ver os comandos disponíveis.\n", programName())
+		return 2
+	}
+
+	var db *Database
+	if cmd.NeedsDB {
+		var err error
+		db, err = NewDatabase()
+		if err != nil {
+			printErrorf("Erro ao conectar ao banco de dados: %v\n", err)
+			return 1
+		}
+		defer db.Close()
+		loadSharedWebhooksOrWarn(db)
+		// No banco em memória as contas só existem se vierem da configuração
+		if isInMemoryDatabase() {
+			if _, err := loadConfiguredAccounts(NewAccountManager(db)); err != nil {
+				printErrorf("Erro ao carregar as contas configuradas: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	err := cmd.Run(db, args[1:])
+	// Os logs das contas são gravados em background: garante que vão para o disco antes do os.Exit
+	flushLoggers()
+	flushLogSinks()
+	if err != nil {
+		printErrorf("Erro: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// programName retorna o nome do executável, usado na ajuda e nos scripts de completion.
+func programName() string {
+	name := filepath.Base(os.Args[0])
+	return strings.TrimSuffix(name, ".exe")
+}
+
+func runHelpCommand(db *Database, args []string) error {
+	fmt.Printf("Uso: %s [opções] [comando] [argumentos]\n", programName())
+	fmt.Println("Sem comando, abre o menu interativo.")
+	fmt.Println("\nOpções globais (antes do comando):")
+	fmt.Printf("  %-28s %s\n", "--db-path <arquivo>", "Caminho do banco SQLite (ou variável DB_PATH)")
+	fmt.Printf("  %-28s %s\n", "--data-dir <diretório>", "Diretório de dados: banco e logs (ou variável DATA_DIR)")
+	fmt.Printf("  %-28s %s\n", "--in-memory", "Banco só em memória, descartado ao encerrar")
+	fmt.Printf("  %-28s %s\n", "--accounts-file <arquivo>", "Contas em JSON cadastradas ao iniciar (ou ACCOUNTS_FILE/ACCOUNTS_JSON)")
+	fmt.Printf("  %-28s %s\n", "--mock-bybit[=<roteiro>]", "Contas Bybit conectam ao servidor simulado local (desenvolvimento)")
+	fmt.Println("\nComandos:")
+	for _, c := range getCLICommands() {
+		if c.Hidden {
+			continue
+		}
+		fmt.Printf("  %-28s %s\n", c.Usage, c.Description)
+	}
+	return nil
+}
+
+func runVersionCommand(db *Database, args []string) error {
+	fmt.Println(projectVersion)
+	return nil
+}
+
+func runListCommand(db *Database, args []string) error {
+	if err := requireMasterPasswordCLI(db); err != nil {
+		return err
+	}
+	accounts, err := NewAccountManager(db).ListAccounts()
+	if err != nil {
+		return err
+	}
+	for _, acc := range accounts {
+		fmt.Printf("%d\t%s\t%s\t%s\t%s\n", acc.ID, acc.Name, acc.Platform, getStatusText(acc.Active), acc.Notes)
+	}
+	return nil
+}
+
+func runActivateCommand(db *Database, args []string) error {
+	return setAccountActiveByArg(db, args, true)
+}
+
+func runDeactivateCommand(db *Database, args []string) error {
+	return setAccountActiveByArg(db, args, false)
+}
+
+func setAccountActiveByArg(db *Database, args []string, active bool) error {
+	if len(args) == 0 {
+		return errors.New("informe o nome ou ID da conta")
+	}
+	manager := NewAccountManager(db)
+	account, err := findAccountByNameOrID(manager, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if err := manager.SetAccountActive(account.ID, active); err != nil {
+		return err
+	}
+	if active {
+		fmt.Printf("Conta '%s' ativada.\n", account.Name)
+	} else {
+		fmt.Printf("Conta '%s' desativada.\n", account.Name)
+	}
+	return nil
+}
+
+func runTestNotifyCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("informe o nome ou ID da conta")
+	}
+	account, err := findAccountByNameOrID(NewAccountManager(db), strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	channels := testNotificationChannels(account)
+	if len(channels) == 0 {
+		return fmt.Errorf("a conta '%s' não tem nenhum webhook configurado", account.Name)
+	}
+	// O resultado de cada teste vai para o histórico de notificações, como as notificações reais
+	enableNotificationHistory(db)
+	failed := 0
+	for _, channel := range channels {
+		if err := sendTestNotification(nil, account, channel); err != nil {
+			printErrorf("%s: %v\n", notifyChannelLabel(channel), err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: enviada\n", notifyChannelLabel(channel))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d de %d notificações de teste falharam", failed, len(channels))
+	}
+	return nil
+}
+
+func runSummaryCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("informe o nome ou ID da conta")
+	}
+	manager := NewAccountManager(db)
+	account, err := findAccountByNameOrID(manager, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	// Usa os snapshots salvos no banco pelo processo que está monitorando a conta
+	messageText, err := sendWalletSummary(db, manager, account)
+	if messageText != "" {
+		fmt.Println(messageText)
+	}
+	return err
+}
+
+// runAPITokenCommand cria, lista ou revoga os tokens da API HTTP (create <nome> <viewer|admin>, list, revoke <id>).
+func runAPITokenCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("informe a ação: create <nome> <viewer|admin>, list ou revoke <id>")
+	}
+	switch args[0] {
+	case "create":
+		if len(args) != 3 {
+			return errors.New("uso: api-token create <nome> <viewer|admin>")
+		}
+		name, role := args[1], strings.ToLower(args[2])
+		if !validAPIRole(role) {
+			return fmt.Errorf("papel inválido: %s (use viewer ou admin)", args[2])
+		}
+		token, tokenHash, err := generateAPIToken()
+		if err != nil {
+			return err
+		}
+		id, err := db.AddAPIToken(name, role, tokenHash)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Token %d (%s, %s) criado:\n%s\n", id, name, role, token)
+		fmt.Println(colorYellow("Guarde o token agora: ele não será exibido novamente."))
+	case "list":
+		tokens, err := db.ListAPITokens()
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			lastUsed := "nunca usado"
+			if t.LastUsedAt.Valid {
+				lastUsed = t.LastUsedAt.Time.Local().Format("02/01/2006 15:04:05")
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\n", t.ID, t.Name, t.Role, lastUsed)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New("uso: api-token revoke <id>")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("ID inválido: %s", args[1])
+		}
+		found, err := db.DeleteAPIToken(id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("token %d não encontrado", id)
+		}
+		fmt.Printf("Token %d revogado.\n", id)
+	default:
+		return fmt.Errorf("ação desconhecida: %s (use create, list ou revoke)", args[0])
+	}
+	return nil
+}
+
+func runMigrateCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("uso: migrate <status|down <versão>>")
+	}
+	switch args[0] {
+	case "status":
+		statuses, err := db.MigrationStatuses()
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pendente"
+			if st.Applied {
+				state = "aplicada"
+			}
+			rollback := ""
+			if !st.HasRollback {
+				rollback = "\t(sem rollback)"
+			}
+			fmt.Printf("%04d\t%s\t%s%s\n", st.Version, st.Name, state, rollback)
+		}
+	case "down":
+		if len(args) != 2 {
+			return errors.New("uso: migrate down <versão>")
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("versão inválida: %s", args[1])
+		}
+		if err := db.MigrateDown(target); err != nil {
+			return err
+		}
+		fmt.Printf("Banco na versão %d do esquema.\n", target)
+	default:
+		return fmt.Errorf("ação desconhecida: %s (use status ou down)", args[0])
+	}
+	return nil
+}
+
+func runPurgeCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("informe o nome ou ID da conta removida")
+	}
+	manager := NewAccountManager(db)
+	removed, err := manager.ListRemovedAccounts()
+	if err != nil {
+		return err
+	}
+	arg := strings.TrimSpace(strings.Join(args, " "))
+	var target *RemovedAccount
+	for i := range removed {
+		if strings.EqualFold(removed[i].Name, arg) || strconv.FormatInt(removed[i].ID, 10) == arg {
+			target = &removed[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("conta removida '%s' não encontrada (só contas já removidas podem ser apagadas definitivamente)", arg)
+	}
+
+	fmt.Printf("A conta '%s' (ID %d) será apagada definitivamente, com a API key. Esta ação não pode ser desfeita.\n", target.Name, target.ID)
+	fmt.Print("Continuar? (sim/s ou não/n): ")
+	cliStdinScanner.Scan()
+	confirmation := strings.ToLower(strings.TrimSpace(cliStdinScanner.Text()))
+	if confirmation != "sim" && confirmation != "s" {
+		fmt.Println("Cancelado.")
+		return nil
+	}
+	if err := manager.PurgeAccount(target.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Conta '%s' apagada definitivamente.\n", target.Name)
+	return nil
+}
+
+// findAccountByNameOrID procura a conta pelo nome (sem diferenciar maiúsculas) ou pelo ID numérico.
+func findAccountByNameOrID(manager AccountRepo, arg string) (*BybitAccount, error) {
+	arg = strings.TrimSpace(arg)
+	accounts, err := manager.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, acc := range accounts {
+		if strings.EqualFold(acc.Name, arg) {
+			return acc, nil
+		}
+	}
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		for _, acc := range accounts {
+			if acc.ID == id {
+				return acc, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("conta '%s' não encontrada", arg)
+}
+
+// runCompleteCommand é usado pelos scripts de completion para obter valores dinâmicos (ex.: nomes de contas).
+func runCompleteCommand(db *Database, args []string) error {
+	if len(args) == 0 || args[0] != "accounts" {
+		return nil
+	}
+	accounts, err := NewAccountManager(db).ListAccounts()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		names = append(names, acc.Name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func runCompletionCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("informe o shell: bash, zsh ou fish")
+	}
+	var visible, accountCmds []string
+	descriptions := make(map[string]string)
+	for _, c := range getCLICommands() {
+		if c.Hidden {
+			continue
+		}
+		visible = append(visible, c.Name)
+		descriptions[c.Name] = c.Description
+		if c.AccountArg {
+			accountCmds = append(accountCmds, c.Name)
+		}
+	}
+	prog := programName()
+
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletionScript(prog, visible, accountCmds))
+	case "zsh":
+		fmt.Print(zshCompletionScript(prog, visible, descriptions, accountCmds))
+	case "fish":
+		fmt.Print(fishCompletionScript(prog, visible, descriptions, accountCmds))
+	default:
+		return fmt.Errorf("shell não suportado: %s (use bash, zsh ou fish)", args[0])
+	}
+	return nil
+}
+
+// completionFuncName gera um identificador de função de shell válido a partir do nome do programa.
+func completionFuncName(prog string) string {
+	return "_" + strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+}
+
+func bashCompletionScript(prog string, commands, accountCmds []string) string {
+	fn := completionFuncName(prog)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion para %s\n", prog)
+	fmt.Fprintf(&b, "# Uso: source <(%s completion bash)\n", prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "\t\tCOMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n", strings.Join(commands, " "))
+	b.WriteString("\t\treturn\n")
+	b.WriteString("\tfi\n")
+	b.WriteString("\tcase \"${COMP_WORDS[1]}\" in\n")
+	if len(accountCmds) > 0 {
+		fmt.Fprintf(&b, "\t%s)\n", strings.Join(accountCmds, "|"))
+		b.WriteString("\t\tlocal IFS=$'\\n'\n")
+		b.WriteString("\t\tlocal names\n")
+		b.WriteString("\t\tnames=$(\"${COMP_WORDS[0]}\" __complete accounts 2>/dev/null)\n")
+		b.WriteString("\t\tCOMPREPLY=( $(compgen -W \"$names\" -- \"$cur\") )\n")
+		b.WriteString("\t\tlocal i\n")
+		b.WriteString("\t\tfor i in \"${!COMPREPLY[@]}\"; do\n")
+		b.WriteString("\t\t\tCOMPREPLY[$i]=$(printf '%q' \"${COMPREPLY[$i]}\")\n")
+		b.WriteString("\t\tdone\n")
+		b.WriteString("\t\t;;\n")
+	}
+	b.WriteString("\tcompletion)\n")
+	b.WriteString("\t\tCOMPREPLY=( $(compgen -W \"bash zsh fish\" -- \"$cur\") )\n")
+	b.WriteString("\t\t;;\n")
+	b.WriteString("\tesac\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, prog)
+	return b.String()
+}
+
+func zshCompletionScript(prog string, commands []string, descriptions map[string]string, accountCmds []string) string {
+	fn := completionFuncName(prog)
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n", prog)
+	fmt.Fprintf(&b, "# Uso: source <(%s completion zsh)\n", prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("\tlocal -a cmds\n")
+	b.WriteString("\tcmds=(\n")
+	for _, c := range commands {
+		desc := strings.ReplaceAll(descriptions[c], "'", "'\\''")
+		desc = strings.ReplaceAll(desc, ":", "\\:")
+		fmt.Fprintf(&b, "\t\t'%s:%s'\n", c, desc)
+	}
+	b.WriteString("\t)\n")
+	b.WriteString("\tif (( CURRENT == 2 )); then\n")
+	b.WriteString("\t\t_describe 'comando' cmds\n")
+	b.WriteString("\t\treturn\n")
+	b.WriteString("\tfi\n")
+	b.WriteString("\tcase $words[2] in\n")
+	if len(accountCmds) > 0 {
+		fmt.Fprintf(&b, "\t%s)\n", strings.Join(accountCmds, "|"))
+		b.WriteString("\t\tlocal -a accounts\n")
+		b.WriteString("\t\taccounts=(\"${(@f)$($words[1] __complete accounts 2>/dev/null)}\")\n")
+		b.WriteString("\t\tcompadd -a accounts\n")
+		b.WriteString("\t\t;;\n")
+	}
+	b.WriteString("\tcompletion)\n")
+	b.WriteString("\t\tcompadd bash zsh fish\n")
+	b.WriteString("\t\t;;\n")
+	b.WriteString("\tesac\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "compdef %s %s\n", fn, prog)
+	return b.String()
+}
+
+func fishCompletionScript(prog string, commands []string, descriptions map[string]string, accountCmds []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion para %s\n", prog)
+	fmt.Fprintf(&b, "# Uso: %s completion fish | source\n", prog)
+	fmt.Fprintf(&b, "complete -c %s -f\n", prog)
+	for _, c := range commands {
+		desc := strings.ReplaceAll(descriptions[c], "'", "\\'")
+		fmt.Fprintf(&b, "complete -c %s -n '__fish_use_subcommand' -a '%s' -d '%s'\n", prog, c, desc)
+	}
+	if len(accountCmds) > 0 {
+		fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from %s' -a '(%s __complete accounts 2>/dev/null)'\n",
+			prog, strings.Join(accountCmds, " "), prog)
+	}
+	fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'\n", prog)
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Alertas de conexão: quando a conexão de uma conta cai e não volta dentro de connectionAlertGrace, uma mensagem
+// vai para o webhook de alertas; quando ela volta, outra mensagem informa quanto tempo ficou fora do ar.
+// Quedas curtas (reconectou dentro da carência) e paradas manuais não geram alerta.
+const connectionAlertGrace = time.Minute
+
+// connectionAlertsWebhookEnv é o webhook de alertas para todas as contas; a preferência connection_alerts_webhook
+// da conta tem prioridade, ex.: settings "Minha Conta" connection_alerts_webhook '"https://discord.com/api/webhooks/..."'
+const (
+	connectionAlertsWebhookEnv        = "CONNECTION_ALERTS_WEBHOOK"
+	connectionAlertsWebhookSettingKey = "connection_alerts_webhook"
+)
+
+// connectionAlertsWebhook retorna o webhook de alertas de conexão da conta (vazio = alertas desativados).
+func connectionAlertsWebhook(account *BybitAccount) string {
+	if url := account.Settings.GetString(connectionAlertsWebhookSettingKey, ""); url != "" {
+		return url
+	}
+	return strings.TrimSpace(os.Getenv(connectionAlertsWebhookEnv))
+}
+
+func validateConnectionAlertsWebhookSetting(value json.RawMessage) error {
+	var url string
+	if json.Unmarshal(value, &url) != nil || !validateDiscordWebhookURL(url) {
+		return errors.New("use a URL de um webhook do Discord")
+	}
+	return nil
+}
+
+// noteDown registra o início de uma queda e agenda o alerta. Chamado com c.mu travado.
+func (c *WebSocketConnection) noteDown(err error) {
+	if err != nil {
+		c.lastError = redactSecrets(err.Error())
+		c.stats.lastError, c.stats.lastErrorAt = c.lastError, time.Now()
+	}
+	// Só conta como queda depois da primeira conexão; reconexões seguidas mantêm o início original
+	if !c.connectedOnce || !c.downSince.IsZero() {
+		return
+	}
+	c.downSince = time.Now()
+	if len(notificationChannels(c.Account(), eventSeverity(c.Account(), eventConnection), notifyChannelConnection)) > 0 {
+		c.downTimer = c.afterFunc(timerDownAlert, connectionAlertGrace, c.sendDownAlert)
+	}
+}
+
+// noteUp encerra a queda em andamento e, se o alerta de queda foi enviado, avisa a recuperação. Chamado com c.mu travado.
+func (c *WebSocketConnection) noteUp() {
+	if c.downSince.IsZero() {
+		return
+	}
+	if c.downTimer != nil {
+		c.downTimer.Stop()
+		c.downTimer = nil
+	}
+	if c.downAlertSent {
+		text := fmt.Sprintf("%sConexão da conta **%s** restabelecida após %s fora do ar.", accountIcons(c.Account()).prefix(eventConnection, "up"), c.Account().Name, formatElapsed(time.Since(c.downSince)))
+		c.spawn(goroutineDelivery, func() { sendConnectionAlert(c.Account(), eventConnection, text) })
+	}
+	c.downSince = time.Time{}
+	c.downAlertSent = false
+	c.lastError = ""
+}
+
+// stopConnectionAlerts cancela o alerta pendente (parada manual da conexão).
+func (c *WebSocketConnection) stopConnectionAlerts() {
+	c.mu.Lock()
+	if c.downTimer != nil {
+		c.downTimer.Stop()
+		c.downTimer = nil
+	}
+	c.mu.Unlock()
+}
+
+// sendDownAlert envia o alerta de queda se, passada a carência, a conexão continua fora do ar.
+func (c *WebSocketConnection) sendDownAlert() {
+	c.mu.Lock()
+	if !c.running() || c.Connected || c.downSince.IsZero() {
+		c.mu.Unlock()
+		return
+	}
+	c.downAlertSent = true
+	c.downTimer = nil
+	since, lastError := c.downSince, c.lastError
+	c.mu.Unlock()
+
+	tz := loadTimezone(c.Account().Timezone)
+	text := fmt.Sprintf("%sConexão da conta **%s** caiu às %s e ainda não voltou.", accountIcons(c.Account()).prefix(eventConnection, "down"), c.Account().Name, since.In(tz).Format("15:04:05"))
+	if lastError != "" {
+		text += "\nÚltimo erro: " + lastError
+	}
+	text += "\nO aplicativo continua tentando reconectar."
+	sendConnectionAlert(c.Account(), eventConnection, text)
+}
+
+// sendConnectionAlert envia o alerta do evento pelo webhook de alertas de conexão (ou pelos canais da rota da
+// severidade, severity.go), com o mesmo histórico e retentativas das demais notificações.
+func sendConnectionAlert(account *BybitAccount, event, text string) {
+	message := buildDiscordMessage(account, text, false, false)
+	for _, channel := range notificationChannels(account, eventSeverity(account, event), notifyChannelConnection) {
+		err := deliverNotification(account.ID, channel, message, nil, channelSender(account, channel, message, "", nil))
+		if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+			if err != nil {
+				logger.Log("Erro ao enviar alerta de conexão (%s): %v", notifyChannelLabel(channel), err)
+			} else {
+				logger.Log("Alerta de conexão enviado (%s)", notifyChannelLabel(channel))
+			}
+		}
+	}
+}
+
+// sendOperatorAlert envia um aviso ao operador pelo webhook de alertas de conexão ou, sem ele, pelo webhook principal.
+// event define a severidade (eventAlert ou eventMonitoringStopped).
+func (wsm *WebSocketManager) sendOperatorAlert(wsConn *WebSocketConnection, event, text string) {
+	if connectionAlertsWebhook(wsConn.Account()) != "" {
+		sendConnectionAlert(wsConn.Account(), event, text)
+		return
+	}
+	wsm.sendNotification(wsConn, event, text)
+}
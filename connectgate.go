@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Início escalonado das conexões: ao iniciar ou restaurar muitas contas de uma vez, no máximo
+// CONNECT_MAX_CONCURRENT conexões ficam em handshake/autenticação ao mesmo tempo, e cada uma começa pelo menos
+// CONNECT_STAGGER depois da anterior, para não estourar o limite de autenticações da corretora. Vale também
+// para reconexões simultâneas (ex.: a rede da máquina voltou).
+//
+// Limites globais do processo, para instalações com muitas contas (desligados por padrão):
+//   - AUTH_RATE_LIMIT: tentativas de conexão/autenticação por minuto somando todas as contas;
+//   - MAX_CONNECTIONS: conexões das contas abertas ao mesmo tempo. Acima do limite, a conta fica aguardando uma
+//     vaga (desconectada) até outra conexão cair ou ser parada; a conexão business da OKX usa a vaga da conta.
+const (
+	connectMaxConcurrentEnv     = "CONNECT_MAX_CONCURRENT"
+	connectStaggerEnv           = "CONNECT_STAGGER"
+	authRateLimitEnv            = "AUTH_RATE_LIMIT"
+	maxConnectionsEnv           = "MAX_CONNECTIONS"
+	connectMaxConcurrentDefault = 3
+	connectStaggerDefault       = time.Second
+)
+
+// connectGate limita as tentativas de conexão em andamento e espaça o início delas.
+type connectGate struct {
+	slots     chan struct{}
+	stagger   time.Duration
+	perMinute int // tentativas por minuto (0 = sem limite)
+
+	mu       sync.Mutex
+	next     time.Time   // horário a partir do qual a próxima tentativa pode começar
+	attempts []time.Time // início das tentativas do último minuto (AUTH_RATE_LIMIT), em ordem
+}
+
+func newConnectGate(maxConcurrent int, stagger time.Duration, perMinute int) *connectGate {
+	return &connectGate{slots: make(chan struct{}, maxConcurrent), stagger: stagger, perMinute: perMinute}
+}
+
+// positiveEnvInt lê um inteiro positivo da variável; 0 se ausente ou inválida.
+func positiveEnvInt(name string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(name)))
+	if err != nil || value < 1 {
+		return 0
+	}
+	return value
+}
+
+// startupGate é a fila global das conexões, configurada pelas variáveis na primeira utilização.
+var startupGate = sync.OnceValue(func() *connectGate {
+	maxConcurrent := positiveEnvInt(connectMaxConcurrentEnv)
+	if maxConcurrent == 0 {
+		maxConcurrent = connectMaxConcurrentDefault
+	}
+	stagger := connectStaggerDefault
+	if value := strings.TrimSpace(os.Getenv(connectStaggerEnv)); value != "" {
+		if d, err := parseReconnectDuration(value); err == nil {
+			stagger = d
+		}
+	}
+	return newConnectGate(maxConcurrent, stagger, positiveEnvInt(authRateLimitEnv))
+})
+
+// acquire aguarda a vez de conectar. Retorna a função que libera a vaga (ao conectar ou falhar) e quanto tempo
+// esperou; ok = false se ctx foi cancelado durante a espera.
+func (g *connectGate) acquire(ctx context.Context) (release func(), waited time.Duration, ok bool) {
+	started := time.Now()
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, 0, false
+	}
+
+	g.mu.Lock()
+	now := time.Now()
+	at := g.next
+	if at.Before(now) {
+		at = now
+	}
+	if g.perMinute > 0 {
+		// Descarta as tentativas de mais de um minuto atrás; com o limite atingido, começa um minuto depois da
+		// tentativa que ocupa a vaga mais antiga
+		cutoff := now.Add(-time.Minute)
+		for len(g.attempts) > 0 && !g.attempts[0].After(cutoff) {
+			g.attempts = g.attempts[1:]
+		}
+		if len(g.attempts) >= g.perMinute {
+			if earliest := g.attempts[len(g.attempts)-g.perMinute].Add(time.Minute); earliest.After(at) {
+				at = earliest
+			}
+		}
+		g.attempts = append(g.attempts, at)
+	}
+	g.next = at.Add(g.stagger)
+	g.mu.Unlock()
+
+	if wait := time.Until(at); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			g.cancelAttempt(at)
+			<-g.slots
+			return nil, 0, false
+		}
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-g.slots }) }, time.Since(started), true
+}
+
+// connectionLimit é a vaga de conexão aberta das contas (MAX_CONNECTIONS); nil = sem limite.
+type connectionLimit struct {
+	slots chan struct{}
+}
+
+var openConnectionLimit = sync.OnceValue(func() *connectionLimit {
+	max := positiveEnvInt(maxConnectionsEnv)
+	if max == 0 {
+		return nil
+	}
+	return &connectionLimit{slots: make(chan struct{}, max)}
+})
+
+// acquireConnectionSlot reserva uma vaga para a conexão da conta, aguardando (com aviso no log da conta) se o
+// limite estiver atingido. A função retornada libera a vaga quando a conexão termina; ok = false se ctx foi cancelado.
+func acquireConnectionSlot(wsConn *WebSocketConnection, logger interface{ Log(string, ...interface{}) }) (release func(), ok bool) {
+	limit := openConnectionLimit()
+	if limit == nil {
+		return func() {}, true
+	}
+	select {
+	case limit.slots <- struct{}{}:
+	default:
+		if logger != nil {
+			logger.Log("⚠️ Limite de %d conexões abertas (%s) atingido; aguardando uma vaga para conectar", cap(limit.slots), maxConnectionsEnv)
+		}
+		select {
+		case limit.slots <- struct{}{}:
+		case <-wsConn.ctx.Done():
+			return nil, false
+		}
+		if logger != nil {
+			logger.Log("Vaga de conexão liberada; conectando")
+		}
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-limit.slots }) }, true
+}
+
+// cancelAttempt devolve a reserva de uma tentativa que desistiu durante a espera.
+func (g *connectGate) cancelAttempt(at time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := len(g.attempts) - 1; i >= 0; i-- {
+		if g.attempts[i].Equal(at) {
+			g.attempts = append(g.attempts[:i], g.attempts[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Estatísticas da conexão desde o início do monitoramento da conta (em memória; os totais acumulados da conta ficam
+// no banco, stats.go): frames e bytes recebidos, mensagens por tópico e o último erro, que continua visível depois
+// da reconexão. Aparecem em "Ver contas monitoradas" e no campo stats do GET /api/status.
+
+// connStats são os contadores da conexão. lastError/lastErrorAt são protegidos por WebSocketConnection.mu.
+type connStats struct {
+	startedAt   time.Time
+	frames      atomic.Int64
+	bytes       atomic.Int64
+	mu          sync.Mutex
+	byTopic     map[string]int64
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// ConnectionStats é um retrato das estatísticas da conexão para exibição.
+type ConnectionStats struct {
+	MonitoringSince time.Time        `json:"monitoring_since"`
+	ConnectedSince  *time.Time       `json:"connected_since,omitempty"`
+	ReconnectCount  int              `json:"reconnect_count"`
+	LastError       string           `json:"last_error,omitempty"`
+	LastErrorAt     *time.Time       `json:"last_error_at,omitempty"`
+	MessagesByTopic map[string]int64 `json:"messages_by_topic,omitempty"`
+	FramesReceived  int64            `json:"frames_received"`
+	BytesReceived   int64            `json:"bytes_received"`
+}
+
+// noteFrame registra um frame recebido no stream principal (conta para a saúde da conexão, como touch).
+func (c *WebSocketConnection) noteFrame(size int) {
+	c.noteBytes(size)
+	c.touch()
+}
+
+// noteBytes conta um frame recebido sem mexer na saúde da conexão (OKX business).
+func (c *WebSocketConnection) noteBytes(size int) {
+	c.stats.frames.Add(1)
+	c.stats.bytes.Add(int64(size))
+}
+
+// countTopic conta uma mensagem de dados do tópico (ou canal OKX).
+func (c *WebSocketConnection) countTopic(topic string) {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	if c.stats.byTopic == nil {
+		c.stats.byTopic = make(map[string]int64)
+	}
+	c.stats.byTopic[topic]++
+}
+
+// GetConnectionStats retorna as estatísticas da conexão da conta (false se não estiver sendo monitorada).
+func (wsm *WebSocketManager) GetConnectionStats(accountID int64) (ConnectionStats, bool) {
+	wsm.mu.RLock()
+	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
+	if !exists {
+		return ConnectionStats{}, false
+	}
+
+	stats := ConnectionStats{
+		MonitoringSince: conn.stats.startedAt,
+		FramesReceived:  conn.stats.frames.Load(),
+		BytesReceived:   conn.stats.bytes.Load(),
+	}
+	conn.mu.Lock()
+	if conn.Connected && !conn.ConnectedAt.IsZero() {
+		connectedSince := conn.ConnectedAt
+		stats.ConnectedSince = &connectedSince
+	}
+	stats.ReconnectCount = conn.ReconnectCount
+	if conn.stats.lastError != "" {
+		lastErrorAt := conn.stats.lastErrorAt
+		stats.LastError = conn.stats.lastError
+		stats.LastErrorAt = &lastErrorAt
+	}
+	conn.mu.Unlock()
+
+	conn.stats.mu.Lock()
+	if len(conn.stats.byTopic) > 0 {
+		stats.MessagesByTopic = make(map[string]int64, len(conn.stats.byTopic))
+		for topic, n := range conn.stats.byTopic {
+			stats.MessagesByTopic[topic] = n
+		}
+	}
+	conn.stats.mu.Unlock()
+	return stats, true
+}
+
+// formatMessages descreve as mensagens por tópico, as mais frequentes primeiro (ex.: "12 (order 8, execution 4)").
+func (s ConnectionStats) formatMessages() string {
+	var total int64
+	topics := make([]string, 0, len(s.MessagesByTopic))
+	for topic, n := range s.MessagesByTopic {
+		total += n
+		topics = append(topics, topic)
+	}
+	if total == 0 {
+		return "0"
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		a, b := s.MessagesByTopic[topics[i]], s.MessagesByTopic[topics[j]]
+		if a != b {
+			return a > b
+		}
+		return topics[i] < topics[j]
+	})
+	parts := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		parts = append(parts, fmt.Sprintf("%s %d", topic, s.MessagesByTopic[topic]))
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
+
+// formatByteSize formata um tamanho em bytes (ex.: "512 B", "1.5 KB", "3.2 MB").
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IDs de correlação: cada mensagem recebida do WebSocket ganha um ID curto, que acompanha as ordens e execuções
+// pelo buffer de delay e pelo agrupamento até o envio. O ID aparece no log da conta em cada etapa, no payload
+// capturado (capture dump) e no histórico de notificações, para rastrear qual evento gerou (ou não) cada mensagem.
+
+// newCorrelationID gera o ID de uma mensagem recebida (8 caracteres hexadecimais).
+func newCorrelationID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// correlationTag formata os IDs para as linhas de log: "[msg 1a2b3c4d]" ou "[msg 1a2b3c4d,5e6f7a8b]".
+func correlationTag(ids ...string) string {
+	ids = uniqueCorrelationIDs(ids)
+	if len(ids) == 0 {
+		return "[msg -]"
+	}
+	return "[msg " + strings.Join(ids, ",") + "]"
+}
+
+// uniqueCorrelationIDs remove os vazios e as repetições, mantendo a ordem de chegada.
+func uniqueCorrelationIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	var unique []string
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+func orderCorrelationIDs(orders []OrderData) []string {
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.CorrelationID)
+	}
+	return uniqueCorrelationIDs(ids)
+}
+
+func executionCorrelationIDs(executions []ExecutionData) []string {
+	ids := make([]string, 0, len(executions))
+	for _, e := range executions {
+		ids = append(ids, e.CorrelationID)
+	}
+	return uniqueCorrelationIDs(ids)
+}
+
+// joinCorrelationIDs e splitCorrelationIDs convertem os IDs para a coluna correlation_ids (separados por vírgula).
+func joinCorrelationIDs(ids []string) string {
+	return strings.Join(uniqueCorrelationIDs(ids), ",")
+}
+
+func splitCorrelationIDs(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -2,36 +2,53 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
+const databaseFileName = "bybit_accounts.db"
+
+// dbPassphraseEnv é a senha do banco cifrado (apenas em executáveis compilados com -tags sqlcipher).
+const dbPassphraseEnv = "DB_PASSPHRASE"
+
+var errSQLCipherUnavailable = errors.New("este executável não tem suporte a banco cifrado; compile com -tags sqlcipher (veja o README)")
+
 type Database struct {
 	db *sql.DB
 }
 
 func NewDatabase() (*Database, error) {
-	// Usar caminho no diretório data para compatibilidade com Docker
-	dbPath := "./bybit_accounts.db"
-	if dataDir := getDataDir(); dataDir != "" {
-		dbPath = filepath.Join(dataDir, "bybit_accounts.db")
-	}
-	
-	db, err := sql.Open("sqlite3", dbPath)
+	dbPath := getDatabasePath()
+
+	passphrase := os.Getenv(dbPassphraseEnv)
+	db, err := openSQLite(dbPath, passphrase)
 	if err != nil {
 		return nil, err
 	}
 
+	// Cada conexão com :memory: é um banco separado: uma só conexão mantém todos vendo os mesmos dados
+	if dbPath == inMemoryDatabasePath {
+		db.SetMaxOpenConns(1)
+	}
+
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
+	// Com SQLCipher a senha só é conferida na primeira leitura
+	if _, err := db.Exec(`SELECT COUNT(*) FROM sqlite_master`); err != nil {
+		db.Close()
+		if passphrase != "" {
+			return nil, fmt.Errorf("não foi possível abrir o banco cifrado (senha de %s incorreta ou banco sem cifra): %w", dbPassphraseEnv, err)
+		}
+		return nil, err
+	}
 
 	database := &Database{db: db}
-	if err := database.initSchema(); err != nil {
+	if err := database.migrate(); err != nil {
 		return nil, err
 	}
 
@@ -42,116 +59,409 @@ func (d *Database) Close() error {
 	return d.db.Close()
 }
 
-func (d *Database) initSchema() error {
-	// Tabela de contas Bybit
-	createAccountsTable := `
-	CREATE TABLE IF NOT EXISTS bybit_accounts (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL DEFAULT '',
-		api_key TEXT NOT NULL DEFAULT '',
-		api_secret TEXT NOT NULL DEFAULT '',
-		webhook_url TEXT NOT NULL DEFAULT '',
-		webhook_url_google_sheets TEXT NOT NULL DEFAULT '',
-		sheet_url_google_sheets TEXT NOT NULL DEFAULT '',
-		mark_everyone_order INTEGER DEFAULT 0,
-		mark_everyone_wallet INTEGER DEFAULT 0,
-		one_way_mode INTEGER DEFAULT 1,
-		active INTEGER DEFAULT 1,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	// Tabela de conexões ativas
-	createConnectionsTable := `
-	CREATE TABLE IF NOT EXISTS active_connections (
-		account_id INTEGER PRIMARY KEY,
-		connected INTEGER DEFAULT 1,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
-	);`
-
-	// Tabela de ordens
-	createOrdersTable := `
-	CREATE TABLE IF NOT EXISTS orders (
-		order_id TEXT PRIMARY KEY,
-		account_id INTEGER NOT NULL,
-		order_data TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
-	);`
-
-	// Tabela de última mensagem por tipo (wallet/position), uma linha por account_id + message_type + symbol
-	createLastMessageSnapshotsTable := `
-	CREATE TABLE IF NOT EXISTS last_message_snapshots (
-		account_id INTEGER NOT NULL,
-		message_type TEXT NOT NULL,
-		symbol TEXT NOT NULL,
-		message TEXT NOT NULL,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (account_id, message_type, symbol),
-		FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
-	);`
-
-	if _, err := d.db.Exec(createAccountsTable); err != nil {
+func (d *Database) GetDB() *sql.DB {
+	return d.db
+}
+
+// AddAccountStats soma os incrementos aos contadores da conta (cria o contador se não existir).
+func (d *Database) AddAccountStats(accountID int64, deltas map[string]int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
 		return err
 	}
+	for key, delta := range deltas {
+		_, err := tx.Exec(
+			`INSERT INTO account_stats (account_id, stat_key, value, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(account_id, stat_key) DO UPDATE SET value = value + excluded.value, updated_at = CURRENT_TIMESTAMP`,
+			accountID, key, delta,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
 
-	if _, err := d.db.Exec(createConnectionsTable); err != nil {
-		return err
+// SetAccountStat grava o valor absoluto de um contador da conta.
+func (d *Database) SetAccountStat(accountID int64, key string, value int64) error {
+	_, err := d.db.Exec(
+		`INSERT INTO account_stats (account_id, stat_key, value, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(account_id, stat_key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`,
+		accountID, key, value,
+	)
+	return err
+}
+
+// GetAccountStats retorna os contadores da conta e a data da última atualização.
+func (d *Database) GetAccountStats(accountID int64) (map[string]int64, time.Time, error) {
+	rows, err := d.db.Query(`SELECT stat_key, value, updated_at FROM account_stats WHERE account_id = ?`, accountID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+	values := make(map[string]int64)
+	var lastUpdate time.Time
+	for rows.Next() {
+		var key string
+		var value int64
+		var updatedAt time.Time
+		if err := rows.Scan(&key, &value, &updatedAt); err != nil {
+			return nil, time.Time{}, err
+		}
+		values[key] = value
+		if updatedAt.After(lastUpdate) {
+			lastUpdate = updatedAt
+		}
 	}
+	return values, lastUpdate, rows.Err()
+}
 
-	if _, err := d.db.Exec(createOrdersTable); err != nil {
+// ResetAccountStats apaga os contadores e os agregados diários da conta.
+func (d *Database) ResetAccountStats(accountID int64) error {
+	if _, err := d.db.Exec(`DELETE FROM account_stats WHERE account_id = ?`, accountID); err != nil {
 		return err
 	}
+	_, err := d.db.Exec(`DELETE FROM account_daily_stats WHERE account_id = ?`, accountID)
+	return err
+}
 
-	if _, err := d.db.Exec(createLastMessageSnapshotsTable); err != nil {
+// AddDailyStats soma os incrementos aos agregados do dia (AAAA-MM-DD, UTC).
+func (d *Database) AddDailyStats(accountID int64, day string, deltas map[string]float64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
 		return err
 	}
+	for key, delta := range deltas {
+		_, err := tx.Exec(
+			`INSERT INTO account_daily_stats (account_id, day, stat_key, value) VALUES (?, ?, ?, ?)
+			ON CONFLICT(account_id, day, stat_key) DO UPDATE SET value = value + excluded.value`,
+			accountID, day, key, delta,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
 
-	// Adicionar novas colunas se não existirem
-	if err := d.addColumnIfNotExists("bybit_accounts", "mark_everyone_order", "INTEGER DEFAULT 0"); err != nil {
-		return err
+// ListDailyStats retorna os agregados da conta a partir do dia from (dia -> chave -> valor).
+func (d *Database) ListDailyStats(accountID int64, from string) (map[string]map[string]float64, error) {
+	rows, err := d.db.Query(`SELECT day, stat_key, value FROM account_daily_stats WHERE account_id = ? AND day >= ?`, accountID, from)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+	result := make(map[string]map[string]float64)
+	for rows.Next() {
+		var day, key string
+		var value float64
+		if err := rows.Scan(&day, &key, &value); err != nil {
+			return nil, err
+		}
+		if result[day] == nil {
+			result[day] = make(map[string]float64)
+		}
+		result[day][key] = value
+	}
+	return result, rows.Err()
+}
 
-	if err := d.addColumnIfNotExists("bybit_accounts", "mark_everyone_wallet", "INTEGER DEFAULT 0"); err != nil {
-		return err
+// GetSetting retorna o valor de uma configuração global ("" e false se não existir).
+func (d *Database) GetSetting(key string) (string, bool, error) {
+	var value string
+	err := d.db.QueryRow(`SELECT value FROM app_settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
 	}
-	if err := d.addColumnIfNotExists("bybit_accounts", "one_way_mode", "INTEGER DEFAULT 1"); err != nil {
-		return err
+	if err != nil {
+		return "", false, err
 	}
+	return value, true, nil
+}
 
-	if err := d.addColumnIfNotExists("bybit_accounts", "webhook_url_google_sheets", "TEXT NOT NULL DEFAULT ''"); err != nil {
-		return err
+// SetSetting grava uma configuração global.
+func (d *Database) SetSetting(key, value string) error {
+	_, err := d.db.Exec(
+		`INSERT INTO app_settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`,
+		key, value,
+	)
+	return err
+}
+
+// ListSettingsByPrefix retorna as configurações cuja chave começa com prefix (chave -> valor).
+func (d *Database) ListSettingsByPrefix(prefix string) (map[string]string, error) {
+	rows, err := d.db.Query(`SELECT key, value FROM app_settings WHERE substr(key, 1, ?) = ?`, len(prefix), prefix)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
 
-	if err := d.addColumnIfNotExists("bybit_accounts", "sheet_url_google_sheets", "TEXT NOT NULL DEFAULT ''"); err != nil {
-		return err
+// DeleteSetting remove uma configuração global.
+func (d *Database) DeleteSetting(key string) error {
+	_, err := d.db.Exec(`DELETE FROM app_settings WHERE key = ?`, key)
+	return err
+}
+
+// Status das notificações no histórico
+const (
+	notificationStatusSent   = "enviada"
+	notificationStatusFailed = "falhou"
+	notificationStatusLogged = "registrada" // conta no modo só registro: não foi enviada (logonly.go)
+)
+
+// NotificationRecord é uma notificação do histórico.
+type NotificationRecord struct {
+	ID             int64
+	AccountID      int64
+	Channel        string
+	Message        string
+	Status         string
+	Error          string
+	HTTPStatus     int // status HTTP do último erro (0 = entregue ou sem resposta)
+	Attempts       int
+	CorrelationIDs []string // mensagens do WebSocket que geraram a notificação
+	CreatedAt      time.Time
+}
+
+// Delivery retorna o resultado da entrega no formato usado pelos logs.
+func (r NotificationRecord) Delivery() notificationDelivery {
+	return notificationDelivery{Attempts: r.Attempts, HTTPStatus: r.HTTPStatus, CorrelationIDs: r.CorrelationIDs}
+}
+
+// AddNotification grava uma notificação no histórico.
+func (d *Database) AddNotification(accountID int64, channel, message, status, errMsg string, httpStatus, attempts int, correlationIDs []string) error {
+	_, err := d.db.Exec(
+		`INSERT INTO notifications (account_id, channel, message, status, error, http_status, attempts, correlation_ids) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountID, channel, message, status, errMsg, httpStatus, attempts, joinCorrelationIDs(correlationIDs),
+	)
+	return err
+}
+
+// CountFailedNotifications retorna, por conta, quantas entregas falharam desde since.
+func (d *Database) CountFailedNotifications(since time.Time) (map[int64]int64, error) {
+	rows, err := d.db.Query(`SELECT account_id, COUNT(*) FROM notifications WHERE status = ? AND created_at >= ? GROUP BY account_id`,
+		notificationStatusFailed, since.UTC())
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var accountID, count int64
+		if err := rows.Scan(&accountID, &count); err != nil {
+			return nil, err
+		}
+		counts[accountID] = count
+	}
+	return counts, rows.Err()
+}
 
-	if err := d.addColumnIfNotExists("bybit_accounts", "webhook_url_executions", "TEXT NOT NULL DEFAULT ''"); err != nil {
-		return err
+// ListNotifications retorna as notificações mais recentes da conta no período e status do filtro (Limit <= 0 = todas).
+func (d *Database) ListNotifications(accountID int64, filter HistoryFilter) ([]NotificationRecord, error) {
+	query := `SELECT id, account_id, channel, message, status, error, http_status, attempts, correlation_ids, created_at FROM notifications WHERE account_id = ?`
+	args := []interface{}{accountID}
+	if !filter.From.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, filter.From.UTC())
 	}
-	if err := d.addColumnIfNotExists("bybit_accounts", "mark_everyone_execution", "INTEGER DEFAULT 0"); err != nil {
-		return err
+	if !filter.To.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, filter.To.UTC())
 	}
-	if err := d.addColumnIfNotExists("bybit_accounts", "sheet_url_google_sheets_executions", "TEXT NOT NULL DEFAULT ''"); err != nil {
-		return err
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+	var records []NotificationRecord
+	for rows.Next() {
+		var r NotificationRecord
+		var correlationIDs string
+		if err := rows.Scan(&r.ID, &r.AccountID, &r.Channel, &r.Message, &r.Status, &r.Error, &r.HTTPStatus, &r.Attempts, &correlationIDs, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.CorrelationIDs = splitCorrelationIDs(correlationIDs)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
 
-	if err := d.addColumnIfNotExists("bybit_accounts", "platform", "TEXT NOT NULL DEFAULT 'bybit'"); err != nil {
-		return err
+// GetNotification busca uma notificação do histórico pelo ID.
+func (d *Database) GetNotification(id int64) (*NotificationRecord, error) {
+	var r NotificationRecord
+	var correlationIDs string
+	err := d.db.QueryRow(`SELECT id, account_id, channel, message, status, error, http_status, attempts, correlation_ids, created_at FROM notifications WHERE id = ?`, id).
+		Scan(&r.ID, &r.AccountID, &r.Channel, &r.Message, &r.Status, &r.Error, &r.HTTPStatus, &r.Attempts, &correlationIDs, &r.CreatedAt)
+	if err != nil {
+		return nil, err
 	}
-	if err := d.addColumnIfNotExists("bybit_accounts", "metadata", "TEXT NOT NULL DEFAULT ''"); err != nil {
-		return err
+	r.CorrelationIDs = splitCorrelationIDs(correlationIDs)
+	return &r, nil
+}
+
+// SaveStreamCheckpoint grava o creationTime do último evento processado do tópico.
+func (d *Database) SaveStreamCheckpoint(accountID int64, topic string, creationTime int64) error {
+	_, err := d.db.Exec(
+		`INSERT INTO stream_checkpoints (account_id, topic, last_creation_time, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(account_id, topic) DO UPDATE SET last_creation_time = MAX(last_creation_time, excluded.last_creation_time), updated_at = CURRENT_TIMESTAMP`,
+		accountID, topic, creationTime,
+	)
+	return err
+}
+
+// ListStreamCheckpoints retorna todos os checkpoints (conta -> tópico -> creationTime em ms).
+func (d *Database) ListStreamCheckpoints() (map[int64]map[string]int64, error) {
+	rows, err := d.db.Query(`SELECT account_id, topic, last_creation_time FROM stream_checkpoints`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	checkpoints := make(map[int64]map[string]int64)
+	for rows.Next() {
+		var accountID, creationTime int64
+		var topic string
+		if err := rows.Scan(&accountID, &topic, &creationTime); err != nil {
+			return nil, err
+		}
+		if checkpoints[accountID] == nil {
+			checkpoints[accountID] = make(map[string]int64)
+		}
+		checkpoints[accountID][topic] = creationTime
 	}
-	if err := d.addColumnIfNotExists("bybit_accounts", "notification_delay_seconds", "INTEGER DEFAULT 0"); err != nil {
+	return checkpoints, rows.Err()
+}
+
+// RawMessageRecord é um payload cru capturado do WebSocket (já descomprimido).
+type RawMessageRecord struct {
+	ID            int64
+	Stream        string
+	CorrelationID string
+	Payload       []byte
+	ReceivedAt    time.Time
+}
+
+// AddRawMessage grava um payload cru, comprimido com gzip.
+func (d *Database) AddRawMessage(accountID int64, stream, correlationID string, payload []byte, receivedAt time.Time) error {
+	compressed, err := compressPayload(payload)
+	if err != nil {
 		return err
 	}
+	_, err = d.db.Exec(`INSERT INTO raw_messages (account_id, stream, correlation_id, payload, received_at) VALUES (?, ?, ?, ?, ?)`,
+		accountID, stream, correlationID, compressed, receivedAt.UTC())
+	return err
+}
 
-	return nil
+// ListRawMessages retorna os payloads capturados da conta, em ordem cronológica.
+func (d *Database) ListRawMessages(accountID int64) ([]RawMessageRecord, error) {
+	rows, err := d.db.Query(`SELECT id, stream, correlation_id, payload, received_at FROM raw_messages WHERE account_id = ? ORDER BY received_at, id`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []RawMessageRecord
+	for rows.Next() {
+		var r RawMessageRecord
+		var compressed []byte
+		if err := rows.Scan(&r.ID, &r.Stream, &r.CorrelationID, &compressed, &r.ReceivedAt); err != nil {
+			return nil, err
+		}
+		if r.Payload, err = decompressPayload(compressed); err != nil {
+			return nil, fmt.Errorf("mensagem %d corrompida: %w", r.ID, err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
 }
 
-func (d *Database) GetDB() *sql.DB {
-	return d.db
+func (d *Database) CountRawMessages(accountID int64) (int64, error) {
+	var count int64
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM raw_messages WHERE account_id = ?`, accountID).Scan(&count)
+	return count, err
+}
+
+// APIToken é um token da API de controle (sem o valor, que só é exibido na criação).
+type APIToken struct {
+	ID         int64
+	Name       string
+	Role       string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+}
+
+// AddAPIToken grava um token novo pelo hash e retorna o ID.
+func (d *Database) AddAPIToken(name, role, tokenHash string) (int64, error) {
+	result, err := d.db.Exec(`INSERT INTO api_tokens (name, role, token_hash) VALUES (?, ?, ?)`, name, role, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListAPITokens retorna os tokens cadastrados.
+func (d *Database) ListAPITokens() ([]APIToken, error) {
+	rows, err := d.db.Query(`SELECT id, name, role, created_at, last_used_at FROM api_tokens ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []APIToken
+	for rows.Next() {
+		var t APIToken
+		if err := rows.Scan(&t.ID, &t.Name, &t.Role, &t.CreatedAt, &t.LastUsedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// GetAPITokenByHash busca o token pelo hash; retorna sql.ErrNoRows se não existir.
+func (d *Database) GetAPITokenByHash(tokenHash string) (*APIToken, error) {
+	var t APIToken
+	err := d.db.QueryRow(`SELECT id, name, role, created_at, last_used_at FROM api_tokens WHERE token_hash = ?`, tokenHash).
+		Scan(&t.ID, &t.Name, &t.Role, &t.CreatedAt, &t.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// TouchAPIToken registra o último uso do token.
+func (d *Database) TouchAPIToken(id int64) error {
+	_, err := d.db.Exec(`UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
+
+// DeleteAPIToken revoga o token; retorna false se não existir.
+func (d *Database) DeleteAPIToken(id int64) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM api_tokens WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
 }
 
 // SaveLastMessageSnapshot grava ou atualiza a última mensagem (wallet ou position) por account_id, tipo e símbolo.
@@ -313,6 +623,20 @@ func (d *Database) addColumnIfNotExists(tableName, columnName, columnDefinition
 	return nil
 }
 
+// getDatabasePath retorna o caminho do arquivo SQLite (no diretório data, para compatibilidade com Docker).
+func getDatabasePath() string {
+	if dbPathOverride != "" {
+		return dbPathOverride
+	}
+	if dbPath := os.Getenv(dbPathEnv); dbPath != "" {
+		return dbPath
+	}
+	if dataDir := getDataDir(); dataDir != "" {
+		return filepath.Join(dataDir, databaseFileName)
+	}
+	return "./" + databaseFileName
+}
+
 func getDataDir() string {
 	// Verificar se existe variável de ambiente
 	if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
@@ -0,0 +1,56 @@
+//go:build sqlcipher
+
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	sqlcipher "github.com/mutecomm/go-sqlcipher/v4"
+)
+
+// Build com SQLCipher: o banco inteiro fica cifrado com a senha de DB_PASSPHRASE.
+// O driver é um fork do go-sqlite3 e também se registra como "sqlite3"; por isso os dois não entram no mesmo build.
+const sqlCipherEnabled = true
+
+const sqlCipherDriverName = "sqlite3_cipher"
+
+var (
+	sqlCipherRegisterOnce sync.Once
+	sqlCipherKey          string
+)
+
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func openSQLite(path, passphrase string) (*sql.DB, error) {
+	if passphrase == "" {
+		return sql.Open("sqlite3", path)
+	}
+	sqlCipherKey = passphrase
+	// PRAGMA key precisa ser o primeiro comando de cada conexão do pool
+	sqlCipherRegisterOnce.Do(func() {
+		sql.Register(sqlCipherDriverName, &sqlcipher.SQLiteDriver{
+			ConnectHook: func(conn *sqlcipher.SQLiteConn) error {
+				_, err := conn.Exec("PRAGMA key = "+quoteSQLString(sqlCipherKey), nil)
+				return err
+			},
+		})
+	})
+	return sql.Open(sqlCipherDriverName, path)
+}
+
+// encryptDatabaseFile grava em destPath uma cópia cifrada do banco aberto (sem cifra) via sqlcipher_export.
+func encryptDatabaseFile(db *Database, destPath, passphrase string) error {
+	if _, err := db.GetDB().Exec(`ATTACH DATABASE ` + quoteSQLString(destPath) + ` AS encrypted KEY ` + quoteSQLString(passphrase)); err != nil {
+		return err
+	}
+	if _, err := db.GetDB().Exec(`SELECT sqlcipher_export('encrypted')`); err != nil {
+		db.GetDB().Exec(`DETACH DATABASE encrypted`)
+		return err
+	}
+	_, err := db.GetDB().Exec(`DETACH DATABASE encrypted`)
+	return err
+}
@@ -0,0 +1,23 @@
+//go:build !sqlcipher
+
+package main
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqlCipherEnabled indica se o executável foi compilado com SQLCipher (build tag sqlcipher).
+const sqlCipherEnabled = false
+
+func openSQLite(path, passphrase string) (*sql.DB, error) {
+	if passphrase != "" {
+		return nil, errSQLCipherUnavailable
+	}
+	return sql.Open("sqlite3", path)
+}
+
+func encryptDatabaseFile(db *Database, destPath, passphrase string) error {
+	return errSQLCipherUnavailable
+}
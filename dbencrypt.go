@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// runDBEncryptCommand converte um banco sem cifra em banco SQLCipher. O original fica com a extensão
+// .sem-cifra para conferência e deve ser apagado depois.
+func runDBEncryptCommand(db *Database, args []string) error {
+	if len(args) != 0 {
+		return errors.New("uso: db-encrypt")
+	}
+	if !sqlCipherEnabled {
+		return errSQLCipherUnavailable
+	}
+	if os.Getenv(dbPassphraseEnv) != "" {
+		return fmt.Errorf("o banco já foi aberto com %s; para cifrar um banco sem cifra, execute sem a variável", dbPassphraseEnv)
+	}
+	lock, err := acquireInstanceLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	if err := requireMasterPasswordCLI(db); err != nil {
+		return err
+	}
+
+	scanner := cliStdinScanner
+	fmt.Print("Senha do banco: ")
+	passphrase := readSecretLine(scanner)
+	if passphrase == "" {
+		return errors.New("a senha não pode ser vazia")
+	}
+	fmt.Print("Confirme a senha: ")
+	if readSecretLine(scanner) != passphrase {
+		return errors.New("as senhas não conferem")
+	}
+
+	dbPath := getDatabasePath()
+	encryptedPath := dbPath + ".cifrado"
+	os.Remove(encryptedPath)
+	if err := encryptDatabaseFile(db, encryptedPath, passphrase); err != nil {
+		os.Remove(encryptedPath)
+		return fmt.Errorf("erro ao cifrar o banco: %w", err)
+	}
+	db.Close()
+	if err := os.Rename(dbPath, dbPath+".sem-cifra"); err != nil {
+		return err
+	}
+	if err := os.Rename(encryptedPath, dbPath); err != nil {
+		return err
+	}
+
+	fmt.Println(colorGreen("Banco cifrado."))
+	fmt.Printf("Defina %s com a senha ao iniciar o aplicativo.\n", dbPassphraseEnv)
+	fmt.Println(colorYellow(fmt.Sprintf("A cópia sem cifra ficou em %s.sem-cifra: apague-a depois de conferir.", dbPath)))
+	return nil
+}
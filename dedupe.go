@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deduplicação de eventos: a Bybit às vezes entrega a mesma atualização de ordem ou execução mais de uma vez (em
+// reconexões, no reenvio do snapshot após a inscrição, ou com a recuperação de lacunas pela API REST somada ao
+// WebSocket). Cada evento recebe uma impressão digital (conta + campos que identificam a atualização) e, se a mesma
+// impressão já foi processada nos últimos dedupeWindow, o evento é descartado antes do histórico e do buffer de
+// atraso, sem gerar notificação repetida. Atualizações de verdade da mesma ordem mudam status, preço, quantidade ou
+// updatedTime e, portanto, a impressão.
+const dedupeWindow = 10 * time.Minute
+
+var recentEvents = struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	nextSweep time.Time
+}{seen: make(map[string]time.Time)}
+
+// isDuplicateEvent registra a impressão do evento da conta e informa se ela já foi vista dentro da janela.
+func isDuplicateEvent(accountID int64, fingerprint string) bool {
+	key := strconv.FormatInt(accountID, 10) + "|" + fingerprint
+	now := time.Now()
+	recentEvents.mu.Lock()
+	defer recentEvents.mu.Unlock()
+	if now.After(recentEvents.nextSweep) {
+		for k, at := range recentEvents.seen {
+			if now.Sub(at) >= dedupeWindow {
+				delete(recentEvents.seen, k)
+			}
+		}
+		recentEvents.nextSweep = now.Add(dedupeWindow)
+	}
+	if at, ok := recentEvents.seen[key]; ok && now.Sub(at) < dedupeWindow {
+		return true
+	}
+	recentEvents.seen[key] = now
+	return false
+}
+
+// orderFingerprint identifica uma atualização de ordem ou stop.
+func orderFingerprint(order OrderData) string {
+	return strings.Join([]string{"order", order.OrderID, order.OrderStatus, order.UpdatedTime, order.Price,
+		order.AvgPrice, order.Qty, order.TriggerPrice}, "|")
+}
+
+// executionFingerprint identifica uma execução (o execId é único na corretora).
+func executionFingerprint(exec ExecutionData) string {
+	return "execution|" + exec.ExecID
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Entrega das notificações: erros temporários (conexão não estabelecida, 429, 5xx) são repetidos com espera crescente.
+// Cada tentativa com falha vai para o arquivo de notificações da conta e o resultado final para o histórico.
+const (
+	deliveryMaxAttempts = 3
+	deliveryRetryDelay  = 2 * time.Second
+)
+
+// failedDeliveriesWindow é o período do contador de entregas com falha exibido no menu.
+const failedDeliveriesWindow = 24 * time.Hour
+
+// webhookStatusError é a resposta de erro de um webhook, com o status HTTP para a auditoria das entregas.
+type webhookStatusError struct {
+	StatusCode int
+	Hint       string
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("status code: %d%s", e.StatusCode, e.Hint)
+}
+
+// notificationDelivery é o resultado de um envio: tentativas feitas, status HTTP do último erro e o erro final (nil = entregue),
+// com os IDs de correlação das mensagens do WebSocket que geraram a notificação.
+type notificationDelivery struct {
+	Attempts       int
+	HTTPStatus     int
+	Err            error
+	CorrelationIDs []string
+	LogOnly        bool // conta no modo só registro: nada foi enviado (logonly.go)
+}
+
+// deliveryHTTPStatus retorna o status HTTP do erro do webhook (0 = sem resposta HTTP).
+func deliveryHTTPStatus(err error) int {
+	var statusErr *webhookStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// isTransientDeliveryError indica se vale tentar de novo: conexão não estabelecida (DNS, conexão recusada), limite de
+// envio (429) ou erro do servidor (5xx). Timeouts e quedas depois de conectar não são repetidos: a requisição pode ter
+// chegado e a notificação sairia duplicada. Erros de configuração (URL inválida, 401, 404...) não se resolvem sozinhos.
+func isTransientDeliveryError(err error) bool {
+	if status := deliveryHTTPStatus(err); status != 0 {
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	// Falha ao conectar: a requisição não chegou a ser enviada
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// deliverNotification executa o envio com novas tentativas para erros temporários e registra o resultado
+// (contadores, histórico e arquivo de notificações). correlationIDs são as mensagens de origem (nil = nenhuma,
+// ex.: resumo agendado). Retorna o erro final.
+func deliverNotification(accountID int64, channel, message string, correlationIDs []string, send func() error) error {
+	delivery := notificationDelivery{CorrelationIDs: uniqueCorrelationIDs(correlationIDs)}
+	for {
+		delivery.Attempts++
+		delivery.Err = send()
+		if errors.Is(delivery.Err, errLogOnly) {
+			delivery.Err, delivery.LogOnly = nil, true
+			break
+		}
+		delivery.HTTPStatus = deliveryHTTPStatus(delivery.Err)
+		if delivery.Err == nil || delivery.Attempts >= deliveryMaxAttempts || !isTransientDeliveryError(delivery.Err) {
+			break
+		}
+		wait := deliveryRetryDelay * time.Duration(delivery.Attempts)
+		appendNotificationAttemptLog(accountID, channel, delivery, wait)
+		time.Sleep(wait)
+	}
+	recordNotificationResult(accountID, channel, message, delivery)
+	return delivery.Err
+}
+
+// deliveryAttemptsText descreve as tentativas para os logs e o histórico (ex.: "2 tentativas, HTTP 502").
+func deliveryAttemptsText(delivery notificationDelivery) string {
+	text := "1 tentativa"
+	if delivery.Attempts > 1 {
+		text = fmt.Sprintf("%d tentativas", delivery.Attempts)
+	}
+	if delivery.HTTPStatus != 0 {
+		text += fmt.Sprintf(", HTTP %d", delivery.HTTPStatus)
+	}
+	return text
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Bot do Discord: em vez do webhook, as notificações do canal principal podem ser postadas por um bot num canal do
+// servidor, configurado na preferência discord_bot da conta, ex.:
+// settings "Minha Conta" discord_bot '{"token": "env:DISCORD_BOT_TOKEN", "channel_id": "123456789012345678"}'.
+// O token aceita as referências env: e keyring: (secrets.go); o bot precisa das permissões Ver canal e Enviar
+// mensagens no canal. Com order_message_edits (ordermessages.go), as mensagens de nova ordem postadas pelo bot são
+// editadas com o status da ordem.
+const discordBotSettingKey = "discord_bot"
+
+const notifyChannelDiscordBot = "bot"
+
+const discordBotAPIURL = "https://discord.com/api/v10"
+
+// discordChannelIDPattern valida o ID do canal (snowflake do Discord).
+var discordChannelIDPattern = regexp.MustCompile(`^\d{5,25}$`)
+
+// discordBotConfig é a preferência discord_bot da conta.
+type discordBotConfig struct {
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+}
+
+func validateDiscordBotSetting(value json.RawMessage) error {
+	var config discordBotConfig
+	if json.Unmarshal(value, &config) != nil || strings.TrimSpace(config.Token) == "" {
+		return errors.New(`use {"token": "...", "channel_id": "..."} com o token do bot (ou env:VARIAVEL) e o ID do canal`)
+	}
+	if !discordChannelIDPattern.MatchString(strings.TrimSpace(config.ChannelID)) {
+		return errors.New("channel_id inválido (use o ID numérico do canal: botão direito no canal > Copiar ID)")
+	}
+	return nil
+}
+
+// accountDiscordBot retorna o bot do Discord da conta, com o token já resolvido (false = não configurado, inválido ou
+// com a referência do token indisponível).
+func accountDiscordBot(account *BybitAccount) (discordBotConfig, bool) {
+	var config discordBotConfig
+	if !account.Settings.Decode(discordBotSettingKey, &config) {
+		return discordBotConfig{}, false
+	}
+	config.ChannelID = strings.TrimSpace(config.ChannelID)
+	token, err := resolveSecretValue(strings.TrimSpace(config.Token))
+	if err != nil || token == "" || !discordChannelIDPattern.MatchString(config.ChannelID) {
+		return discordBotConfig{}, false
+	}
+	config.Token = token
+	return config, true
+}
+
+// messagesURL retorna o endpoint das mensagens do canal (também a chave do limite de envio do bot).
+func (c discordBotConfig) messagesURL() string {
+	return discordBotAPIURL + "/channels/" + c.ChannelID + "/messages"
+}
+
+// discordBotStatusHint explica os status de erro mais comuns da API do bot.
+func discordBotStatusHint(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return "token do bot inválido"
+	case http.StatusForbidden:
+		return "o bot não tem permissão para ver o canal ou enviar mensagens"
+	case http.StatusNotFound:
+		return "canal ou mensagem não encontrado"
+	case http.StatusTooManyRequests:
+		return "limite de envio do Discord"
+	}
+	return ""
+}
+
+// discordBotRequest faz a chamada à API do bot (requestURL: as mensagens do canal ou uma delas).
+func discordBotRequest(config discordBotConfig, method, requestURL string, jsonData []byte) ([]byte, error) {
+	return discordRequest(method, config.messagesURL(), requestURL, "Bot "+config.Token, jsonData, discordBotStatusHint)
+}
+
+// postDiscordBotMessage posta a mensagem no canal do bot e retorna o ID da mensagem criada ("" na reprodução, que só
+// imprime).
+func postDiscordBotMessage(config discordBotConfig, message, imageURL string) (string, error) {
+	if interceptReplayNotification(true, config.messagesURL(), message) {
+		return "", nil
+	}
+	jsonData, err := json.Marshal(discordMessagePayload(message, imageURL))
+	if err != nil {
+		return "", err
+	}
+	body, err := discordBotRequest(config, http.MethodPost, config.messagesURL(), jsonData)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(body, &created) != nil || created.ID == "" {
+		return "", errors.New("resposta do Discord sem o ID da mensagem")
+	}
+	return created.ID, nil
+}
+
+// editDiscordBotMessage troca o texto de uma mensagem postada pelo bot.
+func editDiscordBotMessage(config discordBotConfig, messageID, message string) error {
+	if interceptReplayNotification(true, config.messagesURL(), message) {
+		return nil
+	}
+	jsonData, err := json.Marshal(map[string]string{"content": message})
+	if err != nil {
+		return err
+	}
+	_, err = discordBotRequest(config, http.MethodPatch, config.messagesURL()+"/"+messageID, jsonData)
+	return err
+}
+
+// discordBotSender retorna o envio da mensagem pelo bot da conta para deliverNotification.
+func discordBotSender(account *BybitAccount, message, imageURL string) func() error {
+	return func() error {
+		config, ok := accountDiscordBot(account)
+		if !ok {
+			return errors.New("bot do Discord não configurado (token ou channel_id)")
+		}
+		_, err := postDiscordBotMessage(config, message, imageURL)
+		return err
+	}
+}
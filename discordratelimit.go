@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limite de envio do Discord: os envios a um mesmo webhook passam por uma fila (um de cada vez) e respeitam os
+// cabeçalhos de limite da resposta (X-RateLimit-Remaining / X-RateLimit-Reset-After): esgotado o limite, o próximo
+// envio espera o reset em vez de levar 429. Se o Discord responder 429 mesmo assim, o envio espera o Retry-After
+// (do webhook ou global, que vale para todos os webhooks) e tenta de novo, até discordRateLimitMaxRetries vezes;
+// esperas maiores que discordRateLimitMaxWait voltam como erro para as novas tentativas da entrega (delivery.go).
+// Assim, uma rajada de ordens agrupadas é enviada mais devagar, mas sem perder notificações.
+const (
+	discordRateLimitMaxRetries = 3
+	discordRateLimitMaxWait    = 30 * time.Second
+)
+
+// discordBucket é o estado do limite de um webhook.
+type discordBucket struct {
+	mu        sync.Mutex // fila dos envios ao webhook
+	remaining int        // envios restantes na janela atual (-1 = desconhecido)
+	resetAt   time.Time
+}
+
+var discordRateLimits = struct {
+	mu          sync.Mutex
+	buckets     map[string]*discordBucket
+	globalUntil time.Time // 429 global: nenhum webhook envia antes
+}{buckets: make(map[string]*discordBucket)}
+
+// discordBucketFor retorna o limite do webhook (sem a query string, ex.: ?wait=true).
+func discordBucketFor(webhookURL string) *discordBucket {
+	key, _, _ := strings.Cut(webhookURL, "?")
+	discordRateLimits.mu.Lock()
+	defer discordRateLimits.mu.Unlock()
+	bucket, ok := discordRateLimits.buckets[key]
+	if !ok {
+		bucket = &discordBucket{remaining: -1}
+		discordRateLimits.buckets[key] = bucket
+	}
+	return bucket
+}
+
+// wait espera o limite do webhook (e o global) liberar um envio. Chamado com b.mu travado.
+func (b *discordBucket) wait() {
+	for {
+		now := time.Now()
+		var until time.Time
+		if b.remaining == 0 && b.resetAt.After(now) {
+			until = b.resetAt
+		}
+		discordRateLimits.mu.Lock()
+		if discordRateLimits.globalUntil.After(until) {
+			until = discordRateLimits.globalUntil
+		}
+		discordRateLimits.mu.Unlock()
+		if !until.After(now) {
+			return
+		}
+		time.Sleep(until.Sub(now))
+	}
+}
+
+// update lê os cabeçalhos de limite da resposta. Em 429, retorna a espera pedida pelo Discord e limited = true.
+// Chamado com b.mu travado.
+func (b *discordBucket) update(resp *http.Response) (retryAfter time.Duration, limited bool) {
+	now := time.Now()
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		b.remaining = remaining
+		if resetAfter, ok := parseDiscordSeconds(resp.Header.Get("X-RateLimit-Reset-After")); ok {
+			b.resetAt = now.Add(resetAfter)
+		}
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// O corpo do 429 traz retry_after (segundos, com fração) e se o limite é global
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+	retryAfter, ok := parseDiscordSeconds(resp.Header.Get("Retry-After"))
+	if !ok {
+		retryAfter = time.Duration(body.RetryAfter * float64(time.Second))
+	}
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	b.remaining, b.resetAt = 0, now.Add(retryAfter)
+	if body.Global || strings.EqualFold(resp.Header.Get("X-RateLimit-Global"), "true") {
+		discordRateLimits.mu.Lock()
+		if until := now.Add(retryAfter); until.After(discordRateLimits.globalUntil) {
+			discordRateLimits.globalUntil = until
+		}
+		discordRateLimits.mu.Unlock()
+	}
+	return retryAfter, true
+}
+
+// parseDiscordSeconds lê uma duração em segundos (com fração) dos cabeçalhos do Discord.
+func parseDiscordSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+const eventLogEnv = "EVENTLOG"
+
+func newEventLogSink() (logSink, error) {
+	return nil, errors.New("o Log de Eventos só está disponível no Windows")
+}
+
+// eventLogWanted fora do Windows só é verdadeiro com EVENTLOG=on, para avisar que a opção não tem efeito.
+func eventLogWanted() bool {
+	return os.Getenv(eventLogEnv) == "on"
+}
@@ -0,0 +1,68 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Saída dos avisos e erros das contas para o Log de Eventos do Windows (log Aplicativo, fonte bybit-notifier),
+// ativada com EVENTLOG=on. Linhas de info e debug continuam só nos arquivos de log.
+const (
+	eventLogEnv    = "EVENTLOG"
+	eventLogSource = "bybit-notifier"
+)
+
+// IDs dos eventos por severidade, para filtrar no Visualizador de Eventos.
+const (
+	eventLogIDError   = 1
+	eventLogIDWarning = 2
+)
+
+// eventLogMaxMessage fica abaixo do limite de 31.839 caracteres por string do ReportEvent.
+const eventLogMaxMessage = 30000
+
+type eventLogSink struct {
+	log *eventlog.Log
+}
+
+func newEventLogSink() (logSink, error) {
+	// Registra a fonte (precisa de administrador). Se já estiver registrada ou
+	// sem permissão, os eventos são gravados do mesmo jeito; só a descrição fica com o aviso genérico do Windows.
+	eventlog.InstallAsEventCreate(eventLogSource, eventlog.Error|eventlog.Warning|eventlog.Info)
+	log, err := eventlog.Open(eventLogSource)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogSink{log: log}, nil
+}
+
+func (s *eventLogSink) Name() string {
+	return "eventlog"
+}
+
+func (s *eventLogSink) WriteLog(entry logEntry) error {
+	if entry.Severity < severityWarning {
+		return nil
+	}
+	message := formatLogEntryMessage(entry)
+	if len(message) > eventLogMaxMessage {
+		message = message[:eventLogMaxMessage] + "... (truncado)"
+	}
+	if entry.Severity == severityError {
+		return s.log.Error(eventLogIDError, message)
+	}
+	return s.log.Warning(eventLogIDWarning, message)
+}
+
+// eventLogWanted indica se a saída para o Log de Eventos foi pedida (EVENTLOG=on).
+func eventLogWanted() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(eventLogEnv))) {
+	case "on", "1", "true", "sim":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const exportUsage = "uso: export <conta> <executions|positions> [--from DD/MM/AAAA] [--to DD/MM/AAAA] [--symbol SÍMBOLO] [--out arquivo.csv]"
+
+// runExportCommand exporta em CSV as execuções ou as posições fechadas da conta (datas no fuso da conta).
+// Sem --out o CSV vai para a saída padrão.
+func runExportCommand(db *Database, args []string) error {
+	if len(args) < 2 {
+		return errors.New(exportUsage)
+	}
+	manager := NewAccountManager(db)
+	account, err := findAccountByNameOrID(manager, args[0])
+	if err != nil {
+		return err
+	}
+	kind := args[1]
+	if kind != "executions" && kind != "positions" {
+		return fmt.Errorf("tipo desconhecido: %s (use executions ou positions)", kind)
+	}
+
+	tz := loadTimezone(account.Timezone)
+	var filter HistoryFilter
+	outPath := ""
+	rest := args[2:]
+	for i := 0; i < len(rest); i++ {
+		if i+1 >= len(rest) {
+			return errors.New(exportUsage)
+		}
+		value := rest[i+1]
+		switch rest[i] {
+		case "--from":
+			if filter.From, err = parseHistoryDate(value, tz); err != nil {
+				return fmt.Errorf("data inválida: %s", value)
+			}
+		case "--to":
+			to, err := parseHistoryDate(value, tz)
+			if err != nil {
+				return fmt.Errorf("data inválida: %s", value)
+			}
+			filter.To = to.AddDate(0, 0, 1) // inclusive
+		case "--symbol":
+			filter.Symbol = value
+		case "--out":
+			outPath = value
+		default:
+			return fmt.Errorf("opção desconhecida: %s\n%s", rest[i], exportUsage)
+		}
+		i++
+	}
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var count int
+	if kind == "executions" {
+		count, err = exportExecutionsCSV(out, manager, account, filter, tz)
+	} else {
+		count, err = exportPositionsCSV(out, manager, account, filter, tz)
+	}
+	if err != nil {
+		return err
+	}
+	if outPath != "" {
+		fmt.Printf("%d registro(s) exportado(s) para %s\n", count, outPath)
+	}
+	return nil
+}
+
+func exportExecutionsCSV(out io.Writer, manager HistoryRepo, account *BybitAccount, filter HistoryFilter, tz *time.Location) (int, error) {
+	records, err := manager.ListExecutions(account.ID, filter)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(out)
+	w.Write([]string{"data", "simbolo", "lado", "tipo_ordem", "preco", "quantidade", "valor", "taxa", "taxa_percentual", "maker", "order_id", "exec_id"})
+	// Ordem cronológica, como numa planilha de operações
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		w.Write([]string{
+			r.ExecTime.In(tz).Format("2006-01-02 15:04:05"), r.Symbol, r.Side, r.OrderType, r.Price, r.Qty, r.Value,
+			r.Fee, r.FeeRate, strconv.FormatBool(r.IsMaker), r.OrderID, r.ExecID,
+		})
+	}
+	w.Flush()
+	return len(records), w.Error()
+}
+
+// exportPositionsCSV exporta o diário de operações: só posições já fechadas.
+func exportPositionsCSV(out io.Writer, manager HistoryRepo, account *BybitAccount, filter HistoryFilter, tz *time.Location) (int, error) {
+	records, err := manager.ListPositionHistory(account.ID, filter)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(out)
+	w.Write([]string{"abertura", "fechamento", "simbolo", "lado", "tamanho_maximo", "preco_entrada", "preco_saida", "duracao_segundos", "pnl_realizado"})
+	count := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.ClosedAt.IsZero() {
+			continue
+		}
+		w.Write([]string{
+			r.OpenedAt.In(tz).Format("2006-01-02 15:04:05"), r.ClosedAt.In(tz).Format("2006-01-02 15:04:05"), r.Symbol, r.Side,
+			r.Size, r.EntryPrice, r.ExitPrice, strconv.FormatInt(int64(r.Duration().Seconds()), 10), r.RealizedPnl,
+		})
+		count++
+	}
+	w.Flush()
+	return count, w.Error()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Failover de endpoint: a Bybit publica o stream privado em mais de um domínio. Depois de bybitFailoverAfter falhas
+// seguidas ao abrir a conexão (DNS, TLS, recusa), a conta passa a tentar o próximo endpoint da lista; conectada por
+// um endpoint alternativo, o operador é avisado uma vez, e na reconexão seguinte o principal é tentado de novo.
+// BYBIT_WS_URLS substitui a lista (separada por vírgula, o primeiro é o principal).
+const (
+	bybitWSURLsEnv      = "BYBIT_WS_URLS"
+	bybitFailoverAfter  = 3
+	bybitAlternateWSURL = "wss://stream.bytick.com/v5/private"
+)
+
+// bybitStreamEndpoints retorna os endpoints do stream privado, o principal primeiro.
+func bybitStreamEndpoints() []string {
+	if mockStreamURL != "" {
+		// Servidor simulado (--mock-bybit, mockbybit.go)
+		return []string{mockStreamURL}
+	}
+	var endpoints []string
+	for _, endpoint := range strings.Split(os.Getenv(bybitWSURLsEnv), ",") {
+		endpoint = strings.TrimSpace(endpoint)
+		if strings.HasPrefix(endpoint, "wss://") || strings.HasPrefix(endpoint, "ws://") {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return []string{bybitWSURL, bybitAlternateWSURL}
+	}
+	return endpoints
+}
+
+// endpointFailover é o estado do failover da conexão (protegido por WebSocketConnection.mu).
+type endpointFailover struct {
+	index         int  // endpoint em uso em bybitStreamEndpoints
+	failures      int  // falhas seguidas de conexão no endpoint em uso
+	retryPrimary  bool // conectou por um alternativo: a próxima tentativa volta ao principal
+	fallbackAlert bool // operador já avisado do uso do alternativo
+}
+
+// nextEndpoint retorna o endpoint da próxima tentativa de conexão.
+func (c *WebSocketConnection) nextEndpoint() string {
+	endpoints := bybitStreamEndpoints()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failover.retryPrimary || c.failover.index >= len(endpoints) {
+		c.failover.index, c.failover.failures, c.failover.retryPrimary = 0, 0, false
+	}
+	return endpoints[c.failover.index]
+}
+
+// noteDialResult registra o resultado da abertura da conexão em endpoint: troca de endpoint depois de
+// bybitFailoverAfter falhas seguidas e avisa quando a conta conectou por um alternativo (ou voltou ao principal).
+func (wsm *WebSocketManager) noteDialResult(wsConn *WebSocketConnection, endpoint string, dialErr error) {
+	endpoints := bybitStreamEndpoints()
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+
+	wsConn.mu.Lock()
+	state := &wsConn.failover
+	if dialErr != nil {
+		state.failures++
+		if state.failures < bybitFailoverAfter || len(endpoints) < 2 {
+			wsConn.mu.Unlock()
+			return
+		}
+		failures := state.failures
+		state.index = (state.index + 1) % len(endpoints)
+		state.failures = 0
+		next := endpoints[state.index]
+		wsConn.mu.Unlock()
+		if logger != nil {
+			logger.Log("⚠️ %d falhas seguidas ao conectar em %s; próxima tentativa pelo endpoint %s", failures, endpoint, next)
+		}
+		return
+	}
+
+	state.failures = 0
+	onFallback := state.index != 0
+	alert := onFallback && !state.fallbackAlert
+	recovered := !onFallback && state.fallbackAlert
+	if onFallback {
+		state.retryPrimary = true
+		state.fallbackAlert = true
+	} else {
+		state.fallbackAlert = false
+	}
+	wsConn.mu.Unlock()
+
+	switch {
+	case alert:
+		if logger != nil {
+			logger.Log("⚠️ Conectada pelo endpoint alternativo %s (principal %s indisponível)", endpoint, endpoints[0])
+		}
+		wsm.sendOperatorAlert(wsConn, eventAlert, fmt.Sprintf("🔀 Conta **%s**: o endpoint principal da Bybit (%s) falhou %d vezes seguidas; a conta está conectada pelo endpoint alternativo %s.",
+			wsConn.Account().Name, endpoints[0], bybitFailoverAfter, endpoint))
+	case onFallback && logger != nil:
+		logger.Log("Conectada pelo endpoint alternativo %s", endpoint)
+	case recovered && logger != nil:
+		logger.Log("✅ Endpoint principal %s voltou a responder", endpoint)
+	}
+}
+
+// streamEndpoint retorna o endpoint em uso quando não é o principal (vazio = principal ou OKX). Chamado com c.mu travado.
+func (c *WebSocketConnection) streamEndpoint() string {
+	if c.Account().Platform == "okx" || c.failover.index == 0 {
+		return ""
+	}
+	endpoints := bybitStreamEndpoints()
+	if c.failover.index >= len(endpoints) {
+		return ""
+	}
+	return endpoints[c.failover.index]
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// notifyingConnection retorna a conexão em nome da qual as notificações em buffer da conta são enviadas: a ativa
+// ou, durante a parada, a que está descarregando os buffers. false = conta parada, notificação descartada.
+func (wsm *WebSocketManager) notifyingConnection(accountID int64) (*WebSocketConnection, bool) {
+	wsm.mu.RLock()
+	defer wsm.mu.RUnlock()
+	if conn, exists := wsm.connections[accountID]; exists && conn.running() {
+		return conn, true
+	}
+	conn, exists := wsm.stopping[accountID]
+	return conn, exists
+}
+
+// flushBuffers envia na hora o que está aguardando nos buffers da conta: ordens, cancelamentos e execuções do
+// atraso de agrupamento e, depois deles (as execuções reiniciam os timers da carteira), o resumo da carteira e a
+// atualização da planilha, e por fim os resumos do limite por canal. Os timers são parados; só o que estava pendente
+// é enviado.
+func (wsm *WebSocketManager) flushBuffers(accountID int64, wsConn *WebSocketConnection) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "[PANIC] flushBuffers para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(accountID, wsConn.Account().Name, "flushBuffers", r)
+		}
+	}()
+	logger, _ := getLogger(accountID, wsConn.Account().Name)
+
+	wsm.bufferMu.RLock()
+	delayBuf := wsm.delayBuffers[accountID]
+	wsm.bufferMu.RUnlock()
+	if delayBuf != nil {
+		delayBuf.mu.Lock()
+		if delayBuf.timer != nil {
+			delayBuf.timer.Stop()
+			delayBuf.timer = nil
+		}
+		pending := len(delayBuf.orders) + len(delayBuf.stops) + len(delayBuf.executions)
+		delayBuf.mu.Unlock()
+		if pending > 0 {
+			if logger != nil {
+				logger.Log("Parada: enviando %d item(ns) pendente(s) do buffer de atraso", pending)
+			}
+			wsm.processDelayBuffer(accountID, wsConn)
+		}
+	}
+
+	wsm.bufferMu.RLock()
+	walletBuf := wsm.walletNotificationBuffers[accountID]
+	wsm.bufferMu.RUnlock()
+	if walletBuf != nil {
+		var discordPending, sheetsPending bool
+		walletBuf.mu.Lock()
+		if walletBuf.discordTimer != nil {
+			discordPending = walletBuf.discordTimer.Stop()
+		}
+		if walletBuf.sheetsTimer != nil {
+			sheetsPending = walletBuf.sheetsTimer.Stop()
+		}
+		walletBuf.mu.Unlock()
+		if discordPending {
+			if logger != nil {
+				logger.Log("Parada: enviando o resumo da carteira pendente")
+			}
+			wsm.processWalletNotification(accountID, wsConn)
+		}
+		if sheetsPending {
+			wsm.processSheetsNotification(accountID)
+		}
+	}
+
+	// Por último, o resumo das notificações acumuladas pelo limite por canal (inclusive as geradas acima)
+	wsm.flushRateLimits(wsConn)
+
+	// Uma nova conexão da conta pode já ter criado os próprios buffers; só remove os que foram descarregados
+	wsm.bufferMu.Lock()
+	if delayBuf != nil && wsm.delayBuffers[accountID] == delayBuf {
+		delete(wsm.delayBuffers, accountID)
+	}
+	if walletBuf != nil && wsm.walletNotificationBuffers[accountID] == walletBuf {
+		delete(wsm.walletNotificationBuffers, accountID)
+	}
+	wsm.bufferMu.Unlock()
+}
+
+// FlushAllBuffers envia os buffers pendentes de todas as contas sem parar o monitoramento (ao sair do aplicativo,
+// quando as conexões continuam marcadas como ativas para serem restauradas no próximo início) e espera a fila de
+// envio de cada conta esvaziar.
+func (wsm *WebSocketManager) FlushAllBuffers() {
+	wsm.mu.RLock()
+	conns := make(map[int64]*WebSocketConnection, len(wsm.connections))
+	for accountID, conn := range wsm.connections {
+		conns[accountID] = conn
+	}
+	wsm.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for accountID, conn := range conns {
+		wg.Add(1)
+		go func(accountID int64, conn *WebSocketConnection) {
+			defer wg.Done()
+			wsm.flushBuffers(accountID, conn)
+			conn.drainDeliveries(deliveryDrainTimeout)
+		}(accountID, conn)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Funding no resumo de carteira: enquanto uma conta Bybit tem posição aberta num símbolo, o ticker do símbolo é
+// acompanhado pelo stream público (publicstream.go) e o resumo mostra a taxa de funding atual, o horário do próximo
+// funding e quanto cada posição paga ou recebe nele, ex.:
+// "⏱️ Funding: 0.01% às 16:00 (em 2h15m) - Long paga ≈ 0.0000167 BTC".
+// Sem dados do ticker (conexão pública fora do ar, comando summary fora do aplicativo), a linha não aparece.
+
+// fundingStaleAfter é a idade máxima dos dados do ticker para aparecerem no resumo.
+const fundingStaleAfter = 10 * time.Minute
+
+// fundingInfo é a taxa de funding atual e o próximo funding de um símbolo.
+type fundingInfo struct {
+	Rate      float64 // fração (0.0001 = 0.01%)
+	NextAt    time.Time
+	UpdatedAt time.Time
+}
+
+// fundingRates guarda o último funding de cada símbolo recebido pelo stream público.
+var fundingRates = struct {
+	mu       sync.Mutex
+	bySymbol map[string]fundingInfo
+}{bySymbol: make(map[string]fundingInfo)}
+
+// fundingTracker inscreve os tickers dos símbolos com posição aberta em alguma conta.
+type fundingTracker struct {
+	public  *PublicStreamManager
+	mu      sync.Mutex
+	holders map[string]map[string]bool // símbolo -> "conta:positionIdx" com posição aberta
+	subs    map[string]*PublicSubscription
+}
+
+func newFundingTracker(public *PublicStreamManager) *fundingTracker {
+	return &fundingTracker{public: public, holders: make(map[string]map[string]bool), subs: make(map[string]*PublicSubscription)}
+}
+
+// trackPosition registra a abertura ou o fechamento da posição da conta; o ticker do símbolo fica inscrito enquanto
+// alguma conta tiver posição aberta nele.
+func (t *fundingTracker) trackPosition(accountID int64, pos PositionData) {
+	size, _ := strconv.ParseFloat(pos.Size, 64)
+	holder := fmt.Sprintf("%d:%d", accountID, pos.PositionIdx)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if size > 0 {
+		if t.holders[pos.Symbol] == nil {
+			t.holders[pos.Symbol] = make(map[string]bool)
+		}
+		t.holders[pos.Symbol][holder] = true
+	} else if holders := t.holders[pos.Symbol]; holders != nil {
+		delete(holders, holder)
+	}
+	t.syncLocked(pos.Symbol)
+}
+
+// releaseAccount remove as posições da conta parada.
+func (t *fundingTracker) releaseAccount(accountID int64) {
+	prefix := fmt.Sprintf("%d:", accountID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for symbol, holders := range t.holders {
+		for holder := range holders {
+			if strings.HasPrefix(holder, prefix) {
+				delete(holders, holder)
+			}
+		}
+		t.syncLocked(symbol)
+	}
+}
+
+// syncLocked inscreve ou cancela o ticker do símbolo conforme as posições abertas. Chamado com t.mu travado.
+func (t *fundingTracker) syncLocked(symbol string) {
+	open := len(t.holders[symbol]) > 0
+	sub, subscribed := t.subs[symbol]
+	switch {
+	case open && !subscribed:
+		t.subs[symbol] = t.public.Subscribe(publicTopicTicker(symbol), handleFundingTicker)
+	case !open && subscribed:
+		sub.Unsubscribe()
+		delete(t.subs, symbol)
+		delete(t.holders, symbol)
+		fundingRates.mu.Lock()
+		delete(fundingRates.bySymbol, symbol)
+		fundingRates.mu.Unlock()
+	}
+}
+
+// handleFundingTicker lê fundingRate e nextFundingTime do ticker; nos deltas, só os campos alterados vêm preenchidos.
+func handleFundingTicker(msg PublicMessage) {
+	var ticker struct {
+		Symbol          string `json:"symbol"`
+		FundingRate     string `json:"fundingRate"`
+		NextFundingTime string `json:"nextFundingTime"`
+	}
+	if json.Unmarshal(msg.Data, &ticker) != nil || ticker.Symbol == "" {
+		return
+	}
+	fundingRates.mu.Lock()
+	defer fundingRates.mu.Unlock()
+	info := fundingRates.bySymbol[ticker.Symbol]
+	if rate, err := strconv.ParseFloat(ticker.FundingRate, 64); err == nil {
+		info.Rate = rate
+	}
+	if ms, err := strconv.ParseInt(ticker.NextFundingTime, 10, 64); err == nil && ms > 0 {
+		info.NextAt = time.UnixMilli(ms)
+	}
+	info.UpdatedAt = time.Now()
+	fundingRates.bySymbol[ticker.Symbol] = info
+}
+
+// currentFunding retorna o funding do símbolo, se recente e com o próximo horário ainda por vir.
+func currentFunding(symbol string) (fundingInfo, bool) {
+	fundingRates.mu.Lock()
+	info, ok := fundingRates.bySymbol[symbol]
+	fundingRates.mu.Unlock()
+	now := time.Now()
+	if !ok || now.Sub(info.UpdatedAt) > fundingStaleAfter || !info.NextAt.After(now) {
+		return fundingInfo{}, false
+	}
+	return info, true
+}
+
+// formatFundingLine formata a linha de funding do símbolo no resumo ("" = sem dados), com o que cada posição aberta
+// paga ou recebe: com taxa positiva o long paga e o short recebe; no inverso, o valor é size / mark * taxa na moeda.
+func formatFundingLine(symbol string, positions []walletSummaryPosition, timezone string) string {
+	info, ok := currentFunding(symbol)
+	if !ok {
+		return ""
+	}
+	line := fmt.Sprintf("  ⏱️ Funding: %s%% às %s (em %s)", strconv.FormatFloat(math.Round(info.Rate*1e6)/1e4, 'f', -1, 64),
+		info.NextAt.In(loadTimezone(timezone)).Format("15:04"), formatElapsed(time.Until(info.NextAt)))
+	var flows []string
+	for _, p := range positions {
+		amount, ok := coinAmount(p.Size, p.MarkPrice)
+		if !ok || info.Rate == 0 {
+			continue
+		}
+		verb := "paga"
+		if (p.Side == "Long") != (info.Rate > 0) {
+			verb = "recebe"
+		}
+		flows = append(flows, fmt.Sprintf("%s %s ≈ %s %s", p.Side, verb, formatCoinAmount(amount*math.Abs(info.Rate)), symbolToCoin(symbol)))
+	}
+	if len(flows) > 0 {
+		line += " - " + strings.Join(flows, ", ")
+	}
+	return line
+}
+
+// trackFundingSnapshots acompanha o funding das posições abertas salvas da conta, ao iniciar o monitoramento (antes
+// da primeira mensagem de position).
+func (wsm *WebSocketManager) trackFundingSnapshots(wsConn *WebSocketConnection) {
+	if wsConn.Account().Platform == "okx" {
+		return
+	}
+	rows, err := wsm.db.GetPositionSnapshotsByTypes(wsConn.AccountID, positionSnapshotTypes(wsm.accountManager, wsConn.AccountID))
+	if err != nil {
+		return
+	}
+	for _, positions := range buildPositionsBySymbol(rows) {
+		for _, pos := range positions {
+			wsm.funding.trackPosition(wsConn.AccountID, *pos)
+		}
+	}
+	// Parada durante a leitura: finishStop pode já ter liberado a conta
+	if !wsConn.running() {
+		wsm.funding.releaseAccount(wsConn.AccountID)
+	}
+}
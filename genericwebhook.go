@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Webhook genérico: além dos canais do Discord, as notificações podem ir para um serviço próprio pelo canal webhook
+// das rotas de severidade (severity_routes), configurado na preferência generic_webhook da conta, ex.:
+// settings "Minha Conta" generic_webhook '{"url": "https://meu-servico.local/notificacoes", "secret": "..."}'.
+// Cada notificação é um POST em JSON com um ID crescente (X-Notification-ID), o mesmo nas novas tentativas da mesma
+// entrega, para o serviço descartar duplicadas. Com secret, o corpo é assinado com HMAC-SHA256 no cabeçalho
+// X-Signature-256 ("sha256=<hex>"), para o serviço conferir que a notificação veio do aplicativo.
+const genericWebhookSettingKey = "generic_webhook"
+
+const notifyChannelGeneric = "webhook"
+
+const (
+	genericWebhookIDHeader        = "X-Notification-ID"
+	genericWebhookSignatureHeader = "X-Signature-256"
+	genericWebhookSecretMinLength = 16
+	genericWebhookTimeout         = 10 * time.Second
+	genericWebhookSequenceSetting = "generic_webhook_last_id" // app_settings: último ID enviado
+)
+
+// genericWebhookConfig é a preferência generic_webhook da conta.
+type genericWebhookConfig struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+}
+
+func validateGenericWebhookSetting(value json.RawMessage) error {
+	var config genericWebhookConfig
+	if json.Unmarshal(value, &config) != nil || !validHeartbeatURL(config.URL) {
+		return errors.New(`use {"url": "https://...", "secret": "..."} com a URL http(s) do serviço`)
+	}
+	if config.Secret != "" && len(config.Secret) < genericWebhookSecretMinLength {
+		return fmt.Errorf("secret curto demais (mínimo %d caracteres)", genericWebhookSecretMinLength)
+	}
+	return nil
+}
+
+// accountGenericWebhook retorna o webhook genérico da conta (false = não configurado ou inválido).
+func accountGenericWebhook(account *BybitAccount) (genericWebhookConfig, bool) {
+	var config genericWebhookConfig
+	if !account.Settings.Decode(genericWebhookSettingKey, &config) || !validHeartbeatURL(config.URL) {
+		return genericWebhookConfig{}, false
+	}
+	config.URL = strings.TrimSpace(config.URL)
+	return config, true
+}
+
+// genericWebhookPayload é o corpo enviado ao webhook genérico.
+type genericWebhookPayload struct {
+	ID             int64     `json:"id"`
+	AccountID      int64     `json:"account_id"`
+	Account        string    `json:"account"`
+	Message        string    `json:"message"`
+	ImageURL       string    `json:"image_url,omitempty"`
+	CorrelationIDs []string  `json:"correlation_ids,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// genericWebhookIDs gera os IDs das notificações: o horário em microssegundos, sempre maior que o último ID enviado.
+// O último ID fica gravado no banco (quando há banco), então a sequência continua crescendo após reiniciar mesmo que
+// o relógio volte.
+var genericWebhookIDs struct {
+	mu     sync.Mutex
+	loaded bool
+	last   int64
+}
+
+func nextGenericWebhookID() int64 {
+	notificationHistory.mu.RLock()
+	db := notificationHistory.db
+	notificationHistory.mu.RUnlock()
+
+	genericWebhookIDs.mu.Lock()
+	defer genericWebhookIDs.mu.Unlock()
+	if !genericWebhookIDs.loaded && db != nil {
+		if value, ok, err := db.GetSetting(genericWebhookSequenceSetting); err == nil && ok {
+			genericWebhookIDs.last, _ = strconv.ParseInt(value, 10, 64)
+		}
+		genericWebhookIDs.loaded = true
+	}
+	id := time.Now().UnixMicro()
+	if id <= genericWebhookIDs.last {
+		id = genericWebhookIDs.last + 1
+	}
+	genericWebhookIDs.last = id
+	if db != nil {
+		if err := db.SetSetting(genericWebhookSequenceSetting, strconv.FormatInt(id, 10)); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao gravar o ID do webhook genérico: %v\n", err)
+		}
+	}
+	return id
+}
+
+// signGenericWebhook retorna a assinatura do corpo ("sha256=<hex>").
+func signGenericWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// genericWebhookSender monta a notificação para o webhook genérico e retorna o envio para deliverNotification: o
+// corpo, o ID e a assinatura são gerados uma vez, e as novas tentativas reenviam exatamente o mesmo conteúdo.
+func genericWebhookSender(account *BybitAccount, message, imageURL string, correlationIDs []string) func() error {
+	config, ok := accountGenericWebhook(account)
+	if !ok {
+		return func() error { return errors.New("webhook genérico não configurado") }
+	}
+	payload := genericWebhookPayload{ID: nextGenericWebhookID(), AccountID: account.ID, Account: account.Name, Message: message,
+		ImageURL: imageURL, CorrelationIDs: uniqueCorrelationIDs(correlationIDs), CreatedAt: time.Now().UTC()}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return func() error { return err }
+	}
+	signature := ""
+	if config.Secret != "" {
+		signature = signGenericWebhook(config.Secret, body)
+	}
+	return func() error {
+		if replayOutput != nil {
+			// Reprodução: nunca chama o serviço, só imprime (se as mensagens do Discord também são impressas)
+			interceptReplayNotification(true, config.URL, message)
+			return nil
+		}
+		return sendGenericWebhook(config.URL, body, payload.ID, signature)
+	}
+}
+
+func sendGenericWebhook(webhookURL string, body []byte, id int64, signature string) error {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(genericWebhookIDHeader, strconv.FormatInt(id, 10))
+	if signature != "" {
+		req.Header.Set(genericWebhookSignatureHeader, signature)
+	}
+	client := &http.Client{Timeout: genericWebhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &webhookStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// channelSender retorna o envio da mensagem ao canal para deliverNotification: o webhook genérico, o bot do Discord ou
+// o webhook do Discord (nos webhooks compartilhados, com o nome da conta na frente).
+func channelSender(account *BybitAccount, channel, message, imageURL string, correlationIDs []string) func() error {
+	if isLogOnly(account) {
+		return func() error { return errLogOnly }
+	}
+	if channel == notifyChannelGeneric {
+		return genericWebhookSender(account, message, imageURL, correlationIDs)
+	}
+	if channel == notifyChannelDiscordBot {
+		return discordBotSender(account, message, imageURL)
+	}
+	webhookURL := channelWebhookURL(account, channel)
+	if _, shared := sharedChannelName(channel); shared {
+		message = sharedChannelMessage(account, message)
+	}
+	return func() error {
+		return sendDiscordWebhookWithImage(webhookURL, message, imageURL)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// dbPathEnv substitui o caminho do banco (equivalente a --db-path).
+const dbPathEnv = "DB_PATH"
+
+// inMemoryDatabasePath é o caminho especial do SQLite para um banco só em memória, descartado ao encerrar.
+const inMemoryDatabasePath = ":memory:"
+
+// dbPathOverride vem de --db-path (ou --in-memory) e tem prioridade sobre DB_PATH e DATA_DIR.
+var dbPathOverride string
+
+// isInMemoryDatabase indica se o banco é efêmero (--in-memory ou --db-path :memory:).
+func isInMemoryDatabase() bool {
+	return getDatabasePath() == inMemoryDatabasePath
+}
+
+// parseGlobalFlags trata as opções aceitas antes do subcomando (ou sem subcomando, no menu interativo)
+// e retorna os argumentos restantes:
+//
+//	--db-path <arquivo>   caminho do banco SQLite (o lock de instância fica ao lado dele)
+//	--data-dir <dir>      diretório de dados (banco e logs), equivalente a DATA_DIR
+//	--in-memory           banco só em memória (equivale a --db-path :memory:)
+//	--accounts-file <arq> contas em JSON cadastradas ao iniciar, equivalente a ACCOUNTS_FILE
+//	--mock-bybit[=<arq>]  contas Bybit conectam ao servidor simulado local, com o roteiro padrão ou o do arquivo
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		if args[0] == "--in-memory" {
+			dbPathOverride = inMemoryDatabasePath
+			args = args[1:]
+			continue
+		}
+		if args[0] == "--mock-bybit" || strings.HasPrefix(args[0], "--mock-bybit=") {
+			mockBybitEnabled = true
+			if _, script, hasValue := strings.Cut(args[0], "="); hasValue {
+				if strings.TrimSpace(script) == "" {
+					return nil, fmt.Errorf("a opção --mock-bybit= exige o arquivo do roteiro")
+				}
+				mockBybitScriptPath = script
+			}
+			args = args[1:]
+			continue
+		}
+		name, value, hasValue := strings.Cut(args[0], "=")
+		if name != "--db-path" && name != "--data-dir" && name != "--accounts-file" {
+			break
+		}
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("a opção %s exige um valor", name)
+			}
+			value = args[1]
+			args = args[1:]
+		}
+		args = args[1:]
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("a opção %s exige um valor", name)
+		}
+		switch name {
+		case "--db-path":
+			dbPathOverride = value
+		case "--data-dir":
+			os.Setenv("DATA_DIR", value)
+		case "--accounts-file":
+			accountsFileOverride = value
+		}
+	}
+	return args, nil
+}
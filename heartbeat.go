@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dead man's switch: além do heartbeat gravado no banco, a cada connectionHeartbeatInterval o aplicativo pode
+// chamar (GET) URLs de monitoramento externo no estilo healthchecks.io, que alarmam quando as chamadas param:
+//
+//   - HEARTBEAT_URL: chamada enquanto o processo estiver rodando (o monitor em si está vivo);
+//   - preferência heartbeat_url da conta: chamada só enquanto a conexão da conta estiver conectada e recebendo
+//     mensagens, ex.: settings "Minha Conta" heartbeat_url '"https://hc-ping.com/<uuid>"'.
+//
+// Falhas na chamada são avisadas uma vez por sequência (no stderr ou no log da conta) e não afetam o monitoramento.
+const (
+	heartbeatURLEnv        = "HEARTBEAT_URL"
+	heartbeatURLSettingKey = "heartbeat_url"
+	heartbeatPingTimeout   = 10 * time.Second
+)
+
+// heartbeatPingFailures guarda as URLs cuja última chamada falhou, para não repetir o aviso a cada intervalo.
+var heartbeatPingFailures = struct {
+	mu      sync.Mutex
+	failing map[string]bool
+}{failing: make(map[string]bool)}
+
+func validateHeartbeatURLSetting(value json.RawMessage) error {
+	var raw string
+	if json.Unmarshal(value, &raw) != nil || !validHeartbeatURL(raw) {
+		return errors.New("use uma URL http(s), ex.: \"https://hc-ping.com/<uuid>\"")
+	}
+	return nil
+}
+
+func validHeartbeatURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// pingHeartbeats chama a URL global e as URLs das contas conectadas; chamado a cada gravação do heartbeat.
+func (wsm *WebSocketManager) pingHeartbeats() {
+	var wg sync.WaitGroup
+	if global := strings.TrimSpace(os.Getenv(heartbeatURLEnv)); validHeartbeatURL(global) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pingHeartbeatURL(wsm.ctx, global)
+			if changed := noteHeartbeatPing(global, err); changed && err != nil {
+				fmt.Fprintf(os.Stderr, "[AVISO] Erro ao chamar %s: %v\n", heartbeatURLEnv, err)
+			} else if changed {
+				fmt.Fprintf(os.Stderr, "%s voltou a responder\n", heartbeatURLEnv)
+			}
+		}()
+	}
+
+	wsm.mu.RLock()
+	conns := make([]*WebSocketConnection, 0, len(wsm.connections))
+	for _, conn := range wsm.connections {
+		conns = append(conns, conn)
+	}
+	wsm.mu.RUnlock()
+	for _, conn := range conns {
+		pingURL := strings.TrimSpace(conn.Account().Settings.GetString(heartbeatURLSettingKey, ""))
+		if pingURL == "" || !conn.running() {
+			continue
+		}
+		if health, ok := wsm.GetConnectionHealth(conn.AccountID); !ok || health.Stale {
+			// Conexão fora do ar ou sem mensagens: sem chamada, para o monitoramento externo alarmar
+			continue
+		}
+		wg.Add(1)
+		go func(conn *WebSocketConnection) {
+			defer wg.Done()
+			err := pingHeartbeatURL(conn.ctx, pingURL)
+			changed := noteHeartbeatPing(pingURL, err)
+			logger, _ := getLogger(conn.AccountID, conn.Account().Name)
+			if !changed || logger == nil {
+				return
+			}
+			if err != nil {
+				logger.Log("⚠️ Erro ao chamar a URL de heartbeat: %v", err)
+			} else {
+				logger.Log("URL de heartbeat voltou a responder")
+			}
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// noteHeartbeatPing registra o resultado da chamada e retorna true quando ele mudou (primeira falha ou recuperação).
+func noteHeartbeatPing(pingURL string, err error) bool {
+	heartbeatPingFailures.mu.Lock()
+	defer heartbeatPingFailures.mu.Unlock()
+	failing := err != nil
+	if heartbeatPingFailures.failing[pingURL] == failing {
+		return false
+	}
+	if failing {
+		heartbeatPingFailures.failing[pingURL] = true
+	} else {
+		delete(heartbeatPingFailures.failing, pingURL)
+	}
+	return true
+}
+
+// pingHeartbeatURL faz o GET na URL de monitoramento; a URL (que costuma conter o token do check) não vai para os logs.
+func pingHeartbeatURL(ctx context.Context, pingURL string) error {
+	registerSecret(pingURL, redactedPlaceholder)
+	ctx, cancel := context.WithTimeout(ctx, heartbeatPingTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+	if err != nil {
+		return errors.New(redactSecrets(err.Error()))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.New(redactSecrets(err.Error()))
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
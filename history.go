@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notificationHistory grava no banco cada notificação enviada. Sem banco habilitado (ex.: subcomandos), não grava nada.
+var notificationHistory struct {
+	mu sync.RWMutex
+	db *Database
+}
+
+func enableNotificationHistory(db *Database) {
+	notificationHistory.mu.Lock()
+	notificationHistory.db = db
+	notificationHistory.mu.Unlock()
+}
+
+// recordNotificationHistory grava o resultado de um envio no histórico (tabela notifications)
+// e no arquivo de notificações da conta.
+func recordNotificationHistory(accountID int64, channel, message string, delivery notificationDelivery) {
+	appendNotificationLog(accountID, channel, message, delivery)
+
+	notificationHistory.mu.RLock()
+	db := notificationHistory.db
+	notificationHistory.mu.RUnlock()
+	if db == nil {
+		return
+	}
+
+	status, errMsg := notificationStatusSent, ""
+	if delivery.LogOnly {
+		status = notificationStatusLogged
+	} else if delivery.Err != nil {
+		status, errMsg = notificationStatusFailed, redactSecrets(delivery.Err.Error())
+	}
+	if err := db.AddNotification(accountID, channel, message, status, errMsg, delivery.HTTPStatus, delivery.Attempts, delivery.CorrelationIDs); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao gravar notificação no histórico da conta %d: %v\n", accountID, err)
+	}
+}
+
+// resendNotification reenvia uma notificação do histórico pelo webhook atual do canal.
+// Só canais do Discord (inclusive os compartilhados) e o webhook genérico: as linhas da planilha não são guardadas
+// no formato de envio.
+func resendNotification(account *BybitAccount, record *NotificationRecord) error {
+	if _, shared := sharedChannelName(record.Channel); !shared && !isRoutableChannel(record.Channel) {
+		return fmt.Errorf("reenvio não disponível para %s", notifyChannelLabel(record.Channel))
+	}
+	if channelWebhookURL(account, record.Channel) == "" {
+		return errors.New("o canal não tem webhook configurado")
+	}
+	if isLogOnly(account) {
+		return errors.New("a conta está no modo só registro (log_only); desligue o modo para reenviar")
+	}
+
+	// O reenvio mantém os IDs de correlação da notificação original (no webhook genérico, com um novo ID)
+	err := deliverNotification(account.ID, record.Channel, record.Message, record.CorrelationIDs,
+		channelSender(account, record.Channel, record.Message, "", record.CorrelationIDs))
+	if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+		if err != nil {
+			logger.Log("Reenvio da notificação %d falhou: %v", record.ID, err)
+		} else {
+			logger.Log("Notificação %d reenviada (%s)", record.ID, notifyChannelLabel(record.Channel))
+		}
+	}
+	return err
+}
+
+// notificationLogMaxBytes é o tamanho a partir do qual o arquivo de notificações é arquivado (_archive).
+const notificationLogMaxBytes = 5 * 1024 * 1024
+
+// notificationLogMu serializa a escrita nos arquivos de notificações.
+var notificationLogMu sync.Mutex
+
+func getNotificationLogPath(accountID int64) string {
+	return findAccountLogFile(accountID, logKindNotifications)
+}
+
+// appendNotificationLog grava a notificação no arquivo account_{id}_{slug}_notifications.log, separado do log de
+// depuração: só o que foi enviado (ou falhou), com canal e horário no fuso do log da conta.
+func appendNotificationLog(accountID int64, channel, message string, delivery notificationDelivery) {
+	details := deliveryAttemptsText(delivery)
+	if len(delivery.CorrelationIDs) > 0 {
+		details += "; " + correlationTag(delivery.CorrelationIDs...)
+	}
+	status := fmt.Sprintf("%s (%s)", notificationStatusSent, details)
+	if delivery.LogOnly {
+		status = fmt.Sprintf("%s (modo só registro)", notificationStatusLogged)
+		if len(delivery.CorrelationIDs) > 0 {
+			status = fmt.Sprintf("%s (modo só registro; %s)", notificationStatusLogged, correlationTag(delivery.CorrelationIDs...))
+		}
+	} else if delivery.Err != nil {
+		status = fmt.Sprintf("%s (%s): %s", notificationStatusFailed, details, redactSecrets(delivery.Err.Error()))
+	}
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "[%s] [%s] %s\n", notificationLogTime(accountID), notifyChannelLabel(channel), status)
+	for _, line := range strings.Split(strings.TrimRight(message, "\n"), "\n") {
+		entry.WriteString("    " + line + "\n")
+	}
+	writeNotificationLog(accountID, entry.String())
+}
+
+// appendNotificationAttemptLog registra no arquivo de notificações uma tentativa que falhou e será repetida.
+func appendNotificationAttemptLog(accountID int64, channel string, delivery notificationDelivery, wait time.Duration) {
+	tag := ""
+	if len(delivery.CorrelationIDs) > 0 {
+		tag = " " + correlationTag(delivery.CorrelationIDs...)
+	}
+	writeNotificationLog(accountID, fmt.Sprintf("[%s] [%s]%s tentativa %d/%d falhou: %s; nova tentativa em %s\n",
+		notificationLogTime(accountID), notifyChannelLabel(channel), tag, delivery.Attempts, deliveryMaxAttempts,
+		redactSecrets(delivery.Err.Error()), wait))
+}
+
+// notificationLogTime é o horário das entradas do arquivo de notificações, no fuso do log da conta.
+func notificationLogTime(accountID int64) string {
+	now := getBrasiliaTime()
+	if location := accountLogLocation(accountID); location != nil {
+		now = now.In(location)
+	}
+	return now.Format("2006-01-02 15:04:05")
+}
+
+// writeNotificationLog acrescenta o texto ao arquivo de notificações da conta, arquivando-o ao passar do limite.
+func writeNotificationLog(accountID int64, text string) {
+	notificationLogMu.Lock()
+	defer notificationLogMu.Unlock()
+	path := getNotificationLogPath(accountID)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if info, err := os.Stat(path); err == nil && info.Size() > notificationLogMaxBytes {
+		os.Rename(path, strings.TrimSuffix(path, ".log")+logKindArchive+".log")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao gravar o arquivo de notificações da conta %d: %v\n", accountID, err)
+		return
+	}
+	defer f.Close()
+	f.WriteString(text)
+}
+
+const notificationsUsage = "uso: notifications <conta> [--date DD/MM/AAAA | --from DD/MM/AAAA --to DD/MM/AAAA] [--limit N] [--status sent|failed|logged]"
+
+// runNotificationsCommand lista as notificações enviadas pela conta (tabela notifications), por dia ou período.
+func runNotificationsCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New(notificationsUsage)
+	}
+	manager := NewAccountManager(db)
+	account, err := findAccountByNameOrID(manager, args[0])
+	if err != nil {
+		return err
+	}
+	tz := loadTimezone(account.Timezone)
+	filter := HistoryFilter{Limit: 100}
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		if i+1 >= len(rest) {
+			return errors.New(notificationsUsage)
+		}
+		value := rest[i+1]
+		switch rest[i] {
+		case "--date":
+			day, err := parseHistoryDateArg(value, tz)
+			if err != nil {
+				return err
+			}
+			filter.From, filter.To = day, day.AddDate(0, 0, 1)
+		case "--from":
+			if filter.From, err = parseHistoryDateArg(value, tz); err != nil {
+				return err
+			}
+		case "--to":
+			to, err := parseHistoryDateArg(value, tz)
+			if err != nil {
+				return err
+			}
+			filter.To = to.AddDate(0, 0, 1) // inclusive
+		case "--limit":
+			if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 0 {
+				return fmt.Errorf("limite inválido: %s", value)
+			}
+		case "--status":
+			// A fila de envio só existe no aplicativo em execução (menu e API)
+			if filter.Status, err = parseNotificationStatusFilter(value); err != nil || filter.Status == notificationStatusQueued {
+				return fmt.Errorf("status inválido: %s (use sent, failed ou logged)", value)
+			}
+		default:
+			return fmt.Errorf("opção desconhecida: %s\n%s", rest[i], notificationsUsage)
+		}
+		i++
+	}
+
+	records, err := db.ListNotifications(account.ID, filter)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("Nenhuma notificação no período.")
+		return nil
+	}
+	// Ordem cronológica, como no arquivo de notificações
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		status := colorGreen(r.Status)
+		if r.Status == notificationStatusLogged {
+			status = colorYellow(r.Status)
+		} else if r.Status == notificationStatusFailed {
+			status = colorRed(fmt.Sprintf("%s (%s): %s", r.Status, deliveryAttemptsText(r.Delivery()), r.Error))
+		} else if r.Attempts > 1 {
+			status = colorGreen(fmt.Sprintf("%s (%d tentativas)", r.Status, r.Attempts))
+		}
+		origin := ""
+		if len(r.CorrelationIDs) > 0 {
+			origin = "  " + colorDim(correlationTag(r.CorrelationIDs...))
+		}
+		fmt.Printf("%s  #%d  %s  %s%s\n", r.CreatedAt.In(tz).Format("02/01/2006 15:04:05"), r.ID, notifyChannelLabel(r.Channel), status, origin)
+		for _, line := range strings.Split(strings.TrimRight(r.Message, "\n"), "\n") {
+			fmt.Println("    " + line)
+		}
+	}
+	return nil
+}
+
+// parseHistoryDateArg é o parseHistoryDate com a mensagem de erro dos comandos.
+func parseHistoryDateArg(value string, tz *time.Location) (time.Time, error) {
+	day, err := parseHistoryDate(value, tz)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("data inválida: %s (use DD/MM/AAAA)", value)
+	}
+	return day, nil
+}
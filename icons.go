@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ícones: a preferência icons da conta troca os ícones fixos das notificações, para todos os eventos ou só para um
+// (evento.ícone), ex.: settings "Minha Conta" icons '{"sell": "📕", "stop.sell": "🛑", "cancel": "🚫", "clock": ""}'.
+// Um ícone vazio é removido da mensagem. Com icons false, as notificações saem sem nenhum emoji (inclusive os do
+// resumo de carteira, dos alertas e dos templates), para canais que não os exibem bem.
+const iconsSettingKey = "icons"
+
+// defaultIcons são os ícones que podem ser trocados e os seus valores padrão.
+var defaultIcons = map[string]string{
+	"buy":     "🟢", // lado da ordem ou stop
+	"sell":    "🔴",
+	"moved":   "📝", // ordem ou stop movido
+	"cancel":  "❌", // ordens ou stop cancelados
+	"filled":  "✅", // ordem executada (mensagem da ordem editada, ordermessages.go)
+	"partial": "🔸", // ordem executada em parte
+	"header":  "🔔", // cabeçalho da mensagem (execution.header = webhook de execuções)
+	"clock":   "🕘", // horário no fim da mensagem
+	"up":      "🟢", // conexão restabelecida
+	"down":    "🔴", // conexão caiu
+}
+
+// emojiPattern encontra emojis (com variações e junções) e o espaço seguinte, para icons false.
+var emojiPattern = regexp.MustCompile(`(?:[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}\x{23E9}-\x{23FA}][\x{FE0F}\x{200D}\x{20E3}]*)+ ?`)
+
+// notificationIcons são os ícones de uma conta: os padrão, os trocados pela preferência ou nenhum.
+type notificationIcons struct {
+	disabled  bool
+	overrides map[string]string
+}
+
+// parseIconsSetting lê a preferência: false (sem emojis) ou um objeto {"ícone" ou "evento.ícone": "texto"}.
+func parseIconsSetting(value json.RawMessage) (notificationIcons, error) {
+	var enabled bool
+	if json.Unmarshal(value, &enabled) == nil {
+		if enabled {
+			return notificationIcons{}, errors.New(`use false para tirar os emojis ou um objeto, ex.: {"sell": "📕"}`)
+		}
+		return notificationIcons{disabled: true}, nil
+	}
+	var overrides map[string]string
+	if json.Unmarshal(value, &overrides) != nil {
+		return notificationIcons{}, errors.New(`use false ou um objeto {"ícone": "texto"}, ex.: {"sell": "📕", "stop.sell": "🛑", "clock": ""}`)
+	}
+	for key := range overrides {
+		event, icon, scoped := strings.Cut(key, ".")
+		if !scoped {
+			icon = event
+		} else if _, ok := defaultEventSeverity[event]; !ok {
+			return notificationIcons{}, fmt.Errorf("%s: evento desconhecido: %s (use %s)", key, event, strings.Join(severityEventNames(), ", "))
+		}
+		if _, ok := defaultIcons[icon]; !ok {
+			return notificationIcons{}, fmt.Errorf("ícone desconhecido: %s (use %s)", icon, strings.Join(iconNames(), ", "))
+		}
+	}
+	return notificationIcons{overrides: overrides}, nil
+}
+
+func validateIconsSetting(value json.RawMessage) error {
+	_, err := parseIconsSetting(value)
+	return err
+}
+
+func iconNames() []string {
+	names := make([]string, 0, len(defaultIcons))
+	for name := range defaultIcons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// accountIcons retorna os ícones da conta (os padrão se a preferência não existir ou for inválida).
+func accountIcons(account *BybitAccount) notificationIcons {
+	if raw, ok := account.Settings[iconsSettingKey]; ok {
+		if icons, err := parseIconsSetting(raw); err == nil {
+			return icons
+		}
+	}
+	return notificationIcons{}
+}
+
+// get retorna o ícone do evento ("" = sem ícone): evento.ícone, ícone ou o padrão.
+func (n notificationIcons) get(event, name string) string {
+	if n.disabled {
+		return ""
+	}
+	if icon, ok := n.overrides[event+"."+name]; ok {
+		return icon
+	}
+	if icon, ok := n.overrides[name]; ok {
+		return icon
+	}
+	return defaultIcons[name]
+}
+
+// prefix retorna o ícone seguido de espaço, ou "" sem ícone.
+func (n notificationIcons) prefix(event, name string) string {
+	if icon := n.get(event, name); icon != "" {
+		return icon + " "
+	}
+	return ""
+}
+
+// side retorna o prefixo do lado da ordem (buy ou sell).
+func (n notificationIcons) side(event, side string) string {
+	if side == "Buy" {
+		return n.prefix(event, "buy")
+	}
+	return n.prefix(event, "sell")
+}
+
+// strip tira os emojis do texto quando a conta desligou os ícones (icons false).
+func (n notificationIcons) strip(text string) string {
+	if !n.disabled {
+		return text
+	}
+	return emojiPattern.ReplaceAllString(text, "")
+}
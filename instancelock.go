@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var errInstanceLocked = errors.New("outra instância do aplicativo já está em execução com este banco de dados")
+
+// instanceLock é o lock de instância única, liberado pelo sistema operacional se o processo cair.
+type instanceLock struct {
+	file *os.File
+}
+
+// getInstanceLockPath retorna o arquivo de lock, ao lado do banco: duas cópias do aplicativo usando o mesmo
+// banco enviariam cada notificação em dobro, mas instâncias com bancos diferentes podem rodar juntas.
+func getInstanceLockPath() string {
+	return getDatabasePath() + ".lock"
+}
+
+// acquireInstanceLock obtém o lock sem esperar. Se outra instância o detém, retorna errInstanceLocked
+// com o PID dela quando disponível.
+func acquireInstanceLock() (*instanceLock, error) {
+	// Banco em memória não é compartilhado com nenhum outro processo
+	if isInMemoryDatabase() {
+		return &instanceLock{}, nil
+	}
+	path := getInstanceLockPath()
+	f, err := lockFileExclusive(path)
+	if err != nil {
+		if errors.Is(err, errInstanceLocked) {
+			if pid := readLockPID(path); pid > 0 {
+				return nil, fmt.Errorf("%w (PID %d, lock %s)", errInstanceLocked, pid, path)
+			}
+			return nil, fmt.Errorf("%w (lock %s)", errInstanceLocked, path)
+		}
+		return nil, fmt.Errorf("erro ao criar o lock de instância %s: %w", path, err)
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return &instanceLock{file: f}, nil
+}
+
+func (l *instanceLock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+}
+
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation é o ERROR_SHARING_VIOLATION do Windows (arquivo aberto por outro processo).
+const errorSharingViolation syscall.Errno = 32
+
+// lockFileExclusive abre o arquivo sem compartilhamento: enquanto o processo estiver vivo, nenhum outro consegue abri-lo.
+func lockFileExclusive(path string) (*os.File, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(pathPtr, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, errInstanceLocked
+		}
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), path), nil
+}
+
+func unlockFile(f *os.File) {}
@@ -0,0 +1,109 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Saída dos logs das contas para o journald, com campos estruturados (ACCOUNT_ID, ACCOUNT_NAME, TOPIC, LEVEL):
+//
+//	JOURNALD=auto   padrão: ativa quando o aplicativo roda como serviço systemd (JOURNAL_STREAM definido)
+//	JOURNALD=on     ativa sempre que o socket do journald existir
+//	JOURNALD=off    desativa
+//
+// Ex.: journalctl -u bybit-notifier ACCOUNT_ID=3 TOPIC=execution
+const (
+	journaldEnv        = "JOURNALD"
+	journaldSocketPath = "/run/systemd/journal/socket"
+)
+
+// journaldMaxMessage limita o MESSAGE para o datagrama caber no buffer do socket (payloads grandes em debug).
+const journaldMaxMessage = 48 * 1024
+
+type journaldSink struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+func newJournaldSink() (logSink, error) {
+	if _, err := os.Stat(journaldSocketPath); err != nil {
+		return nil, errors.New("socket do journald não encontrado (" + journaldSocketPath + ")")
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldSink{conn: conn, addr: &net.UnixAddr{Name: journaldSocketPath, Net: "unixgram"}}, nil
+}
+
+func (s *journaldSink) Name() string {
+	return "journald"
+}
+
+func (s *journaldSink) WriteLog(entry logEntry) error {
+	topic := logLineTopic(entry.Message)
+	if len(entry.Message) > journaldMaxMessage {
+		entry.Message = entry.Message[:journaldMaxMessage] + "... (truncado)"
+	}
+
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", formatLogEntryMessage(entry))
+	writeJournaldField(&buf, "PRIORITY", strconv.Itoa(journaldPriority(entry.Severity)))
+	writeJournaldField(&buf, "SYSLOG_IDENTIFIER", syslogDefaultTag)
+	writeJournaldField(&buf, "ACCOUNT_ID", strconv.FormatInt(entry.AccountID, 10))
+	writeJournaldField(&buf, "ACCOUNT_NAME", entry.AccountName)
+	writeJournaldField(&buf, "TOPIC", topic)
+	writeJournaldField(&buf, "LEVEL", entry.Severity.String())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.conn.WriteToUnix(buf.Bytes(), s.addr)
+	return err
+}
+
+// writeJournaldField grava o campo no protocolo nativo do journald: NOME=valor, ou, se o valor tiver
+// quebra de linha, NOME\n + tamanho (uint64 little endian) + valor.
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(name + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+// journaldPriority converte a severidade para a prioridade do syslog usada pelo journalctl -p.
+func journaldPriority(severity logSeverity) int {
+	switch severity {
+	case severityDebug:
+		return 7
+	case severityWarning:
+		return 4
+	case severityError:
+		return 3
+	default:
+		return 6
+	}
+}
+
+// journaldWanted indica se a saída journald deve ser ativada e se foi pedida explicitamente (JOURNALD=on).
+func journaldWanted() (enabled, explicit bool) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(journaldEnv))) {
+	case "on", "1", "true", "sim":
+		return true, true
+	case "off", "0", "false", "nao", "não":
+		return false, false
+	default:
+		return os.Getenv("JOURNAL_STREAM") != "", false
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+const journaldEnv = "JOURNALD"
+
+func newJournaldSink() (logSink, error) {
+	return nil, errors.New("journald só está disponível no Linux")
+}
+
+// journaldWanted fora do Linux só é verdadeiro com JOURNALD=on, para avisar que a opção não tem efeito.
+func journaldWanted() (enabled, explicit bool) {
+	return os.Getenv(journaldEnv) == "on", true
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Preço de liquidação no resumo de carteira: cada posição aberta com preço de liquidação mostra o preço e a
+// distância até ele a partir do mark price da posição (em preço e em %), ex.:
+// "⚡ Liquidação Long: 45000 (mark 60020, distância 15020 / 25.03%)".
+
+// walletSummaryPosition é uma posição aberta no position_summary, com a distância até a liquidação.
+type walletSummaryPosition struct {
+	Side           string // Long ou Short
+	Size           float64
+	MarkPrice      float64
+	LiqPrice       float64 // 0 = sem preço de liquidação
+	LiqDistance    float64 // |liquidação - mark|
+	LiqDistancePct float64 // distância em % do mark
+}
+
+// positionLiquidation lê a posição; ok = false sem posição aberta (size 0).
+func positionLiquidation(pos *PositionData) (walletSummaryPosition, bool) {
+	size, _ := strconv.ParseFloat(pos.Size, 64)
+	if size <= 0 {
+		return walletSummaryPosition{}, false
+	}
+	side := "Long"
+	if pos.Side == "Sell" {
+		side = "Short"
+	}
+	p := walletSummaryPosition{Side: side, Size: size}
+	p.MarkPrice, _ = strconv.ParseFloat(pos.MarkPrice, 64)
+	p.LiqPrice, _ = strconv.ParseFloat(pos.LiqPrice, 64)
+	if p.LiqPrice > 0 && p.MarkPrice > 0 {
+		p.LiqDistance = math.Abs(p.LiqPrice - p.MarkPrice)
+		p.LiqDistancePct = p.LiqDistance / p.MarkPrice * 100
+	}
+	return p, true
+}
+
+// formatLiquidationLine formata a linha da posição no resumo ("" = sem preço de liquidação).
+func formatLiquidationLine(p walletSummaryPosition) string {
+	if p.LiqPrice <= 0 {
+		return ""
+	}
+	if p.MarkPrice <= 0 {
+		return fmt.Sprintf("  ⚡ Liquidação %s: %s", p.Side, formatPriceCoin(p.LiqPrice))
+	}
+	return fmt.Sprintf("  ⚡ Liquidação %s: %s (mark %s, distância %s / %s%%)",
+		p.Side, formatPriceCoin(p.LiqPrice), formatPriceCoin(p.MarkPrice), formatPriceCoin(p.LiqDistance), formatPriceCoin(p.LiqDistancePct))
+}
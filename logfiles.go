@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Arquivos de log das contas: account_{id}_{slug do nome}{tipo}.log, ex.: account_3_minha-conta.log e
+// account_3_minha-conta_archive.log. O slug só tem [a-z0-9-], então o "_" separa o tipo sem ambiguidade.
+// Arquivos do formato antigo (account_{id}{tipo}.log) são renomeados na próxima abertura do log.
+const (
+	logKindMain                 = ""
+	logKindArchive              = "_archive"
+	logKindNotifications        = "_notifications"
+	logKindNotificationsArchive = "_notifications_archive"
+)
+
+// accountLogSlugMaxLen limita o trecho do nome no arquivo (nomes longos ficam truncados).
+const accountLogSlugMaxLen = 40
+
+var slugReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ç", "c", "ñ", "n",
+)
+
+// slugifyAccountName converte o nome da conta para uso no nome do arquivo: minúsculas, sem acentos,
+// demais caracteres trocados por "-". Nomes que coincidem com um tipo de arquivo ganham o sufixo -conta.
+func slugifyAccountName(name string) string {
+	name = slugReplacer.Replace(strings.ToLower(strings.TrimSpace(name)))
+	var b strings.Builder
+	lastDash := true
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+	slug := strings.TrimRight(b.String(), "-")
+	if len(slug) > accountLogSlugMaxLen {
+		slug = strings.TrimRight(slug[:accountLogSlugMaxLen], "-")
+	}
+	if slug == "archive" || slug == "notifications" {
+		slug += "-conta"
+	}
+	return slug
+}
+
+// accountLogFileName retorna o nome do arquivo de log da conta (sem diretório). Sem nome = formato antigo.
+func accountLogFileName(accountID int64, accountName, kind string) string {
+	if slug := slugifyAccountName(accountName); slug != "" {
+		return fmt.Sprintf("account_%d_%s%s.log", accountID, slug, kind)
+	}
+	return fmt.Sprintf("account_%d%s.log", accountID, kind)
+}
+
+// parseAccountLogFileName identifica a conta e o tipo de um arquivo de log (novo ou antigo formato).
+func parseAccountLogFileName(fileName string) (accountID int64, kind string, ok bool) {
+	if !strings.HasPrefix(fileName, "account_") || !strings.HasSuffix(fileName, ".log") {
+		return 0, "", false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(fileName, "account_"), ".log")
+	idPart, tail, _ := strings.Cut(rest, "_")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	// No formato antigo o que vem depois do ID já é o tipo; no novo, primeiro vem o slug
+	if first, after, _ := strings.Cut(tail, "_"); first != "archive" && first != "notifications" {
+		tail = after
+	}
+	if tail != "" {
+		kind = "_" + tail
+	}
+	switch kind {
+	case logKindMain, logKindArchive, logKindNotifications, logKindNotificationsArchive:
+		return id, kind, true
+	}
+	return 0, "", false
+}
+
+// findAccountLogFile retorna o caminho do arquivo de log da conta: pelo nome do logger aberto ou, sem ele,
+// pelo arquivo existente no diretório. Se não houver nenhum, usa o formato sem nome.
+func findAccountLogFile(accountID int64, kind string) string {
+	logsDir := getLogsDir()
+	loggersMu.RLock()
+	logger, exists := loggers[accountID]
+	loggersMu.RUnlock()
+	if exists {
+		return filepath.Join(logsDir, accountLogFileName(accountID, logger.fileAccountName(), kind))
+	}
+
+	entries, _ := os.ReadDir(logsDir)
+	for _, entry := range entries {
+		if id, fileKind, ok := parseAccountLogFileName(entry.Name()); ok && id == accountID && fileKind == kind {
+			return filepath.Join(logsDir, entry.Name())
+		}
+	}
+	return filepath.Join(logsDir, accountLogFileName(accountID, "", kind))
+}
+
+// renameAccountLogFiles renomeia os arquivos de log da conta para o nome atual (após editar o nome da conta).
+// O logger da conta deve estar fechado (no Windows arquivos abertos não podem ser renomeados).
+func renameAccountLogFiles(accountID int64, accountName string) error {
+	return renameAccountLogFilesIn(getLogsDir(), accountID, accountName)
+}
+
+func renameAccountLogFilesIn(logsDir string, accountID int64, accountName string) error {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		id, kind, ok := parseAccountLogFileName(entry.Name())
+		if !ok || id != accountID {
+			continue
+		}
+		target := accountLogFileName(accountID, accountName, kind)
+		if entry.Name() == target {
+			continue
+		}
+		// Se já existe um arquivo com o nome novo, o antigo fica como está para não perder linhas
+		if _, err := os.Stat(filepath.Join(logsDir, target)); err == nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(logsDir, entry.Name()), filepath.Join(logsDir, target)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
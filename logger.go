@@ -2,17 +2,25 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	_ "time/tzdata" // garante os fusos IANA também no Windows/containers sem zoneinfo
 )
 
 const (
-	maxLogLines = 5000
+	// maxLogBytes é o tamanho do arquivo de log a partir do qual ele vira o _archive (~5000 linhas)
+	maxLogBytes = 2 * 1024 * 1024
+	// defaultTimezone é usado quando a conta não tem fuso configurado
+	defaultTimezone = "America/Sao_Paulo"
 )
 
 // getBrasiliaTime retorna o horário atual no fuso horário de Brasília (UTC-3)
@@ -29,13 +37,73 @@ func getBrasiliaTime() time.Time {
 	return time.Now().In(brasiliaTZ)
 }
 
+// loadTimezone retorna o *time.Location do fuso IANA informado. Vazio ou inválido usa o horário de Brasília.
+func loadTimezone(tz string) *time.Location {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		tz = defaultTimezone
+	}
+	if loc, err := time.LoadLocation(tz); err == nil {
+		return loc
+	}
+	return time.FixedZone("BRT", -3*60*60)
+}
+
+// isValidTimezone indica se o fuso informado é um nome IANA conhecido (vazio é válido e significa Brasília).
+func isValidTimezone(tz string) bool {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return true
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// getAccountTime retorna o horário atual no fuso da conta.
+func getAccountTime(tz string) time.Time {
+	return time.Now().In(loadTimezone(tz))
+}
+
+// timezoneLabel retorna o texto exibido nas notificações para o fuso da conta.
+func timezoneLabel(tz string) string {
+	tz = strings.TrimSpace(tz)
+	if tz == "" || tz == defaultTimezone {
+		return "Horário de Brasília"
+	}
+	return tz
+}
+
 type Logger struct {
-	accountID int64
+	accountID   int64
 	accountName string
-	file       *os.File
-	writer    *bufio.Writer
-	mu        sync.Mutex
-	lineCount int
+	file        *os.File
+	writer      *bufio.Writer
+	mu          sync.Mutex
+	size        int64            // bytes no arquivo atual; só usado pela goroutine run
+	location    *time.Location   // fuso usado no timestamp das linhas; nil = Brasília
+	level       logSeverity      // linhas abaixo deste nível são descartadas (protegido por mu)
+	sampleRate  int64            // LogSampled grava 1 a cada sampleRate ocorrências
+	samples     map[string]int64 // ocorrências de cada formato passado ao LogSampled (protegido por mu)
+
+	// Escrita assíncrona: Log só enfileira; a goroutine run grava no arquivo e entrega às saídas adicionais,
+	// para o processamento das mensagens do WebSocket nunca esperar o disco (ou o syslog remoto).
+	queue   chan logQueueItem
+	stopped chan struct{} // fechado quando run termina
+	closed  bool          // protegido por mu
+	dropped int64         // linhas descartadas com a fila cheia (atômico)
+}
+
+// logQueueSize é quantas linhas podem aguardar gravação; com a fila cheia as linhas novas são descartadas.
+const logQueueSize = 4096
+
+// logQueueItem é uma linha a gravar ou, com done preenchido, um pedido de flush (stop encerra a goroutine; rename
+// reabre o log com o novo nome da conta).
+type logQueueItem struct {
+	line   string
+	entry  logEntry
+	done   chan struct{}
+	stop   bool
+	rename string
 }
 
 var loggers = make(map[int64]*Logger)
@@ -68,16 +136,20 @@ func getLogger(accountID int64, accountName string) (*Logger, error) {
 		logsDir = altLogsDir
 	}
 
-	// Nome do arquivo de log: account_{id}.log
-	logFileName := filepath.Join(logsDir, fmt.Sprintf("account_%d.log", accountID))
-	
+	// Nome do arquivo de log: account_{id}_{slug do nome}.log. Arquivos com o nome antigo da conta
+	// (ou do formato account_{id}.log) são renomeados antes de abrir.
+	if err := renameAccountLogFilesIn(logsDir, accountID, accountName); err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: não foi possível renomear os logs da conta %d: %v\n", accountID, err)
+	}
+	logFileName := filepath.Join(logsDir, accountLogFileName(accountID, accountName, logKindMain))
+
 	// Abrir arquivo em modo append
-	file, err := os.OpenFile(logFileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	file, err := os.OpenFile(logFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		// No Windows, pode haver problemas com permissões, tentar criar em local alternativo
 		if runtime.GOOS == "windows" {
-			altLogFileName := filepath.Join(".", fmt.Sprintf("account_%d.log", accountID))
-			if altFile, altErr := os.OpenFile(altLogFileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644); altErr == nil {
+			altLogFileName := filepath.Join(".", accountLogFileName(accountID, accountName, logKindMain))
+			if altFile, altErr := os.OpenFile(altLogFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); altErr == nil {
 				file = altFile
 				err = nil
 			}
@@ -90,53 +162,35 @@ func getLogger(accountID int64, accountName string) (*Logger, error) {
 	logger := &Logger{
 		accountID:   accountID,
 		accountName: accountName,
-		file:       file,
-		writer:     bufio.NewWriter(file),
-		lineCount:  0,
+		file:        file,
+		writer:      bufio.NewWriter(file),
+		level:       defaultLogLevel(),
+		sampleRate:  debugSampleRate(),
+		samples:     make(map[string]int64),
 	}
 
-	// Contar linhas existentes no arquivo
-	if err := logger.countExistingLines(); err != nil {
+	// O arquivo é só de append: o tamanho atual vem do Stat, sem ler o conteúdo
+	info, err := file.Stat()
+	if err != nil {
 		file.Close()
-		return nil, fmt.Errorf("erro ao contar linhas do log: %w", err)
+		return nil, fmt.Errorf("erro ao ler o tamanho do log: %w", err)
 	}
+	logger.size = info.Size()
+
+	logger.queue = make(chan logQueueItem, logQueueSize)
+	logger.stopped = make(chan struct{})
+	go logger.run()
 
 	loggers[accountID] = logger
 	return logger, nil
 }
 
-// countExistingLines conta as linhas existentes no arquivo e atualiza lineCount
-func (l *Logger) countExistingLines() error {
-	// Reposicionar para o início do arquivo para contar
-	if _, err := l.file.Seek(0, 0); err != nil {
-		return err
-	}
-
-	scanner := bufio.NewScanner(l.file)
-	count := 0
-	for scanner.Scan() {
-		count++
-	}
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	l.lineCount = count
-
-	// Reposicionar para o final do arquivo para continuar escrevendo
-	if _, err := l.file.Seek(0, 2); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // rotateLog rotaciona o arquivo de log quando atinge o limite
-// Renomeia o arquivo atual para _archive.log e cria um novo arquivo zerado
+// Renomeia o arquivo atual para _archive.log e cria um novo arquivo zerado (o conteúdo não é reescrito)
 func (l *Logger) rotateLog() error {
 	logsDir := getLogsDir()
-	currentLogFile := filepath.Join(logsDir, fmt.Sprintf("account_%d.log", l.accountID))
-	archiveLogFile := filepath.Join(logsDir, fmt.Sprintf("account_%d_archive.log", l.accountID))
+	currentLogFile := filepath.Join(logsDir, accountLogFileName(l.accountID, l.accountName, logKindMain))
+	archiveLogFile := filepath.Join(logsDir, accountLogFileName(l.accountID, l.accountName, logKindArchive))
 
 	// Fechar arquivo e writer atuais
 	if l.writer != nil {
@@ -165,7 +219,7 @@ func (l *Logger) rotateLog() error {
 	}
 
 	// Criar novo arquivo zerado
-	file, err := os.OpenFile(currentLogFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	file, err := os.OpenFile(currentLogFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		return fmt.Errorf("erro ao criar novo arquivo de log: %w", err)
 	}
@@ -173,37 +227,149 @@ func (l *Logger) rotateLog() error {
 	// Atualizar referências do logger
 	l.file = file
 	l.writer = bufio.NewWriter(file)
-	l.lineCount = 0
+	l.size = 0
 
 	return nil
 }
 
-func (l *Logger) Log(format string, args ...interface{}) {
+// accountLogLocation retorna o fuso do log da conta, se o logger já foi criado (nil = Brasília).
+func accountLogLocation(accountID int64) *time.Location {
+	loggersMu.RLock()
+	logger, exists := loggers[accountID]
+	loggersMu.RUnlock()
+	if !exists {
+		return nil
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	return logger.location
+}
+
+// SetLevel define o nível mínimo do log da conta. Vazio volta ao nível global (LOG_LEVEL).
+func (l *Logger) SetLevel(name string) error {
+	level := defaultLogLevel()
+	if strings.TrimSpace(name) != "" {
+		var ok bool
+		if level, ok = parseLogLevel(name); !ok {
+			return fmt.Errorf("nível de log inválido: %s (use debug, info, warning ou error)", name)
+		}
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.level = level
+	return nil
+}
 
-	// Obter timestamp no horário de Brasília (funciona no Windows e Linux)
-	now := getBrasiliaTime()
-	timestamp := now.Format("2006-01-02 15:04:05")
+// SetTimezone define o fuso usado nos timestamps do log da conta.
+func (l *Logger) SetTimezone(tz string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.location = loadTimezone(tz)
+}
 
-	// Formatar mensagem
-	message := fmt.Sprintf(format, args...)
-	logLine := fmt.Sprintf("[%s] %s\n", timestamp, message)
+// Log enfileira a linha para gravação. Não bloqueia: com a fila cheia a linha é descartada e contada.
+func (l *Logger) Log(format string, args ...interface{}) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	location, level, accountName := l.location, l.level, l.accountName
+	l.mu.Unlock()
 
-	// Escrever no arquivo
-	if _, err := l.writer.WriteString(logLine); err != nil {
-		// Se houver erro, tentar continuar
+	// As linhas [DEBUG] (a maioria) são descartadas antes de formatar
+	if level > severityDebug && strings.Contains(format, "[DEBUG]") {
+		return
+	}
+	// Formatar mensagem (sem segredos: API keys, secrets e webhooks são mascarados)
+	message := redactSecrets(fmt.Sprintf(format, args...))
+	severity := logLineSeverity(message)
+	if severity < level {
 		return
 	}
+	now := time.Now()
 
-	if err := l.writer.Flush(); err != nil {
+	// Timestamp no fuso da conta (padrão: horário de Brasília)
+	local := getBrasiliaTime()
+	if location != nil {
+		local = local.In(location)
+	}
+	item := logQueueItem{
+		line:  fmt.Sprintf("[%s] %s\n", local.Format("2006-01-02 15:04:05"), message),
+		entry: logEntry{Time: now, AccountID: l.accountID, AccountName: accountName, Severity: severity, Message: message},
+	}
+	select {
+	case l.queue <- item:
+	default:
+		atomic.AddInt64(&l.dropped, 1)
+	}
+}
+
+// LogSampled é o Log das linhas [DEBUG] repetidas a cada mensagem (ex.: "Mensagem de position recebida"): grava a
+// primeira e depois 1 a cada sampleRate ocorrências do mesmo formato, com o contador, para o modo debug continuar
+// legível numa rajada de ordens. Fora do nível debug não conta nada.
+func (l *Logger) LogSampled(format string, args ...interface{}) {
+	l.mu.Lock()
+	if l.closed || l.level > severityDebug {
+		l.mu.Unlock()
 		return
 	}
+	rate := l.sampleRate
+	l.samples[format]++
+	count := l.samples[format]
+	l.mu.Unlock()
 
-	l.lineCount++
+	if rate <= 1 {
+		l.Log(format, args...)
+		return
+	}
+	if (count-1)%rate != 0 {
+		return
+	}
+	l.Log(format+" (amostragem: 1 a cada %d, ocorrência %d)", append(args, rate, count)...)
+}
 
-	// Verificar se precisa rotacionar (quando atingir exatamente 5000 linhas)
-	if l.lineCount >= maxLogLines {
+// run grava as linhas da fila. O buffer só vai para o disco quando a fila esvazia (ou num flush),
+// então rajadas de mensagens viram poucas escritas.
+func (l *Logger) run() {
+	defer close(l.stopped)
+	for {
+		item := <-l.queue
+		if item.done != nil {
+			l.writer.Flush()
+			if item.rename != "" {
+				l.reopen(item.rename)
+			}
+			close(item.done)
+			if item.stop {
+				return
+			}
+			continue
+		}
+
+		if dropped := atomic.SwapInt64(&l.dropped, 0); dropped > 0 {
+			l.writeLine(fmt.Sprintf("[%s] %d linha(s) de log descartada(s): fila de gravação cheia\n", getBrasiliaTime().Format("2006-01-02 15:04:05"), dropped))
+		}
+		l.writeLine(item.line)
+		if len(l.queue) == 0 {
+			l.writer.Flush()
+		}
+		// Saídas adicionais (syslog, Loki...)
+		dispatchLogEntry(item.entry)
+	}
+}
+
+// writeLine grava uma linha no buffer e rotaciona o arquivo ao atingir o limite. Só chamado por run.
+func (l *Logger) writeLine(line string) {
+	n, err := l.writer.WriteString(line)
+	l.size += int64(n)
+	if err != nil {
+		// Se houver erro, tentar continuar
+		return
+	}
+
+	// Verificar se precisa rotacionar
+	if l.size >= maxLogBytes {
 		if err := l.rotateLog(); err != nil {
 			// Log de erro silencioso - continuar mesmo se falhar
 			return
@@ -211,9 +377,95 @@ func (l *Logger) Log(format string, args ...interface{}) {
 	}
 }
 
-func (l *Logger) Close() error {
+// Flush espera a gravação de todas as linhas enfileiradas até agora.
+func (l *Logger) Flush() {
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case l.queue <- logQueueItem{done: done}:
+	case <-l.stopped:
+		return
+	}
+	select {
+	case <-done:
+	case <-l.stopped:
+	}
+}
+
+// Rename passa o log para o novo nome da conta sem fechá-lo: as goroutines da conexão guardam o *Logger e continuam
+// gravando nele. Espera a goroutine run gravar o que estava na fila, renomear os arquivos e reabrir o log.
+func (l *Logger) Rename(accountName string) {
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case l.queue <- logQueueItem{done: done, rename: accountName}:
+	case <-l.stopped:
+		return
+	}
+	select {
+	case <-done:
+	case <-l.stopped:
+	}
+}
+
+// reopen fecha o arquivo (o Windows não renomeia arquivo aberto), renomeia os logs da conta e reabre o principal
+// com o nome novo. Só chamado por run.
+func (l *Logger) reopen(accountName string) {
+	if err := l.file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: não foi possível fechar o log da conta %d: %v\n", l.accountID, err)
+	}
+	name := accountName
+	if err := renameAccountLogFiles(l.accountID, accountName); err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: não foi possível renomear os arquivos de log da conta '%s': %v\n", accountName, err)
+		name = l.accountName
+	}
+	logFileName := filepath.Join(getLogsDir(), accountLogFileName(l.accountID, name, logKindMain))
+	file, err := os.OpenFile(logFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: não foi possível reabrir o log '%s': %v\n", logFileName, err)
+		return
+	}
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	l.file = file
+	l.writer = bufio.NewWriter(file)
+	l.size = size
+	l.mu.Lock()
+	l.accountName = name
+	l.mu.Unlock()
+}
+
+// fileAccountName retorna o nome da conta usado nos arquivos do log.
+func (l *Logger) fileAccountName() string {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	return l.accountName
+}
+
+// Close grava o que estiver na fila e fecha o arquivo.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	l.queue <- logQueueItem{done: make(chan struct{}), stop: true}
+	<-l.stopped
 
 	if l.writer != nil {
 		if err := l.writer.Flush(); err != nil {
@@ -228,6 +480,19 @@ func (l *Logger) Close() error {
 	return nil
 }
 
+// flushLoggers espera a gravação das linhas pendentes de todas as contas (antes de encerrar o processo).
+func flushLoggers() {
+	loggersMu.RLock()
+	all := make([]*Logger, 0, len(loggers))
+	for _, logger := range loggers {
+		all = append(all, logger)
+	}
+	loggersMu.RUnlock()
+	for _, logger := range all {
+		logger.Flush()
+	}
+}
+
 func closeLogger(accountID int64) {
 	loggersMu.Lock()
 	defer loggersMu.Unlock()
@@ -267,7 +532,7 @@ func getLogsDir() string {
 }
 
 func getLogFilePath(accountID int64) string {
-	return filepath.Join(getLogsDir(), fmt.Sprintf("account_%d.log", accountID))
+	return findAccountLogFile(accountID, logKindMain)
 }
 
 func readLogFile(accountID int64, lines int) ([]string, error) {
@@ -300,11 +565,82 @@ func readLogFile(accountID int64, lines int) ([]string, error) {
 	return allLines, nil
 }
 
-func tailLogFile(accountID int64, stopChan chan struct{}, callback func(string)) error {
+// LogFilter filtra as linhas exibidas no visualizador de logs. O valor zero não filtra nada.
+type LogFilter struct {
+	HideDebug  bool           // oculta linhas [DEBUG]
+	ErrorsOnly bool           // somente linhas de erro/falha/panic
+	Pattern    *regexp.Regexp // busca por texto ou regex (nil = sem busca)
+}
+
+// IsEmpty indica se o filtro não restringe nenhuma linha.
+func (f LogFilter) IsEmpty() bool {
+	return !f.HideDebug && !f.ErrorsOnly && f.Pattern == nil
+}
+
+// Match indica se a linha passa pelo filtro.
+func (f LogFilter) Match(line string) bool {
+	if f.HideDebug && strings.Contains(line, "[DEBUG]") {
+		return false
+	}
+	if f.ErrorsOnly && !isErrorLogLine(line) {
+		return false
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(line) {
+		return false
+	}
+	return true
+}
+
+// Description descreve o filtro para o cabeçalho do visualizador.
+func (f LogFilter) Description() string {
+	var parts []string
+	if f.ErrorsOnly {
+		parts = append(parts, "somente erros")
+	} else if f.HideDebug {
+		parts = append(parts, "sem [DEBUG]")
+	}
+	if f.Pattern != nil {
+		parts = append(parts, fmt.Sprintf("busca: %s", f.Pattern.String()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var errorLogLineRe = regexp.MustCompile(`(?i)erro|panic|falh|❌`)
+
+// isErrorLogLine identifica linhas de erro pelas palavras usadas nos logs (Erro, ERRO, PANIC, falhou, ❌).
+func isErrorLogLine(line string) bool {
+	return errorLogLineRe.MatchString(line)
+}
+
+// readFilteredLogFile retorna as últimas N linhas do log que passam pelo filtro.
+// Com filtro, percorre o arquivo inteiro para não limitar a busca às últimas N linhas.
+func readFilteredLogFile(accountID int64, lines int, filter LogFilter) ([]string, error) {
+	if filter.IsEmpty() {
+		return readLogFile(accountID, lines)
+	}
+	allLines, err := readLogFile(accountID, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, line := range allLines {
+		if filter.Match(line) {
+			matched = append(matched, line)
+		}
+	}
+	if len(matched) > lines {
+		return matched[len(matched)-lines:], nil
+	}
+	return matched, nil
+}
+
+// tailLogFile acompanha o log da conta, chamando callback para as últimas 50 linhas e depois para cada linha nova.
+// Com pattern, só as linhas que casam com a regex são emitidas (nil = todas), inclusive nas 50 iniciais.
+func tailLogFile(accountID int64, pattern *regexp.Regexp, stopChan chan struct{}, callback func(string)) error {
 	logFilePath := getLogFilePath(accountID)
 	
-	// Ler linhas existentes primeiro (últimas 50)
-	allLines, err := readLogFile(accountID, 50)
+	// Ler linhas existentes primeiro (últimas 50 que casam com a regex)
+	allLines, err := readFilteredLogFile(accountID, 50, LogFilter{Pattern: pattern})
 	if err == nil {
 		for _, line := range allLines {
 			callback(line)
@@ -350,7 +686,10 @@ func tailLogFile(accountID int64, stopChan chan struct{}, callback func(string))
 				scanner := bufio.NewScanner(file)
 				for scanner.Scan() {
 					line := scanner.Text()
-					if strings.TrimSpace(line) != "" {
+					if strings.TrimSpace(line) == "" {
+						continue
+					}
+					if pattern == nil || pattern.MatchString(line) {
 						callback(line)
 					}
 				}
@@ -363,3 +702,24 @@ func tailLogFile(accountID int64, stopChan chan struct{}, callback func(string))
 	}
 }
 
+
+// runTailCommand acompanha o log da conta no terminal até o Ctrl+C. Com regex, só as linhas que casam
+// (ex.: "Stop|Erro" durante um incidente).
+func runTailCommand(db *Database, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("uso: tail <conta> [regex]")
+	}
+	account, err := findAccountByNameOrID(NewAccountManager(db), args[0])
+	if err != nil {
+		return err
+	}
+	var pattern *regexp.Regexp
+	if len(args) == 2 {
+		if pattern, err = regexp.Compile(args[1]); err != nil {
+			return fmt.Errorf("expressão regular inválida: %v", err)
+		}
+	}
+	return tailLogFile(account.ID, pattern, make(chan struct{}), func(line string) {
+		fmt.Println(colorLogLine(line))
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Modo só registro: com a preferência log_only da conta, ex.: settings "Cliente Novo" log_only true, a conta é
+// monitorada normalmente (ordens, execuções, snapshots, logs e histórico de notificações), mas nenhuma notificação
+// sai para o Discord ou o webhook genérico: cada uma vai para o histórico e o arquivo de notificações com o status
+// "registrada". Serve para ajustar filtros e templates ou acompanhar uma conta cujo dono ainda não quer os alertas.
+// A planilha do Google continua recebendo as linhas, que são um registro e não um alerta.
+const logOnlySettingKey = "log_only"
+
+// errLogOnly é o resultado do envio numa conta no modo só registro; deliverNotification registra a notificação
+// sem contar como falha.
+var errLogOnly = errors.New("conta no modo só registro (log_only)")
+
+func validateLogOnlySetting(value json.RawMessage) error {
+	var enabled bool
+	if json.Unmarshal(value, &enabled) != nil {
+		return errors.New("use true ou false")
+	}
+	return nil
+}
+
+// isLogOnly informa se a conta está no modo só registro.
+func isLogOnly(account *BybitAccount) bool {
+	return account.Settings.GetBool(logOnlySettingKey, false)
+}
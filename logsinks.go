@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logSeverity é a severidade de uma linha de log, deduzida do texto (os logs das contas são texto livre).
+type logSeverity int
+
+const (
+	severityDebug logSeverity = iota
+	severityInfo
+	severityWarning
+	severityError
+)
+
+func (s logSeverity) String() string {
+	switch s {
+	case severityDebug:
+		return "debug"
+	case severityWarning:
+		return "warning"
+	case severityError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// logLevelEnv define o nível mínimo dos logs das contas (debug, info, warning, error; padrão: info).
+// Uma conta pode ter outro nível na preferência logLevelSettingKey (ex.: settings "Minha Conta" log_level debug).
+const (
+	logLevelEnv        = "LOG_LEVEL"
+	logLevelSettingKey = "log_level"
+)
+
+// debugSampleEnv define a amostragem das linhas [DEBUG] de alta frequência (Logger.LogSampled): só 1 a cada N
+// é gravada, com o contador de ocorrências. 1 desliga a amostragem; inválido ou ausente = debugSampleDefault.
+const (
+	debugSampleEnv     = "LOG_DEBUG_SAMPLE"
+	debugSampleDefault = 10
+)
+
+func debugSampleRate() int64 {
+	rate, err := strconv.ParseInt(strings.TrimSpace(os.Getenv(debugSampleEnv)), 10, 64)
+	if err != nil || rate < 1 {
+		return debugSampleDefault
+	}
+	return rate
+}
+
+// parseLogLevel interpreta o nome do nível (aceita também warn e erro/aviso).
+func parseLogLevel(name string) (logSeverity, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return severityDebug, true
+	case "info":
+		return severityInfo, true
+	case "warning", "warn", "aviso":
+		return severityWarning, true
+	case "error", "erro":
+		return severityError, true
+	}
+	return severityInfo, false
+}
+
+// defaultLogLevel é o nível global (LOG_LEVEL); inválido ou ausente = info.
+func defaultLogLevel() logSeverity {
+	level, _ := parseLogLevel(os.Getenv(logLevelEnv))
+	return level
+}
+
+// logLineSeverity classifica a mensagem: [DEBUG] → debug; erro/falha/panic → error; aviso/⚠️ → warning; demais → info.
+func logLineSeverity(message string) logSeverity {
+	switch {
+	case strings.Contains(message, "[DEBUG]"):
+		return severityDebug
+	case isErrorLogLine(message):
+		return severityError
+	case strings.Contains(message, "⚠️") || strings.Contains(strings.ToLower(message), "aviso"):
+		return severityWarning
+	default:
+		return severityInfo
+	}
+}
+
+// logLineTopic classifica a mensagem pelo assunto (campo TOPIC do journald): order, execution, position,
+// wallet, notification, connection ou general.
+func logLineTopic(message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "execu"):
+		return "execution"
+	case strings.Contains(lower, "ordem") || strings.Contains(lower, "order"):
+		return "order"
+	case strings.Contains(lower, "posição") || strings.Contains(lower, "position"):
+		return "position"
+	case strings.Contains(lower, "wallet") || strings.Contains(lower, "carteira"):
+		return "wallet"
+	case strings.Contains(lower, "notifica") || strings.Contains(lower, "webhook"):
+		return "notification"
+	case strings.Contains(lower, "conex") || strings.Contains(lower, "websocket") || strings.Contains(lower, "autentica") ||
+		strings.Contains(lower, "inscrição") || strings.Contains(lower, "reconect"):
+		return "connection"
+	default:
+		return "general"
+	}
+}
+
+// logEntry é uma linha de log de conta entregue às saídas adicionais (syslog etc.), já sem segredos.
+type logEntry struct {
+	Time        time.Time
+	AccountID   int64
+	AccountName string
+	Severity    logSeverity
+	Message     string
+}
+
+// logSink é uma saída adicional dos logs das contas, além do arquivo account_{id}.log.
+type logSink interface {
+	Name() string
+	WriteLog(entry logEntry) error
+}
+
+var logSinks struct {
+	mu     sync.RWMutex
+	sinks  []logSink
+	failed map[string]bool // saídas cujo erro já foi avisado, para não repetir a cada linha
+}
+
+func registerLogSink(sink logSink) {
+	logSinks.mu.Lock()
+	defer logSinks.mu.Unlock()
+	logSinks.sinks = append(logSinks.sinks, sink)
+}
+
+// dispatchLogEntry entrega a linha às saídas adicionais. O primeiro erro de cada saída vai para o stderr;
+// os seguintes são ignorados até ela voltar a funcionar.
+func dispatchLogEntry(entry logEntry) {
+	logSinks.mu.RLock()
+	sinks := logSinks.sinks
+	logSinks.mu.RUnlock()
+	for _, sink := range sinks {
+		err := sink.WriteLog(entry)
+		logSinks.mu.Lock()
+		if logSinks.failed == nil {
+			logSinks.failed = make(map[string]bool)
+		}
+		if err != nil && !logSinks.failed[sink.Name()] {
+			fmt.Fprintf(os.Stderr, "Erro ao enviar log para %s: %v\n", sink.Name(), err)
+		}
+		logSinks.failed[sink.Name()] = err != nil
+		logSinks.mu.Unlock()
+	}
+}
+
+// formatLogEntryMessage é o texto enviado às saídas que não têm campo próprio para a conta.
+func formatLogEntryMessage(entry logEntry) string {
+	return fmt.Sprintf("[%s #%d] %s", entry.AccountName, entry.AccountID, entry.Message)
+}
+
+// initLogSinks registra as saídas adicionais configuradas por variáveis de ambiente.
+// Uma saída com configuração inválida é ignorada e o erro retornado para aviso.
+func initLogSinks() []error {
+	var errs []error
+	if addr := strings.TrimSpace(os.Getenv(syslogAddrEnv)); addr != "" {
+		sink, err := newSyslogSink(addr, os.Getenv(syslogFacilityEnv), os.Getenv(syslogTagEnv))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("syslog: %w", err))
+		} else {
+			registerLogSink(sink)
+		}
+	}
+	if strings.TrimSpace(os.Getenv(lokiURLEnv)) != "" {
+		sink, err := newLokiSinkFromEnv()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Loki: %w", err))
+		} else {
+			registerLogSink(sink)
+		}
+	}
+	if enabled, explicit := journaldWanted(); enabled {
+		sink, err := newJournaldSink()
+		if err != nil {
+			// No modo automático o journald é opcional: sem o socket, segue só com os arquivos
+			if explicit {
+				errs = append(errs, fmt.Errorf("journald: %w", err))
+			}
+		} else {
+			registerLogSink(sink)
+		}
+	}
+	if eventLogWanted() {
+		sink, err := newEventLogSink()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Log de Eventos: %w", err))
+		} else {
+			registerLogSink(sink)
+		}
+	}
+	return errs
+}
+
+// logSinkFlusher é implementado pelas saídas que enviam em lotes.
+type logSinkFlusher interface {
+	Flush()
+}
+
+// flushLogSinks envia o que estiver pendente nas saídas em lote (ao desligar).
+func flushLogSinks() {
+	logSinks.mu.RLock()
+	sinks := logSinks.sinks
+	logSinks.mu.RUnlock()
+	for _, sink := range sinks {
+		if f, ok := sink.(logSinkFlusher); ok {
+			f.Flush()
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Envio dos logs das contas para o Grafana Loki (API de push), com labels por conta:
+//
+//	LOKI_URL=https://logs.exemplo:3100      endereço do Loki (o caminho /loki/api/v1/push é completado se faltar)
+//	LOKI_USER / LOKI_PASSWORD               basic auth (ex.: Grafana Cloud)
+//	LOKI_TENANT_ID                          header X-Scope-OrgID (Loki multi-tenant)
+//	LOKI_LABELS=env=prod,host=vps1          labels fixos adicionais
+const (
+	lokiURLEnv      = "LOKI_URL"
+	lokiUserEnv     = "LOKI_USER"
+	lokiPasswordEnv = "LOKI_PASSWORD"
+	lokiTenantEnv   = "LOKI_TENANT_ID"
+	lokiLabelsEnv   = "LOKI_LABELS"
+	lokiPushPath    = "/loki/api/v1/push"
+)
+
+// As linhas são enviadas em lotes, em background, para o Loki nunca atrasar o processamento das mensagens.
+const (
+	lokiBatchSize     = 500
+	lokiBatchInterval = 2 * time.Second
+	lokiQueueSize     = 10000
+	lokiHTTPTimeout   = 10 * time.Second
+)
+
+type lokiSink struct {
+	url      string
+	user     string
+	password string
+	tenant   string
+	labels   map[string]string
+	client   *http.Client
+	queue    chan logEntry
+	flushReq chan chan struct{}
+
+	mu      sync.Mutex
+	lastErr error
+	dropped int64
+}
+
+func newLokiSink(rawURL, user, password, tenant, extraLabels string) (*lokiSink, error) {
+	rawURL = strings.TrimRight(strings.TrimSpace(rawURL), "/")
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		return nil, fmt.Errorf("URL inválida: %s", rawURL)
+	}
+	if !strings.HasSuffix(rawURL, lokiPushPath) {
+		rawURL += lokiPushPath
+	}
+	labels := map[string]string{"app": "bybit-notifier"}
+	for _, pair := range strings.Split(extraLabels, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("label inválido em %s: %s (use nome=valor)", lokiLabelsEnv, pair)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	sink := &lokiSink{
+		url:      rawURL,
+		user:     user,
+		password: password,
+		tenant:   strings.TrimSpace(tenant),
+		labels:   labels,
+		client:   &http.Client{Timeout: lokiHTTPTimeout},
+		queue:    make(chan logEntry, lokiQueueSize),
+		flushReq: make(chan chan struct{}),
+	}
+	go sink.run()
+	return sink, nil
+}
+
+func (s *lokiSink) Name() string {
+	return "Loki"
+}
+
+// WriteLog enfileira a linha. Com a fila cheia (Loki fora do ar por muito tempo) a linha é descartada;
+// o arquivo de log da conta continua completo. Retorna o erro do último envio, para o aviso no stderr.
+func (s *lokiSink) WriteLog(entry logEntry) error {
+	select {
+	case s.queue <- entry:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastErr
+}
+
+// Flush envia o que estiver na fila e espera o envio terminar.
+func (s *lokiSink) Flush() {
+	done := make(chan struct{})
+	s.flushReq <- done
+	<-done
+}
+
+func (s *lokiSink) run() {
+	ticker := time.NewTicker(lokiBatchInterval)
+	defer ticker.Stop()
+	var batch []logEntry
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := s.push(batch)
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+		batch = nil
+	}
+	for {
+		select {
+		case entry := <-s.queue:
+			batch = append(batch, entry)
+			if len(batch) >= lokiBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case done := <-s.flushReq:
+			for drained := false; !drained; {
+				select {
+				case entry := <-s.queue:
+					batch = append(batch, entry)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(done)
+		}
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push envia o lote agrupado em streams (uma por conta e severidade).
+func (s *lokiSink) push(batch []logEntry) error {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+
+	streams := make(map[string]*lokiStream)
+	var order []string
+	for _, entry := range batch {
+		key := fmt.Sprintf("%d|%s", entry.AccountID, entry.Severity)
+		stream, exists := streams[key]
+		if !exists {
+			labels := make(map[string]string, len(s.labels)+3)
+			for k, v := range s.labels {
+				labels[k] = v
+			}
+			labels["account"] = entry.AccountName
+			labels["account_id"] = strconv.FormatInt(entry.AccountID, 10)
+			labels["level"] = entry.Severity.String()
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			order = append(order, key)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.Time.UnixNano(), 10), entry.Message})
+	}
+	if dropped > 0 {
+		labels := map[string]string{"level": severityWarning.String()}
+		for k, v := range s.labels {
+			labels[k] = v
+		}
+		streams["dropped"] = &lokiStream{Stream: labels, Values: [][2]string{{strconv.FormatInt(time.Now().UnixNano(), 10),
+			fmt.Sprintf("%d linha(s) de log descartada(s): fila do Loki cheia", dropped)}}}
+		order = append(order, "dropped")
+	}
+
+	payload := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range order {
+		payload.Streams = append(payload.Streams, streams[key])
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.user != "" || s.password != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	if s.tenant != "" {
+		req.Header.Set("X-Scope-OrgID", s.tenant)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New(redactSecrets(err.Error()))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func newLokiSinkFromEnv() (*lokiSink, error) {
+	return newLokiSink(os.Getenv(lokiURLEnv), os.Getenv(lokiUserEnv), os.Getenv(lokiPasswordEnv), os.Getenv(lokiTenantEnv), os.Getenv(lokiLabelsEnv))
+}
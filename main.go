@@ -2,31 +2,124 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 const projectVersion = "v0.0.6"
 
 func main() {
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		printErrorf("Erro: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Subcomandos (modo não interativo), ex.: "list", "completion bash"
+	if len(args) > 0 {
+		os.Exit(runCLI(args))
+	}
+
+	lock, err := acquireInstanceLock()
+	if err != nil {
+		printErrorf("Erro: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Encerre a outra instância ou use outro banco (--db-path ou DATA_DIR).")
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	// Configurações recarregáveis (SETTINGS_FILE) antes dos logs, que usam LOG_LEVEL
+	if _, err := applySettingsFile(); err != nil {
+		printErrorf("Erro ao carregar as configurações: %v\n", err)
+		os.Exit(1)
+	}
+
 	db, err := NewDatabase()
 	if err != nil {
-		fmt.Printf("Erro ao conectar ao banco de dados: %v\n", err)
+		printErrorf("Erro ao conectar ao banco de dados: %v\n", err)
 		os.Exit(1)
 	}
 	defer db.Close()
 
+	for _, err := range initLogSinks() {
+		printErrorf("Aviso: saída de log desativada: %v\n", err)
+	}
+
+	if err := initSentry(); err != nil {
+		printErrorf("Aviso: envio ao Sentry desativado: %v\n", err)
+	}
+
 	manager := NewAccountManager(db)
-	wsManager := NewWebSocketManager(db, manager)
+	if added, err := loadConfiguredAccounts(manager); err != nil {
+		printErrorf("Erro ao carregar as contas configuradas: %v\n", err)
+		os.Exit(1)
+	} else if added > 0 {
+		fmt.Printf("%d conta(s) carregada(s) da configuração.\n", added)
+	}
+	if isInMemoryDatabase() {
+		fmt.Println(colorYellow("Banco em memória: contas, estatísticas e históricos são descartados ao encerrar."))
+	}
+	// Contexto raiz das conexões: cancelado ao sair, encerra as goroutines de todas as contas
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if mockBybitEnabled {
+		mockURL, err := startMockBybit(ctx, mockBybitScriptPath)
+		if err != nil {
+			printErrorf("Erro ao iniciar o servidor simulado da Bybit: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(colorYellow("Modo simulação: as contas Bybit conectam ao servidor simulado em " + mockURL + " (nenhuma mensagem vem da corretora)."))
+	}
+	wsManager := NewWebSocketManager(ctx, db, manager)
+	startStatsFlusher(db)
+	loadSharedWebhooksOrWarn(db)
+	startRetentionPruner(db)
+	startAdminAPI(db, manager, wsManager)
+	wsManager.startHeartbeat()
+	wsManager.watchSettings()
 
 	// Restaurar conexões ativas ao iniciar
 	if err := wsManager.RestoreConnections(); err != nil {
-		fmt.Printf("Erro ao restaurar conexões: %v\n", err)
+		printErrorf("Erro ao restaurar conexões: %v\n", err)
+	}
+
+	var shutdownOnce sync.Once
+	shutdown := func() {
+		shutdownOnce.Do(func() {
+			// As conexões continuam marcadas para o próximo início; só o que estava nos buffers é enviado agora
+			wsManager.FlushAllBuffers()
+			cancel()
+			flushStats()
+			flushLoggers()
+			flushLogSinks()
+			flushSentry(5 * time.Second)
+			// Aqui e não só nos defers: o sinal sai com os.Exit, que não roda os defers de main
+			db.Close()
+			lock.Release()
+			fmt.Println("Saindo...")
+		})
 	}
 
+	// Ctrl+C e SIGTERM (systemctl stop, docker stop) saem como a opção do menu
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Println()
+		shutdown()
+		os.Exit(0)
+	}()
+
 	scanner := bufio.NewScanner(os.Stdin)
 
 	for {
@@ -56,7 +149,23 @@ func main() {
 		case "9":
 			handleManageSnapshots(wsManager.accountManager, db, scanner)
 		case "10":
-			fmt.Println("Saindo...")
+			handleToggleAccountActive(manager, wsManager, scanner)
+		case "11":
+			handleTestNotification(manager, wsManager, scanner)
+		case "12":
+			handleSendWalletSummary(manager, wsManager, scanner)
+		case "13":
+			handleViewAccountStats(manager, wsManager, db, scanner)
+		case "14":
+			handleNotificationHistory(manager, wsManager, db, scanner)
+		case "15":
+			handleOrderHistory(manager, scanner)
+		case "16":
+			handleRestoreRemovedAccount(manager, scanner)
+		case "17":
+			handleMuteNotifications(manager, scanner)
+		case "18":
+			shutdown()
 			return
 		default:
 		}
@@ -92,37 +201,72 @@ func getMonitoredAccountsCount(wsManager *WebSocketManager) int {
 func showMenu(wsManager *WebSocketManager) {
 	monitoredCount := getMonitoredAccountsCount(wsManager)
 	
-	fmt.Printf("\n=== Gerenciador de Contas Bybit (%s) ===\n", projectVersion)
-	fmt.Printf("📊 Contas sendo monitoradas: %d\n", monitoredCount)
+	fmt.Println(colorBold(fmt.Sprintf("\n=== Gerenciador de Contas Bybit (%s) ===", projectVersion)))
+	fmt.Printf("📊 Contas sendo monitoradas: %s\n", colorStatus(fmt.Sprintf("%d", monitoredCount), monitoredCount > 0))
+	printFailedDeliveriesSummary(wsManager)
 	fmt.Println("═══════════════════════════════════════════════════════════")
 	fmt.Println("1. Cadastrar conta")
 	fmt.Println("2. Listar contas cadastradas")
 	fmt.Println("3. Remover conta cadastrada")
 	fmt.Println("4. Editar conta")
 	fmt.Println("5. Monitorar conta")
-	fmt.Println("6. Parar monitoramento da conta")
+	fmt.Println("6. Parar ou pausar monitoramento da conta")
 	fmt.Println("7. Ver contas monitoradas")
 	fmt.Println("8. Visualizar logs")
 	fmt.Println("9. Gerenciar snapshots do banco")
-	fmt.Println("10. Desligar")
+	fmt.Println("10. Ativar/desativar conta")
+	fmt.Println("11. Enviar notificação de teste")
+	fmt.Println("12. Enviar resumo de posições agora")
+	fmt.Println("13. Estatísticas da conta")
+	fmt.Println("14. Histórico de notificações")
+	fmt.Println("15. Histórico de ordens")
+	fmt.Println("16. Restaurar conta removida")
+	fmt.Println("17. Silenciar símbolo ou evento")
+	fmt.Println("18. Desligar")
 	fmt.Println("═══════════════════════════════════════════════════════════")
-	fmt.Println("ℹ️  Se a janela for fechada, o monitoramento será pausado")
-	fmt.Println("   automaticamente.")
+	fmt.Println(colorYellow("ℹ️  Se a janela for fechada, o monitoramento será pausado"))
+	fmt.Println(colorYellow("   automaticamente."))
 	fmt.Println()
 }
 
+// printFailedDeliveriesSummary avisa no menu quando há notificações que não foram entregues nas últimas 24h,
+// para falhas de webhook não passarem despercebidas.
+func printFailedDeliveriesSummary(wsManager *WebSocketManager) {
+	counts, err := wsManager.db.CountFailedNotifications(time.Now().Add(-failedDeliveriesWindow))
+	if err != nil || len(counts) == 0 {
+		return
+	}
+	accounts, err := wsManager.accountManager.ListAccounts()
+	if err != nil {
+		return
+	}
+	var total int64
+	var parts []string
+	for _, acc := range accounts {
+		if count := counts[acc.ID]; count > 0 {
+			total += count
+			parts = append(parts, fmt.Sprintf("%s: %d", acc.Name, count))
+		}
+	}
+	if total > 0 {
+		fmt.Println(colorRed(fmt.Sprintf("⚠️  Entregas com falha (24h): %d (%s) - veja em 14. Histórico de notificações", total, strings.Join(parts, ", "))))
+	}
+}
+
 // AuthField descreve um campo de autenticação por plataforma.
 type AuthField struct {
 	Label    string
 	Key      string
 	Required bool
+	Secret   bool // lido sem eco no terminal
 }
 
-// GetAuthOptionsBybit retorna as opções de autenticação para Bybit: API Key e API Secret.
+// GetAuthOptionsBybit retorna as opções de autenticação para Bybit: API Key e API Secret (ou a chave privada, nas
+// keys RSA; rsakey.go).
 func GetAuthOptionsBybit() []AuthField {
 	return []AuthField{
 		{Label: "API Key", Key: "api_key", Required: true},
-		{Label: "API Secret", Key: "api_secret", Required: true},
+		{Label: "API Secret (ou chave privada RSA)", Key: "api_secret", Required: true, Secret: true},
 	}
 }
 
@@ -130,8 +274,8 @@ func GetAuthOptionsBybit() []AuthField {
 func GetAuthOptionsOKX() []AuthField {
 	return []AuthField{
 		{Label: "API Key", Key: "api_key", Required: true},
-		{Label: "API Secret", Key: "api_secret", Required: true},
-		{Label: "Passphrase", Key: "passphrase", Required: true},
+		{Label: "API Secret", Key: "api_secret", Required: true, Secret: true},
+		{Label: "Passphrase", Key: "passphrase", Required: true, Secret: true},
 	}
 }
 
@@ -199,7 +343,7 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 		title = "=== Cadastrar Conta OKX ==="
 	}
 	fmt.Println(title)
-	fmt.Println("(Digite 'cancelar' ou '0' em qualquer momento para voltar ao menu principal)\n")
+	fmt.Print("(Digite 'cancelar' ou '0' em qualquer momento para voltar ao menu principal)\n\n")
 
 	fmt.Print("Nome da conta: ")
 	scanner.Scan()
@@ -217,8 +361,13 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 	var apiKey, apiSecret, metadata string
 	for _, f := range opts {
 		fmt.Printf("%s: ", f.Label)
-		scanner.Scan()
-		val := strings.TrimSpace(scanner.Text())
+		var val string
+		if f.Secret {
+			val = strings.TrimSpace(readSecretLine(scanner))
+		} else {
+			scanner.Scan()
+			val = strings.TrimSpace(scanner.Text())
+		}
 		if val == "cancelar" || val == "0" {
 			return
 		}
@@ -231,19 +380,74 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 			metadata = `{"passphrase":"` + escapeJSONString(val) + `"}`
 		}
 		if f.Required && val == "" {
-			fmt.Printf("Erro: %s é obrigatório!\n", f.Label)
+			printErrorf("Erro: %s é obrigatório!\n", f.Label)
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
 			return
 		}
 	}
 
+	// Validar as credenciais agora, em vez de descobrir o erro depois pela falha de autenticação do WebSocket
+	fmt.Println("\nValidando credenciais na corretora...")
+	candidate := &BybitAccount{APIKey: apiKey, APISecret: apiSecret, Platform: platform, Metadata: metadata}
+	resolveAccountSecrets(candidate)
+	var keyInfo *APIKeyInfo
+	err := candidate.SecretError()
+	if err == nil {
+		keyInfo, err = validateAPICredentials(candidate)
+	}
+	if err != nil {
+		printErrorf("❌ Não foi possível validar as credenciais: %v\n", err)
+		fmt.Print("Deseja continuar o cadastro mesmo assim? (sim/s ou não/n): ")
+		scanner.Scan()
+		confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if confirmation != "sim" && confirmation != "s" {
+			return
+		}
+	} else {
+		fmt.Println(colorGreen("✅ Credenciais válidas"))
+		for _, line := range formatAPIKeyInfo(keyInfo) {
+			fmt.Printf("   %s\n", line)
+		}
+
+		if risky := keyInfo.riskyPermissions(); len(risky) > 0 {
+			fmt.Println()
+			printWarningf("⚠️  A key tem permissões além de leitura: %s\n", strings.Join(risky, ", "))
+			fmt.Println("   O notificador só precisa de leitura; uma key somente leitura limita o estrago se vazar.")
+			if refuseRiskyAPIKeys() {
+				printErrorf("❌ Cadastro recusado (%s=refuse). Crie uma key somente leitura na corretora.\n", apiKeyPolicyEnv)
+				return
+			}
+			fmt.Print("Deseja continuar o cadastro mesmo assim? (sim/s ou não/n): ")
+			scanner.Scan()
+			confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if confirmation != "sim" && confirmation != "s" {
+				return
+			}
+		}
+	}
+	fmt.Println()
+
+	secretsInKeyring := false
+	if keyringAvailable() {
+		fmt.Print("Guardar API Secret/Passphrase no keyring do sistema em vez do banco local? (sim/s ou não/n, padrão: não): ")
+		scanner.Scan()
+		keyringInput := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if keyringInput == "cancelar" || keyringInput == "0" {
+			return
+		}
+		secretsInKeyring = keyringInput == "sim" || keyringInput == "s"
+	}
+
 	fmt.Print("Webhook Discord (opcional, deixe em branco para notificar no terminal): ")
 	scanner.Scan()
 	webhookURL := strings.TrimSpace(scanner.Text())
 	if webhookURL == "cancelar" || webhookURL == "0" {
 		return
 	}
+	if !checkDiscordWebhookOnSave(scanner, webhookURL, "Webhook Discord") {
+		return
+	}
 
 	fmt.Print("Marcar @everyone em notificações de ordens? (sim/s ou não/n, padrão: não): ")
 	scanner.Scan()
@@ -267,6 +471,9 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 	if webhookURLExecutions == "cancelar" || webhookURLExecutions == "0" {
 		return
 	}
+	if !checkDiscordWebhookOnSave(scanner, webhookURLExecutions, "Webhook Discord para execuções") {
+		return
+	}
 
 	fmt.Print("Marcar @everyone em notificações de execuções? (sim/s ou não/n, padrão: não): ")
 	scanner.Scan()
@@ -287,7 +494,7 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 	if webhookURLGoogleSheets != "" {
 		// Validar webhook URL
 		if !validateGoogleSheetsWebhookURL(webhookURLGoogleSheets) {
-			fmt.Println("Erro: Webhook URL do Google Planilhas inválida!")
+			fmt.Println(colorRed("Erro: Webhook URL do Google Planilhas inválida!"))
 			fmt.Println("Formato esperado: https://script.google.com/macros/s/.../exec")
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
@@ -302,7 +509,7 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 		}
 
 		if sheetURLGoogleSheets == "" {
-			fmt.Println("Erro: URL da planilha do Google é obrigatória quando webhook URL é preenchida!")
+			fmt.Println(colorRed("Erro: URL da planilha do Google é obrigatória quando webhook URL é preenchida!"))
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
 			return
@@ -310,7 +517,7 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 
 		// Validar sheet URL
 		if !validateGoogleSheetsURL(sheetURLGoogleSheets) {
-			fmt.Println("Erro: URL da planilha do Google inválida!")
+			fmt.Println(colorRed("Erro: URL da planilha do Google inválida!"))
 			fmt.Println("Formato esperado: https://docs.google.com/spreadsheets/d/.../edit...")
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
@@ -327,7 +534,7 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 			return
 		}
 		if sheetURLGoogleSheetsExecutions != "" && !validateGoogleSheetsURL(sheetURLGoogleSheetsExecutions) {
-			fmt.Println("Erro: URL da planilha do Google inválida!")
+			fmt.Println(colorRed("Erro: URL da planilha do Google inválida!"))
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
 			return
@@ -349,50 +556,73 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 			} else if d >= 3 && d <= 20 {
 				notificationDelaySeconds = d
 			} else {
-				fmt.Println("Erro: delay deve ser 0 ou um valor entre 3 e 20 segundos.")
+				fmt.Println(colorRed("Erro: delay deve ser 0 ou um valor entre 3 e 20 segundos."))
 				fmt.Println("\nPressione Enter para voltar ao menu principal...")
 				scanner.Scan()
 				return
 			}
 		} else {
-			fmt.Println("Erro: valor inválido. Use 0 ou um número entre 3 e 20.")
+			fmt.Println(colorRed("Erro: valor inválido. Use 0 ou um número entre 3 e 20."))
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
 			return
 		}
 	}
 
+	fmt.Print("Fuso horário das notificações e logs (ex.: America/Sao_Paulo, Europe/Lisbon; Enter=horário de Brasília): ")
+	scanner.Scan()
+	timezone := strings.TrimSpace(scanner.Text())
+	if timezone == "cancelar" || timezone == "0" {
+		return
+	}
+	if !isValidTimezone(timezone) {
+		fmt.Println(colorRed("Erro: fuso horário inválido! Use um nome IANA, ex.: America/Sao_Paulo."))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Print("Observações (opcional: responsável, estratégia, validade da key...): ")
+	scanner.Scan()
+	notes := strings.TrimSpace(scanner.Text())
+	if notes == "cancelar" || notes == "0" {
+		return
+	}
+
 	if nome == "" || apiKey == "" || apiSecret == "" {
-		fmt.Println("Erro: Nome, API Key e API Secret são obrigatórios!")
+		fmt.Println(colorRed("Erro: Nome, API Key e API Secret são obrigatórios!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
 	}
 
 	account := &BybitAccount{
-		Name:                            nome,
-		APIKey:                          apiKey,
-		APISecret:                       apiSecret,
-		WebhookURL:                      webhookURL,
-		Active:                          true,
-		MarkEveryoneOrder:               markEveryoneOrder,
-		MarkEveryoneWallet:              markEveryoneWallet,
-		WebhookURLGoogleSheets:          webhookURLGoogleSheets,
-		SheetURLGoogleSheets:            sheetURLGoogleSheets,
-		WebhookURLExecutions:            webhookURLExecutions,
-		MarkEveryoneExecution:           markEveryoneExecution,
-		SheetURLGoogleSheetsExecutions:  sheetURLGoogleSheetsExecutions,
-		Platform:                        platform,
-		Metadata:                        metadata,
-		NotificationDelaySeconds:        notificationDelaySeconds,
+		Name:                           nome,
+		APIKey:                         apiKey,
+		APISecret:                      apiSecret,
+		WebhookURL:                     webhookURL,
+		Active:                         true,
+		MarkEveryoneOrder:              markEveryoneOrder,
+		MarkEveryoneWallet:             markEveryoneWallet,
+		WebhookURLGoogleSheets:         webhookURLGoogleSheets,
+		SheetURLGoogleSheets:           sheetURLGoogleSheets,
+		WebhookURLExecutions:           webhookURLExecutions,
+		MarkEveryoneExecution:          markEveryoneExecution,
+		SheetURLGoogleSheetsExecutions: sheetURLGoogleSheetsExecutions,
+		Platform:                       platform,
+		Metadata:                       metadata,
+		NotificationDelaySeconds:       notificationDelaySeconds,
+		Timezone:                       timezone,
+		Notes:                          notes,
+		SecretsInKeyring:               secretsInKeyring,
 	}
 
 	if err := manager.AddAccount(account); err != nil {
-		fmt.Printf("Erro ao cadastrar conta: %v\n", err)
+		printErrorf("Erro ao cadastrar conta: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 	} else {
-		fmt.Println("Conta cadastrada com sucesso!")
+		fmt.Println(colorGreen("Conta cadastrada com sucesso!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 	}
@@ -400,20 +630,26 @@ func handleAddAccountCommon(manager *AccountManager, scanner *bufio.Scanner, pla
 
 func handleListAccounts(manager *AccountManager, wsManager *WebSocketManager, scanner *bufio.Scanner) {
 	clearScreen()
+	if !requireMasterPassword(manager.db, scanner) {
+		return
+	}
 	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
 		return
 	}
 
+	failedDeliveries, _ := manager.db.CountFailedNotifications(time.Now().Add(-failedDeliveriesWindow))
+	authFailures, _ := manager.GetAuthFailures()
+
 	fmt.Println("\n=== Contas Cadastradas ===")
 	if len(accounts) == 0 {
 		fmt.Println("Nenhuma conta cadastrada.")
 	} else {
 		for i, acc := range accounts {
-			monitoringStatus := "Desligado"
+			monitoringStatus := colorRed("Desligado")
 			if wsManager.IsConnectionActive(acc.ID) {
-				monitoringStatus = "Ligado"
+				monitoringStatus = colorGreen("Ligado")
 			}
 			platformLabel := acc.Platform
 			if platformLabel == "" {
@@ -422,18 +658,35 @@ func handleListAccounts(manager *AccountManager, wsManager *WebSocketManager, sc
 			fmt.Printf("\n%d. Nome: %s\n", i+1, acc.Name)
 			fmt.Printf("   Plataforma: %s\n", platformLabel)
 			fmt.Printf("   API Key: %s\n", maskAPIKey(acc.APIKey))
+			fmt.Printf("   Fuso horário: %s\n", timezoneLabel(acc.Timezone))
+			if acc.Notes != "" {
+				fmt.Printf("   Observações: %s\n", acc.Notes)
+			}
+			if err := acc.SecretError(); err != nil {
+				fmt.Printf("   Segredos: %s\n", colorRed(err.Error()))
+			} else if acc.SecretsInKeyring {
+				fmt.Printf("   Segredos: keyring do sistema\n")
+			}
 			if acc.WebhookURL != "" {
 				fmt.Printf("   Webhook Discord: Configurado\n")
 			} else {
 				fmt.Printf("   Webhook Discord: Não configurado (notificações no terminal)\n")
 			}
+			if _, ok := accountDiscordBot(acc); ok {
+				fmt.Printf("   Bot do Discord: Configurado (canal principal)\n")
+			}
 			if acc.WebhookURLGoogleSheets != "" && acc.SheetURLGoogleSheets != "" {
 				fmt.Printf("   Webhook Google Planilhas: Configurado\n")
 			} else {
 				fmt.Printf("   Webhook Google Planilhas: Não configurado\n")
 			}
-			fmt.Printf("   Status: %s\n", getStatusText(acc.Active))
+			fmt.Printf("   Status: %s\n", colorStatus(getStatusText(acc.Active), acc.Active))
 			fmt.Printf("   Monitoramento: %s\n", monitoringStatus)
+			if failure, failed := authFailures[acc.ID]; failed {
+				fmt.Printf("   Autenticação: %s\n", colorRed(authFailureText(failure, loadTimezone(acc.Timezone))))
+			}
+			printConnectionHealth(wsManager, acc.ID)
+			fmt.Printf("   Entregas com falha (24h): %s\n", colorStatus(fmt.Sprintf("%d", failedDeliveries[acc.ID]), failedDeliveries[acc.ID] == 0))
 			fmt.Printf("   Marcar @everyone em ordens: %s\n", getBooleanText(acc.MarkEveryoneOrder))
 			fmt.Printf("   Marcar @everyone no balance da carteira: %s\n", getBooleanText(acc.MarkEveryoneWallet))
 			if acc.WebhookURLExecutions != "" {
@@ -459,7 +712,7 @@ func handleRemoveAccount(manager *AccountManager, wsManager *WebSocketManager, s
 	clearScreen()
 	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
 		return
 	}
 
@@ -480,7 +733,7 @@ func handleRemoveAccount(manager *AccountManager, wsManager *WebSocketManager, s
 	scanner.Scan()
 	var index int
 	if _, err := fmt.Sscanf(scanner.Text(), "%d", &index); err != nil {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -491,7 +744,7 @@ func handleRemoveAccount(manager *AccountManager, wsManager *WebSocketManager, s
 	}
 
 	if index < 1 || index > len(accounts) {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -522,7 +775,8 @@ func handleRemoveAccount(manager *AccountManager, wsManager *WebSocketManager, s
 	fmt.Println("═══════════════════════════════════════════════════════════")
 	fmt.Printf("  ATENÇÃO: Você está prestes a remover a conta '%s'\n", account.Name)
 	fmt.Println("═══════════════════════════════════════════════════════════")
-	fmt.Println("\nEsta ação não pode ser desfeita!")
+	fmt.Println("\nA conta poderá ser restaurada em 'Restaurar conta removida' até ser apagada")
+	fmt.Printf("definitivamente com o comando '%s purge'.\n", programName())
 	fmt.Print("\nDeseja realmente remover esta conta? (sim/s ou não/n): ")
 	scanner.Scan()
 	confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
@@ -536,7 +790,7 @@ func handleRemoveAccount(manager *AccountManager, wsManager *WebSocketManager, s
 
 	// Remover a conta
 	if err := manager.RemoveAccount(account.ID); err != nil {
-		fmt.Printf("\nErro ao remover conta: %v\n", err)
+		printErrorf("\nErro ao remover conta: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 	} else {
@@ -546,11 +800,61 @@ func handleRemoveAccount(manager *AccountManager, wsManager *WebSocketManager, s
 	}
 }
 
+func handleRestoreRemovedAccount(manager *AccountManager, scanner *bufio.Scanner) {
+	clearScreen()
+	removed, err := manager.ListRemovedAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas removidas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if len(removed) == 0 {
+		fmt.Println("Nenhuma conta removida.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Contas Removidas ===")
+	for i, acc := range removed {
+		fmt.Printf("%d. %s (%s) - removida em %s\n", i+1, acc.Name, acc.Platform, acc.DeletedAt.In(loadTimezone("")).Format("02/01/2006 15:04"))
+	}
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para restaurar (ou 0 para voltar): ")
+	scanner.Scan()
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(removed) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	account := removed[index-1]
+	if err := manager.RestoreAccount(account.ID); err != nil {
+		printErrorf("\nErro ao restaurar conta: %v\n", err)
+	} else {
+		fmt.Printf("\nConta '%s' restaurada! Ela está desativada; ative-a em 'Ativar/desativar conta' para voltar a monitorá-la.\n", account.Name)
+	}
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
+}
+
 func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, scanner *bufio.Scanner) {
 	clearScreen()
+	if !requireMasterPassword(manager.db, scanner) {
+		return
+	}
 	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -573,7 +877,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	scanner.Scan()
 	var index int
 	if _, err := fmt.Sscanf(scanner.Text(), "%d", &index); err != nil {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -584,7 +888,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	}
 
 	if index < 1 || index > len(accounts) {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -595,7 +899,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	clearScreen()
 	fmt.Println("=== Editar Conta ===")
 	fmt.Printf("Conta: %s\n", account.Name)
-	fmt.Println("(Digite 'cancelar' ou '0' em qualquer momento para voltar ao menu principal)\n")
+	fmt.Print("(Digite 'cancelar' ou '0' em qualquer momento para voltar ao menu principal)\n\n")
 	
 	// Mostrar valores atuais
 	fmt.Printf("Nome atual: %s\n", account.Name)
@@ -616,14 +920,23 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	} else {
 		editOpts = GetAuthOptionsBybit()
 	}
-	newApiKey := account.APIKey
-	newApiSecret := account.APISecret
+	newApiKey := account.StoredAPIKey()
+	newApiSecret := account.StoredAPISecret()
 	var newMetadata string
 	for _, f := range editOpts {
-		fmt.Printf("\n%s atual: (configurado)\n", f.Label)
+		current := "(configurado)"
+		if (f.Key == "api_key" && isEnvRef(newApiKey)) || (f.Key == "api_secret" && isEnvRef(newApiSecret)) || (f.Key == "passphrase" && isEnvRef(metadataPassphrase(account.StoredMetadata()))) {
+			current = "(variável de ambiente)"
+		}
+		fmt.Printf("\n%s atual: %s\n", f.Label, current)
 		fmt.Printf("Novo %s (pressione Enter para manter o atual): ", f.Label)
-		scanner.Scan()
-		val := strings.TrimSpace(scanner.Text())
+		var val string
+		if f.Secret {
+			val = strings.TrimSpace(readSecretLine(scanner))
+		} else {
+			scanner.Scan()
+			val = strings.TrimSpace(scanner.Text())
+		}
 		if val == "cancelar" || val == "0" {
 			return
 		}
@@ -658,6 +971,8 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 		newWebhook = account.WebhookURL
 	} else if newWebhook == "remover" {
 		newWebhook = ""
+	} else if !checkDiscordWebhookOnSave(scanner, newWebhook, "Webhook Discord") {
+		return
 	}
 
 	currentMarkEveryoneOrder := "Não"
@@ -710,7 +1025,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	} else {
 		// Validar webhook URL
 		if !validateGoogleSheetsWebhookURL(newWebhookURLGoogleSheets) {
-			fmt.Println("Erro: Webhook URL do Google Planilhas inválida!")
+			fmt.Println(colorRed("Erro: Webhook URL do Google Planilhas inválida!"))
 			fmt.Println("Formato esperado: https://script.google.com/macros/s/.../exec")
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
@@ -736,7 +1051,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	} else {
 		// Validar sheet URL
 		if !validateGoogleSheetsURL(newSheetURLGoogleSheets) {
-			fmt.Println("Erro: URL da planilha do Google inválida!")
+			fmt.Println(colorRed("Erro: URL da planilha do Google inválida!"))
 			fmt.Println("Formato esperado: https://docs.google.com/spreadsheets/d/.../edit...")
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
@@ -746,7 +1061,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 
 	// Validar que se webhook URL foi preenchida, sheet URL também deve estar preenchida
 	if newWebhookURLGoogleSheets != "" && newSheetURLGoogleSheets == "" {
-		fmt.Println("Erro: URL da planilha do Google é obrigatória quando webhook URL do google planilhas é preenchida!")
+		fmt.Println(colorRed("Erro: URL da planilha do Google é obrigatória quando webhook URL do google planilhas é preenchida!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -768,6 +1083,8 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 		newWebhookURLExecutions = account.WebhookURLExecutions
 	} else if newWebhookURLExecutions == "remover" {
 		newWebhookURLExecutions = ""
+	} else if !checkDiscordWebhookOnSave(scanner, newWebhookURLExecutions, "Webhook Discord para execuções") {
+		return
 	}
 
 	// Marcar @everyone em execuções
@@ -804,7 +1121,7 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	} else if newSheetURLGoogleSheetsExecutions == "remover" {
 		newSheetURLGoogleSheetsExecutions = ""
 	} else if !validateGoogleSheetsURL(newSheetURLGoogleSheetsExecutions) {
-		fmt.Println("Erro: URL da planilha do Google inválida!")
+		fmt.Println(colorRed("Erro: URL da planilha do Google inválida!"))
 		fmt.Println("Formato esperado: https://docs.google.com/spreadsheets/d/.../edit...")
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
@@ -833,42 +1150,118 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 			} else if d >= 3 && d <= 20 {
 				newNotificationDelaySeconds = d
 			} else {
-				fmt.Println("Erro: delay deve ser 0 ou um valor entre 3 e 20 segundos.")
+				fmt.Println(colorRed("Erro: delay deve ser 0 ou um valor entre 3 e 20 segundos."))
 				fmt.Println("\nPressione Enter para voltar ao menu principal...")
 				scanner.Scan()
 				return
 			}
 		} else {
-			fmt.Println("Erro: valor inválido. Use 0 ou um número entre 3 e 20.")
+			fmt.Println(colorRed("Erro: valor inválido. Use 0 ou um número entre 3 e 20."))
 			fmt.Println("\nPressione Enter para voltar ao menu principal...")
 			scanner.Scan()
 			return
 		}
 	}
 
+	// Fuso horário (vazio = horário de Brasília)
+	currentTimezone := account.Timezone
+	if currentTimezone == "" {
+		currentTimezone = "(horário de Brasília)"
+	}
+	fmt.Printf("\nFuso horário atual: %s\n", currentTimezone)
+	fmt.Print("Novo fuso horário (ex.: Europe/Lisbon; Enter para manter, ou 'remover' para voltar ao horário de Brasília): ")
+	scanner.Scan()
+	newTimezone := strings.TrimSpace(scanner.Text())
+	if newTimezone == "cancelar" || newTimezone == "0" {
+		return
+	}
+	if newTimezone == "" {
+		newTimezone = account.Timezone
+	} else if newTimezone == "remover" {
+		newTimezone = ""
+	} else if !isValidTimezone(newTimezone) {
+		fmt.Println(colorRed("Erro: fuso horário inválido! Use um nome IANA, ex.: America/Sao_Paulo."))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	currentNotes := account.Notes
+	if currentNotes == "" {
+		currentNotes = "(nenhuma)"
+	}
+	fmt.Printf("\nObservações atuais: %s\n", currentNotes)
+	fmt.Print("Novas observações (pressione Enter para manter as atuais, ou digite 'remover' para apagar): ")
+	scanner.Scan()
+	newNotes := strings.TrimSpace(scanner.Text())
+	if newNotes == "cancelar" || newNotes == "0" {
+		return
+	}
+	if newNotes == "" {
+		newNotes = account.Notes
+	} else if newNotes == "remover" {
+		newNotes = ""
+	}
+
+	// Armazenamento dos segredos (banco local ou keyring do sistema)
+	newSecretsInKeyring := account.SecretsInKeyring
+	if account.SecretsInKeyring || keyringAvailable() {
+		storageLabel := "banco local"
+		if account.SecretsInKeyring {
+			storageLabel = "keyring do sistema"
+		}
+		fmt.Printf("\nSegredos armazenados em: %s\n", storageLabel)
+		fmt.Print("Guardar API Secret/Passphrase no keyring do sistema? (sim/s ou não/n, Enter para manter): ")
+		scanner.Scan()
+		keyringInput := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if keyringInput == "cancelar" || keyringInput == "0" {
+			return
+		}
+		if keyringInput == "sim" || keyringInput == "s" {
+			newSecretsInKeyring = true
+		} else if keyringInput == "não" || keyringInput == "nao" || keyringInput == "n" {
+			newSecretsInKeyring = false
+		}
+	}
+
 	// Verificar se a conta está sendo monitorada antes de editar
 	wasMonitored := wsManager.IsConnectionActive(account.ID)
 
 	// Atualizar conta (newMetadata == "" mantém o metadata atual)
-	if err := manager.UpdateAccount(account.ID, newName, newApiKey, newApiSecret, newWebhook, newMarkEveryoneOrder, newMarkEveryoneWallet, newWebhookURLGoogleSheets, newSheetURLGoogleSheets, newWebhookURLExecutions, newSheetURLGoogleSheetsExecutions, newMarkEveryoneExecution, newMetadata, newNotificationDelaySeconds); err != nil {
-		fmt.Printf("\nErro ao editar conta: %v\n", err)
+	if err := manager.UpdateAccount(account.ID, newName, newApiKey, newApiSecret, newWebhook, newMarkEveryoneOrder, newMarkEveryoneWallet, newWebhookURLGoogleSheets, newSheetURLGoogleSheets, newWebhookURLExecutions, newSheetURLGoogleSheetsExecutions, newMarkEveryoneExecution, newMetadata, newNotificationDelaySeconds, newTimezone, newNotes); err != nil {
+		printErrorf("\nErro ao editar conta: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 	} else {
-		fmt.Println("\nConta editada com sucesso!")
+		fmt.Println(colorGreen("\nConta editada com sucesso!"))
+
+		if newSecretsInKeyring != account.SecretsInKeyring {
+			if err := manager.SetSecretsInKeyring(account.ID, newSecretsInKeyring); err != nil {
+				printWarningf("Aviso: não foi possível alterar o armazenamento dos segredos: %v\n", err)
+			} else if newSecretsInKeyring {
+				fmt.Println("Segredos movidos para o keyring do sistema.")
+			} else {
+				fmt.Println("Segredos movidos para o banco local.")
+			}
+		}
 		
-		// Se a conta estava sendo monitorada, reiniciar o monitoramento
+		// Se a conta estava sendo monitorada, aplicar as alterações ao monitoramento (só reconecta se as credenciais mudaram)
 		if wasMonitored {
-			fmt.Println("\nReiniciando monitoramento para aplicar as alterações...")
-			// Parar o monitoramento atual
-			wsManager.StopConnection(account.ID)
-			// Aguardar um pouco para garantir que a conexão foi fechada
-			// Reiniciar o monitoramento com os dados atualizados
-			if err := wsManager.StartConnection(account.ID); err != nil {
-				fmt.Printf("Aviso: Erro ao reiniciar monitoramento: %v\n", err)
+			reconnected, err := wsManager.ReloadAccount(account.ID)
+			switch {
+			case err != nil:
+				printWarningf("Aviso: Erro ao aplicar as alterações ao monitoramento: %v\n", err)
 				fmt.Println("Por favor, reinicie o monitoramento manualmente.")
-			} else {
-				fmt.Println("Monitoramento reiniciado com sucesso!")
+			case reconnected:
+				fmt.Println(colorGreen("Credenciais alteradas: monitoramento reconectado com os dados atualizados!"))
+			default:
+				fmt.Println(colorGreen("Alterações aplicadas ao monitoramento sem reconectar!"))
+			}
+		} else if newName != account.Name {
+			// Monitorada, ReloadAccount já renomeia os arquivos de log; parada, os arquivos são renomeados aqui
+			closeLogger(account.ID)
+			if err := renameAccountLogFiles(account.ID, newName); err != nil {
+				printWarningf("Aviso: não foi possível renomear os arquivos de log: %v\n", err)
 			}
 		}
 		
@@ -877,11 +1270,14 @@ func handleEditAccount(manager *AccountManager, wsManager *WebSocketManager, sca
 	}
 }
 
-func handleStartWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
+// handleToggleAccountActive ativa ou desativa uma conta. Contas inativas não entram em "Todas as contas" nem são restauradas ao iniciar.
+func handleToggleAccountActive(manager *AccountManager, wsManager *WebSocketManager, scanner *bufio.Scanner) {
 	clearScreen()
-	accounts, err := wsManager.accountManager.ListAccounts()
+	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
 		return
 	}
 
@@ -894,17 +1290,17 @@ func handleStartWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
 
 	fmt.Println("\n=== Contas Cadastradas ===")
 	for i, acc := range accounts {
-		fmt.Printf("%d. %s\n", i+1, acc.Name)
+		fmt.Printf("%d. %s (%s)\n", i+1, acc.Name, colorStatus(getStatusText(acc.Active), acc.Active))
 	}
-	fmt.Printf("%d. Todas as contas\n", len(accounts)+1)
 	fmt.Println("0. Voltar ao menu principal")
 
-	fmt.Print("\nDigite o número da conta para monitorar (ou 0 para voltar): ")
+	fmt.Print("\nDigite o número da conta para ativar/desativar (ou 0 para voltar): ")
 	scanner.Scan()
-	input := strings.TrimSpace(scanner.Text())
 	var index int
-	if _, err := fmt.Sscanf(input, "%d", &index); err != nil {
-		fmt.Println("Número inválido!")
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
 		return
 	}
 
@@ -912,81 +1308,81 @@ func handleStartWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
 		return
 	}
 
-	if index == len(accounts)+1 {
-		// Iniciar todas as contas
-		if err := wsManager.StartAllConnections(); err != nil {
-			fmt.Printf("Erro ao iniciar monitoramento: %v\n", err)
-			fmt.Println("\nPressione Enter para voltar ao menu principal...")
-			scanner.Scan()
-		} else {
-			fmt.Println("Todas as contas estão sendo monitoradas!")
-			fmt.Println("\nPressione Enter para ver as contas monitoradas...")
-			scanner.Scan()
-			handleViewMonitoredAccounts(wsManager, scanner)
-		}
-	} else if index >= 1 && index <= len(accounts) {
-		// Iniciar conta específica
-		account := accounts[index-1]
-		if err := wsManager.StartConnection(account.ID); err != nil {
-			fmt.Printf("Erro ao iniciar monitoramento: %v\n", err)
-			fmt.Println("\nPressione Enter para voltar ao menu principal...")
-			scanner.Scan()
-		} else {
-			fmt.Printf("Monitoramento iniciado para conta '%s'!\n", account.Name)
-			fmt.Println("\nPressione Enter para ver as contas monitoradas...")
-			scanner.Scan()
-			handleViewMonitoredAccounts(wsManager, scanner)
-		}
-	} else {
-		fmt.Println("Número inválido!")
+	if index < 1 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
+		return
 	}
-}
 
-func handleStartAllWebSockets(wsManager *WebSocketManager) {
-	if err := wsManager.StartAllConnections(); err != nil {
-		fmt.Printf("Erro ao iniciar WebSockets: %v\n", err)
+	account := accounts[index-1]
+	newActive := !account.Active
+
+	action := "ativar"
+	if !newActive {
+		action = "desativar"
+	}
+	fmt.Printf("\nDeseja %s a conta '%s'? (sim/s ou não/n): ", action, account.Name)
+	scanner.Scan()
+	confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	if confirmation != "sim" && confirmation != "s" {
+		fmt.Println("\nOperação cancelada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if err := manager.SetAccountActive(account.ID, newActive); err != nil {
+		printErrorf("\nErro ao alterar status da conta: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if newActive {
+		fmt.Printf("\nConta '%s' ativada com sucesso!\n", account.Name)
 	} else {
-		fmt.Println("Todos os WebSockets iniciados!")
+		fmt.Printf("\nConta '%s' desativada com sucesso!\n", account.Name)
+		// Conta desativada não deve continuar sendo monitorada
+		if wsManager.IsConnectionActive(account.ID) {
+			wsManager.StopConnection(account.ID)
+			fmt.Println("Monitoramento da conta foi parado.")
+		}
 	}
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
 }
 
-func handleStopWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
+func handleTestNotification(manager *AccountManager, wsManager *WebSocketManager, scanner *bufio.Scanner) {
 	clearScreen()
-	accounts, err := wsManager.accountManager.ListAccounts()
+	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
 		return
 	}
 
-	activeAccounts := []*BybitAccount{}
-	for _, acc := range accounts {
-		if wsManager.IsConnectionActive(acc.ID) {
-			activeAccounts = append(activeAccounts, acc)
-		}
-	}
-
-	if len(activeAccounts) == 0 {
-		fmt.Println("Nenhuma conexão ativa.")
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
 	}
 
-	fmt.Println("\n=== Conexões Ativas ===")
-	for i, acc := range activeAccounts {
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
 		fmt.Printf("%d. %s\n", i+1, acc.Name)
 	}
-	fmt.Printf("%d. Todas as contas\n", len(activeAccounts)+1)
 	fmt.Println("0. Voltar ao menu principal")
 
-	fmt.Print("\nDigite o número da conta para parar monitoramento (ou 0 para voltar): ")
+	fmt.Print("\nDigite o número da conta (ou 0 para voltar): ")
 	scanner.Scan()
-	input := strings.TrimSpace(scanner.Text())
 	var index int
-	if _, err := fmt.Sscanf(input, "%d", &index); err != nil {
-		fmt.Println("Número inválido!")
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
 		return
 	}
 
@@ -994,23 +1390,626 @@ func handleStopWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
 		return
 	}
 
-	if index == len(activeAccounts)+1 {
-		// Parar todas as contas
-		wsManager.StopAll()
-		fmt.Println("Monitoramento de todas as contas parado!")
-		fmt.Println("\nPressione Enter para ver as contas monitoradas...")
+	account := accounts[index-1]
+	channels := testNotificationChannels(account)
+	if len(channels) == 0 {
+		fmt.Println(colorYellow("\nA conta não tem nenhum webhook configurado."))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\nEscolha o canal:")
+	for i, channel := range channels {
+		fmt.Printf("%d. %s\n", i+1, notifyChannelLabel(channel))
+	}
+	fmt.Printf("%d. Todos\n", len(channels)+1)
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nEscolha uma opção: ")
+	scanner.Scan()
+	var channelIndex int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &channelIndex); err != nil || channelIndex < 0 || channelIndex > len(channels)+1 {
+		fmt.Println(colorRed("Opção inválida!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if channelIndex == 0 {
+		return
+	}
+
+	selected := channels
+	if channelIndex <= len(channels) {
+		selected = channels[channelIndex-1 : channelIndex]
+	}
+
+	fmt.Println()
+	for _, channel := range selected {
+		if err := sendTestNotification(wsManager, account, channel); err != nil {
+			printErrorf("❌ %s: %v\n", notifyChannelLabel(channel), err)
+		} else {
+			fmt.Printf("%s %s: enviada\n", colorGreen("✅"), notifyChannelLabel(channel))
+		}
+	}
+
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
+}
+
+func handleSendWalletSummary(manager *AccountManager, wsManager *WebSocketManager, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := manager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
+		fmt.Printf("%d. %s\n", i+1, acc.Name)
+	}
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para enviar o resumo (ou 0 para voltar): ")
+	scanner.Scan()
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	account := accounts[index-1]
+	messageText, err := wsManager.SendWalletSummaryNow(account)
+	if messageText != "" {
+		fmt.Printf("\n=== Resumo da conta '%s' ===\n\n%s\n", account.Name, messageText)
+	}
+	if err != nil {
+		printErrorf("\nErro ao enviar resumo: %v\n", err)
+	} else if _, hasBot := accountDiscordBot(account); account.WebhookURL == "" && !hasBot {
+		fmt.Println(colorYellow("\nA conta não tem webhook nem bot do Discord; o resumo foi apenas exibido aqui."))
+	} else {
+		fmt.Println(colorGreen("\nResumo enviado para o Discord!"))
+	}
+
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
+}
+
+func handleViewAccountStats(manager *AccountManager, wsManager *WebSocketManager, db *Database, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := manager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
+		fmt.Printf("%d. %s\n", i+1, acc.Name)
+	}
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para ver as estatísticas (ou 0 para voltar): ")
+	scanner.Scan()
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	account := accounts[index-1]
+	stats, err := getAccountStatsView(db, account.ID)
+	if err != nil {
+		printErrorf("Erro ao ler estatísticas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	clearScreen()
+	fmt.Printf("\n=== Estatísticas da conta '%s' ===\n\n", account.Name)
+	if !stats.MonitoringStartedAt.IsZero() {
+		fmt.Printf("Monitoramento iniciado em: %s\n", stats.MonitoringStartedAt.In(loadTimezone(account.Timezone)).Format("02/01/2006 15:04:05"))
+	}
+	if health, ok := wsManager.GetConnectionHealth(account.ID); ok && health.Connected {
+		fmt.Printf("Conectado há: %s\n", formatElapsed(time.Since(health.ConnectedAt)))
+	} else {
+		fmt.Printf("Conexão: %s\n", colorYellow("não monitorada"))
+	}
+	fmt.Printf("Reconexões: %d\n", stats.Reconnects)
+	fmt.Printf("Notificações enviadas: %d\n", stats.NotificationsSent)
+	fmt.Printf("Falhas de webhook: %s\n", colorStatus(fmt.Sprintf("%d", stats.WebhookFailures), stats.WebhookFailures == 0))
+
+	fmt.Println("\nMensagens recebidas por tópico:")
+	topics := stats.sortedTopics()
+	if len(topics) == 0 {
+		fmt.Println("  (nenhuma)")
+	}
+	for _, topic := range topics {
+		fmt.Printf("  %-14s %d\n", topic, stats.MessagesByTopic[topic])
+	}
+
+	if daily, err := getDailyStats(db, account.ID, dailyStatsDays); err != nil {
+		printErrorf("Erro ao ler estatísticas diárias: %v\n", err)
+	} else {
+		fmt.Printf("\nÚltimos %d dias (UTC):\n", dailyStatsDays)
+		fmt.Printf("  %-10s %8s %7s %7s %9s %14s %10s\n", "Dia", "Notific.", "Falhas", "Ordens", "Execuções", "Volume (USD)", "Reconexões")
+		for _, day := range daily {
+			date, _ := time.Parse("2006-01-02", day.Day)
+			fmt.Printf("  %-10s %8d %7d %7d %9d %14.0f %10d\n", date.Format("02/01/2006"), day.NotificationsSent, day.WebhookFailures,
+				day.OrdersSeen, day.Executions, day.VolumeUSD, day.Reconnects)
+		}
+	}
+
+	if checkpoints := getStreamCheckpoints(account.ID); len(checkpoints) > 0 {
+		fmt.Println("\nÚltimo evento processado por tópico:")
+		checkpointTopics := make([]string, 0, len(checkpoints))
+		for topic := range checkpoints {
+			checkpointTopics = append(checkpointTopics, topic)
+		}
+		sort.Strings(checkpointTopics)
+		for _, topic := range checkpointTopics {
+			fmt.Printf("  %-14s %s\n", topic, checkpoints[topic].In(loadTimezone(account.Timezone)).Format("02/01/2006 15:04:05"))
+		}
+	}
+	if !stats.UpdatedAt.IsZero() {
+		fmt.Println(colorDim(fmt.Sprintf("\nÚltima atualização: %s", stats.UpdatedAt.In(loadTimezone(account.Timezone)).Format("02/01/2006 15:04:05"))))
+	}
+
+	fmt.Print("\nDigite 'zerar' para zerar as estatísticas ou Enter para voltar: ")
+	scanner.Scan()
+	if strings.ToLower(strings.TrimSpace(scanner.Text())) == "zerar" {
+		if err := db.ResetAccountStats(account.ID); err != nil {
+			printErrorf("Erro ao zerar estatísticas: %v\n", err)
+		} else {
+			fmt.Println(colorGreen("Estatísticas zeradas!"))
+		}
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+	}
+}
+
+// notificationHistoryPageSize é quantas notificações o histórico mostra por vez no menu.
+const notificationHistoryPageSize = 20
+
+// notificationSummary retorna a primeira linha da mensagem, cortada em 80 caracteres, para as listagens.
+func notificationSummary(message string) string {
+	summary, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	if len([]rune(summary)) > 80 {
+		summary = string([]rune(summary)[:80]) + "..."
+	}
+	return summary
+}
+
+func handleNotificationHistory(manager *AccountManager, wsManager *WebSocketManager, db *Database, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := manager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
+		fmt.Printf("%d. %s\n", i+1, acc.Name)
+	}
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para ver o histórico (ou 0 para voltar): ")
+	scanner.Scan()
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	account := accounts[index-1]
+	status := "" // filtro: "" (todas), na fila, enviadas, falhas ou registradas
+	for {
+		var records []NotificationRecord
+		if status != notificationStatusQueued {
+			records, err = db.ListNotifications(account.ID, HistoryFilter{Status: status, Limit: notificationHistoryPageSize})
+			if err != nil {
+				printErrorf("Erro ao ler o histórico: %v\n", err)
+				fmt.Println("\nPressione Enter para voltar ao menu principal...")
+				scanner.Scan()
+				return
+			}
+		}
+		var queued []QueuedNotification
+		if status == "" || status == notificationStatusQueued {
+			queued, _ = wsManager.GetQueuedNotifications(account.ID)
+		}
+
+		clearScreen()
+		switch status {
+		case "":
+			fmt.Printf("\n=== Últimas notificações da conta '%s' ===\n\n", account.Name)
+		case notificationStatusQueued:
+			fmt.Printf("\n=== Fila de envio da conta '%s' ===\n\n", account.Name)
+		default:
+			fmt.Printf("\n=== Últimas notificações da conta '%s' (%s) ===\n\n", account.Name, status)
+		}
+		if status == "" && len(records) == 0 && len(queued) == 0 {
+			fmt.Println("Nenhuma notificação registrada.")
+			fmt.Println("\nPressione Enter para voltar ao menu principal...")
+			scanner.Scan()
+			return
+		}
+		tz := loadTimezone(account.Timezone)
+		if len(queued) > 0 {
+			fmt.Printf("--- Na fila de envio (%d) ---\n", len(queued))
+			for _, q := range queued {
+				fmt.Printf("[fila %d] %s | %-20s | %s\n", q.ID, q.QueuedAt.In(tz).Format("02/01/2006 15:04:05"), notifyChannelLabel(q.Channel), colorYellow(q.Status))
+				fmt.Printf("     %s\n", notificationSummary(q.Message))
+			}
+			if status == "" {
+				fmt.Println("\n--- Histórico ---")
+			}
+		}
+		if len(records) == 0 && len(queued) == 0 {
+			fmt.Println("Nenhuma notificação.")
+		}
+		for _, r := range records {
+			statusText := colorStatus(r.Status, r.Status == notificationStatusSent)
+			if r.Status == notificationStatusLogged {
+				statusText = colorYellow(r.Status)
+			}
+			fmt.Printf("[%d] %s | %-20s | %s\n", r.ID, r.CreatedAt.In(tz).Format("02/01/2006 15:04:05"), notifyChannelLabel(r.Channel), statusText)
+			fmt.Printf("     %s\n", notificationSummary(r.Message))
+			if r.Error != "" {
+				fmt.Printf("     %s\n", colorRed(fmt.Sprintf("Erro (%s): %s", deliveryAttemptsText(r.Delivery()), r.Error)))
+			} else if r.Attempts > 1 {
+				fmt.Printf("     Entregue após %d tentativas\n", r.Attempts)
+			}
+			if len(r.CorrelationIDs) > 0 {
+				fmt.Printf("     %s\n", colorDim("Origem: "+correlationTag(r.CorrelationIDs...)))
+			}
+		}
+
+		fmt.Print("\nDigite o ID da notificação para reenviar, F (só falhas), E (só enviadas), R (só registradas), P (fila de envio), T (todas) ou 0 para voltar: ")
+		scanner.Scan()
+		switch strings.ToUpper(strings.TrimSpace(scanner.Text())) {
+		case "F":
+			status = notificationStatusFailed
+			continue
+		case "E":
+			status = notificationStatusSent
+			continue
+		case "R":
+			status = notificationStatusLogged
+			continue
+		case "P":
+			status = notificationStatusQueued
+			continue
+		case "T":
+			status = ""
+			continue
+		}
+		var id int64
+		if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &id); err != nil || id == 0 {
+			return
+		}
+		record, err := db.GetNotification(id)
+		if err != nil || record.AccountID != account.ID {
+			fmt.Println(colorRed("Notificação não encontrada!"))
+		} else if err := resendNotification(account, record); err != nil {
+			printErrorf("Erro ao reenviar: %v\n", err)
+		} else {
+			fmt.Println(colorGreen("Notificação reenviada!"))
+		}
+		fmt.Println("\nPressione Enter para continuar...")
+		scanner.Scan()
+	}
+}
+
+// orderHistoryLimit é o máximo de eventos exibidos por consulta no histórico de ordens.
+const orderHistoryLimit = 50
+
+// parseHistoryDate lê uma data DD/MM/AAAA no fuso da conta (vazio = sem filtro).
+func parseHistoryDate(input string, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("02/01/2006", input, loc)
+}
+
+func handleOrderHistory(manager *AccountManager, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := manager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
+		fmt.Printf("%d. %s\n", i+1, acc.Name)
+	}
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para ver o histórico de ordens (ou 0 para voltar): ")
+	scanner.Scan()
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	account := accounts[index-1]
+	tz := loadTimezone(account.Timezone)
+	filter := HistoryFilter{Limit: orderHistoryLimit}
+
+	fmt.Print("Símbolo (ex.: BTCUSD, Enter para todos): ")
+	scanner.Scan()
+	filter.Symbol = strings.TrimSpace(scanner.Text())
+
+	fmt.Print("Data inicial (DD/MM/AAAA, Enter para sem limite): ")
+	scanner.Scan()
+	if filter.From, err = parseHistoryDate(scanner.Text(), tz); err != nil {
+		fmt.Println(colorRed("Data inválida!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Print("Data final (DD/MM/AAAA, inclusive, Enter para sem limite): ")
+	scanner.Scan()
+	to, err := parseHistoryDate(scanner.Text(), tz)
+	if err != nil {
+		fmt.Println(colorRed("Data inválida!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+	if !to.IsZero() {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	events, err := manager.ListOrderEvents(account.ID, filter)
+	if err != nil {
+		printErrorf("Erro ao ler o histórico de ordens: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	clearScreen()
+	fmt.Printf("\n=== Histórico de ordens da conta '%s' ===\n\n", account.Name)
+	if len(events) == 0 {
+		fmt.Println("Nenhuma atualização de ordem encontrada.")
+	}
+	for _, e := range events {
+		transition := e.Status
+		if e.PreviousStatus != "" && e.PreviousStatus != e.Status {
+			transition = e.PreviousStatus + " → " + e.Status
+		}
+		orderType := e.OrderType
+		if e.StopOrderType != "" {
+			orderType += "/" + e.StopOrderType
+		}
+		fmt.Printf("%s | %-10s | %-4s %-18s | %-28s | %s @ %s\n", e.EventTime.In(tz).Format("02/01/2006 15:04:05"), e.Symbol, e.Side, orderType, transition, e.Qty, e.Price)
+		fmt.Println(colorDim("    " + e.OrderID))
+	}
+	if len(events) == orderHistoryLimit {
+		fmt.Println(colorDim(fmt.Sprintf("\nExibindo as %d atualizações mais recentes; use os filtros para ver as anteriores.", orderHistoryLimit)))
+	}
+
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
+}
+
+func handleStartWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := wsManager.accountManager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		return
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	authFailures, _ := wsManager.accountManager.GetAuthFailures()
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
+		if failure, failed := authFailures[acc.ID]; failed {
+			fmt.Printf("%d. %s %s\n", i+1, acc.Name, colorRed("("+authFailureText(failure, loadTimezone(acc.Timezone))+")"))
+			continue
+		}
+		fmt.Printf("%d. %s\n", i+1, acc.Name)
+	}
+	fmt.Printf("%d. Todas as contas\n", len(accounts)+1)
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para monitorar (ou 0 para voltar): ")
+	scanner.Scan()
+	input := strings.TrimSpace(scanner.Text())
+	var index int
+	if _, err := fmt.Sscanf(input, "%d", &index); err != nil {
+		fmt.Println(colorRed("Número inválido!"))
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	if index == len(accounts)+1 {
+		// Iniciar todas as contas
+		if err := wsManager.StartAllConnections(); err != nil {
+			printErrorf("Erro ao iniciar monitoramento: %v\n", err)
+			fmt.Println("\nPressione Enter para voltar ao menu principal...")
+			scanner.Scan()
+		} else {
+			fmt.Println("Todas as contas estão sendo monitoradas!")
+			if len(authFailures) > 0 {
+				fmt.Println(colorYellow("Contas com autenticação recusada não foram iniciadas; corrija a API key e inicie cada uma individualmente."))
+			}
+			fmt.Println("\nPressione Enter para ver as contas monitoradas...")
+			scanner.Scan()
+			handleViewMonitoredAccounts(wsManager, scanner)
+		}
+	} else if index >= 1 && index <= len(accounts) {
+		// Iniciar conta específica
+		account := accounts[index-1]
+		if err := wsManager.StartConnection(account.ID); err != nil {
+			printErrorf("Erro ao iniciar monitoramento: %v\n", err)
+			fmt.Println("\nPressione Enter para voltar ao menu principal...")
+			scanner.Scan()
+		} else {
+			fmt.Printf("Monitoramento iniciado para conta '%s'!\n", account.Name)
+			fmt.Println("\nPressione Enter para ver as contas monitoradas...")
+			scanner.Scan()
+			handleViewMonitoredAccounts(wsManager, scanner)
+		}
+	} else {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+	}
+}
+
+func handleStartAllWebSockets(wsManager *WebSocketManager) {
+	if err := wsManager.StartAllConnections(); err != nil {
+		printErrorf("Erro ao iniciar WebSockets: %v\n", err)
+	} else {
+		fmt.Println("Todos os WebSockets iniciados!")
+	}
+}
+
+func handleStopWebSocket(wsManager *WebSocketManager, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := wsManager.accountManager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		return
+	}
+
+	activeAccounts := []*BybitAccount{}
+	for _, acc := range accounts {
+		if wsManager.IsConnectionActive(acc.ID) {
+			activeAccounts = append(activeAccounts, acc)
+		}
+	}
+
+	if len(activeAccounts) == 0 {
+		fmt.Println("Nenhuma conexão ativa.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Conexões Ativas ===")
+	for i, acc := range activeAccounts {
+		if health, ok := wsManager.GetConnectionHealth(acc.ID); ok && health.Paused {
+			fmt.Printf("%d. %s %s\n", i+1, acc.Name, colorYellow("("+health.pauseStatus()+")"))
+			continue
+		}
+		fmt.Printf("%d. %s\n", i+1, acc.Name)
+	}
+	fmt.Printf("%d. Todas as contas\n", len(activeAccounts)+1)
+	fmt.Println("0. Voltar ao menu principal")
+
+	fmt.Print("\nDigite o número da conta para parar monitoramento (ou 0 para voltar): ")
+	scanner.Scan()
+	input := strings.TrimSpace(scanner.Text())
+	var index int
+	if _, err := fmt.Sscanf(input, "%d", &index); err != nil {
+		fmt.Println(colorRed("Número inválido!"))
+		return
+	}
+
+	if index == 0 {
+		return
+	}
+
+	if index == len(activeAccounts)+1 {
+		// Parar todas as contas
+		wsManager.StopAll()
+		fmt.Println("Monitoramento de todas as contas parado!")
+		fmt.Println("\nPressione Enter para ver as contas monitoradas...")
 		scanner.Scan()
 		handleViewMonitoredAccounts(wsManager, scanner)
 	} else if index >= 1 && index <= len(activeAccounts) {
-		// Parar conta específica
+		// Parar, pausar ou retomar conta específica
 		account := activeAccounts[index-1]
-		wsManager.StopConnection(account.ID)
-		fmt.Printf("Monitoramento parado para conta '%s'!\n", account.Name)
+		if !handlePauseChoice(wsManager, account, scanner) {
+			wsManager.StopConnection(account.ID)
+			fmt.Printf("Monitoramento parado para conta '%s'!\n", account.Name)
+		}
 		fmt.Println("\nPressione Enter para ver as contas monitoradas...")
 		scanner.Scan()
 		handleViewMonitoredAccounts(wsManager, scanner)
 	} else {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 	}
@@ -1021,6 +2020,42 @@ func handleStopAllWebSockets(wsManager *WebSocketManager) {
 	fmt.Println("Todos os WebSockets parados!")
 }
 
+// checkDiscordWebhookOnSave valida o formato da URL do webhook e, se o usuário quiser, envia uma mensagem
+// de teste, para que um 404/401 apareça no cadastro e não só na primeira notificação real.
+// Retorna false se o cadastro/edição deve ser interrompido.
+func checkDiscordWebhookOnSave(scanner *bufio.Scanner, webhookURL, label string) bool {
+	if webhookURL == "" {
+		return true
+	}
+	if !validateDiscordWebhookURL(webhookURL) {
+		fmt.Println(colorRed(fmt.Sprintf("Erro: %s inválido!", label)))
+		fmt.Println("Formato esperado: https://discord.com/api/webhooks/.../...")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return false
+	}
+
+	fmt.Print("Enviar uma mensagem de teste para este webhook agora? (sim/s ou não/n, padrão: sim): ")
+	scanner.Scan()
+	testInput := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	if testInput == "cancelar" || testInput == "0" {
+		return false
+	}
+	if testInput == "não" || testInput == "nao" || testInput == "n" {
+		return true
+	}
+
+	if err := sendDiscordWebhook(webhookURL, fmt.Sprintf("🔔 Teste do notificador: %s configurado com sucesso.", label)); err != nil {
+		printErrorf("❌ O teste do %s falhou: %v\n", label, err)
+		fmt.Print("Deseja salvar mesmo assim? (sim/s ou não/n): ")
+		scanner.Scan()
+		confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		return confirmation == "sim" || confirmation == "s"
+	}
+	fmt.Println(colorGreen("✅ Mensagem de teste enviada"))
+	return true
+}
+
 func maskAPIKey(key string) string {
 	if len(key) <= 8 {
 		return "****"
@@ -1042,11 +2077,73 @@ func getBooleanText(value bool) string {
 	return "Não"
 }
 
+// formatElapsed formata uma duração de forma curta (ex.: 45s, 3m12s, 2h05m).
+func formatElapsed(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// printConnectionHealth exibe saúde da conexão: estado, última mensagem recebida e reconexões.
+func printConnectionHealth(wsManager *WebSocketManager, accountID int64) {
+	health, ok := wsManager.GetConnectionHealth(accountID)
+	if !ok {
+		return
+	}
+	switch {
+	case health.Paused:
+		fmt.Printf("   Saúde: %s\n", colorYellow(health.pauseStatus()))
+	case !health.Connected:
+		fmt.Printf("   Saúde: %s\n", colorRed("desconectada (reconectando)"))
+	case health.Stale:
+		fmt.Printf("   Saúde: %s\n", colorYellow("sem dados (conexão pode estar travada)"))
+	default:
+		fmt.Printf("   Saúde: %s\n", colorGreen("saudável"))
+	}
+	if !health.LastMessageAt.IsZero() {
+		fmt.Printf("   Última mensagem: há %s\n", formatElapsed(time.Since(health.LastMessageAt)))
+	} else {
+		fmt.Printf("   Última mensagem: nenhuma\n")
+	}
+	if health.Connected && !health.ConnectedAt.IsZero() {
+		fmt.Printf("   Conectada há: %s\n", formatElapsed(time.Since(health.ConnectedAt)))
+	}
+	fmt.Printf("   Reconexões: %d\n", health.ReconnectCount)
+	if account, err := wsManager.accountManager.GetAccount(accountID); err == nil {
+		if next, ok := nextScheduledReconnect(account, time.Now()); ok {
+			fmt.Printf("   Próxima reconexão programada: %s\n", next.Format("02/01 15:04"))
+		}
+	}
+	if health.Endpoint != "" {
+		fmt.Printf("   Endpoint: %s\n", colorYellow("alternativo ("+health.Endpoint+")"))
+	}
+	if len(health.UnackedTopics) > 0 {
+		fmt.Printf("   Inscrições sem confirmação: %s\n", colorYellow(strings.Join(health.UnackedTopics, ", ")))
+	}
+	if stats, ok := wsManager.GetConnectionStats(accountID); ok {
+		fmt.Printf("   Mensagens: %s; %s em %d frame(s) desde %s\n", stats.formatMessages(), formatByteSize(stats.BytesReceived),
+			stats.FramesReceived, stats.MonitoringSince.Format("02/01 15:04"))
+		if stats.LastError != "" {
+			fmt.Printf("   Último erro: %s (há %s)\n", stats.LastError, formatElapsed(time.Since(*stats.LastErrorAt)))
+		}
+	}
+	if resources, ok := wsManager.GetConnectionResources(accountID); ok {
+		fmt.Printf("   Recursos: %s\n", resources)
+	}
+}
+
 func handleViewMonitoredAccounts(wsManager *WebSocketManager, scanner *bufio.Scanner) {
 	clearScreen()
 	accounts, err := wsManager.accountManager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
 		return
 	}
 
@@ -1057,6 +2154,8 @@ func handleViewMonitoredAccounts(wsManager *WebSocketManager, scanner *bufio.Sca
 		}
 	}
 
+	failedDeliveries, _ := wsManager.db.CountFailedNotifications(time.Now().Add(-failedDeliveriesWindow))
+
 	fmt.Println("\n=== Contas Monitoradas (WebSocket Ativo) ===")
 	if len(monitoredAccounts) == 0 {
 		fmt.Println("Nenhuma conta está sendo monitorada no momento.")
@@ -1069,12 +2168,21 @@ func handleViewMonitoredAccounts(wsManager *WebSocketManager, scanner *bufio.Sca
 			} else {
 				fmt.Printf("   Webhook Discord: Não configurado (notificações no terminal)\n")
 			}
+			if _, ok := accountDiscordBot(acc); ok {
+				fmt.Printf("   Bot do Discord: Configurado (canal principal)\n")
+			}
 			if acc.WebhookURLGoogleSheets != "" && acc.SheetURLGoogleSheets != "" {
 				fmt.Printf("   Webhook Google Planilhas: Configurado\n")
 			} else {
 				fmt.Printf("   Webhook Google Planilhas: Não configurado\n")
 			}
-			fmt.Printf("   Status: Monitorando\n")
+			if isLogOnly(acc) {
+				fmt.Printf("   Status: %s\n", colorYellow("Monitorando (modo só registro, sem envio de notificações)"))
+			} else {
+				fmt.Printf("   Status: %s\n", colorGreen("Monitorando"))
+			}
+			printConnectionHealth(wsManager, acc.ID)
+			fmt.Printf("   Entregas com falha (24h): %s\n", colorStatus(fmt.Sprintf("%d", failedDeliveries[acc.ID]), failedDeliveries[acc.ID] == 0))
 			fmt.Printf("   Marcar @everyone em ordens: %s\n", getBooleanText(acc.MarkEveryoneOrder))
 			fmt.Printf("   Marcar @everyone em carteira: %s\n", getBooleanText(acc.MarkEveryoneWallet))
 			if acc.WebhookURLExecutions != "" {
@@ -1091,16 +2199,43 @@ func handleViewMonitoredAccounts(wsManager *WebSocketManager, scanner *bufio.Sca
 		}
 		fmt.Printf("\nTotal: %d conta(s) sendo monitorada(s)\n", len(monitoredAccounts))
 	}
+
+	// Conexão pública compartilhada (publicstream.go); só aparece quando algum recurso usa tópicos públicos
+	if public := wsManager.PublicStreams().Status(); len(public.Topics) > 0 {
+		fmt.Printf("\nStream público Bybit: %s\n", colorStatus(public.String(), public.Connected && !public.Stale))
+	}
+
+	// Linhas de active_connections sem heartbeat recente e que não pertencem a este processo
+	if heartbeats, err := wsManager.accountManager.GetConnectionHeartbeats(); err == nil {
+		var orphans []string
+		for _, acc := range accounts {
+			heartbeat, registered := heartbeats[acc.ID]
+			if !registered || wsManager.IsConnectionActive(acc.ID) || time.Since(heartbeat) < connectionHeartbeatStaleAfter {
+				continue
+			}
+			last := "nunca"
+			if !heartbeat.IsZero() {
+				last = heartbeat.In(loadTimezone(acc.Timezone)).Format("02/01/2006 15:04:05")
+			}
+			orphans = append(orphans, fmt.Sprintf("   %s (último heartbeat: %s)", acc.Name, last))
+		}
+		if len(orphans) > 0 {
+			fmt.Println(colorYellow("\n⚠️  Conexões registradas sem heartbeat recente (outro processo parou sem limpar?):"))
+			for _, line := range orphans {
+				fmt.Println(line)
+			}
+		}
+	}
 	
 	fmt.Println("\nPressione Enter para voltar ao menu principal...")
 	scanner.Scan()
 }
 
-func handleViewLogs(manager *AccountManager, scanner *bufio.Scanner) {
+func handleViewLogs(manager AccountRepo, scanner *bufio.Scanner) {
 	clearScreen()
 	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
 		return
 	}
 
@@ -1121,7 +2256,7 @@ func handleViewLogs(manager *AccountManager, scanner *bufio.Scanner) {
 	scanner.Scan()
 	var index int
 	if _, err := fmt.Sscanf(scanner.Text(), "%d", &index); err != nil {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		return
 	}
 
@@ -1130,7 +2265,7 @@ func handleViewLogs(manager *AccountManager, scanner *bufio.Scanner) {
 	}
 
 	if index < 1 || index > len(accounts) {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		return
 	}
 
@@ -1149,47 +2284,115 @@ func handleViewLogs(manager *AccountManager, scanner *bufio.Scanner) {
 		return
 	}
 
+	if viewChoice != "1" && viewChoice != "2" {
+		return
+	}
+
+	filter, ok := promptLogFilter(scanner)
+	if !ok {
+		return
+	}
+
 	switch viewChoice {
 	case "1":
-		viewLogFile(account.ID, account.Name, scanner)
+		viewLogFile(account.ID, account.Name, filter, scanner)
 	case "2":
-		viewLogTail(account.ID, account.Name, scanner)
+		viewLogTail(account.ID, account.Name, filter, scanner)
+	}
+}
+
+// promptLogFilter pergunta qual filtro aplicar na visualização de logs. Retorna false se o usuário voltar ou digitar algo inválido.
+func promptLogFilter(scanner *bufio.Scanner) (LogFilter, bool) {
+	var filter LogFilter
+	fmt.Println("\n=== Filtro ===")
+	fmt.Println("1. Sem filtro")
+	fmt.Println("2. Ocultar [DEBUG]")
+	fmt.Println("3. Somente erros")
+	fmt.Println("4. Buscar texto")
+	fmt.Println("5. Buscar por expressão regular")
+	fmt.Println("0. Voltar ao menu anterior")
+	fmt.Print("Escolha uma opção (Enter=sem filtro): ")
+	scanner.Scan()
+	choice := strings.TrimSpace(scanner.Text())
+
+	switch choice {
+	case "", "1":
+		return filter, true
+	case "2":
+		filter.HideDebug = true
+		return filter, true
+	case "3":
+		filter.ErrorsOnly = true
+		return filter, true
+	case "4", "5":
 	default:
+		return filter, false
 	}
+
+	fmt.Print("Texto a buscar: ")
+	scanner.Scan()
+	pattern := strings.TrimSpace(scanner.Text())
+	if pattern == "" {
+		return filter, true
+	}
+	if choice == "4" {
+		// Busca por texto não diferencia maiúsculas de minúsculas
+		pattern = "(?i)" + regexp.QuoteMeta(pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		printErrorf("Expressão regular inválida: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return filter, false
+	}
+	filter.Pattern = re
+	// Na busca, as linhas de [DEBUG] continuam visíveis apenas se o usuário confirmar
+	fmt.Print("Incluir linhas [DEBUG] na busca? (sim/s ou não/n, padrão: sim): ")
+	scanner.Scan()
+	includeDebug := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	filter.HideDebug = includeDebug == "não" || includeDebug == "nao" || includeDebug == "n"
+	return filter, true
 }
 
-func viewLogFile(accountID int64, accountName string, scanner *bufio.Scanner) {
+func viewLogFile(accountID int64, accountName string, filter LogFilter, scanner *bufio.Scanner) {
 	clearScreen()
-	lines, err := readLogFile(accountID, 1000)
+	lines, err := readFilteredLogFile(accountID, 1000, filter)
 	if err != nil {
-		fmt.Printf("Erro ao ler arquivo de log: %v\n", err)
+		printErrorf("Erro ao ler arquivo de log: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu anterior...")
 		scanner.Scan()
 		return
 	}
 
 	if len(lines) == 0 {
-		fmt.Printf("\nNenhum log encontrado para a conta '%s'.\n", accountName)
+		if filter.IsEmpty() {
+			fmt.Printf("\nNenhum log encontrado para a conta '%s'.\n", accountName)
+		} else {
+			fmt.Printf("\nNenhuma linha de log da conta '%s' corresponde ao filtro (%s).\n", accountName, filter.Description())
+		}
 		fmt.Println("\nPressione Enter para voltar ao menu anterior...")
 		scanner.Scan()
 		return
 	}
 
-	fmt.Printf("\n=== Logs da conta '%s' (últimas %d linhas) ===\n\n", accountName, len(lines))
-	for _, line := range lines {
-		fmt.Println(line)
+	title := fmt.Sprintf("=== Logs da conta '%s' (últimas %d linhas) ===", accountName, len(lines))
+	if !filter.IsEmpty() {
+		title = fmt.Sprintf("=== Logs da conta '%s' (últimas %d linhas; %s) ===", accountName, len(lines), filter.Description())
 	}
-	fmt.Println("\n=== Fim dos logs ===")
-	fmt.Println("\nPressione Enter para voltar ao menu anterior...")
-	scanner.Scan()
+	runPager(title, lines, scanner)
 }
 
-func viewLogTail(accountID int64, accountName string, scanner *bufio.Scanner) {
+func viewLogTail(accountID int64, accountName string, filter LogFilter, scanner *bufio.Scanner) {
 	clearScreen()
-	fmt.Printf("\n=== Tail dos logs da conta '%s' ===\n", accountName)
+	if filter.IsEmpty() {
+		fmt.Printf("\n=== Tail dos logs da conta '%s' ===\n", accountName)
+	} else {
+		fmt.Printf("\n=== Tail dos logs da conta '%s' (%s) ===\n", accountName, filter.Description())
+	}
 	fmt.Println("\n═══════════════════════════════════════════════════════════")
 	fmt.Println("  Pressione ENTER para parar e voltar ao menu principal")
-	fmt.Println("═══════════════════════════════════════════════════════════\n")
+	fmt.Print("═══════════════════════════════════════════════════════════\n\n")
 
 	stopChan := make(chan struct{})
 	lineChan := make(chan string, 100)
@@ -1202,7 +2405,10 @@ func viewLogTail(accountID int64, accountName string, scanner *bufio.Scanner) {
 
 	// Goroutine para fazer tail do arquivo
 	go func() {
-		err := tailLogFile(accountID, stopChan, func(line string) {
+		err := tailLogFile(accountID, filter.Pattern, stopChan, func(line string) {
+			if !filter.Match(line) {
+				return
+			}
 			select {
 			case lineChan <- line:
 			case <-stopChan:
@@ -1210,7 +2416,7 @@ func viewLogTail(accountID int64, accountName string, scanner *bufio.Scanner) {
 			}
 		})
 		if err != nil {
-			fmt.Printf("Erro ao fazer tail do log: %v\n", err)
+			printErrorf("Erro ao fazer tail do log: %v\n", err)
 		}
 		close(lineChan)
 	}()
@@ -1219,22 +2425,22 @@ func viewLogTail(accountID int64, accountName string, scanner *bufio.Scanner) {
 	for {
 		select {
 		case <-stopChan:
-			fmt.Println("\n=== Parando visualização de logs ===\n")
+			fmt.Print("\n=== Parando visualização de logs ===\n\n")
 			return
 		case line, ok := <-lineChan:
 			if !ok {
 				return
 			}
-			fmt.Println(line)
+			fmt.Println(colorLogLine(line))
 		}
 	}
 }
 
-func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio.Scanner) {
+func handleManageSnapshots(manager AccountRepo, db *Database, scanner *bufio.Scanner) {
 	clearScreen()
 	accounts, err := manager.ListAccounts()
 	if err != nil {
-		fmt.Printf("Erro ao listar contas: %v\n", err)
+		printErrorf("Erro ao listar contas: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -1262,7 +2468,7 @@ func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio
 	scanner.Scan()
 	var accountIndex int
 	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &accountIndex); err != nil {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -1271,7 +2477,7 @@ func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio
 		return
 	}
 	if accountIndex < 1 || accountIndex > len(accounts) {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -1282,7 +2488,7 @@ func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio
 
 	rows, err := db.ListLastMessageSnapshots(accountID)
 	if err != nil {
-		fmt.Printf("Erro ao listar snapshots: %v\n", err)
+		printErrorf("Erro ao listar snapshots: %v\n", err)
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -1308,7 +2514,7 @@ func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio
 	scanner.Scan()
 	var index int
 	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -1317,7 +2523,7 @@ func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio
 		return
 	}
 	if index < 1 || index > len(rows) {
-		fmt.Println("Número inválido!")
+		fmt.Println(colorRed("Número inválido!"))
 		fmt.Println("\nPressione Enter para voltar ao menu principal...")
 		scanner.Scan()
 		return
@@ -1334,7 +2540,7 @@ func handleManageSnapshots(manager *AccountManager, db *Database, scanner *bufio
 	}
 
 	if err := db.DeleteLastMessageSnapshot(accountID, selected.MessageType, selected.Symbol); err != nil {
-		fmt.Printf("Erro ao excluir snapshot: %v\n", err)
+		printErrorf("Erro ao excluir snapshot: %v\n", err)
 	} else {
 		fmt.Println("Snapshot excluído com sucesso!")
 	}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// settingMasterPassword guarda o hash da senha mestra no formato pbkdf2-sha256$<iterações>$<salt hex>$<hash hex>.
+const settingMasterPassword = "master_password"
+
+const masterPasswordIterations = 600000
+
+// masterPasswordUnlockDuration é por quanto tempo a senha mestra fica desbloqueada no menu depois de digitada.
+const masterPasswordUnlockDuration = 5 * time.Minute
+
+const masterPasswordMaxAttempts = 3
+
+// masterUnlockedUntil é o fim do desbloqueio atual (apenas no processo do menu interativo).
+var masterUnlockedUntil time.Time
+
+func hashMasterPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2.Key([]byte(password), salt, masterPasswordIterations, 32, sha256.New)
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", masterPasswordIterations, hex.EncodeToString(salt), hex.EncodeToString(key)), nil
+}
+
+func verifyMasterPassword(stored, password string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(pbkdf2.Key([]byte(password), salt, iterations, len(expected), sha256.New), expected)
+}
+
+// requireMasterPassword pede a senha mestra antes de uma ação sensível (exibir contas, alterar credenciais, exportar).
+// Sem senha mestra configurada, ou dentro do período de desbloqueio, libera direto.
+func requireMasterPassword(db *Database, scanner *bufio.Scanner) bool {
+	stored, exists, err := db.GetSetting(settingMasterPassword)
+	if err != nil {
+		printErrorf("Erro ao ler a senha mestra: %v\n", err)
+		return false
+	}
+	if !exists || time.Now().Before(masterUnlockedUntil) {
+		return true
+	}
+
+	for attempt := 1; attempt <= masterPasswordMaxAttempts; attempt++ {
+		fmt.Print("🔒 Senha mestra: ")
+		if verifyMasterPassword(stored, readSecretLine(scanner)) {
+			masterUnlockedUntil = time.Now().Add(masterPasswordUnlockDuration)
+			return true
+		}
+		fmt.Println(colorRed("Senha incorreta!"))
+	}
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
+	return false
+}
+
+// requireMasterPasswordCLI é a versão dos subcomandos: sem desbloqueio por tempo e retornando erro.
+func requireMasterPasswordCLI(db *Database) error {
+	stored, exists, err := db.GetSetting(settingMasterPassword)
+	if err != nil || !exists {
+		return err
+	}
+	fmt.Fprint(os.Stderr, "🔒 Senha mestra: ")
+	if !verifyMasterPassword(stored, readSecretLine(cliStdinScanner)) {
+		return errors.New("senha mestra incorreta")
+	}
+	return nil
+}
+
+func runMasterPasswordCommand(db *Database, args []string) error {
+	if len(args) != 1 || (args[0] != "set" && args[0] != "remove") {
+		return errors.New("uso: master-password <set|remove>")
+	}
+	// Trocar ou remover exige a senha atual
+	if err := requireMasterPasswordCLI(db); err != nil {
+		return err
+	}
+
+	if args[0] == "remove" {
+		if err := db.DeleteSetting(settingMasterPassword); err != nil {
+			return err
+		}
+		fmt.Println("Senha mestra removida.")
+		return nil
+	}
+
+	scanner := cliStdinScanner
+	fmt.Print("Nova senha mestra: ")
+	password := readSecretLine(scanner)
+	if password == "" {
+		return errors.New("a senha não pode ser vazia")
+	}
+	fmt.Print("Confirme a senha mestra: ")
+	if readSecretLine(scanner) != password {
+		return errors.New("as senhas não conferem")
+	}
+	hash, err := hashMasterPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := db.SetSetting(settingMasterPassword, hash); err != nil {
+		return err
+	}
+	fmt.Println(colorGreen("Senha mestra definida."))
+	fmt.Println("Ela será pedida para listar, editar e exportar contas (backup).")
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Menções: com as opções "Marcar @everyone" da conta (ordens, carteira, execuções), a notificação começa com
+// @everyone. A preferência mention troca o @everyone por menções de cargos ou usuários do Discord (ou @here), para
+// todos os tipos ou por tipo, ex.:
+// settings "Minha Conta" mention '"<@&123456789012345678>"' ou '{"order": "<@&1234>", "wallet": "@here"}'.
+// A preferência só define o texto da menção; quem liga ou desliga a menção de cada tipo continuam sendo as opções
+// "Marcar @everyone".
+const mentionSettingKey = "mention"
+
+// Tipos de notificação com menção
+const (
+	mentionOrder     = "order"
+	mentionWallet    = "wallet"
+	mentionExecution = "execution"
+)
+
+// mentionPattern aceita @everyone, @here, cargos (<@&id>) e usuários (<@id>).
+var mentionPattern = regexp.MustCompile(`^(@everyone|@here|<@&?\d+>)$`)
+
+// parseMentionSetting lê a preferência: um texto para todos os tipos ou um objeto por tipo.
+func parseMentionSetting(value json.RawMessage) (map[string]string, error) {
+	var all string
+	if json.Unmarshal(value, &all) == nil {
+		return map[string]string{mentionOrder: all, mentionWallet: all, mentionExecution: all}, nil
+	}
+	var byKind map[string]string
+	if json.Unmarshal(value, &byKind) != nil {
+		return nil, errors.New(`use um texto, ex.: "<@&1234>", ou um objeto por tipo, ex.: {"order": "<@&1234>", "wallet": "@here"}`)
+	}
+	for kind := range byKind {
+		if kind != mentionOrder && kind != mentionWallet && kind != mentionExecution {
+			return nil, fmt.Errorf("tipo desconhecido: %s (use %s, %s ou %s)", kind, mentionOrder, mentionWallet, mentionExecution)
+		}
+	}
+	return byKind, nil
+}
+
+func validateMentionSetting(value json.RawMessage) error {
+	var all string
+	if json.Unmarshal(value, &all) == nil {
+		return validateMentionText(all)
+	}
+	mentions, err := parseMentionSetting(value)
+	if err != nil {
+		return err
+	}
+	for kind, text := range mentions {
+		if err := validateMentionText(text); err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+func validateMentionText(text string) error {
+	for _, mention := range strings.Fields(text) {
+		if !mentionPattern.MatchString(mention) {
+			return fmt.Errorf("menção inválida: %s (use @everyone, @here, <@&id do cargo> ou <@id do usuário>)", mention)
+		}
+	}
+	return nil
+}
+
+// mentionTag retorna o prefixo de menção da notificação do tipo ("" = sem menção): o da preferência mention ou
+// @everyone. enabled é a opção "Marcar @everyone" do tipo.
+func mentionTag(account *BybitAccount, kind string, enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	if raw, ok := account.Settings[mentionSettingKey]; ok {
+		if mentions, err := parseMentionSetting(raw); err == nil {
+			if text, ok := mentions[kind]; ok && strings.TrimSpace(text) != "" {
+				return strings.Join(strings.Fields(text), " ") + " "
+			}
+		}
+	}
+	return "@everyone "
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Filas internas de cada conta, todas limitadas e com contadores de pressão (esperas, tempo esperado, pico e
+// descartes) exibidos nos recursos da conexão:
+//
+//   - mensagens: o loop de leitura só enfileira as mensagens recebidas e um worker da conta as processa na ordem
+//     de chegada, para que a demora do processamento não segure a leitura do WebSocket. Comporta
+//     MESSAGE_QUEUE_SIZE mensagens (padrão 1000); cheia, a leitura espera uma vaga em vez de descartar mensagens.
+//     A fila é da conta (não de cada conexão), então as conexões OKX principal e business também ficam em ordem.
+//   - envios: as notificações (Discord, planilha, alertas) saem por um worker de envio, na ordem em que foram
+//     geradas. Comporta DELIVERY_QUEUE_SIZE envios (padrão 100); cheia, quem gerou a notificação espera até
+//     deliveryQueueMaxWait e, se não abrir vaga (webhook fora do ar, por exemplo), a notificação é descartada e
+//     registrada no histórico como falha, de onde pode ser reenviada.
+const (
+	messageQueueSizeEnv      = "MESSAGE_QUEUE_SIZE"
+	messageQueueSizeDefault  = 1000
+	deliveryQueueSizeEnv     = "DELIVERY_QUEUE_SIZE"
+	deliveryQueueSizeDefault = 100
+	deliveryQueueMaxWait     = 10 * time.Second
+	deliveryDrainTimeout     = 30 * time.Second // espera pelos envios pendentes ao parar a conta ou sair
+)
+
+const goroutineWorker = "worker" // runMessageWorker (resources.go)
+
+// Nomes das filas nos recursos da conexão
+const (
+	queueMessages   = "mensagens"
+	queueDeliveries = "envios"
+)
+
+var errDeliveryQueueFull = errors.New("descartada: fila de envio cheia")
+
+func queueSizeFromEnv(env string, fallback int) int {
+	size, err := strconv.Atoi(strings.TrimSpace(os.Getenv(env)))
+	if err != nil || size < 1 {
+		return fallback
+	}
+	return size
+}
+
+// queueCounters são os contadores de pressão de uma fila.
+type queueCounters struct {
+	waits     atomic.Int64 // vezes em que a fila estava cheia e foi preciso esperar
+	waitNanos atomic.Int64
+	dropped   atomic.Int64
+	peak      atomic.Int64 // maior ocupação observada
+	full      atomic.Bool  // fila encheu; evita repetir o aviso a cada item
+}
+
+func (q *queueCounters) observe(length int) {
+	for {
+		peak := q.peak.Load()
+		if int64(length) <= peak || q.peak.CompareAndSwap(peak, int64(length)) {
+			return
+		}
+	}
+}
+
+// QueueStats é um retrato de uma fila para exibição.
+type QueueStats struct {
+	Length   int   `json:"length"`
+	Capacity int   `json:"capacity"`
+	Peak     int64 `json:"peak"`
+	Waits    int64 `json:"waits"`
+	WaitMs   int64 `json:"wait_ms"`
+	Dropped  int64 `json:"dropped"`
+}
+
+// String resume a fila (ex.: "3/1000 (pico 120, 2 espera(s) em 1.5s, 1 descarte(s))").
+func (s QueueStats) String() string {
+	text := fmt.Sprintf("%d/%d", s.Length, s.Capacity)
+	var details []string
+	if s.Peak > 0 {
+		details = append(details, fmt.Sprintf("pico %d", s.Peak))
+	}
+	if s.Waits > 0 {
+		details = append(details, fmt.Sprintf("%d espera(s) em %s", s.Waits, time.Duration(s.WaitMs)*time.Millisecond))
+	}
+	if s.Dropped > 0 {
+		details = append(details, fmt.Sprintf("%d descarte(s)", s.Dropped))
+	}
+	if len(details) > 0 {
+		text += " (" + strings.Join(details, ", ") + ")"
+	}
+	return text
+}
+
+func (q *queueCounters) stats(length, capacity int) QueueStats {
+	return QueueStats{
+		Length:   length,
+		Capacity: capacity,
+		Peak:     q.peak.Load(),
+		Waits:    q.waits.Load(),
+		WaitMs:   time.Duration(q.waitNanos.Load()).Milliseconds(),
+		Dropped:  q.dropped.Load(),
+	}
+}
+
+// deliveryJob é uma notificação aguardando envio; channel, message e correlationIDs vão para o histórico se ela
+// for descartada.
+type deliveryJob struct {
+	channel        string
+	message        string
+	correlationIDs []string
+	send           func()
+	outboxID       int64 // entrada na lista da fila de envio (outbox.go)
+}
+
+// startMessageWorker cria as filas da conta e inicia os workers de processamento e de envio. O de processamento
+// roda até o monitoramento ser parado; o de envio, até os envios pendentes da parada terminarem (stopDeliveries).
+func (wsm *WebSocketManager) startMessageWorker(wsConn *WebSocketConnection) {
+	wsConn.queue = make(chan func(), queueSizeFromEnv(messageQueueSizeEnv, messageQueueSizeDefault))
+	wsConn.queueDrained = make(chan struct{})
+	wsConn.deliveries = make(chan deliveryJob, queueSizeFromEnv(deliveryQueueSizeEnv, deliveryQueueSizeDefault))
+	wsConn.deliveryCtx, wsConn.stopDeliveryWorker = context.WithCancel(wsm.ctx)
+	wsConn.spawn(goroutineWorker, func() { wsm.runMessageWorker(wsConn) })
+	wsConn.spawn(goroutineDelivery, wsConn.runDeliveryWorker)
+}
+
+// runMessageWorker processa a fila da conta. Na parada, as mensagens já recebidas são processadas antes de
+// terminar, para finishStop descarregar os buffers com elas.
+func (wsm *WebSocketManager) runMessageWorker(wsConn *WebSocketConnection) {
+	defer close(wsConn.queueDrained)
+	for {
+		select {
+		case <-wsConn.ctx.Done():
+			if pending := len(wsConn.queue); pending > 0 {
+				if logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name); logger != nil {
+					logger.Log("Parada: processando %d mensagem(ns) da fila de processamento", pending)
+				}
+			}
+			for {
+				select {
+				case handle := <-wsConn.queue:
+					handle()
+				default:
+					return
+				}
+			}
+		case handle := <-wsConn.queue:
+			handle()
+		}
+	}
+}
+
+// enqueueMessage coloca o processamento de uma mensagem na fila da conta. Com a fila cheia, espera uma vaga
+// (avisando no log uma vez por vez que enche); false = monitoramento parado durante a espera.
+func (c *WebSocketConnection) enqueueMessage(handle func()) bool {
+	counters := &c.queueCounters
+	select {
+	case c.queue <- handle:
+		counters.observe(len(c.queue))
+		counters.full.Store(false)
+		return true
+	default:
+	}
+	counters.waits.Add(1)
+	if counters.full.CompareAndSwap(false, true) {
+		if logger, _ := getLogger(c.AccountID, c.Account().Name); logger != nil {
+			logger.Log("⚠️ Fila de processamento cheia (%d mensagens); a leitura aguarda o processamento alcançar", cap(c.queue))
+		}
+	}
+	started := time.Now()
+	defer func() { counters.waitNanos.Add(int64(time.Since(started))) }()
+	select {
+	case c.queue <- handle:
+		counters.observe(cap(c.queue))
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+func (c *WebSocketConnection) runDeliveryWorker() {
+	for {
+		select {
+		case <-c.deliveryCtx.Done():
+			for {
+				select {
+				case job := <-c.deliveries:
+					c.dropDelivery(job, "monitoramento parado")
+				default:
+					return
+				}
+			}
+		case job := <-c.deliveries:
+			c.outbox.sending(job.outboxID)
+			job.send()
+			c.outbox.remove(job.outboxID)
+			c.deliveryPending.Add(-1)
+		}
+	}
+}
+
+// enqueueDelivery coloca uma notificação na fila de envio da conta. Com a fila cheia, espera até
+// deliveryQueueMaxWait; sem vaga, a notificação é descartada e registrada no histórico como falha (false).
+func (c *WebSocketConnection) enqueueDelivery(channel, message string, correlationIDs []string, send func()) bool {
+	job := deliveryJob{channel: channel, message: message, correlationIDs: correlationIDs, send: send}
+	job.outboxID = c.outbox.add(job)
+	c.deliveryPending.Add(1)
+	counters := &c.deliveryCounters
+	if c.deliveryCtx.Err() != nil {
+		c.dropDelivery(job, "monitoramento parado")
+		return false
+	}
+	select {
+	case c.deliveries <- job:
+		counters.observe(len(c.deliveries))
+		counters.full.Store(false)
+		return true
+	default:
+	}
+
+	counters.waits.Add(1)
+	if counters.full.CompareAndSwap(false, true) {
+		if logger, _ := getLogger(c.AccountID, c.Account().Name); logger != nil {
+			logger.Log("⚠️ Fila de envio cheia (%d notificações); novas notificações aguardam até %s antes de serem descartadas", cap(c.deliveries), deliveryQueueMaxWait)
+		}
+	}
+	started := time.Now()
+	defer func() { counters.waitNanos.Add(int64(time.Since(started))) }()
+	timer := time.NewTimer(deliveryQueueMaxWait)
+	defer timer.Stop()
+	select {
+	case c.deliveries <- job:
+		counters.observe(cap(c.deliveries))
+		return true
+	case <-timer.C:
+		c.dropDelivery(job, errDeliveryQueueFull.Error())
+	case <-c.deliveryCtx.Done():
+		c.dropDelivery(job, "monitoramento parado")
+	}
+	return false
+}
+
+// dropDelivery descarta uma notificação, registrando a falha no histórico (de onde pode ser reenviada).
+func (c *WebSocketConnection) dropDelivery(job deliveryJob, reason string) {
+	c.outbox.remove(job.outboxID)
+	c.deliveryPending.Add(-1)
+	c.deliveryCounters.dropped.Add(1)
+	err := errDeliveryQueueFull
+	if reason != errDeliveryQueueFull.Error() {
+		err = errors.New("descartada: " + reason)
+	}
+	recordNotificationResult(c.AccountID, job.channel, job.message, notificationDelivery{Err: err, CorrelationIDs: uniqueCorrelationIDs(job.correlationIDs)})
+	if logger, _ := getLogger(c.AccountID, c.Account().Name); logger != nil {
+		logger.Log("%s Notificação (%s) %v", correlationTag(job.correlationIDs...), notifyChannelLabel(job.channel), err)
+	}
+}
+
+// drainDeliveries espera os envios pendentes da conta terminarem (até timeout). false = ainda havia envios.
+func (c *WebSocketConnection) drainDeliveries(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for c.deliveryPending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+// stopDeliveries espera os envios pendentes e encerra o worker de envio; o que restar é descartado e registrado.
+func (c *WebSocketConnection) stopDeliveries() {
+	if !c.drainDeliveries(deliveryDrainTimeout) {
+		if logger, _ := getLogger(c.AccountID, c.Account().Name); logger != nil {
+			logger.Log("⚠️ Envios pendentes não terminaram em %s", deliveryDrainTimeout)
+		}
+	}
+	c.stopDeliveryWorker()
+}
+
+// queueStats retorna o retrato das filas da conta.
+func (c *WebSocketConnection) queueStats() map[string]QueueStats {
+	return map[string]QueueStats{
+		queueMessages:   c.queueCounters.stats(len(c.queue), cap(c.queue)),
+		queueDeliveries: c.deliveryCounters.stats(len(c.deliveries), cap(c.deliveries)),
+	}
+}
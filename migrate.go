@@ -0,0 +1,247 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations do banco: arquivos migrations/NNNN_nome.up.sql (obrigatório) e NNNN_nome.down.sql (rollback, opcional).
+// A versão aplicada fica na tabela schema_version. Toda mudança de esquema nova entra como um arquivo novo aqui.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string // vazio = sem rollback
+}
+
+// loadMigrations lê as migrations embutidas, ordenadas pela versão.
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionStr, migrationName, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("nome de migration inválido: %s", name)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("nome de migration inválido: %s", name)
+		}
+		content, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &migration{Version: version, Name: migrationName}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s sem arquivo .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// migrate aplica as migrations pendentes. Bancos criados antes do controle de versões são
+// completados com as colunas que faltarem e então marcados com a versão do baseline.
+func (d *Database) migrate() error {
+	if _, err := d.db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].Version; current > latest {
+		return fmt.Errorf("o banco está na versão %d do esquema, mais nova que a suportada por este executável (%d); atualize o aplicativo ou faça o rollback com a versão mais nova", current, latest)
+	}
+
+	if current == 0 {
+		if err := d.upgradeLegacySchema(); err != nil {
+			return fmt.Errorf("erro ao atualizar esquema antigo: %w", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := d.applyMigration(m.Version, m.Name, m.Up, true); err != nil {
+			return fmt.Errorf("erro na migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration executa o SQL da migration e atualiza schema_version na mesma transação.
+func (d *Database) applyMigration(version int, name, script string, up bool) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_version (version, name) VALUES (?, ?)`, version, name)
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_version WHERE version = ?`, version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion retorna a última migration aplicada (0 = nenhuma).
+func (d *Database) SchemaVersion() (int, error) {
+	var version int
+	err := d.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// MigrateDown desfaz as migrations acima de target, da mais nova para a mais antiga.
+func (d *Database) MigrateDown(target int) error {
+	if target < 1 {
+		return errors.New("não é possível desfazer o esquema inicial (versão 1)")
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		if m.Down == "" {
+			return fmt.Errorf("a migration %04d_%s não tem rollback", m.Version, m.Name)
+		}
+		if err := d.applyMigration(m.Version, m.Name, m.Down, false); err != nil {
+			return fmt.Errorf("erro no rollback da migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// upgradeLegacySchema completa bancos criados antes das migrations, quando as colunas eram
+// adicionadas uma a uma na inicialização. Em banco novo não faz nada (a tabela ainda não existe).
+func (d *Database) upgradeLegacySchema() error {
+	var count int
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'bybit_accounts'`).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	legacyColumns := []struct{ name, definition string }{
+		{"mark_everyone_order", "INTEGER DEFAULT 0"},
+		{"mark_everyone_wallet", "INTEGER DEFAULT 0"},
+		{"one_way_mode", "INTEGER DEFAULT 1"},
+		{"webhook_url_google_sheets", "TEXT NOT NULL DEFAULT ''"},
+		{"sheet_url_google_sheets", "TEXT NOT NULL DEFAULT ''"},
+		{"webhook_url_executions", "TEXT NOT NULL DEFAULT ''"},
+		{"mark_everyone_execution", "INTEGER DEFAULT 0"},
+		{"sheet_url_google_sheets_executions", "TEXT NOT NULL DEFAULT ''"},
+		{"platform", "TEXT NOT NULL DEFAULT 'bybit'"},
+		{"metadata", "TEXT NOT NULL DEFAULT ''"},
+		{"notification_delay_seconds", "INTEGER DEFAULT 0"},
+		{"timezone", "TEXT NOT NULL DEFAULT ''"},
+		{"notes", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, column := range legacyColumns {
+		if err := d.addColumnIfNotExists("bybit_accounts", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrationStatus descreve uma migration para o comando migrate status.
+type MigrationStatus struct {
+	Version     int
+	Name        string
+	Applied     bool
+	HasRollback bool
+}
+
+func (d *Database) MigrationStatuses() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool)
+	rows, err := d.db.Query(`SELECT version FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		statuses = append(statuses, MigrationStatus{Version: m.Version, Name: m.Name, Applied: applied[m.Version], HasRollback: m.Down != ""})
+	}
+	return statuses, nil
+}
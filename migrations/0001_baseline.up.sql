@@ -0,0 +1,78 @@
+-- Esquema inicial (equivalente ao criado pelas versões anteriores ao controle de migrations)
+
+CREATE TABLE IF NOT EXISTS bybit_accounts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL DEFAULT '',
+	api_key TEXT NOT NULL DEFAULT '',
+	api_secret TEXT NOT NULL DEFAULT '',
+	webhook_url TEXT NOT NULL DEFAULT '',
+	webhook_url_google_sheets TEXT NOT NULL DEFAULT '',
+	sheet_url_google_sheets TEXT NOT NULL DEFAULT '',
+	mark_everyone_order INTEGER DEFAULT 0,
+	mark_everyone_wallet INTEGER DEFAULT 0,
+	one_way_mode INTEGER DEFAULT 1,
+	active INTEGER DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	webhook_url_executions TEXT NOT NULL DEFAULT '',
+	mark_everyone_execution INTEGER DEFAULT 0,
+	sheet_url_google_sheets_executions TEXT NOT NULL DEFAULT '',
+	platform TEXT NOT NULL DEFAULT 'bybit',
+	metadata TEXT NOT NULL DEFAULT '',
+	notification_delay_seconds INTEGER DEFAULT 0,
+	timezone TEXT NOT NULL DEFAULT '',
+	notes TEXT NOT NULL DEFAULT ''
+);
+
+-- Conexões ativas (restauradas ao reiniciar)
+CREATE TABLE IF NOT EXISTS active_connections (
+	account_id INTEGER PRIMARY KEY,
+	connected INTEGER DEFAULT 1,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	order_id TEXT PRIMARY KEY,
+	account_id INTEGER NOT NULL,
+	order_data TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+-- Última mensagem por tipo (wallet/position), uma linha por account_id + message_type + symbol
+CREATE TABLE IF NOT EXISTS last_message_snapshots (
+	account_id INTEGER NOT NULL,
+	message_type TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	message TEXT NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (account_id, message_type, symbol),
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+-- Contadores por conta (mensagens por tópico, notificações, falhas de webhook, reconexões)
+CREATE TABLE IF NOT EXISTS account_stats (
+	account_id INTEGER NOT NULL,
+	stat_key TEXT NOT NULL,
+	value INTEGER NOT NULL DEFAULT 0,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (account_id, stat_key),
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+-- Tokens da API de controle (apenas o hash SHA-256 do token é guardado)
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	role TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_used_at DATETIME
+);
+
+-- Configurações globais do aplicativo (chave/valor)
+CREATE TABLE IF NOT EXISTS app_settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_account_created;
+DROP TABLE IF EXISTS notifications;
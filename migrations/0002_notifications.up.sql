@@ -0,0 +1,13 @@
+-- Histórico das notificações enviadas (ou que falharam), para consulta e reenvio
+CREATE TABLE notifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	channel TEXT NOT NULL,
+	message TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_account_created ON notifications (account_id, created_at);
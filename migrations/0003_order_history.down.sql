@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_order_events_account_order;
+DROP INDEX IF EXISTS idx_order_events_account_time;
+DROP TABLE IF EXISTS order_events;
+
+ALTER TABLE orders DROP COLUMN updated_at;
+ALTER TABLE orders DROP COLUMN status;
+ALTER TABLE orders DROP COLUMN symbol;
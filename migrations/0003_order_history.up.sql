@@ -0,0 +1,26 @@
+-- Ordens em aberto passam a guardar símbolo, status e horário da última atualização
+ALTER TABLE orders ADD COLUMN symbol TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN updated_at DATETIME;
+
+-- Histórico de cada atualização de ordem recebida, com a transição de status
+CREATE TABLE order_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	order_id TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	side TEXT NOT NULL DEFAULT '',
+	order_type TEXT NOT NULL DEFAULT '',
+	stop_order_type TEXT NOT NULL DEFAULT '',
+	previous_status TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	price TEXT NOT NULL DEFAULT '',
+	qty TEXT NOT NULL DEFAULT '',
+	order_data TEXT NOT NULL,
+	event_time DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_order_events_account_time ON order_events (account_id, event_time);
+CREATE INDEX idx_order_events_account_order ON order_events (account_id, order_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_executions_account_time;
+DROP INDEX IF EXISTS idx_executions_account_exec;
+DROP TABLE IF EXISTS executions;
@@ -0,0 +1,22 @@
+-- Histórico das execuções (Trade) por conta: base para relatórios de PnL, resumos e exportação
+CREATE TABLE executions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	exec_id TEXT NOT NULL,
+	order_id TEXT NOT NULL DEFAULT '',
+	symbol TEXT NOT NULL,
+	side TEXT NOT NULL DEFAULT '',
+	order_type TEXT NOT NULL DEFAULT '',
+	price TEXT NOT NULL DEFAULT '',
+	qty TEXT NOT NULL DEFAULT '',
+	value TEXT NOT NULL DEFAULT '',
+	fee TEXT NOT NULL DEFAULT '',
+	fee_rate TEXT NOT NULL DEFAULT '',
+	is_maker INTEGER NOT NULL DEFAULT 0,
+	exec_time DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_executions_account_exec ON executions (account_id, exec_id);
+CREATE INDEX idx_executions_account_time ON executions (account_id, exec_time);
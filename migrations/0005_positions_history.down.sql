@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_positions_history_open;
+DROP INDEX IF EXISTS idx_positions_history_account_opened;
+DROP TABLE IF EXISTS positions_history;
@@ -0,0 +1,19 @@
+-- Histórico de posições: uma linha por posição, da abertura ao fechamento (closed_at nulo = ainda aberta)
+CREATE TABLE positions_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	symbol TEXT NOT NULL,
+	position_idx INTEGER NOT NULL DEFAULT 0,
+	side TEXT NOT NULL DEFAULT '',
+	size TEXT NOT NULL DEFAULT '',
+	entry_price TEXT NOT NULL DEFAULT '',
+	exit_price TEXT NOT NULL DEFAULT '',
+	realized_pnl TEXT NOT NULL DEFAULT '',
+	pnl_baseline TEXT NOT NULL DEFAULT '',
+	opened_at DATETIME NOT NULL,
+	closed_at DATETIME,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_positions_history_account_opened ON positions_history (account_id, opened_at);
+CREATE INDEX idx_positions_history_open ON positions_history (account_id, symbol, position_idx, closed_at);
@@ -0,0 +1,9 @@
+-- Último evento processado por conta e tópico (creationTime em ms), para retomada e descarte de duplicados
+CREATE TABLE stream_checkpoints (
+	account_id INTEGER NOT NULL,
+	topic TEXT NOT NULL,
+	last_creation_time INTEGER NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (account_id, topic),
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE active_connections DROP COLUMN last_heartbeat_at;
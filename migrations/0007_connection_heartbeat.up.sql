@@ -0,0 +1,2 @@
+-- Heartbeat gravado a cada minuto por conexão monitorada; linha com heartbeat antigo = processo encerrado sem limpar
+ALTER TABLE active_connections ADD COLUMN last_heartbeat_at DATETIME;
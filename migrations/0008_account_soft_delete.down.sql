@@ -0,0 +1,2 @@
+DELETE FROM bybit_accounts WHERE deleted_at IS NOT NULL;
+ALTER TABLE bybit_accounts DROP COLUMN deleted_at;
@@ -0,0 +1,2 @@
+-- Remoção de conta passa a ser lógica: deleted_at preenchido = removida (pode ser restaurada ou apagada de vez com purge)
+ALTER TABLE bybit_accounts ADD COLUMN deleted_at DATETIME;
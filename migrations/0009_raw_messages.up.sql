@@ -0,0 +1,11 @@
+-- Payloads crus do WebSocket (gzip), gravados só com a captura ligada na conta, para depurar notificações e reproduzir eventos
+CREATE TABLE raw_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	stream TEXT NOT NULL,
+	payload BLOB NOT NULL,
+	received_at DATETIME NOT NULL,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_raw_messages_account_time ON raw_messages (account_id, received_at);
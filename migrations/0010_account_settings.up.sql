@@ -0,0 +1,2 @@
+-- Preferências por conta em JSON (filtros, horários, idioma...): novas opções não precisam de migration
+ALTER TABLE bybit_accounts ADD COLUMN settings TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,9 @@
+-- Agregados diários por conta (dia em UTC): notificações, ordens, execuções, volume e reconexões
+CREATE TABLE account_daily_stats (
+	account_id INTEGER NOT NULL,
+	day TEXT NOT NULL,
+	stat_key TEXT NOT NULL,
+	value REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (account_id, day, stat_key),
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP INDEX idx_notifications_status_created;
+ALTER TABLE notifications DROP COLUMN attempts;
+ALTER TABLE notifications DROP COLUMN http_status;
@@ -0,0 +1,4 @@
+-- Auditoria de entregas: status HTTP da última tentativa (0 = sem resposta ou entregue) e quantas tentativas foram feitas
+ALTER TABLE notifications ADD COLUMN http_status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE notifications ADD COLUMN attempts INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX idx_notifications_status_created ON notifications (status, created_at);
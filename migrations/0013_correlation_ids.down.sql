@@ -0,0 +1,2 @@
+ALTER TABLE notifications DROP COLUMN correlation_ids;
+ALTER TABLE raw_messages DROP COLUMN correlation_id;
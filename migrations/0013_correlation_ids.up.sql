@@ -0,0 +1,3 @@
+-- IDs de correlação: o ID da mensagem do WebSocket no payload capturado e os IDs das mensagens que geraram cada notificação
+ALTER TABLE raw_messages ADD COLUMN correlation_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE notifications ADD COLUMN correlation_ids TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- Contas paradas pelo circuit breaker de autenticação: a corretora recusou as credenciais repetidas vezes com o mesmo
+-- erro (ex.: key revogada). A linha é apagada quando o monitoramento da conta é iniciado de novo.
+CREATE TABLE auth_failures (
+	account_id INTEGER PRIMARY KEY,
+	reason TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	failed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES bybit_accounts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Servidor simulado do stream privado da Bybit, para desenvolvimento: com --mock-bybit, as contas Bybit conectam a
+// um WebSocket local em vez da corretora. O servidor aceita qualquer key, confirma as inscrições e reproduz um
+// roteiro de mensagens de ordem, execução, posição e carteira, para testar a formatação e o agrupamento das
+// notificações sem keys reais nem operações. --mock-bybit=<arquivo.json> troca o roteiro padrão (mockDefaultScript).
+//
+// Nos dados do roteiro, {{cycle}} vira o número da repetição (para os IDs das ordens não se repetirem), {{now}} o
+// horário atual em ms e {{uuid}} um UUID novo a cada ocorrência.
+const (
+	mockStepDelayDefault = 2 * time.Second
+	mockPauseDefault     = 30 * time.Second
+)
+
+// mockBybitEnabled e mockBybitScriptPath vêm de --mock-bybit[=<arquivo>].
+var (
+	mockBybitEnabled    bool
+	mockBybitScriptPath string
+)
+
+// mockStreamURL é o endereço do servidor simulado em execução; quando definido, substitui os endpoints da Bybit.
+var mockStreamURL string
+
+// mockScript é o roteiro reproduzido em cada conexão ao servidor simulado.
+type mockScript struct {
+	Loop  *bool      `json:"loop"`  // repetir o roteiro (padrão: true)
+	Pause string     `json:"pause"` // espera entre as repetições (padrão: 30s)
+	Steps []mockStep `json:"steps"`
+
+	pause time.Duration
+}
+
+// mockStep é uma mensagem do roteiro, enviada delay depois da anterior.
+type mockStep struct {
+	Delay string          `json:"delay"` // padrão: 2s
+	Topic string          `json:"topic"` // order, execution, position ou wallet
+	Data  json.RawMessage `json:"data"`  // lista de itens, no formato da Bybit
+
+	delay time.Duration
+}
+
+// loadMockScript lê o roteiro do arquivo (vazio = roteiro padrão) e valida tópicos, dados e esperas.
+func loadMockScript(path string) (*mockScript, error) {
+	content := []byte(mockDefaultScript)
+	if path != "" {
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	var script mockScript
+	if err := json.Unmarshal(content, &script); err != nil {
+		return nil, fmt.Errorf("JSON inválido: %w", err)
+	}
+	if len(script.Steps) == 0 {
+		return nil, errors.New("o roteiro não tem nenhum passo em \"steps\"")
+	}
+	script.pause = mockPauseDefault
+	if script.Pause != "" {
+		d, err := parseReconnectDuration(script.Pause)
+		if err != nil {
+			return nil, fmt.Errorf("pause: %w", err)
+		}
+		script.pause = d
+	}
+	for i := range script.Steps {
+		step := &script.Steps[i]
+		if !slices.Contains(bybitPrivateTopics, step.Topic) {
+			return nil, fmt.Errorf("passo %d: tópico %q inválido (use %s)", i+1, step.Topic, strings.Join(bybitPrivateTopics, ", "))
+		}
+		var items []json.RawMessage
+		if json.Unmarshal(step.Data, &items) != nil || len(items) == 0 {
+			return nil, fmt.Errorf("passo %d: data deve ser uma lista com pelo menos um item", i+1)
+		}
+		step.delay = mockStepDelayDefault
+		if step.Delay != "" {
+			d, err := parseReconnectDuration(step.Delay)
+			if err != nil {
+				return nil, fmt.Errorf("passo %d: delay: %w", i+1, err)
+			}
+			step.delay = d
+		}
+	}
+	return &script, nil
+}
+
+// startMockBybit inicia o servidor simulado numa porta local livre e passa a usá-lo como endpoint das contas Bybit.
+// O servidor e as conexões abertas nele são encerrados quando ctx é cancelado.
+func startMockBybit(ctx context.Context, scriptPath string) (string, error) {
+	script, err := loadMockScript(scriptPath)
+	if err != nil {
+		if scriptPath != "" {
+			return "", fmt.Errorf("%s: %w", scriptPath, err)
+		}
+		return "", err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveMockStream(ctx, w, r, script)
+	})}
+	go server.Serve(listener)
+	context.AfterFunc(ctx, func() { server.Close() })
+
+	mockStreamURL = "ws://" + listener.Addr().String() + "/v5/private"
+	return mockStreamURL, nil
+}
+
+// serveMockStream atende uma conexão: responde auth, subscribe e ping como a Bybit e, na primeira inscrição,
+// começa a reproduzir o roteiro.
+func serveMockStream(ctx context.Context, w http.ResponseWriter, r *http.Request, script *mockScript) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(connCtx, func() { conn.Close() })
+
+	var writeMu sync.Mutex
+	send := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(v)
+	}
+	connID := uuid.New().String()
+	playing := false
+	for {
+		var request struct {
+			ReqID string `json:"req_id"`
+			Op    string `json:"op"`
+		}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+		switch request.Op {
+		case "auth":
+			send(map[string]interface{}{"success": true, "ret_msg": "", "op": "auth", "conn_id": connID})
+		case "subscribe":
+			send(map[string]interface{}{"success": true, "ret_msg": "", "op": "subscribe", "req_id": request.ReqID, "conn_id": connID})
+			if !playing {
+				playing = true
+				go playMockScript(connCtx, script, send)
+			}
+		case "ping":
+			send(map[string]interface{}{"success": true, "ret_msg": "pong", "op": "ping", "conn_id": connID})
+		}
+	}
+}
+
+// playMockScript envia os passos do roteiro até a conexão fechar (ou o roteiro terminar, sem loop).
+func playMockScript(ctx context.Context, script *mockScript, send func(interface{}) error) {
+	for cycle := 1; ; cycle++ {
+		for _, step := range script.Steps {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(step.delay):
+			}
+			message := map[string]interface{}{
+				"id":           uuid.New().String(),
+				"topic":        step.Topic,
+				"creationTime": time.Now().UnixMilli(),
+				"data":         json.RawMessage(expandMockPlaceholders(string(step.Data), cycle)),
+			}
+			if err := send(message); err != nil {
+				return
+			}
+		}
+		if script.Loop != nil && !*script.Loop {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(script.pause):
+		}
+	}
+}
+
+// expandMockPlaceholders substitui {{cycle}}, {{now}} e {{uuid}} nos dados de um passo.
+func expandMockPlaceholders(data string, cycle int) string {
+	data = strings.ReplaceAll(data, "{{cycle}}", strconv.Itoa(cycle))
+	data = strings.ReplaceAll(data, "{{now}}", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	for strings.Contains(data, "{{uuid}}") {
+		data = strings.Replace(data, "{{uuid}}", uuid.New().String(), 1)
+	}
+	return data
+}
+
+// mockDefaultScript é o roteiro padrão (contrato inverse BTCUSD): ordem limite de compra preenchida em duas
+// execuções, stop loss, atualização da carteira, saída com lucro e cancelamento do stop.
+const mockDefaultScript = `{
+  "loop": true,
+  "pause": "30s",
+  "steps": [
+    {"topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-entry", "side": "Buy", "orderType": "Limit", "orderStatus": "New", "price": "60000", "avgPrice": "0", "qty": "100", "createType": "CreateByUser", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]},
+    {"topic": "execution", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-entry", "execId": "{{uuid}}", "execType": "Trade", "side": "Buy", "orderType": "Limit", "execPrice": "60000", "execQty": "40", "execValue": "0.00066667", "execFee": "0.00000013", "feeRate": "0.0002", "isMaker": true, "markPrice": "60010", "execTime": "{{now}}"}]},
+    {"delay": "1s", "topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-entry", "side": "Buy", "orderType": "Limit", "orderStatus": "PartiallyFilled", "price": "60000", "avgPrice": "60000", "qty": "100", "createType": "CreateByUser", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]},
+    {"topic": "execution", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-entry", "execId": "{{uuid}}", "execType": "Trade", "side": "Buy", "orderType": "Limit", "execPrice": "60000", "execQty": "60", "execValue": "0.001", "execFee": "0.0000002", "feeRate": "0.0002", "isMaker": true, "markPrice": "60020", "execTime": "{{now}}"}]},
+    {"delay": "1s", "topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-entry", "side": "Buy", "orderType": "Limit", "orderStatus": "Filled", "price": "60000", "avgPrice": "60000", "qty": "100", "createType": "CreateByUser", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]},
+    {"delay": "1s", "topic": "position", "data": [{"category": "inverse", "symbol": "BTCUSD", "side": "Buy", "size": "100", "entryPrice": "60000", "markPrice": "60020", "liqPrice": "45000", "positionValue": "0.00166667", "positionIM": "0.00016667", "positionMM": "0.00000834", "positionStatus": "Normal", "positionIdx": 0, "curRealisedPnl": "-0.00000033", "cumRealisedPnl": "-0.00000033", "updatedTime": "{{now}}"}]},
+    {"topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-stop", "side": "Sell", "orderType": "Market", "orderStatus": "Untriggered", "price": "0", "avgPrice": "0", "qty": "100", "reduceOnly": true, "stopOrderType": "StopLoss", "triggerPrice": "58000", "createType": "CreateByStopLoss", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]},
+    {"topic": "wallet", "data": [{"accountType": "UNIFIED", "totalEquity": "6102.35", "totalWalletBalance": "6100.00", "totalMarginBalance": "6102.35", "totalPerpUPL": "2.35", "totalInitialMargin": "10.00", "totalMaintenanceMargin": "0.50", "accountIMRate": "0.0016", "accountMMRate": "0.0001", "coin": [{"coin": "BTC", "equity": "0.10170583", "usdValue": "6102.35"}]}]},
+    {"delay": "5s", "topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-exit", "side": "Sell", "orderType": "Limit", "orderStatus": "New", "price": "61500", "avgPrice": "0", "qty": "100", "reduceOnly": true, "createType": "CreateByUser", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]},
+    {"topic": "execution", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-exit", "execId": "{{uuid}}", "execType": "Trade", "side": "Sell", "orderType": "Limit", "execPrice": "61500", "execQty": "100", "execValue": "0.00162602", "execFee": "0.00000033", "feeRate": "0.0002", "isMaker": true, "markPrice": "61490", "execTime": "{{now}}"}]},
+    {"delay": "1s", "topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-exit", "side": "Sell", "orderType": "Limit", "orderStatus": "Filled", "price": "61500", "avgPrice": "61500", "qty": "100", "reduceOnly": true, "createType": "CreateByUser", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]},
+    {"delay": "1s", "topic": "position", "data": [{"category": "inverse", "symbol": "BTCUSD", "side": "", "size": "0", "entryPrice": "0", "markPrice": "61490", "positionValue": "0", "positionIM": "0", "positionMM": "0", "positionStatus": "Normal", "positionIdx": 0, "curRealisedPnl": "0.00004032", "cumRealisedPnl": "0.00003999", "updatedTime": "{{now}}"}]},
+    {"topic": "order", "data": [{"category": "inverse", "symbol": "BTCUSD", "orderId": "mock-{{cycle}}-stop", "side": "Sell", "orderType": "Market", "orderStatus": "Deactivated", "cancelType": "CancelByTpSlTsClear", "price": "0", "avgPrice": "0", "qty": "100", "reduceOnly": true, "stopOrderType": "StopLoss", "triggerPrice": "58000", "createType": "CreateByStopLoss", "createdTime": "{{now}}", "updatedTime": "{{now}}"}]}
+  ]
+}`
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Silenciar notificações: um símbolo (ou moeda) ou um tipo de evento da conta fica sem notificações por um tempo,
+// sem mexer nas regras gravadas (notification_rules). O silêncio fica só em memória e termina sozinho no fim do
+// prazo (ou ao reiniciar o aplicativo). Ordens de símbolos silenciados passam pelo buffer de atraso, que grava o
+// estado delas, e só a notificação é descartada: ao fim do silêncio, a execução ou o cancelamento de uma ordem aberta
+// durante ele segue do status certo. Eventos silenciados são descartados ao montar a mensagem. O histórico de ordens
+// e a planilha continuam recebendo tudo.
+const (
+	muteSymbol = "symbol"
+	muteEvent  = "event"
+)
+
+// muteMaxDuration é o maior prazo aceito para um silêncio.
+const muteMaxDuration = 7 * 24 * time.Hour
+
+// notificationMute é um silêncio ativo numa conta.
+type notificationMute struct {
+	Kind  string    `json:"kind"` // symbol ou event
+	Value string    `json:"value"`
+	Until time.Time `json:"until"`
+	timer *time.Timer
+}
+
+var notificationMutes = struct {
+	mu        sync.Mutex
+	byAccount map[int64][]*notificationMute
+}{byAccount: make(map[int64][]*notificationMute)}
+
+// mutableEvents são os tipos de evento que podem ser silenciados.
+func mutableEvents() []string {
+	events := append(templateEventNames(), eventExecution)
+	sort.Strings(events)
+	return events
+}
+
+// muteNotifications silencia o símbolo ou evento da conta por d, substituindo um silêncio anterior do mesmo alvo.
+func muteNotifications(account *BybitAccount, kind, value string, d time.Duration) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case muteSymbol:
+		if value == "" {
+			return time.Time{}, errors.New("informe o símbolo ou a moeda (ex.: BTCUSD ou BTC)")
+		}
+		value = strings.ToUpper(value)
+	case muteEvent:
+		value = strings.ToLower(value)
+		if !containsEvent(mutableEvents(), value) {
+			return time.Time{}, fmt.Errorf("evento desconhecido: %s (use %s)", value, strings.Join(mutableEvents(), ", "))
+		}
+	default:
+		return time.Time{}, errors.New("informe um símbolo ou um evento")
+	}
+	if d < time.Minute || d > muteMaxDuration {
+		return time.Time{}, fmt.Errorf("duração inválida (de 1 minuto a %s)", formatElapsed(muteMaxDuration))
+	}
+
+	mute := &notificationMute{Kind: kind, Value: value, Until: time.Now().Add(d)}
+	notificationMutes.mu.Lock()
+	removeMuteLocked(account.ID, kind, value)
+	notificationMutes.byAccount[account.ID] = append(notificationMutes.byAccount[account.ID], mute)
+	mute.timer = time.AfterFunc(d, func() {
+		notificationMutes.mu.Lock()
+		expired := removeMuteEntryLocked(account.ID, mute)
+		notificationMutes.mu.Unlock()
+		if expired {
+			if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+				logger.Log("🔔 Fim do silêncio de %s", muteLabel(kind, value))
+			}
+		}
+	})
+	notificationMutes.mu.Unlock()
+
+	if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+		logger.Log("🔕 Notificações de %s silenciadas por %s", muteLabel(kind, value), formatElapsed(d))
+	}
+	return mute.Until, nil
+}
+
+// unmuteNotifications encerra o silêncio do símbolo ou evento (false se não havia).
+func unmuteNotifications(account *BybitAccount, kind, value string) bool {
+	value = strings.TrimSpace(value)
+	if kind == muteSymbol {
+		value = strings.ToUpper(value)
+	} else {
+		value = strings.ToLower(value)
+	}
+	notificationMutes.mu.Lock()
+	removed := removeMuteLocked(account.ID, kind, value)
+	notificationMutes.mu.Unlock()
+	if removed == nil {
+		return false
+	}
+	if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+		logger.Log("🔔 Silêncio de %s removido", muteLabel(kind, value))
+	}
+	return true
+}
+
+// removeMuteLocked tira o silêncio da lista e para o timer. Chamado com notificationMutes.mu travado.
+func removeMuteLocked(accountID int64, kind, value string) *notificationMute {
+	mutes := notificationMutes.byAccount[accountID]
+	for i, mute := range mutes {
+		if mute.Kind == kind && mute.Value == value {
+			mute.timer.Stop()
+			notificationMutes.byAccount[accountID] = append(mutes[:i:i], mutes[i+1:]...)
+			if len(notificationMutes.byAccount[accountID]) == 0 {
+				delete(notificationMutes.byAccount, accountID)
+			}
+			return mute
+		}
+	}
+	return nil
+}
+
+// removeMuteEntryLocked tira da lista exatamente este silêncio (false se ele já foi removido ou substituído por
+// outro do mesmo alvo). Chamado com notificationMutes.mu travado.
+func removeMuteEntryLocked(accountID int64, mute *notificationMute) bool {
+	mutes := notificationMutes.byAccount[accountID]
+	for i, m := range mutes {
+		if m == mute {
+			notificationMutes.byAccount[accountID] = append(mutes[:i:i], mutes[i+1:]...)
+			if len(notificationMutes.byAccount[accountID]) == 0 {
+				delete(notificationMutes.byAccount, accountID)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// activeMutes retorna os silêncios em vigor na conta, pelo fim do prazo.
+func activeMutes(accountID int64) []notificationMute {
+	now := time.Now()
+	notificationMutes.mu.Lock()
+	defer notificationMutes.mu.Unlock()
+	var mutes []notificationMute
+	for _, mute := range notificationMutes.byAccount[accountID] {
+		if mute.Until.After(now) {
+			mutes = append(mutes, notificationMute{Kind: mute.Kind, Value: mute.Value, Until: mute.Until})
+		}
+	}
+	sort.Slice(mutes, func(i, j int) bool { return mutes[i].Until.Before(mutes[j].Until) })
+	return mutes
+}
+
+// isSymbolMuted informa se o símbolo (ou a moeda dele) está silenciado na conta.
+func isSymbolMuted(accountID int64, symbol string) bool {
+	coin := symbolToCoin(symbol)
+	for _, mute := range activeMutes(accountID) {
+		if mute.Kind == muteSymbol && (strings.EqualFold(mute.Value, symbol) || strings.EqualFold(mute.Value, coin)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEventMuted informa se o tipo de evento está silenciado na conta.
+func isEventMuted(accountID int64, event string) bool {
+	for _, mute := range activeMutes(accountID) {
+		if mute.Kind == muteEvent && mute.Value == event {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// muteLabel descreve o alvo do silêncio (ex.: "BTCUSD" ou "evento cancel").
+func muteLabel(kind, value string) string {
+	if kind == muteEvent {
+		return "evento " + value
+	}
+	return value
+}
+
+// parseMuteDuration lê o prazo do menu: horas (ex.: 2 ou 0.5) ou duração Go (ex.: 90m).
+func parseMuteDuration(input string) (time.Duration, error) {
+	if hours, err := strconv.ParseFloat(strings.Replace(input, ",", ".", 1), 64); err == nil {
+		return time.Duration(hours * float64(time.Hour)), nil
+	}
+	return time.ParseDuration(input)
+}
+
+// handleMuteNotifications é a opção do menu para silenciar ou reativar notificações de uma conta.
+func handleMuteNotifications(manager *AccountManager, scanner *bufio.Scanner) {
+	clearScreen()
+	accounts, err := manager.ListAccounts()
+	if err != nil {
+		printErrorf("Erro ao listar contas: %v\n", err)
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+	if len(accounts) == 0 {
+		fmt.Println("Nenhuma conta cadastrada.")
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+
+	fmt.Println("\n=== Contas Cadastradas ===")
+	for i, acc := range accounts {
+		suffix := ""
+		if n := len(activeMutes(acc.ID)); n > 0 {
+			suffix = colorYellow(fmt.Sprintf(" (%d silêncio(s) ativo(s))", n))
+		}
+		fmt.Printf("%d. %s%s\n", i+1, acc.Name, suffix)
+	}
+	fmt.Println("0. Voltar ao menu principal")
+	fmt.Print("\nDigite o número da conta (ou 0 para voltar): ")
+	scanner.Scan()
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &index); err != nil || index < 0 || index > len(accounts) {
+		fmt.Println(colorRed("Número inválido!"))
+		fmt.Println("\nPressione Enter para voltar ao menu principal...")
+		scanner.Scan()
+		return
+	}
+	if index == 0 {
+		return
+	}
+	account := accounts[index-1]
+	tz := loadTimezone(account.Timezone)
+
+	mutes := activeMutes(account.ID)
+	fmt.Printf("\n=== Silêncios da conta '%s' ===\n", account.Name)
+	if len(mutes) == 0 {
+		fmt.Println("Nenhum silêncio ativo.")
+	}
+	for i, mute := range mutes {
+		fmt.Printf("%d. %s até %s\n", i+1, muteLabel(mute.Kind, mute.Value), mute.Until.In(tz).Format("02/01/2006 15:04"))
+	}
+	fmt.Println("\n1. Silenciar símbolo ou moeda")
+	fmt.Println("2. Silenciar tipo de evento")
+	if len(mutes) > 0 {
+		fmt.Println("3. Remover silêncio")
+	}
+	fmt.Print("Escolha (Enter = voltar): ")
+	scanner.Scan()
+	switch choice := strings.TrimSpace(scanner.Text()); choice {
+	case "1", "2":
+		kind := muteSymbol
+		if choice == "2" {
+			kind = muteEvent
+			fmt.Printf("Evento (%s): ", strings.Join(mutableEvents(), ", "))
+		} else {
+			fmt.Print("Símbolo ou moeda (ex.: BTCUSD ou BTC): ")
+		}
+		scanner.Scan()
+		value := scanner.Text()
+		fmt.Print("Por quantas horas? (ex.: 2, 0.5 ou 90m; Enter = 1): ")
+		scanner.Scan()
+		d := time.Hour
+		if input := strings.TrimSpace(scanner.Text()); input != "" {
+			if d, err = parseMuteDuration(input); err != nil {
+				fmt.Println(colorRed("Duração inválida!"))
+				break
+			}
+		}
+		until, err := muteNotifications(account, kind, value, d)
+		if err != nil {
+			printErrorf("Erro ao silenciar: %v\n", err)
+			break
+		}
+		fmt.Printf("Notificações silenciadas até %s.\n", until.In(tz).Format("02/01/2006 15:04"))
+	case "3":
+		if len(mutes) == 0 {
+			break
+		}
+		fmt.Print("Número do silêncio a remover: ")
+		scanner.Scan()
+		var n int
+		if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &n); err != nil || n < 1 || n > len(mutes) {
+			fmt.Println(colorRed("Número inválido!"))
+			break
+		}
+		if unmuteNotifications(account, mutes[n-1].Kind, mutes[n-1].Value) {
+			fmt.Println("Silêncio removido.")
+		}
+	default:
+		return
+	}
+	fmt.Println("\nPressione Enter para voltar ao menu principal...")
+	scanner.Scan()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Origem da ordem: as notificações de ordens e execuções mostram o orderLinkId (na OKX, o clOrdId) que o bot ou a
+// estratégia pôs na ordem, e a preferência order_link_names da conta troca os prefixos conhecidos por nomes, ex.:
+// settings "Minha Conta" order_link_names '{"grid-": "Bot Grid", "dca_": "DCA"}' mostra "Origem: Bot Grid (grid-8f3a)".
+// Ordens sem orderLinkId (abertas pelo site ou app da corretora) não ganham a linha.
+const orderLinkNamesSettingKey = "order_link_names"
+
+// orderLinkMaxNames é quantas origens diferentes aparecem num grupo de ordens; o resto vira "e mais N".
+const orderLinkMaxNames = 3
+
+// orderLinkNames são os nomes dos prefixos de orderLinkId da conta (prefixo -> nome).
+type orderLinkNames map[string]string
+
+func validateOrderLinkNamesSetting(value json.RawMessage) error {
+	var names map[string]string
+	if json.Unmarshal(value, &names) != nil {
+		return errors.New(`use um objeto {"prefixo do orderLinkId": "nome"}, ex.: {"grid-": "Bot Grid"}`)
+	}
+	for prefix, name := range names {
+		if prefix == "" {
+			return errors.New("prefixo vazio")
+		}
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%s: nome vazio", prefix)
+		}
+	}
+	return nil
+}
+
+// accountOrderLinkNames retorna os nomes dos prefixos da conta (nil = sem a preferência ou inválida).
+func accountOrderLinkNames(account *BybitAccount) orderLinkNames {
+	var names orderLinkNames
+	if !account.Settings.Decode(orderLinkNamesSettingKey, &names) {
+		return nil
+	}
+	return names
+}
+
+// name retorna o nome do prefixo mais longo que casa com o orderLinkId ("" = nenhum).
+func (n orderLinkNames) name(linkID string) string {
+	best, name := -1, ""
+	for prefix, prefixName := range n {
+		if strings.HasPrefix(linkID, prefix) && len(prefix) > best {
+			best, name = len(prefix), strings.TrimSpace(prefixName)
+		}
+	}
+	return name
+}
+
+// label descreve a origem da ordem: "Bot Grid (grid-8f3a)", o próprio orderLinkId sem nome ou "" sem orderLinkId.
+func (n orderLinkNames) label(linkID string) string {
+	if linkID == "" {
+		return ""
+	}
+	if name := n.name(linkID); name != "" {
+		return fmt.Sprintf("%s (%s)", name, linkID)
+	}
+	return linkID
+}
+
+// origin descreve a origem das ordens ("" = nenhuma com orderLinkId). Num grupo, cada origem aparece uma vez: o nome
+// do prefixo ou o orderLinkId.
+func (n orderLinkNames) origin(orders []OrderData) string {
+	if len(orders) == 1 {
+		return n.label(orders[0].OrderLinkID)
+	}
+	var origins []string
+	seen := make(map[string]bool)
+	for _, o := range orders {
+		origin := n.name(o.OrderLinkID)
+		if origin == "" {
+			origin = o.OrderLinkID
+		}
+		if origin != "" && !seen[origin] {
+			seen[origin] = true
+			origins = append(origins, origin)
+		}
+	}
+	text := strings.Join(origins[:min(len(origins), orderLinkMaxNames)], ", ")
+	if rest := len(origins) - orderLinkMaxNames; rest > 0 {
+		text += fmt.Sprintf(" e mais %d", rest)
+	}
+	return text
+}
+
+// originLine retorna a linha "Origem" no fim da mensagem das ordens ("" = nenhuma com orderLinkId).
+func (n orderLinkNames) originLine(orders []OrderData) string {
+	if origin := n.origin(orders); origin != "" {
+		return "\n   Origem: " + origin
+	}
+	return ""
+}
+
+// suffix retorna a origem no fim de uma linha de ordem ou execução (" | Origem: ..."; "" sem orderLinkId).
+func (n orderLinkNames) suffix(linkID string) string {
+	if label := n.label(linkID); label != "" {
+		return " | Origem: " + label
+	}
+	return ""
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Edição da mensagem da ordem: com a preferência order_message_edits da conta, ex.:
+// settings "Minha Conta" order_message_edits true, a mensagem de nova ordem Limit é enviada sozinha e, quando a ordem
+// é executada em parte, executada ou cancelada, a própria mensagem é editada com uma linha de status em vez de sair
+// uma mensagem nova, deixando o canal com uma mensagem por ordem. A edição usa a API do bot do Discord (discordbot.go),
+// então vale para o canal postado pelo bot; os webhooks não são editados e, como os canais em que a abertura foi
+// acumulada pelo limite ou não chegou, recebem a notificação normal do cancelamento ou da execução. As mensagens ficam
+// na memória da conexão: ordens abertas antes de iniciar o monitoramento seguem o comportamento normal.
+const orderMessageEditsSettingKey = "order_message_edits"
+
+// orderMessageMaxAge é por quanto tempo a mensagem de uma ordem ainda aberta continua sendo editada.
+const orderMessageMaxAge = 30 * 24 * time.Hour
+
+func validateOrderMessageEditsSetting(value json.RawMessage) error {
+	var enabled bool
+	if json.Unmarshal(value, &enabled) != nil {
+		return errors.New("use true ou false")
+	}
+	return nil
+}
+
+// orderMessageRef é a mensagem postada pelo bot do Discord: o ID para editar e o conteúdo original.
+type orderMessageRef struct {
+	bot       discordBotConfig
+	messageID string
+	content   string
+}
+
+// orderMessage é uma mensagem de nova ordem acompanhada: as ordens dela e a linha de status de cada uma.
+type orderMessage struct {
+	orderIDs   []string
+	status     map[string]string
+	channels   []string                   // canais da notificação da abertura
+	uneditable map[string]bool            // canais sem mensagem para editar (webhooks ou abertura acumulada)
+	refs       map[string]orderMessageRef // canal -> mensagem enviada (preenchido pela fila de envio)
+	sentAt     time.Time
+}
+
+// orderMessageTracker guarda as mensagens das ordens abertas da conexão.
+type orderMessageTracker struct {
+	mu      sync.Mutex
+	byOrder map[string]*orderMessage
+}
+
+// orderMessageEditsEnabled informa se a conta edita as mensagens das ordens.
+func orderMessageEditsEnabled(account *BybitAccount) bool {
+	return account.Settings.GetBool(orderMessageEditsSettingKey, false)
+}
+
+// isOpenOrder informa se a ordem ainda pode ser executada ou cancelada (e ter a mensagem editada).
+func isOpenOrder(order OrderData) bool {
+	return order.OrderStatus == "New" || order.OrderStatus == "PartiallyFilled"
+}
+
+// hasOpenOrder informa se alguma ordem do grupo continua aberta.
+func hasOpenOrder(orders []OrderData) bool {
+	for _, o := range orders {
+		if isOpenOrder(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// tracks informa se a mensagem da ordem está sendo acompanhada.
+func (t *orderMessageTracker) tracks(orderID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.byOrder[orderID]
+	return ok
+}
+
+// track passa a acompanhar a mensagem das ordens abertas do grupo, descartando as mensagens antigas demais.
+func (t *orderMessageTracker) track(orders []OrderData) *orderMessage {
+	msg := &orderMessage{status: make(map[string]string), uneditable: make(map[string]bool), refs: make(map[string]orderMessageRef),
+		sentAt: time.Now()}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.byOrder == nil {
+		t.byOrder = make(map[string]*orderMessage)
+	}
+	for orderID, tracked := range t.byOrder {
+		if time.Since(tracked.sentAt) > orderMessageMaxAge {
+			delete(t.byOrder, orderID)
+		}
+	}
+	for _, o := range orders {
+		if isOpenOrder(o) {
+			msg.orderIDs = append(msg.orderIDs, o.OrderID)
+			t.byOrder[o.OrderID] = msg
+		}
+	}
+	return msg
+}
+
+// addChannel registra um canal da notificação da abertura; editable = a mensagem foi enfileirada com o envio que
+// guarda o ID para as edições.
+func (t *orderMessageTracker) addChannel(msg *orderMessage, channel string, editable bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	msg.channels = append(msg.channels, channel)
+	if !editable {
+		msg.uneditable[channel] = true
+	}
+}
+
+// sender retorna o envio da mensagem pelo bot do Discord para deliverNotification, guardando o ID da mensagem
+// postada para as edições.
+func (t *orderMessageTracker) sender(account *BybitAccount, channel, message string, msg *orderMessage) func() error {
+	return func() error {
+		bot, ok := accountDiscordBot(account)
+		if !ok {
+			return errors.New("bot do Discord não configurado (token ou channel_id)")
+		}
+		messageID, err := postDiscordBotMessage(bot, message, "")
+		if err == nil && messageID != "" {
+			t.mu.Lock()
+			msg.refs[channel] = orderMessageRef{bot: bot, messageID: messageID, content: message}
+			t.mu.Unlock()
+		}
+		return err
+	}
+}
+
+// update grava a linha de status da ordem e retorna a mensagem dela (nil = não acompanhada); ordens executadas ou
+// canceladas deixam de ser acompanhadas.
+func (t *orderMessageTracker) update(order OrderData, line string) *orderMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	msg, ok := t.byOrder[order.OrderID]
+	if !ok {
+		return nil
+	}
+	msg.status[order.OrderID] = line
+	if !isOpenOrder(order) {
+		delete(t.byOrder, order.OrderID)
+	}
+	return msg
+}
+
+// content retorna o texto atual da mensagem no canal: o original com as linhas de status das ordens
+// (false = a mensagem não chegou ao canal).
+func (t *orderMessageTracker) content(msg *orderMessage, channel string) (orderMessageRef, string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ref, ok := msg.refs[channel]
+	if !ok {
+		return orderMessageRef{}, "", false
+	}
+	var lines []string
+	for _, orderID := range msg.orderIDs {
+		if line := msg.status[orderID]; line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return ref, ref.content + "\n\n" + strings.Join(lines, "\n"), true
+}
+
+// orderStatusLine formata a linha de status da ordem na mensagem editada, ex.:
+// "✅ BTCUSD Buy Limit @ 60000: executada às 14:32 (preço médio 59990)".
+func orderStatusLine(account *BybitAccount, order OrderData) string {
+	icons := accountIcons(account)
+	// O preço da ordem, como na mensagem da abertura (getDisplayPrice traz o preço médio das executadas)
+	price := order.Price
+	if price == "" {
+		price = getDisplayPrice(order)
+	}
+	orderText := fmt.Sprintf("%s %s %s @ %s", order.Symbol, order.Side, order.OrderType, price)
+	at := getAccountTime(account.Timezone).Format("15:04")
+	switch order.OrderStatus {
+	case "Filled":
+		line := fmt.Sprintf("%s%s: executada às %s", icons.prefix("", "filled"), orderText, at)
+		limit, _ := strconv.ParseFloat(price, 64)
+		if avg, err := strconv.ParseFloat(order.AvgPrice, 64); err == nil && avg > 0 && avg != limit {
+			line += fmt.Sprintf(" (preço médio %s)", strconv.FormatFloat(avg, 'f', -1, 64))
+		}
+		return icons.strip(line)
+	case "PartiallyFilled":
+		filled := ""
+		if order.CumExecQty != "" && order.Qty != "" {
+			filled = fmt.Sprintf(" (%s de %s)", order.CumExecQty, order.Qty)
+		}
+		return icons.strip(fmt.Sprintf("%s%s: executada em parte%s às %s", icons.prefix("", "partial"), orderText, filled, at))
+	}
+	return icons.strip(fmt.Sprintf("%s%s: cancelada às %s", icons.prefix(templateCancel, "cancel"), orderText, at))
+}
+
+// orderUpdateText é a notificação normal das ordens, para os canais em que a mensagem da abertura não pode ser
+// editada: o cancelamento como na mensagem padrão (ou no template da conta) e as execuções com a linha de status.
+func orderUpdateText(account *BybitAccount, orders []OrderData) string {
+	var cancelled []OrderData
+	var parts []string
+	for _, o := range orders {
+		if o.OrderStatus == "Cancelled" {
+			cancelled = append(cancelled, o)
+		} else {
+			parts = append(parts, orderStatusLine(account, o))
+		}
+	}
+	if len(cancelled) > 0 {
+		item := delayNotificationItem{NotificationType: "cancelled_order", Data: cancelled}
+		defaultText := formatCancelMessage(accountIcons(account), accountOrderLinkNames(account), cancelled)
+		if text := formatOrderNotification(account, item, nil, defaultText); text != "" {
+			parts = append([]string{text}, parts...)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// sendOrderUpdate enfileira para o canal a notificação normal das ordens, no lugar da edição.
+func (wsm *WebSocketManager) sendOrderUpdate(wsConn *WebSocketConnection, channel string, orders []OrderData) {
+	text := orderUpdateText(wsConn.Account(), orders)
+	if text == "" {
+		return
+	}
+	ids := orderCorrelationIDs(orders)
+	if !wsm.admitNotification(wsConn, channel, text, ids) {
+		return
+	}
+	account := wsConn.Account()
+	discordMsg := buildDiscordMessage(account, text, true, false)
+	wsConn.enqueueDelivery(channel, discordMsg, ids, func() {
+		deliverOrderUpdate(account, channel, discordMsg, ids)
+	})
+}
+
+// deliverOrderUpdate envia a notificação normal das ordens já montada (na fila de envio).
+func deliverOrderUpdate(account *BybitAccount, channel, discordMsg string, correlationIDs []string) {
+	err := deliverNotification(account.ID, channel, discordMsg, correlationIDs, channelSender(account, channel, discordMsg, "", correlationIDs))
+	if err != nil {
+		if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+			logger.Log("%s Erro ao enviar a atualização da ordem (%s): %v", correlationTag(correlationIDs...), notifyChannelLabel(channel), err)
+		}
+	}
+}
+
+// editOrderMessages edita as mensagens das ordens acompanhadas com o novo status (execução parcial, execução ou
+// cancelamento). As edições passam pela fila de envio, depois do envio da própria mensagem, e não contam no limite por
+// canal (channelratelimit.go). Nos canais sem a mensagem para editar (webhooks, abertura acumulada pelo limite ou com
+// falha no envio), sai a notificação normal do cancelamento ou da execução.
+func (wsm *WebSocketManager) editOrderMessages(wsConn *WebSocketConnection, orders []OrderData) {
+	t := &wsConn.orderMessages
+	var edited []*orderMessage
+	editedOrders := make(map[*orderMessage][]OrderData)
+	for _, o := range orders {
+		if o.OrderStatus == "Cancelled" && isEventMuted(wsConn.AccountID, templateCancel) {
+			t.update(o, "")
+			continue
+		}
+		msg := t.update(o, orderStatusLine(wsConn.Account(), o))
+		if msg == nil {
+			continue
+		}
+		if _, ok := editedOrders[msg]; !ok {
+			edited = append(edited, msg)
+		}
+		editedOrders[msg] = append(editedOrders[msg], o)
+	}
+
+	account := wsConn.Account()
+	for _, msg := range edited {
+		msg, ids := msg, orderCorrelationIDs(editedOrders[msg])
+		// Na fila, a edição aparece com o novo status das ordens
+		var lines []string
+		for _, o := range editedOrders[msg] {
+			lines = append(lines, orderStatusLine(account, o))
+		}
+		queued := strings.Join(lines, "\n")
+		t.mu.Lock()
+		channels := append([]string(nil), msg.channels...)
+		uneditable := make(map[string]bool, len(msg.uneditable))
+		for channel := range msg.uneditable {
+			uneditable[channel] = true
+		}
+		t.mu.Unlock()
+		updated := editedOrders[msg]
+		for _, channel := range channels {
+			channel := channel
+			if uneditable[channel] {
+				wsm.sendOrderUpdate(wsConn, channel, updated)
+				continue
+			}
+			wsConn.enqueueDelivery(channel, queued, ids, func() {
+				ref, content, ok := t.content(msg, channel)
+				if !ok {
+					// O envio da abertura falhou ou foi descartado: não há o que editar
+					if text := orderUpdateText(account, updated); text != "" {
+						deliverOrderUpdate(account, channel, buildDiscordMessage(account, text, true, false), ids)
+					}
+					return
+				}
+				err := deliverNotification(account.ID, channel, content, ids, func() error {
+					return editDiscordBotMessage(ref.bot, ref.messageID, content)
+				})
+				if err != nil {
+					if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+						logger.Log("%s Erro ao editar a mensagem da ordem (%s): %v", correlationTag(ids...), notifyChannelLabel(channel), err)
+					}
+				}
+			})
+		}
+	}
+}
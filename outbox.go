@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fila de envio no histórico: as notificações que ainda estão na fila de envio da conta (messagequeue.go) aparecem
+// no histórico do menu e da API junto com as enviadas e as que falharam, e o histórico pode ser filtrado por status
+// (na fila, enviadas ou falhas) para achar e reenviar as que não chegaram.
+
+// Status das notificações que ainda não saíram da fila de envio (não vão para o banco)
+const (
+	notificationStatusQueued  = "na fila"
+	notificationStatusSending = "enviando"
+)
+
+// QueuedNotification é uma notificação na fila de envio da conta.
+type QueuedNotification struct {
+	ID             int64 // posição na fila da conexão (não é o ID do histórico)
+	Channel        string
+	Message        string
+	Status         string // notificationStatusQueued ou notificationStatusSending
+	CorrelationIDs []string
+	QueuedAt       time.Time
+}
+
+// deliveryOutbox guarda as notificações da fila de envio até saírem (enviadas ou descartadas).
+type deliveryOutbox struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]*QueuedNotification
+}
+
+func (o *deliveryOutbox) add(job deliveryJob) int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.entries == nil {
+		o.entries = make(map[int64]*QueuedNotification)
+	}
+	o.nextID++
+	o.entries[o.nextID] = &QueuedNotification{ID: o.nextID, Channel: job.channel, Message: job.message, Status: notificationStatusQueued,
+		CorrelationIDs: uniqueCorrelationIDs(job.correlationIDs), QueuedAt: time.Now()}
+	return o.nextID
+}
+
+// sending marca a notificação como em envio pelo worker.
+func (o *deliveryOutbox) sending(id int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if entry, ok := o.entries[id]; ok {
+		entry.Status = notificationStatusSending
+	}
+}
+
+func (o *deliveryOutbox) remove(id int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.entries, id)
+}
+
+// list retorna as notificações na fila, da mais antiga para a mais nova.
+func (o *deliveryOutbox) list() []QueuedNotification {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	queued := make([]QueuedNotification, 0, len(o.entries))
+	for _, entry := range o.entries {
+		queued = append(queued, *entry)
+	}
+	sort.Slice(queued, func(i, j int) bool { return queued[i].ID < queued[j].ID })
+	return queued
+}
+
+// GetQueuedNotifications retorna as notificações na fila de envio da conta (false se não estiver sendo monitorada).
+func (wsm *WebSocketManager) GetQueuedNotifications(accountID int64) ([]QueuedNotification, bool) {
+	wsm.mu.RLock()
+	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+	return conn.outbox.list(), true
+}
+
+// parseNotificationStatusFilter lê o filtro de status do histórico: queued, sent, failed ou logged (ou os nomes em
+// português); "" = todas.
+func parseNotificationStatusFilter(input string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "":
+		return "", nil
+	case "queued", "fila", notificationStatusQueued:
+		return notificationStatusQueued, nil
+	case "sent", notificationStatusSent, "enviadas":
+		return notificationStatusSent, nil
+	case "failed", notificationStatusFailed, "falhas":
+		return notificationStatusFailed, nil
+	case "logged", notificationStatusLogged, "registradas":
+		return notificationStatusLogged, nil
+	}
+	return "", fmt.Errorf("status inválido: %s (use queued, sent, failed ou logged)", input)
+}
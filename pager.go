@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// pagerReservedLines são as linhas do cabeçalho, da barra de status e do prompt.
+const pagerReservedLines = 5
+const pagerDefaultPageSize = 20
+
+// runPager exibe as linhas página a página. Os comandos são lidos por linha (Enter),
+// o que funciona igual no Windows e no Linux sem colocar o terminal em modo raw:
+//
+//	Enter/j  próxima página      k/b  página anterior
+//	g        início              G    fim
+//	/texto   busca (regex)       n/N  próxima/anterior ocorrência
+//	número   vai para a linha    q    sair
+//
+// Quando a saída não é um terminal, imprime tudo de uma vez.
+func runPager(title string, lines []string, scanner *bufio.Scanner) {
+	pageSize := pagerPageSize()
+	if pageSize <= 0 {
+		fmt.Println(title)
+		fmt.Println()
+		for _, line := range lines {
+			fmt.Println(colorLogTimestamp(line))
+		}
+		fmt.Println("\n=== Fim ===")
+		fmt.Println("\nPressione Enter para voltar ao menu anterior...")
+		scanner.Scan()
+		return
+	}
+
+	top := 0
+	var search *regexp.Regexp
+	status := ""
+	maxTop := len(lines) - pageSize
+	if maxTop < 0 {
+		maxTop = 0
+	}
+
+	for {
+		if top > maxTop {
+			top = maxTop
+		}
+		if top < 0 {
+			top = 0
+		}
+		end := top + pageSize
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		clearScreen()
+		fmt.Println(title)
+		fmt.Println()
+		for _, line := range lines[top:end] {
+			if search != nil {
+				fmt.Println(highlightMatches(line, search))
+			} else {
+				fmt.Println(colorLogTimestamp(line))
+			}
+		}
+		for i := end - top; i < pageSize; i++ {
+			fmt.Println(colorDim("~"))
+		}
+
+		position := "FIM"
+		if end < len(lines) {
+			position = fmt.Sprintf("%d%%", end*100/len(lines))
+		}
+		fmt.Println(colorDim(fmt.Sprintf("-- linhas %d-%d de %d (%s) -- Enter/j: próx  k: ant  g/G: início/fim  /texto: buscar  n/N: ocorrência  q: sair --",
+			top+1, end, len(lines), position)))
+		if status != "" {
+			fmt.Println(colorYellow(status))
+			status = ""
+		}
+		fmt.Print(": ")
+
+		if !scanner.Scan() {
+			return
+		}
+		cmd := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case cmd == "" || cmd == "j" || cmd == " ":
+			if end >= len(lines) {
+				return
+			}
+			top += pageSize
+		case cmd == "k" || cmd == "b":
+			top -= pageSize
+		case cmd == "g":
+			top = 0
+		case cmd == "G":
+			top = maxTop
+		case cmd == "q" || cmd == "0":
+			return
+		case strings.HasPrefix(cmd, "/"):
+			pattern := strings.TrimSpace(cmd[1:])
+			if pattern == "" {
+				search = nil
+				continue
+			}
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				status = fmt.Sprintf("Expressão inválida: %v", err)
+				continue
+			}
+			search = re
+			if idx := findLine(lines, search, top, 1); idx >= 0 {
+				top = idx
+			} else {
+				status = "Nenhuma ocorrência encontrada"
+			}
+		case cmd == "n" || cmd == "N":
+			if search == nil {
+				status = "Nenhuma busca ativa (use /texto)"
+				continue
+			}
+			var idx int
+			if cmd == "n" {
+				idx = findLine(lines, search, top+1, 1)
+			} else {
+				idx = findLine(lines, search, top-1, -1)
+			}
+			if idx >= 0 {
+				top = idx
+			} else {
+				status = "Nenhuma outra ocorrência"
+			}
+		default:
+			if n, err := strconv.Atoi(cmd); err == nil {
+				top = n - 1
+				continue
+			}
+			status = fmt.Sprintf("Comando desconhecido: %s", cmd)
+		}
+	}
+}
+
+// pagerPageSize calcula quantas linhas cabem na tela. Retorna 0 quando a saída não é um terminal.
+func pagerPageSize() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	_, height, err := term.GetSize(fd)
+	if err != nil || height <= pagerReservedLines {
+		return pagerDefaultPageSize
+	}
+	return height - pagerReservedLines
+}
+
+// findLine procura a próxima linha (step=1) ou a anterior (step=-1) que casa com a busca, a partir de start.
+func findLine(lines []string, re *regexp.Regexp, start, step int) int {
+	for i := start; i >= 0 && i < len(lines); i += step {
+		if re.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// highlightMatches destaca as ocorrências da busca na linha.
+func highlightMatches(line string, re *regexp.Regexp) string {
+	if !colorsEnabled() {
+		return line
+	}
+	return re.ReplaceAllStringFunc(line, func(m string) string {
+		return ansiReverse + m + ansiReset
+	})
+}
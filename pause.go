@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Pausa do monitoramento: diferente de parar, a conexão com a corretora é fechada mas a conta continua registrada
+// no manager. Os buffers (atraso de agrupamento, carteira, planilha) e as filas seguem como estão, a conta continua
+// marcada para ser restaurada ao abrir o aplicativo e, ao retomar, as execuções do período pausado são recuperadas
+// pela API REST a partir da última mensagem recebida, como numa reconexão. Útil para manutenções curtas (rede,
+// firewall, troca de IP) sem perder o ponto de recuperação. Na OKX, só a conexão privada é pausada.
+
+var (
+	errNotMonitored  = errors.New("a conta não está sendo monitorada")
+	errAlreadyPaused = errors.New("o monitoramento da conta já está pausado")
+	errNotPaused     = errors.New("o monitoramento da conta não está pausado")
+)
+
+// PauseConnection pausa o monitoramento da conta; com d > 0, retoma sozinho depois de d.
+func (wsm *WebSocketManager) PauseConnection(accountID int64, d time.Duration) error {
+	wsm.mu.RLock()
+	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
+	if !exists || !conn.running() {
+		return errNotMonitored
+	}
+
+	conn.mu.Lock()
+	if conn.paused {
+		conn.mu.Unlock()
+		return errAlreadyPaused
+	}
+	conn.paused = true
+	conn.resume = make(chan struct{})
+	conn.pausedAt = time.Now()
+	conn.pausedUntil = time.Time{}
+	if d > 0 {
+		conn.pausedUntil = conn.pausedAt.Add(d)
+	}
+	if conn.Conn != nil {
+		// runConnection vê a pausa quando a leitura falhar e espera a retomada em vez de reconectar
+		conn.Conn.Close()
+	}
+	conn.mu.Unlock()
+
+	if logger, _ := getLogger(accountID, conn.Account().Name); logger != nil {
+		if d > 0 {
+			logger.Log("⏸️ Monitoramento pausado por %s (buffers e ponto de recuperação mantidos)", formatElapsed(d))
+		} else {
+			logger.Log("⏸️ Monitoramento pausado (buffers e ponto de recuperação mantidos)")
+		}
+	}
+	return nil
+}
+
+// ResumeConnection retoma o monitoramento pausado da conta.
+func (wsm *WebSocketManager) ResumeConnection(accountID int64) error {
+	wsm.mu.RLock()
+	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
+	if !exists || !conn.running() {
+		return errNotMonitored
+	}
+	if !conn.resumeFromPause("") {
+		return errNotPaused
+	}
+	return nil
+}
+
+// resumeFromPause encerra a pausa (false se não estava pausada); reason complementa o log.
+func (c *WebSocketConnection) resumeFromPause(reason string) bool {
+	c.mu.Lock()
+	if !c.paused {
+		c.mu.Unlock()
+		return false
+	}
+	pausedFor := time.Since(c.pausedAt)
+	c.paused = false
+	close(c.resume)
+	c.mu.Unlock()
+
+	if logger, _ := getLogger(c.AccountID, c.Account().Name); logger != nil {
+		logger.Log("▶️ Monitoramento retomado%s após %s de pausa", reason, formatElapsed(pausedFor))
+	}
+	return true
+}
+
+// isPaused informa se o monitoramento da conta está pausado.
+func (c *WebSocketConnection) isPaused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// markPaused registra a conexão fechada pela pausa, sem contar como queda (sem alerta de conexão).
+func (c *WebSocketConnection) markPaused() {
+	c.mu.Lock()
+	c.Connected = false
+	// Queda em andamento antes da pausa e ainda sem alerta: a pausa não conta como queda
+	if c.downTimer != nil && !c.downAlertSent {
+		c.downTimer.Stop()
+		c.downTimer = nil
+		c.downSince = time.Time{}
+	}
+	c.mu.Unlock()
+}
+
+// waitWhilePaused bloqueia enquanto a conta estiver pausada, retomando sozinha no fim do prazo, se houver.
+// Retorna false se o monitoramento foi parado durante a pausa.
+func (c *WebSocketConnection) waitWhilePaused() bool {
+	for {
+		c.mu.Lock()
+		paused, resume, until := c.paused, c.resume, c.pausedUntil
+		c.mu.Unlock()
+		if !paused {
+			return true
+		}
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if !until.IsZero() {
+			timer = time.NewTimer(time.Until(until))
+			expired = timer.C
+		}
+		select {
+		case <-c.ctx.Done():
+		case <-resume:
+		case <-expired:
+			c.resumeFromPause(" (fim do prazo)")
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if c.ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// handlePauseChoice pergunta, no menu de parar, se a conta deve ser parada, pausada ou retomada. Retorna false
+// quando a escolha é parar (o chamador para a conta).
+func handlePauseChoice(wsManager *WebSocketManager, account *BybitAccount, scanner *bufio.Scanner) bool {
+	health, ok := wsManager.GetConnectionHealth(account.ID)
+	if !ok {
+		return false
+	}
+	if health.Paused {
+		fmt.Printf("\nA conta '%s' está %s.\n", account.Name, health.pauseStatus())
+		fmt.Println("1. Retomar monitoramento")
+		fmt.Println("2. Parar monitoramento")
+		fmt.Print("Escolha (Enter = retomar): ")
+		scanner.Scan()
+		if strings.TrimSpace(scanner.Text()) == "2" {
+			return false
+		}
+		if err := wsManager.ResumeConnection(account.ID); err != nil {
+			printErrorf("Erro ao retomar: %v\n", err)
+		} else {
+			fmt.Printf("Monitoramento retomado para conta '%s'!\n", account.Name)
+		}
+		return true
+	}
+
+	fmt.Println("\n1. Parar monitoramento")
+	fmt.Println("2. Pausar (fecha a conexão, mas mantém buffers e ponto de recuperação e retoma sem perder execuções)")
+	fmt.Print("Escolha (Enter = parar): ")
+	scanner.Scan()
+	if strings.TrimSpace(scanner.Text()) != "2" {
+		return false
+	}
+	fmt.Print("Retomar automaticamente depois de (ex.: 15m, 1h; Enter = só manualmente): ")
+	scanner.Scan()
+	var d time.Duration
+	if input := strings.TrimSpace(scanner.Text()); input != "" {
+		parsed, err := time.ParseDuration(input)
+		if err != nil || parsed <= 0 {
+			fmt.Println(colorRed("Duração inválida! A conta não foi pausada."))
+			return true
+		}
+		d = parsed
+	}
+	if err := wsManager.PauseConnection(account.ID, d); err != nil {
+		printErrorf("Erro ao pausar: %v\n", err)
+	} else {
+		fmt.Printf("Monitoramento pausado para conta '%s'!\n", account.Name)
+	}
+	return true
+}
+
+// pauseStatus descreve a pausa para exibição (ex.: "pausada há 3m" ou "pausada há 3m, retoma às 15:40").
+func (h ConnectionHealth) pauseStatus() string {
+	text := fmt.Sprintf("pausada há %s", formatElapsed(time.Since(h.PausedAt)))
+	if h.PausedUntil != nil {
+		text += ", retoma às " + h.PausedUntil.Format("15:04")
+	}
+	return text
+}
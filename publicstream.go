@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Streams públicos da Bybit (inverse): uma única conexão, compartilhada por todas as contas, para tickers,
+// liquidações e klines. Quem precisa de um tópico (alertas de preço, feed de liquidações, funding...) se inscreve
+// com Subscribe e recebe as mensagens no handler; o tópico é inscrito na corretora enquanto tiver ao menos um
+// inscrito. A conexão só é aberta quando há tópicos e reconecta com a política padrão de reconexão (RECONNECT_*),
+// reinscrevendo todos os tópicos.
+const (
+	bybitPublicWSURL        = "wss://stream.bybit.com/v5/public/inverse"
+	publicSubscribeBatch    = 10 // tópicos por requisição de subscribe/unsubscribe
+	publicReadTimeout       = 60 * time.Second
+	publicWriteTimeout      = 10 * time.Second
+	publicStreamStaleAfter  = 2 * time.Minute
+	publicStreamReqIDPrefix = "pub:"
+)
+
+// publicTopicTicker é o tópico de ticker do símbolo (preço, funding), usado pelo acompanhamento de funding.
+func publicTopicTicker(symbol string) string { return "tickers." + symbol }
+
+// PublicMessage é uma mensagem de um tópico público; Data fica crua para cada inscrito decodificar o seu formato.
+type PublicMessage struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"` // snapshot ou delta
+	TS    int64           `json:"ts"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// PublicHandler recebe as mensagens do tópico no loop de leitura da conexão pública: precisa ser rápido (enfileirar
+// o trabalho pesado), ou atrasa os demais inscritos.
+type PublicHandler func(msg PublicMessage)
+
+// PublicSubscription é a inscrição de um interessado num tópico; Unsubscribe encerra.
+type PublicSubscription struct {
+	manager *PublicStreamManager
+	topic   string
+	id      int64
+	once    sync.Once
+}
+
+// PublicStreamManager mantém a conexão pública e os inscritos de cada tópico.
+type PublicStreamManager struct {
+	ctx context.Context
+	url string
+
+	mu            sync.Mutex
+	handlers      map[string]map[int64]PublicHandler // tópico -> inscrição -> handler
+	nextID        int64
+	running       bool
+	wake          chan struct{} // primeira inscrição com a conexão ociosa
+	conn          *websocket.Conn
+	writeMu       sync.Mutex // gorilla/websocket aceita um escritor por vez
+	connected     bool
+	connectedAt   time.Time
+	lastMessageAt time.Time
+	reconnects    int
+	lastError     string
+}
+
+// PublicStreamStatus é um retrato da conexão pública para exibição.
+type PublicStreamStatus struct {
+	Connected      bool           `json:"connected"`
+	Stale          bool           `json:"stale"`
+	ConnectedAt    *time.Time     `json:"connected_at,omitempty"`
+	LastMessageAt  *time.Time     `json:"last_message_at,omitempty"`
+	ReconnectCount int            `json:"reconnect_count"`
+	LastError      string         `json:"last_error,omitempty"`
+	Topics         map[string]int `json:"topics"` // tópico -> inscritos
+}
+
+func NewPublicStreamManager(ctx context.Context, url string) *PublicStreamManager {
+	return &PublicStreamManager{
+		ctx:      ctx,
+		url:      url,
+		handlers: make(map[string]map[int64]PublicHandler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Subscribe inscreve handler no tópico. A conexão é aberta na primeira inscrição; se já estiver aberta e o tópico
+// for novo, ele é inscrito na hora.
+func (m *PublicStreamManager) Subscribe(topic string, handler PublicHandler) *PublicSubscription {
+	m.mu.Lock()
+	m.nextID++
+	sub := &PublicSubscription{manager: m, topic: topic, id: m.nextID}
+	isNew := len(m.handlers[topic]) == 0
+	if isNew {
+		m.handlers[topic] = make(map[int64]PublicHandler)
+	}
+	m.handlers[topic][sub.id] = handler
+	conn := m.subscribedConn()
+	if !m.running {
+		m.running = true
+		go m.run()
+	}
+	m.mu.Unlock()
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	if isNew && conn != nil {
+		if err := m.send(conn, "subscribe", []string{topic}); err != nil {
+			// A conexão caiu; a reconexão inscreve todos os tópicos de novo
+			m.noteError(err)
+		}
+	}
+	return sub
+}
+
+// Unsubscribe remove a inscrição; o último inscrito de um tópico o remove da corretora.
+func (s *PublicSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		m := s.manager
+		m.mu.Lock()
+		handlers := m.handlers[s.topic]
+		delete(handlers, s.id)
+		last := len(handlers) == 0
+		if last {
+			delete(m.handlers, s.topic)
+		}
+		conn := m.subscribedConn()
+		idle := len(m.handlers) == 0
+		m.mu.Unlock()
+		if last && conn != nil {
+			m.send(conn, "unsubscribe", []string{s.topic})
+		}
+		if idle && conn != nil {
+			// Sem tópicos, a conexão é fechada e run aguarda a próxima inscrição
+			conn.Close()
+		}
+	})
+}
+
+// subscribedConn retorna a conexão que já enviou a inscrição inicial (nil = quem conectar inscreve tudo).
+// Chamado com m.mu travado.
+func (m *PublicStreamManager) subscribedConn() *websocket.Conn {
+	if !m.connected {
+		return nil
+	}
+	return m.conn
+}
+
+// topics retorna os tópicos com inscritos, em ordem.
+func (m *PublicStreamManager) topics() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.topicsLocked()
+}
+
+func (m *PublicStreamManager) topicsLocked() []string {
+	topics := make([]string, 0, len(m.handlers))
+	for topic := range m.handlers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// send envia subscribe/unsubscribe em lotes de publicSubscribeBatch tópicos.
+func (m *PublicStreamManager) send(conn *websocket.Conn, op string, topics []string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	for start := 0; start < len(topics); start += publicSubscribeBatch {
+		end := start + publicSubscribeBatch
+		if end > len(topics) {
+			end = len(topics)
+		}
+		conn.SetWriteDeadline(time.Now().Add(publicWriteTimeout))
+		err := conn.WriteJSON(map[string]interface{}{
+			"req_id": publicStreamReqIDPrefix + op,
+			"op":     op,
+			"args":   topics[start:end],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run mantém a conexão enquanto houver tópicos, até o aplicativo sair.
+func (m *PublicStreamManager) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "[PANIC] stream público: %v\n", redactSecrets(fmt.Sprint(r)))
+			reportPanic(0, "stream público", "PublicStreamManager.run", r)
+			m.mu.Lock()
+			m.running = false
+			m.mu.Unlock()
+		}
+	}()
+	policy, _ := loadReconnectPolicy(nil)
+	retryDelay := policy.InitialDelay
+	for {
+		if len(m.topics()) == 0 {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-m.wake:
+				continue
+			}
+		}
+
+		connected, err := m.connectAndListen()
+		if m.ctx.Err() != nil {
+			return
+		}
+		if len(m.topics()) == 0 {
+			// Fechada pelo último Unsubscribe
+			retryDelay = policy.InitialDelay
+			continue
+		}
+		if connected {
+			retryDelay = policy.InitialDelay
+		}
+		if err != nil {
+			m.noteError(err)
+		}
+		m.mu.Lock()
+		m.reconnects++
+		m.mu.Unlock()
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+		if !connected {
+			retryDelay = policy.nextDelay(retryDelay)
+		}
+	}
+}
+
+// noteError guarda o erro para o status e avisa no stderr quando ele muda (evita repetir a cada tentativa).
+func (m *PublicStreamManager) noteError(err error) {
+	text := redactSecrets(err.Error())
+	m.mu.Lock()
+	changed := text != m.lastError
+	m.lastError = text
+	m.mu.Unlock()
+	if changed {
+		fmt.Fprintf(os.Stderr, "[AVISO] Stream público da Bybit: %s\n", text)
+	}
+}
+
+// connectAndListen conecta, inscreve os tópicos e lê até a conexão cair. connected indica que a inscrição chegou
+// a ser enviada (para reiniciar a espera entre tentativas).
+func (m *PublicStreamManager) connectAndListen() (connected bool, err error) {
+	conn, closeConn, err := dialStream(m.ctx, m.url)
+	if err != nil {
+		return false, fmt.Errorf("erro ao conectar: %w", err)
+	}
+	// Marca como conectada junto com a lista de tópicos: quem se inscrever depois envia o próprio subscribe
+	now := time.Now()
+	m.mu.Lock()
+	m.conn = conn
+	m.connected = true
+	m.connectedAt = now
+	m.lastMessageAt = now
+	topics := m.topicsLocked()
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.conn = nil
+		m.connected = false
+		m.mu.Unlock()
+		closeConn()
+	}()
+
+	if err := m.send(conn, "subscribe", topics); err != nil {
+		return false, fmt.Errorf("erro ao inscrever: %w", err)
+	}
+	m.mu.Lock()
+	recovered := m.lastError != ""
+	m.lastError = ""
+	m.mu.Unlock()
+	if recovered {
+		fmt.Fprintf(os.Stderr, "Stream público da Bybit reconectado\n")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(publicReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(publicReadTimeout))
+		return nil
+	})
+	pingCtx, stopPing := context.WithCancel(m.ctx)
+	defer stopPing()
+	go pingLoop(pingCtx, conn)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(publicReadTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if m.ctx.Err() != nil || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return true, nil
+			}
+			return true, fmt.Errorf("erro na leitura: %w", err)
+		}
+		m.handleMessage(message)
+	}
+}
+
+// handleMessage despacha a mensagem aos inscritos do tópico; respostas de subscribe recusadas vão para o stderr.
+func (m *PublicStreamManager) handleMessage(message []byte) {
+	var envelope struct {
+		Op      string `json:"op"`
+		Success bool   `json:"success"`
+		RetMsg  string `json:"ret_msg"`
+		PublicMessage
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return
+	}
+	m.mu.Lock()
+	m.lastMessageAt = time.Now()
+	m.mu.Unlock()
+
+	if envelope.Op != "" {
+		if (envelope.Op == "subscribe" || envelope.Op == "unsubscribe") && !envelope.Success {
+			m.noteError(fmt.Errorf("%s recusado: %s", envelope.Op, envelope.RetMsg))
+		}
+		return
+	}
+	if envelope.Topic == "" {
+		return
+	}
+
+	m.mu.Lock()
+	handlers := make([]PublicHandler, 0, len(m.handlers[envelope.Topic]))
+	for _, handler := range m.handlers[envelope.Topic] {
+		handlers = append(handlers, handler)
+	}
+	m.mu.Unlock()
+	for _, handler := range handlers {
+		m.dispatch(handler, envelope.PublicMessage)
+	}
+}
+
+// dispatch chama o handler isolando panics, para que um inscrito com defeito não derrube a conexão dos demais.
+func (m *PublicStreamManager) dispatch(handler PublicHandler, msg PublicMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "[PANIC] inscrito do tópico público %s: %v\n", msg.Topic, redactSecrets(fmt.Sprint(r)))
+			reportPanic(0, "stream público", "PublicHandler "+msg.Topic, r)
+		}
+	}()
+	handler(msg)
+}
+
+// Status retorna o estado da conexão pública e os inscritos por tópico.
+func (m *PublicStreamManager) Status() PublicStreamStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := PublicStreamStatus{
+		Connected:      m.connected,
+		ReconnectCount: m.reconnects,
+		LastError:      m.lastError,
+		Topics:         make(map[string]int, len(m.handlers)),
+	}
+	for topic, handlers := range m.handlers {
+		status.Topics[topic] = len(handlers)
+	}
+	status.Stale = len(m.handlers) > 0 && (!m.connected || time.Since(m.lastMessageAt) > publicStreamStaleAfter)
+	if !m.connectedAt.IsZero() {
+		connectedAt := m.connectedAt
+		status.ConnectedAt = &connectedAt
+	}
+	if !m.lastMessageAt.IsZero() {
+		lastMessageAt := m.lastMessageAt
+		status.LastMessageAt = &lastMessageAt
+	}
+	return status
+}
+
+// String resume o status numa linha (ex.: "conectado, 3 tópico(s): tickers.BTCUSD (2), ...").
+func (s PublicStreamStatus) String() string {
+	state := "desconectado"
+	if s.Connected {
+		state = "conectado"
+	}
+	if len(s.Topics) == 0 {
+		return "ocioso (nenhum tópico)"
+	}
+	topics := make([]string, 0, len(s.Topics))
+	for topic, n := range s.Topics {
+		topics = append(topics, fmt.Sprintf("%s (%d)", topic, n))
+	}
+	sort.Strings(topics)
+	text := fmt.Sprintf("%s, %d tópico(s): %s", state, len(s.Topics), strings.Join(topics, ", "))
+	if s.LastError != "" {
+		text += "; último erro: " + s.LastError
+	}
+	return text
+}
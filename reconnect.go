@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reconnectPolicy controla as reconexões de runConnection: a espera começa em InitialDelay e é multiplicada por
+// Multiplier a cada falha, até MaxDelay. Após CleanupFailures falhas seguidas (0 = nunca) a conexão é limpa,
+// aguarda CleanupPause e a espera volta ao início.
+type reconnectPolicy struct {
+	InitialDelay    time.Duration
+	MaxDelay        time.Duration
+	Multiplier      float64
+	CleanupFailures int
+	CleanupPause    time.Duration
+}
+
+var defaultReconnectPolicy = reconnectPolicy{
+	InitialDelay:    5 * time.Second,
+	MaxDelay:        time.Minute,
+	Multiplier:      2,
+	CleanupFailures: 10,
+	CleanupPause:    30 * time.Second,
+}
+
+// reconnectSettingKey é a preferência da conta que sobrepõe os valores globais, ex.:
+// settings "Minha Conta" reconnect '{"initial_delay":"10s","max_delay":"5m","multiplier":1.5}'
+const reconnectSettingKey = "reconnect"
+
+// reconnectFields liga cada parâmetro à sua variável de ambiente (valor global).
+var reconnectFields = []struct{ key, env string }{
+	{"initial_delay", "RECONNECT_INITIAL_DELAY"},
+	{"max_delay", "RECONNECT_MAX_DELAY"},
+	{"multiplier", "RECONNECT_MULTIPLIER"},
+	{"cleanup_failures", "RECONNECT_CLEANUP_FAILURES"},
+	{"cleanup_pause", "RECONNECT_CLEANUP_PAUSE"},
+}
+
+// loadReconnectPolicy monta a política da conta: padrão, depois as variáveis RECONNECT_*, depois a preferência
+// reconnect da conta. Valores inválidos ficam com o anterior e são devolvidos no erro, para aviso no log.
+func loadReconnectPolicy(settings AccountSettings) (reconnectPolicy, error) {
+	policy := defaultReconnectPolicy
+	var problems []string
+	for _, field := range reconnectFields {
+		if value := strings.TrimSpace(os.Getenv(field.env)); value != "" {
+			if err := policy.set(field.key, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", field.env, err))
+			}
+		}
+	}
+	var values map[string]interface{}
+	if settings.Decode(reconnectSettingKey, &values) {
+		for _, field := range reconnectFields {
+			if value, ok := values[field.key]; ok {
+				if err := policy.set(field.key, fmt.Sprint(value)); err != nil {
+					problems = append(problems, fmt.Sprintf("%s.%s: %v", reconnectSettingKey, field.key, err))
+				}
+			}
+		}
+	}
+	if policy.MaxDelay < policy.InitialDelay {
+		problems = append(problems, fmt.Sprintf("espera máxima (%s) menor que a inicial (%s)", policy.MaxDelay, policy.InitialDelay))
+		policy.MaxDelay = policy.InitialDelay
+	}
+	if len(problems) > 0 {
+		return policy, errors.New(strings.Join(problems, "; "))
+	}
+	return policy, nil
+}
+
+// set altera um parâmetro a partir do texto (durações como "30s"/"5m" ou em segundos).
+func (p *reconnectPolicy) set(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "initial_delay", "max_delay", "cleanup_pause":
+		d, err := parseReconnectDuration(value)
+		if err != nil {
+			return err
+		}
+		if key == "cleanup_pause" {
+			p.CleanupPause = d
+			return nil
+		}
+		if d < time.Second {
+			return fmt.Errorf("mínimo de 1s: %s", value)
+		}
+		if key == "initial_delay" {
+			p.InitialDelay = d
+		} else {
+			p.MaxDelay = d
+		}
+	case "multiplier":
+		m, err := strconv.ParseFloat(value, 64)
+		if err != nil || m < 1 || m > 10 {
+			return fmt.Errorf("multiplicador inválido: %s (de 1 a 10)", value)
+		}
+		p.Multiplier = m
+	case "cleanup_failures":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("quantidade de falhas inválida: %s (0 = sem limpeza)", value)
+		}
+		p.CleanupFailures = n
+	default:
+		return fmt.Errorf("parâmetro desconhecido: %s", key)
+	}
+	return nil
+}
+
+func parseReconnectDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("duração inválida: %s (ex.: 30s, 5m)", value)
+	}
+	return d, nil
+}
+
+// nextDelay é a espera após mais uma falha, limitada a MaxDelay.
+func (p reconnectPolicy) nextDelay(current time.Duration) time.Duration {
+	next := time.Duration(float64(current) * p.Multiplier)
+	if next > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return next
+}
+
+func (p reconnectPolicy) String() string {
+	cleanup := "sem limpeza forçada"
+	if p.CleanupFailures > 0 {
+		cleanup = fmt.Sprintf("limpeza após %d falhas com pausa de %s", p.CleanupFailures, p.CleanupPause)
+	}
+	return fmt.Sprintf("espera de %s a %s (x%g), %s", p.InitialDelay, p.MaxDelay, p.Multiplier, cleanup)
+}
+
+// validateReconnectSetting valida a preferência reconnect pelo comando settings.
+func validateReconnectSetting(value json.RawMessage) error {
+	var values map[string]interface{}
+	if json.Unmarshal(value, &values) != nil {
+		return errors.New(`use um objeto JSON, ex.: {"initial_delay":"10s","max_delay":"5m","multiplier":1.5,"cleanup_failures":20,"cleanup_pause":"1m"}`)
+	}
+	policy := defaultReconnectPolicy
+	for key, v := range values {
+		if err := policy.set(key, fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if policy.MaxDelay < policy.InitialDelay {
+		return fmt.Errorf("espera máxima (%s) menor que a inicial (%s)", policy.MaxDelay, policy.InitialDelay)
+	}
+	return nil
+}
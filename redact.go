@@ -0,0 +1,110 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedPlaceholder substitui segredos em logs e mensagens de erro.
+const redactedPlaceholder = "[REDACTED]"
+
+// minRedactLength evita mascarar valores curtos demais (ex.: "{}"), que apareceriam em qualquer texto.
+const minRedactLength = 8
+
+// secretRedactor guarda os valores sensíveis conhecidos (API keys, secrets, passphrases, webhooks)
+// para removê-los de qualquer texto antes de ir para os logs.
+type secretRedactor struct {
+	mu       sync.RWMutex
+	values   map[string]string // valor -> substituto
+	replacer *strings.Replacer
+}
+
+var redactor = &secretRedactor{values: make(map[string]string)}
+
+// Padrões removidos mesmo quando o valor não foi registrado (ex.: URL digitada errada no cadastro)
+var redactPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// Token do webhook do Discord: https://discord.com/api/webhooks/<id>/<token>
+	{regexp.MustCompile(`(https://(?:[a-z]+\.)?discord(?:app)?\.com/api/webhooks/\d+/)[A-Za-z0-9_.-]+`), "${1}" + redactedPlaceholder},
+	// ID do deploy do Google Apps Script
+	{regexp.MustCompile(`(https://script\.google\.com/macros/s/)[A-Za-z0-9_-]+`), "${1}" + redactedPlaceholder},
+	// Campos sensíveis em JSON ou em mapas impressos com %v
+	{regexp.MustCompile(`(?i)("(?:api_?secret|secret|passphrase|sign|signature|apiKey|api_key)"\s*:\s*")[^"]*(")`), "${1}" + redactedPlaceholder + "${2}"},
+	{regexp.MustCompile(`(?i)\b((?:api_?secret|passphrase|sign|signature|apiKey|api_key):)[^\s\]]+`), "${1}" + redactedPlaceholder},
+}
+
+// registerSecret registra um valor que nunca deve aparecer nos logs.
+func registerSecret(value, replacement string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minRedactLength {
+		return
+	}
+	redactor.mu.Lock()
+	defer redactor.mu.Unlock()
+	if current, exists := redactor.values[value]; exists && current == replacement {
+		return
+	}
+	redactor.values[value] = replacement
+	redactor.replacer = nil
+}
+
+// registerAccountSecrets registra API key (mascarada), secret, passphrase e webhooks da conta (com o secret do genérico).
+func registerAccountSecrets(acc *BybitAccount) {
+	registerSecret(acc.APIKey, maskAPIKey(acc.APIKey))
+	registerSecret(acc.APISecret, redactedPlaceholder)
+	registerSecret(metadataPassphrase(acc.Metadata), redactedPlaceholder)
+	registerSecret(acc.WebhookURL, redactedPlaceholder)
+	registerSecret(acc.WebhookURLExecutions, redactedPlaceholder)
+	registerSecret(acc.WebhookURLGoogleSheets, redactedPlaceholder)
+	if config, ok := accountGenericWebhook(acc); ok {
+		registerSecret(config.URL, redactedPlaceholder)
+		registerSecret(config.Secret, redactedPlaceholder)
+	}
+	if config, ok := accountDiscordBot(acc); ok {
+		registerSecret(config.Token, redactedPlaceholder)
+	}
+}
+
+// redactSecrets remove os segredos conhecidos e os padrões sensíveis do texto.
+func redactSecrets(s string) string {
+	redactor.mu.RLock()
+	replacer := redactor.replacer
+	hasValues := len(redactor.values) > 0
+	redactor.mu.RUnlock()
+
+	if replacer == nil && hasValues {
+		replacer = redactor.buildReplacer()
+	}
+	if replacer != nil {
+		s = replacer.Replace(s)
+	}
+	for _, p := range redactPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+// buildReplacer monta o Replacer com os valores mais longos primeiro, para que um valor contido
+// em outro (ex.: URL dentro de outra URL) não deixe pedaços do maior visíveis.
+func (r *secretRedactor) buildReplacer() *strings.Replacer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replacer != nil {
+		return r.replacer
+	}
+	keys := make([]string, 0, len(r.values))
+	for k := range r.values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	pairs := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		pairs = append(pairs, k, r.values[k])
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+	return r.replacer
+}
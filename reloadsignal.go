@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReloadSignal encaminha o SIGHUP (ex.: systemctl reload, kill -HUP) para a recarga das configurações.
+func notifyReloadSignal(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGHUP)
+}
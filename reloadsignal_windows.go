@@ -0,0 +1,6 @@
+package main
+
+import "os"
+
+// notifyReloadSignal não faz nada no Windows, que não tem SIGHUP: a recarga vem do arquivo ou da API.
+func notifyReloadSignal(ch chan<- os.Signal) {}
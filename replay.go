@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Reprodução de uma captura crua (capture <conta> dump): os payloads passam pelo mesmo processamento das mensagens
+// recebidas do WebSocket (fila, buffer de atraso, agrupamento, envio), no ritmo original ou acelerado, para
+// reproduzir um problema ou conferir uma alteração com tráfego real. A conta é copiada, sem credenciais, para um
+// banco em memória e os logs vão para <dados>/replay/logs: a reprodução não altera o banco nem os logs da conta.
+// Sem --webhook, as notificações são impressas em vez de enviadas; o webhook da planilha nunca é chamado.
+const replayUsage = "uso: replay <conta> <arquivo.jsonl|-> [--speed <N>|max] [--webhook <url do Discord>]"
+
+// replayPlaceholderWebhook é usado quando a conta não tem webhook do Discord, para as notificações serem geradas
+// (e impressas) mesmo assim.
+const replayPlaceholderWebhook = "https://discord.com/api/webhooks/0/replay"
+
+// replayOutput recebe as notificações no lugar dos webhooks durante a reprodução (nil = envio normal).
+var replayOutput *replaySink
+
+type replaySink struct {
+	mu      sync.Mutex
+	out     io.Writer
+	discord bool              // imprimir também as do Discord (reprodução sem --webhook)
+	labels  map[string]string // webhook do Discord -> canal, para o cabeçalho
+	tz      *time.Location    // fuso da conta, para o horário do cabeçalho
+}
+
+// interceptReplayNotification imprime a notificação durante a reprodução; true = não enviar ao webhook.
+func interceptReplayNotification(discord bool, webhookURL, content string) bool {
+	sink := replayOutput
+	if sink == nil || (discord && !sink.discord) {
+		return false
+	}
+	label := notifyChannelLabel(notifyChannelGoogleSheets)
+	if discord {
+		label = sink.labels[webhookURL]
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	fmt.Fprintf(sink.out, "%s\n%s\n\n", colorCyan("── "+label+" "+time.Now().In(sink.tz).Format("15:04:05")+" ──"), content)
+	return true
+}
+
+// readReplayFile lê o dump (JSON Lines, em ordem cronológica); "-" lê do stdin.
+func readReplayFile(path string) ([]rawMessageDump, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var entries []rawMessageDump
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry rawMessageDump
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("linha %d: %w", line, err)
+		}
+		// Payloads que não eram JSON foram exportados como texto
+		var text string
+		if json.Unmarshal(entry.Payload, &text) == nil {
+			entry.Payload = json.RawMessage(text)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// copyAccountForReplay cadastra no banco da reprodução uma cópia da conta sem credenciais. Com webhook, as
+// notificações do Discord (ordens e, se configurado, execuções) vão para ele.
+func copyAccountForReplay(manager *AccountManager, account *BybitAccount, webhook string) (*BybitAccount, error) {
+	replica := &BybitAccount{
+		Name:                           account.Name,
+		APIKey:                         "replay",
+		WebhookURL:                     account.WebhookURL,
+		MarkEveryoneOrder:              account.MarkEveryoneOrder,
+		MarkEveryoneWallet:             account.MarkEveryoneWallet,
+		WebhookURLGoogleSheets:         account.WebhookURLGoogleSheets,
+		SheetURLGoogleSheets:           account.SheetURLGoogleSheets,
+		WebhookURLExecutions:           account.WebhookURLExecutions,
+		MarkEveryoneExecution:          account.MarkEveryoneExecution,
+		SheetURLGoogleSheetsExecutions: account.SheetURLGoogleSheetsExecutions,
+		Platform:                       account.Platform,
+		NotificationDelaySeconds:       account.NotificationDelaySeconds,
+		Timezone:                       account.Timezone,
+		Settings:                       account.Settings,
+	}
+	switch {
+	case webhook != "":
+		replica.WebhookURL = webhook
+		if replica.WebhookURLExecutions != "" {
+			replica.WebhookURLExecutions = webhook
+		}
+		// O bot postaria no canal real: o canal principal volta para o webhook de teste
+		if _, hasBot := replica.Settings[discordBotSettingKey]; hasBot {
+			replica.Settings = make(AccountSettings, len(account.Settings))
+			for key, value := range account.Settings {
+				if key != discordBotSettingKey {
+					replica.Settings[key] = value
+				}
+			}
+		}
+	case replica.WebhookURL == "":
+		replica.WebhookURL = replayPlaceholderWebhook
+	}
+	if err := manager.AddAccount(replica); err != nil {
+		return nil, err
+	}
+	return manager.GetAccount(replica.ID)
+}
+
+// openReplayDatabase abre o banco em memória da reprodução (com as migrations aplicadas).
+func openReplayDatabase() (*Database, error) {
+	previous := dbPathOverride
+	dbPathOverride = inMemoryDatabasePath
+	defer func() { dbPathOverride = previous }()
+	return NewDatabase()
+}
+
+// startReplayConnection registra a conexão da conta reproduzida, com as filas e os workers de uma conexão real,
+// mas sem abrir o WebSocket: as mensagens chegam por feedReplay.
+func (wsm *WebSocketManager) startReplayConnection(account *BybitAccount) *WebSocketConnection {
+	ctx, cancel := context.WithCancel(wsm.ctx)
+	wsConn := &WebSocketConnection{
+		AccountID: account.ID,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	wsConn.account.Store(account)
+	wsConn.stats.startedAt = time.Now()
+	wsm.mu.Lock()
+	wsm.connections[account.ID] = wsConn
+	wsm.mu.Unlock()
+	configureAccountLogger(account)
+	wsm.startMessageWorker(wsConn)
+	return wsConn
+}
+
+// feedReplay entrega os payloads à fila de processamento da conexão, esperando entre eles o intervalo original
+// dividido por speed (0 = sem espera). Retorna quantos foram entregues e quantos foram ignorados (origem
+// desconhecida) até o fim do dump ou o cancelamento de ctx.
+func (wsm *WebSocketManager) feedReplay(ctx context.Context, wsConn *WebSocketConnection, entries []rawMessageDump, speed float64) (int, int) {
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	fed, skipped := 0, 0
+	for i, entry := range entries {
+		if i > 0 && speed > 0 {
+			if gap := entry.ReceivedAt.Sub(entries[i-1].ReceivedAt); gap > 0 {
+				select {
+				case <-ctx.Done():
+					return fed, skipped
+				case <-time.After(time.Duration(float64(gap) / speed)):
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return fed, skipped
+		}
+		correlationID := entry.CorrelationID
+		if correlationID == "" {
+			correlationID = newCorrelationID()
+		}
+		message := []byte(entry.Payload)
+		var handle func()
+		switch entry.Stream {
+		case rawStreamBybitPrivate:
+			handle = func() { wsm.handleMessage(wsConn, correlationID, message) }
+		case rawStreamOKXPrivate:
+			handle = func() { wsm.handleOKXMessage(wsConn, correlationID, message, logger) }
+		case rawStreamOKXBusiness:
+			handle = func() { wsm.handleOKXAlgoMessage(wsConn, correlationID, message, logger) }
+		default:
+			skipped++
+			continue
+		}
+		wsConn.noteBytes(len(message))
+		if !wsConn.enqueueMessage(handle) {
+			return fed, skipped
+		}
+		fed++
+	}
+	return fed, skipped
+}
+
+// waitMessageQueue espera a fila de processamento da conexão esvaziar.
+func (c *WebSocketConnection) waitMessageQueue() {
+	done := make(chan struct{})
+	if c.enqueueMessage(func() { close(done) }) {
+		<-done
+	}
+}
+
+// runReplayCommand reproduz o dump da captura na conta.
+func runReplayCommand(db *Database, args []string) error {
+	if len(args) < 2 {
+		return errors.New(replayUsage)
+	}
+	account, err := findAccountByNameOrID(NewAccountManager(db), args[0])
+	if err != nil {
+		return err
+	}
+	path := args[1]
+	speed := 1.0
+	webhook := ""
+	rest := args[2:]
+	for i := 0; i < len(rest); i++ {
+		if i+1 >= len(rest) {
+			return errors.New(replayUsage)
+		}
+		value := rest[i+1]
+		switch rest[i] {
+		case "--speed":
+			if value == "max" {
+				speed = 0
+			} else if speed, err = strconv.ParseFloat(value, 64); err != nil || speed <= 0 {
+				return fmt.Errorf("velocidade inválida: %s (use um número maior que zero, ex.: 10, ou max)", value)
+			}
+		case "--webhook":
+			if value == "" || !validateDiscordWebhookURL(value) {
+				return errors.New("use a URL de um webhook do Discord em --webhook")
+			}
+			webhook = value
+		default:
+			return fmt.Errorf("opção desconhecida: %s\n%s", rest[i], replayUsage)
+		}
+		i++
+	}
+
+	entries, err := readReplayFile(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("nenhuma mensagem em %s", path)
+	}
+
+	scratch, err := openReplayDatabase()
+	if err != nil {
+		return fmt.Errorf("erro ao criar o banco da reprodução: %w", err)
+	}
+	defer scratch.Close()
+	scratchManager := NewAccountManager(scratch)
+	replica, err := copyAccountForReplay(scratchManager, account, webhook)
+	if err != nil {
+		return fmt.Errorf("erro ao copiar a conta para a reprodução: %w", err)
+	}
+	os.Setenv("DATA_DIR", filepath.Join(getDataDir(), "replay"))
+
+	tz := loadTimezone(account.Timezone)
+	replayOutput = &replaySink{out: os.Stdout, discord: webhook == "", tz: tz, labels: map[string]string{
+		replica.WebhookURL: notifyChannelLabel(notifyChannelDiscord),
+	}}
+	if replica.WebhookURLExecutions != "" && replica.WebhookURLExecutions != replica.WebhookURL {
+		replayOutput.labels[replica.WebhookURLExecutions] = notifyChannelLabel(notifyChannelExecutions)
+	}
+	if config, ok := accountGenericWebhook(replica); ok {
+		replayOutput.labels[config.URL] = notifyChannelLabel(notifyChannelGeneric)
+	}
+	if config, ok := accountDiscordBot(replica); ok {
+		replayOutput.labels[config.messagesURL()] = notifyChannelLabel(notifyChannelDiscordBot)
+	}
+	defer func() { replayOutput = nil }()
+
+	pace := "sem esperas"
+	if speed > 0 {
+		pace = fmt.Sprintf("velocidade %gx", speed)
+	}
+	fmt.Printf("Reproduzindo %d mensagem(ns) de '%s' (%s a %s, %s). Ctrl+C interrompe.\n", len(entries), account.Name,
+		entries[0].ReceivedAt.In(tz).Format("02/01 15:04:05"), entries[len(entries)-1].ReceivedAt.In(tz).Format("02/01 15:04:05"), pace)
+	if webhook == "" {
+		fmt.Println(colorYellow("As notificações são impressas abaixo em vez de enviadas (use --webhook para enviar a um canal de teste)."))
+	}
+
+	// O manager usa um contexto próprio: o Ctrl+C só interrompe a leitura do dump, e os buffers são descarregados
+	wsm := NewWebSocketManager(context.Background(), scratch, scratchManager)
+	wsConn := wsm.startReplayConnection(replica)
+	interrupt, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	fed, skipped := wsm.feedReplay(interrupt, wsConn, entries, speed)
+	stop()
+
+	// Como numa parada: processa o que está na fila e descarrega o buffer de atraso e o resumo da carteira
+	wsConn.waitMessageQueue()
+	wsm.StopConnection(replica.ID)
+
+	fmt.Printf("%d de %d mensagem(ns) reproduzida(s)", fed, len(entries))
+	if skipped > 0 {
+		fmt.Printf(" (%d de origem desconhecida ignorada(s))", skipped)
+	}
+	fmt.Printf(". Log da reprodução: %s\n", getLogFilePath(replica.ID))
+	return nil
+}
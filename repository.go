@@ -0,0 +1,57 @@
+package main
+
+import "time"
+
+// Interfaces de armazenamento usadas pela lógica de monitoramento, pela API e pelos comandos.
+// AccountManager é a implementação em SQLite; outro backend (ou um mock em testes) só precisa implementá-las.
+
+// AccountRepo guarda o cadastro das contas.
+type AccountRepo interface {
+	AddAccount(account *BybitAccount) error
+	GetAccount(id int64) (*BybitAccount, error)
+	ListAccounts() ([]*BybitAccount, error)
+	UpdateAccount(id int64, name, apiKey, apiSecret, webhookURL string, markEveryoneOrder, markEveryoneWallet bool, webhookURLGoogleSheets, sheetURLGoogleSheets, webhookURLExecutions, sheetURLGoogleSheetsExecutions string, markEveryoneExecution bool, metadata string, notificationDelaySeconds int, timezone string, notes string) error
+	SetAccountActive(id int64, active bool) error
+	UpdateAccountSettings(id int64, update func(AccountSettings)) error
+	SetSecretsInKeyring(id int64, enable bool) error
+	UpdateOneWayMode(accountID int64, oneWayMode bool) error
+	GetOneWayMode(accountID int64) (bool, error)
+	RemoveAccount(id int64) error
+	ListRemovedAccounts() ([]RemovedAccount, error)
+	RestoreAccount(id int64) error
+	PurgeAccount(id int64) error
+}
+
+// ConnectionRepo guarda quais contas estão sendo monitoradas (restauradas ao reiniciar) e o heartbeat de cada uma.
+type ConnectionRepo interface {
+	SetConnectionActive(accountID int64, active bool) error
+	GetActiveConnections() ([]int64, error)
+	TouchConnectionHeartbeat(accountIDs []int64) error
+	GetConnectionHeartbeats() (map[int64]time.Time, error)
+	SetAuthFailure(accountID int64, reason string, attempts int) error
+	ClearAuthFailure(accountID int64) error
+	GetAuthFailures() (map[int64]AuthFailure, error)
+}
+
+// HistoryRepo guarda as ordens abertas e os históricos de ordens, execuções e posições.
+type HistoryRepo interface {
+	SaveOrder(accountID int64, order OrderData) error
+	GetOrder(orderID string) (string, error)
+	DeleteOrder(orderID string) error
+	RecordOrderEvent(accountID int64, order OrderData) error
+	ListOrderEvents(accountID int64, filter HistoryFilter) ([]OrderEvent, error)
+	RecordExecution(accountID int64, exec ExecutionData) error
+	HasExecution(accountID int64, execID string) (bool, error)
+	ListExecutions(accountID int64, filter HistoryFilter) ([]ExecutionRecord, error)
+	TrackPosition(accountID int64, pos PositionData) ([]PositionRecord, error)
+	ListPositionHistory(accountID int64, filter HistoryFilter) ([]PositionRecord, error)
+}
+
+// Repository reúne os três repositórios, como o WebSocketManager e a API precisam.
+type Repository interface {
+	AccountRepo
+	ConnectionRepo
+	HistoryRepo
+}
+
+var _ Repository = (*AccountManager)(nil)
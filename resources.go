@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Contabilidade de recursos por conexão: as goroutines e os timers criados em nome de uma conta são contados
+// por tipo enquanto estão vivos. A contagem aparece na saúde da conexão ("Ver contas monitoradas") e no campo
+// resources do GET /api/status; resourceLeakCheckDelay depois de parar a conta, o que ainda estiver vivo (ou os
+// buffers que continuarem registrados) é avisado no stderr e no Sentry como vazamento.
+const resourceLeakCheckDelay = 2 * time.Minute
+
+// Tipos de goroutine da conexão
+const (
+	goroutineConnection  = "conexão"      // runConnection (reconexão)
+	goroutineReader      = "leitura"      // connectAndListen
+	goroutinePing        = "ping"         // pingLoop
+	goroutineSubscribe   = "inscrições"   // subscriptionTracker.watch
+	goroutineOKXBusiness = "okx-business" // runOKXBusinessWithRetry
+	goroutineBackfill    = "recuperação"  // backfillExecutions
+	goroutineBuffer      = "buffer"       // processDelayBuffer
+	goroutineDelivery    = "envio"        // envio de notificações (Discord/planilha)
+)
+
+// Tipos de timer da conexão
+const (
+	timerDelay     = "atraso"   // buffer de atraso de agrupamento
+	timerWallet    = "carteira" // resumo da carteira (Discord)
+	timerSheets    = "planilha" // atualização da planilha
+	timerDownAlert = "alerta"   // alerta de queda da conexão
+	timerRateLimit = "limite"   // resumo das notificações acima do limite por canal (channelratelimit.go)
+)
+
+// connResources conta os recursos vivos da conexão por tipo.
+type connResources struct {
+	mu         sync.Mutex
+	goroutines map[string]int
+	timers     map[string]int
+}
+
+func (r *connResources) add(counts *map[string]int, kind string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if *counts == nil {
+		*counts = make(map[string]int)
+	}
+	(*counts)[kind] += delta
+	if (*counts)[kind] <= 0 {
+		delete(*counts, kind)
+	}
+}
+
+// spawn executa fn numa goroutine contada como recurso da conexão até terminar.
+func (c *WebSocketConnection) spawn(kind string, fn func()) {
+	c.resources.add(&c.resources.goroutines, kind, 1)
+	go func() {
+		defer c.resources.add(&c.resources.goroutines, kind, -1)
+		fn()
+	}()
+}
+
+// connTimer é um time.AfterFunc contado como recurso da conexão até disparar ou ser parado.
+type connTimer struct {
+	timer    *time.Timer
+	res      *connResources
+	kind     string
+	released atomic.Bool
+}
+
+// afterFunc agenda fn como time.AfterFunc, contando o timer como recurso da conexão.
+func (c *WebSocketConnection) afterFunc(kind string, d time.Duration, fn func()) *connTimer {
+	t := &connTimer{res: &c.resources, kind: kind}
+	c.resources.add(&c.resources.timers, kind, 1)
+	t.timer = time.AfterFunc(d, func() {
+		t.release()
+		fn()
+	})
+	return t
+}
+
+func (t *connTimer) release() {
+	if t.released.CompareAndSwap(false, true) {
+		t.res.add(&t.res.timers, t.kind, -1)
+	}
+}
+
+// Stop funciona como time.Timer.Stop: true se o timer foi parado antes de disparar.
+func (t *connTimer) Stop() bool {
+	stopped := t.timer.Stop()
+	if stopped {
+		t.release()
+	}
+	return stopped
+}
+
+// ConnectionResources é um retrato dos recursos da conexão para exibição.
+type ConnectionResources struct {
+	Goroutines     map[string]int        `json:"goroutines,omitempty"`
+	Timers         map[string]int        `json:"timers,omitempty"`
+	Buffers        int                   `json:"buffers"`          // buffers registrados (atraso de agrupamento, carteira)
+	PendingBuffers int                   `json:"pending_buffers"`  // itens aguardando no buffer de atraso
+	Queues         map[string]QueueStats `json:"queues,omitempty"` // filas de mensagens e de envios (messagequeue.go)
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// formatCounts descreve as contagens por tipo (ex.: "3 (conexão 1, leitura 1, ping 1)").
+func formatCounts(counts map[string]int) string {
+	total := sumCounts(counts)
+	if total == 0 {
+		return "0"
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%s %d", kind, counts[kind]))
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
+
+// String resume os recursos numa linha (ex.: "goroutines 3 (...), timers 1 (atraso 1), buffers 1 (2 item(ns) pendente(s))").
+func (r ConnectionResources) String() string {
+	text := fmt.Sprintf("goroutines %s, timers %s, buffers %d", formatCounts(r.Goroutines), formatCounts(r.Timers), r.Buffers)
+	if r.PendingBuffers > 0 {
+		text += fmt.Sprintf(" (%d item(ns) pendente(s))", r.PendingBuffers)
+	}
+	if len(r.Queues) > 0 {
+		names := make([]string, 0, len(r.Queues))
+		for name := range r.Queues {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, name+" "+r.Queues[name].String())
+		}
+		text += "; filas: " + strings.Join(parts, ", ")
+	}
+	return text
+}
+
+func (r ConnectionResources) empty() bool {
+	return sumCounts(r.Goroutines) == 0 && sumCounts(r.Timers) == 0 && r.Buffers == 0
+}
+
+// connectionResources monta o retrato dos recursos da conexão e dos buffers da conta. withBuffers = false ignora
+// os buffers (a conta já tem outra conexão, dona dos buffers atuais).
+func (wsm *WebSocketManager) connectionResources(accountID int64, conn *WebSocketConnection, withBuffers bool) ConnectionResources {
+	var resources ConnectionResources
+	conn.resources.mu.Lock()
+	resources.Goroutines = copyCounts(conn.resources.goroutines)
+	resources.Timers = copyCounts(conn.resources.timers)
+	conn.resources.mu.Unlock()
+	resources.Queues = conn.queueStats()
+	if !withBuffers {
+		return resources
+	}
+
+	wsm.bufferMu.RLock()
+	delayBuf := wsm.delayBuffers[accountID]
+	_, hasWallet := wsm.walletNotificationBuffers[accountID]
+	wsm.bufferMu.RUnlock()
+	if delayBuf != nil {
+		resources.Buffers++
+		delayBuf.mu.Lock()
+		resources.PendingBuffers = len(delayBuf.orders) + len(delayBuf.stops) + len(delayBuf.executions)
+		delayBuf.mu.Unlock()
+	}
+	if hasWallet {
+		resources.Buffers++
+	}
+	return resources
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	if len(counts) == 0 {
+		return nil
+	}
+	result := make(map[string]int, len(counts))
+	for kind, n := range counts {
+		result[kind] = n
+	}
+	return result
+}
+
+// GetConnectionResources retorna os recursos da conexão da conta (false se não estiver sendo monitorada).
+func (wsm *WebSocketManager) GetConnectionResources(accountID int64) (ConnectionResources, bool) {
+	wsm.mu.RLock()
+	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
+	if !exists {
+		return ConnectionResources{}, false
+	}
+	return wsm.connectionResources(accountID, conn, true), true
+}
+
+// scheduleLeakCheck verifica, resourceLeakCheckDelay depois da parada, se a conexão ainda tem recursos vivos.
+func (wsm *WebSocketManager) scheduleLeakCheck(accountID int64, conn *WebSocketConnection) {
+	time.AfterFunc(resourceLeakCheckDelay, func() {
+		wsm.mu.RLock()
+		_, restarted := wsm.connections[accountID]
+		wsm.mu.RUnlock()
+		resources := wsm.connectionResources(accountID, conn, !restarted)
+		if resources.empty() {
+			return
+		}
+		err := errors.New("recursos ainda ativos após a parada: " + resources.String())
+		fmt.Fprintf(os.Stderr, "[AVISO] Conta '%s' (ID: %d): %v\n", conn.Account().Name, accountID, err)
+		reportAccountError(accountID, conn.Account().Name, "vazamento", err, nil)
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retentionSettingPrefix + nome do histórico guarda, em app_settings, quantos dias manter (0 = para sempre).
+const retentionSettingPrefix = "retention_days."
+
+// retentionPruneInterval é o intervalo da limpeza automática enquanto o menu está aberto.
+const retentionPruneInterval = 6 * time.Hour
+
+// retentionTarget é um histórico sujeito à retenção: linhas com timeColumn anterior ao corte são apagadas.
+type retentionTarget struct {
+	Name        string
+	Table       string
+	TimeColumn  string
+	DefaultDays int
+	Description string
+}
+
+// retentionTargets lista os históricos com retenção. Posições só são apagadas depois de fechadas (closed_at preenchido).
+var retentionTargets = []retentionTarget{
+	{Name: "notifications", Table: "notifications", TimeColumn: "created_at", DefaultDays: 30, Description: "Histórico de notificações"},
+	{Name: "orders", Table: "order_events", TimeColumn: "event_time", DefaultDays: 90, Description: "Histórico de ordens"},
+	{Name: "executions", Table: "executions", TimeColumn: "exec_time", DefaultDays: 90, Description: "Execuções"},
+	{Name: "positions", Table: "positions_history", TimeColumn: "closed_at", DefaultDays: 365, Description: "Posições fechadas"},
+	{Name: "raw", Table: "raw_messages", TimeColumn: "received_at", DefaultDays: 3, Description: "Mensagens cruas capturadas"},
+}
+
+func findRetentionTarget(name string) (retentionTarget, bool) {
+	for _, t := range retentionTargets {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return retentionTarget{}, false
+}
+
+// retentionDays retorna os dias configurados para o histórico (ou o padrão, se não configurado).
+func retentionDays(db *Database, target retentionTarget) (int, error) {
+	value, exists, err := db.GetSetting(retentionSettingPrefix + target.Name)
+	if err != nil || !exists {
+		return target.DefaultDays, err
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return target.DefaultDays, nil
+	}
+	return days, nil
+}
+
+// pruneHistory apaga dos históricos as linhas mais antigas que a retenção configurada.
+// Com dryRun apenas conta. Retorna a quantidade por histórico (nome -> linhas).
+func pruneHistory(db *Database, dryRun bool) (map[string]int64, error) {
+	result := make(map[string]int64)
+	for _, target := range retentionTargets {
+		days, err := retentionDays(db, target)
+		if err != nil {
+			return result, err
+		}
+		if days == 0 {
+			continue
+		}
+		cutoff := time.Now().UTC().AddDate(0, 0, -days)
+		where := fmt.Sprintf(`%s IS NOT NULL AND %s < ?`, target.TimeColumn, target.TimeColumn)
+		if dryRun {
+			var count int64
+			if err := db.GetDB().QueryRow(`SELECT COUNT(*) FROM `+target.Table+` WHERE `+where, cutoff).Scan(&count); err != nil {
+				return result, fmt.Errorf("%s: %w", target.Name, err)
+			}
+			result[target.Name] = count
+			continue
+		}
+		res, err := db.GetDB().Exec(`DELETE FROM `+target.Table+` WHERE `+where, cutoff)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", target.Name, err)
+		}
+		result[target.Name], _ = res.RowsAffected()
+	}
+	return result, nil
+}
+
+// startRetentionPruner executa a limpeza na inicialização e depois periodicamente, em background.
+func startRetentionPruner(db *Database) {
+	go func() {
+		ticker := time.NewTicker(retentionPruneInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := pruneHistory(db, false); err != nil {
+				fmt.Fprintf(os.Stderr, "Erro na limpeza automática dos históricos: %v\n", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func runPruneCommand(db *Database, args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		default:
+			return errors.New("uso: prune [--dry-run]")
+		}
+	}
+
+	result, err := pruneHistory(db, dryRun)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, target := range retentionTargets {
+		count, ok := result[target.Name]
+		if !ok {
+			fmt.Printf("%-14s retenção desativada\n", target.Name)
+			continue
+		}
+		total += count
+		if dryRun {
+			fmt.Printf("%-14s %d linha(s) seriam apagadas\n", target.Name, count)
+		} else {
+			fmt.Printf("%-14s %d linha(s) apagadas\n", target.Name, count)
+		}
+	}
+	// Sem VACUUM o SQLite reaproveita o espaço, mas o arquivo não diminui
+	if !dryRun && total > 0 {
+		if _, err := db.GetDB().Exec(`VACUUM`); err != nil {
+			return fmt.Errorf("linhas apagadas, mas erro ao compactar o banco: %w", err)
+		}
+		fmt.Println("Banco compactado.")
+	}
+	return nil
+}
+
+func runRetentionCommand(db *Database, args []string) error {
+	switch len(args) {
+	case 0:
+		for _, target := range retentionTargets {
+			days, err := retentionDays(db, target)
+			if err != nil {
+				return err
+			}
+			value := fmt.Sprintf("%d dia(s)", days)
+			if days == 0 {
+				value = "para sempre"
+			}
+			fmt.Printf("%-14s %-14s %s (padrão: %d)\n", target.Name, value, target.Description, target.DefaultDays)
+		}
+		return nil
+	case 2:
+		target, ok := findRetentionTarget(args[0])
+		if !ok {
+			names := make([]string, 0, len(retentionTargets))
+			for _, t := range retentionTargets {
+				names = append(names, t.Name)
+			}
+			return fmt.Errorf("histórico desconhecido: %s (use %s)", args[0], strings.Join(names, ", "))
+		}
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days < 0 {
+			return fmt.Errorf("quantidade de dias inválida: %s", args[1])
+		}
+		if err := db.SetSetting(retentionSettingPrefix+target.Name, strconv.Itoa(days)); err != nil {
+			return err
+		}
+		if days == 0 {
+			fmt.Printf("%s: sem limite de retenção.\n", target.Description)
+		} else {
+			fmt.Printf("%s: retenção de %d dia(s).\n", target.Description, days)
+		}
+		return nil
+	default:
+		return errors.New("uso: retention [<histórico> <dias>]")
+	}
+}
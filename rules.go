@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Regras de notificação: a preferência notification_rules da conta é uma lista de regras avaliadas em ordem para
+// cada ordem ou stop, antes do buffer de atraso; a primeira regra cujas condições batem decide se o evento é
+// notificado ("notify") ou ignorado ("ignore"), e sem regra que bata o evento é notificado. Assim ordens pequenas de
+// robôs não geram ruído e ordens manuais grandes sempre avisam, ex.:
+// settings "Minha Conta" notification_rules '[{"min_qty": 10000, "action": "notify"}, {"max_qty": 500, "action": "ignore"}]'.
+// As regras só afetam as notificações: o histórico de ordens continua com tudo. Nas execuções, valem só para a
+// mensagem do webhook de execuções (a planilha recebe todas).
+const notificationRulesSettingKey = "notification_rules"
+
+const (
+	ruleActionNotify = "notify"
+	ruleActionIgnore = "ignore"
+)
+
+// notificationRule é uma regra: todas as condições informadas precisam bater. Quantidades em USD (contratos
+// inversos); stops de posição inteira (qty 0) não batem com min_qty nem max_qty.
+type notificationRule struct {
+	Action      string   `json:"action"`
+	MinQty      *float64 `json:"min_qty,omitempty"`
+	MaxQty      *float64 `json:"max_qty,omitempty"`
+	ReduceOnly  *bool    `json:"reduce_only,omitempty"` // execuções não têm reduceOnly: a condição não bate
+	OrderTypes  []string `json:"order_types,omitempty"`
+	CreateTypes []string `json:"create_types,omitempty"`
+	Symbols     []string `json:"symbols,omitempty"`
+}
+
+// ruleSubject são os campos de uma ordem ou execução comparados pelas regras.
+type ruleSubject struct {
+	Qty        float64
+	ReduceOnly *bool
+	OrderType  string
+	CreateType string
+	Symbol     string
+}
+
+func validateNotificationRulesSetting(value json.RawMessage) error {
+	_, err := parseNotificationRules(value)
+	return err
+}
+
+func parseNotificationRules(value json.RawMessage) ([]notificationRule, error) {
+	var rules []notificationRule
+	decoder := json.NewDecoder(strings.NewReader(string(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf(`use uma lista de regras, ex.: [{"max_qty": 500, "action": "ignore"}] (%v)`, err)
+	}
+	for i, rule := range rules {
+		if rule.Action != ruleActionNotify && rule.Action != ruleActionIgnore {
+			return nil, fmt.Errorf("regra %d: action deve ser %q ou %q", i+1, ruleActionNotify, ruleActionIgnore)
+		}
+		if rule.MinQty != nil && rule.MaxQty != nil && *rule.MinQty > *rule.MaxQty {
+			return nil, fmt.Errorf("regra %d: min_qty maior que max_qty", i+1)
+		}
+	}
+	return rules, nil
+}
+
+// matches informa se todas as condições da regra batem com o evento.
+func (r notificationRule) matches(s ruleSubject) bool {
+	if r.MinQty != nil && (s.Qty <= 0 || s.Qty < *r.MinQty) {
+		return false
+	}
+	if r.MaxQty != nil && (s.Qty <= 0 || s.Qty > *r.MaxQty) {
+		return false
+	}
+	if r.ReduceOnly != nil && (s.ReduceOnly == nil || *s.ReduceOnly != *r.ReduceOnly) {
+		return false
+	}
+	return matchesAny(r.OrderTypes, s.OrderType) && matchesAny(r.CreateTypes, s.CreateType) && matchesAny(r.Symbols, s.Symbol)
+}
+
+// matchesAny informa se value está na lista (sem diferenciar maiúsculas); lista vazia = qualquer valor.
+func matchesAny(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationRuleVerdict retorna se o evento deve ser notificado e a regra que decidiu (0 = nenhuma).
+func notificationRuleVerdict(account *BybitAccount, s ruleSubject) (bool, int) {
+	raw, ok := account.Settings[notificationRulesSettingKey]
+	if !ok {
+		return true, 0
+	}
+	rules, err := parseNotificationRules(raw)
+	if err != nil {
+		return true, 0
+	}
+	for i, rule := range rules {
+		if rule.matches(s) {
+			return rule.Action == ruleActionNotify, i + 1
+		}
+	}
+	return true, 0
+}
+
+func orderRuleSubject(order OrderData) ruleSubject {
+	qty, _ := strconv.ParseFloat(order.Qty, 64)
+	reduceOnly := order.ReduceOnly
+	return ruleSubject{Qty: qty, ReduceOnly: &reduceOnly, OrderType: order.OrderType, CreateType: order.CreateType, Symbol: order.Symbol}
+}
+
+func executionRuleSubject(exec ExecutionData) ruleSubject {
+	qty, _ := strconv.ParseFloat(exec.ExecQty, 64)
+	return ruleSubject{Qty: qty, OrderType: exec.OrderType, CreateType: exec.CreateType, Symbol: exec.Symbol}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reconexão programada: a Bybit troca os servidores do stream e conexões abertas há muito tempo às vezes param de
+// receber mensagens sem cair. A preferência scheduled_reconnect da conta fecha a conexão nos horários indicados (no
+// fuso da conta) e reconecta em seguida, sem contar como falha, ex.:
+// settings "Minha Conta" scheduled_reconnect '"04:00"' ou '["04:00","16:00"]'.
+// A verificação roda junto com o heartbeat (a cada connectionHeartbeatInterval), então a reconexão acontece até um
+// minuto depois do horário. Só conexões conectadas são reconectadas; na OKX, só a conexão privada (a business segue).
+const scheduledReconnectSettingKey = "scheduled_reconnect"
+
+// parseReconnectSchedule lê os horários ("HH:MM" ou lista deles) em minutos do dia, ordenados.
+func parseReconnectSchedule(value json.RawMessage) ([]int, error) {
+	var times []string
+	var single string
+	if json.Unmarshal(value, &single) == nil {
+		times = []string{single}
+	} else if json.Unmarshal(value, &times) != nil || len(times) == 0 {
+		return nil, errors.New("use um horário \"HH:MM\" ou uma lista, ex.: [\"04:00\",\"16:00\"]")
+	}
+	minutes := make([]int, 0, len(times))
+	for _, raw := range times {
+		at, err := time.Parse("15:04", strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("horário inválido: %q (use HH:MM)", raw)
+		}
+		minutes = append(minutes, at.Hour()*60+at.Minute())
+	}
+	sort.Ints(minutes)
+	return minutes, nil
+}
+
+func validateScheduledReconnectSetting(value json.RawMessage) error {
+	_, err := parseReconnectSchedule(value)
+	return err
+}
+
+// nextScheduledReconnect retorna o primeiro horário programado da conta depois de after (false = sem programação).
+func nextScheduledReconnect(account *BybitAccount, after time.Time) (time.Time, bool) {
+	raw, ok := account.Settings[scheduledReconnectSettingKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	minutes, err := parseReconnectSchedule(raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := after.In(loadTimezone(account.Timezone))
+	for day := 0; day <= 1; day++ {
+		for _, m := range minutes {
+			at := time.Date(local.Year(), local.Month(), local.Day()+day, m/60, m%60, 0, 0, local.Location())
+			if at.After(after) {
+				return at, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// runScheduledReconnects reconecta as conexões com horário programado vencido desde a verificação anterior.
+// Chamado a cada gravação do heartbeat.
+func (wsm *WebSocketManager) runScheduledReconnects() {
+	now := time.Now()
+	wsm.mu.RLock()
+	conns := make([]*WebSocketConnection, 0, len(wsm.connections))
+	for _, conn := range wsm.connections {
+		conns = append(conns, conn)
+	}
+	wsm.mu.RUnlock()
+	for _, conn := range conns {
+		conn.mu.Lock()
+		checkedAt := conn.scheduleCheckedAt
+		conn.scheduleCheckedAt = now
+		connected := conn.Connected && conn.Conn != nil
+		conn.mu.Unlock()
+		if checkedAt.IsZero() || !connected || !conn.running() {
+			continue
+		}
+		if next, ok := nextScheduledReconnect(conn.Account(), checkedAt); ok && !next.After(now) {
+			conn.closeForScheduledReconnect()
+		}
+	}
+}
+
+// closeForScheduledReconnect fecha a conexão atual; runConnection reconecta em seguida sem contar falha.
+func (c *WebSocketConnection) closeForScheduledReconnect() {
+	if logger, _ := getLogger(c.AccountID, c.Account().Name); logger != nil {
+		logger.Log("🔄 Reconexão programada (%s): fechando a conexão para reconectar", scheduledReconnectSettingKey)
+	}
+	c.scheduledReconnect.Store(true)
+	c.mu.Lock()
+	if c.Conn != nil {
+		c.Conn.Close()
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService é o nome do serviço sob o qual os segredos ficam no keyring do sistema
+// (Windows Credential Manager, macOS Keychain ou Secret Service no Linux).
+const keyringService = "bybit-notifier"
+
+// secretRefKeyringPrefix marca um valor do banco que é apenas a referência para o segredo no keyring.
+const secretRefKeyringPrefix = "keyring:"
+
+// secretRefEnvPrefix marca um valor que deve ser lido de uma variável de ambiente (ex.: env:BYBIT_API_SECRET),
+// para quem injeta as credenciais via secrets do Docker/Kubernetes em vez de gravá-las no SQLite.
+const secretRefEnvPrefix = "env:"
+
+func isKeyringRef(value string) bool {
+	return strings.HasPrefix(value, secretRefKeyringPrefix)
+}
+
+func isEnvRef(value string) bool {
+	return strings.HasPrefix(value, secretRefEnvPrefix)
+}
+
+// keyringSecretName retorna o identificador do segredo da conta no keyring, ex.: account-5-api_secret.
+func keyringSecretName(accountID int64, field string) string {
+	return fmt.Sprintf("account-%d-%s", accountID, field)
+}
+
+// keyringAvailable verifica se há um keyring utilizável (em VPS sem sessão gráfica normalmente não há Secret Service).
+func keyringAvailable() bool {
+	probe := "probe"
+	if err := keyring.Set(keyringService, probe, probe); err != nil {
+		return false
+	}
+	keyring.Delete(keyringService, probe)
+	return true
+}
+
+// storeInKeyring grava o segredo no keyring e retorna a referência a ser salva no banco.
+func storeInKeyring(name, value string) (string, error) {
+	if err := keyring.Set(keyringService, name, value); err != nil {
+		return "", fmt.Errorf("erro ao gravar no keyring do sistema: %w", err)
+	}
+	return secretRefKeyringPrefix + name, nil
+}
+
+// deleteFromKeyring remove o segredo do keyring; ausência não é erro.
+func deleteFromKeyring(name string) error {
+	if err := keyring.Delete(keyringService, name); err != nil && err != keyring.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// resolveSecretValue retorna o segredo real: busca no keyring ou na variável de ambiente quando o valor é uma referência,
+// senão devolve o próprio valor.
+func resolveSecretValue(value string) (string, error) {
+	if isEnvRef(value) {
+		name := strings.TrimSpace(strings.TrimPrefix(value, secretRefEnvPrefix))
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("variável de ambiente '%s' não definida", name)
+		}
+		return secret, nil
+	}
+	if !isKeyringRef(value) {
+		return value, nil
+	}
+	name := strings.TrimPrefix(value, secretRefKeyringPrefix)
+	secret, err := keyring.Get(keyringService, name)
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler '%s' do keyring do sistema: %w", name, err)
+	}
+	return secret, nil
+}
+
+// metadataPassphrase retorna o passphrase guardado no metadata (cru, podendo ser uma referência).
+func metadataPassphrase(metadata string) string {
+	if metadata == "" {
+		return ""
+	}
+	var m okxMetadata
+	if err := json.Unmarshal([]byte(metadata), &m); err != nil {
+		return ""
+	}
+	return m.Passphrase
+}
+
+// replaceMetadataPassphrase troca o passphrase do metadata, preservando os demais campos.
+func replaceMetadataPassphrase(metadata, passphrase string) (string, error) {
+	m := make(map[string]interface{})
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &m); err != nil {
+			return "", fmt.Errorf("metadata inválido: %w", err)
+		}
+	}
+	m["passphrase"] = passphrase
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// resolveAccountSecrets substitui as referências (keyring ou env:) pelos valores reais (API Key, API Secret e passphrase OKX).
+// Em caso de erro a conta fica com o segredo vazio e o erro em secretErr, para não derrubar a listagem das demais.
+func resolveAccountSecrets(acc *BybitAccount) {
+	defer registerAccountSecrets(acc)
+	acc.SecretsInKeyring = isKeyringRef(acc.APISecret)
+	if isEnvRef(acc.APIKey) {
+		acc.apiKeyRef = acc.APIKey
+		apiKey, err := resolveSecretValue(acc.APIKey)
+		acc.APIKey = apiKey
+		if err != nil {
+			acc.secretErr = err
+			return
+		}
+	}
+	if isEnvRef(acc.APISecret) {
+		acc.apiSecretRef = acc.APISecret
+	}
+	secret, err := resolveSecretValue(acc.APISecret)
+	if err != nil {
+		acc.APISecret = ""
+		acc.secretErr = err
+		return
+	}
+	acc.APISecret = secret
+
+	if passphrase := metadataPassphrase(acc.Metadata); isKeyringRef(passphrase) || isEnvRef(passphrase) {
+		if isEnvRef(passphrase) {
+			acc.metadataRef = acc.Metadata
+		}
+		resolved, err := resolveSecretValue(passphrase)
+		if err != nil {
+			acc.secretErr = err
+			return
+		}
+		if metadata, err := replaceMetadataPassphrase(acc.Metadata, resolved); err == nil {
+			acc.Metadata = metadata
+		}
+	}
+}
+
+// storeAccountSecretsInKeyring grava API Secret e passphrase (se houver) no keyring e retorna os valores
+// que devem ir para o banco (referências). metadata "" é mantido como "" (sentinela de "manter o atual").
+// Referências env: continuam apontando para a variável de ambiente.
+func storeAccountSecretsInKeyring(accountID int64, apiSecret, metadata string) (string, string, error) {
+	secretRef := apiSecret
+	if !isEnvRef(apiSecret) {
+		var err error
+		secretRef, err = storeInKeyring(keyringSecretName(accountID, "api_secret"), apiSecret)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	passphrase := metadataPassphrase(metadata)
+	if passphrase == "" || isKeyringRef(passphrase) || isEnvRef(passphrase) {
+		return secretRef, metadata, nil
+	}
+	passphraseRef, err := storeInKeyring(keyringSecretName(accountID, "passphrase"), passphrase)
+	if err != nil {
+		return "", "", err
+	}
+	metadata, err = replaceMetadataPassphrase(metadata, passphraseRef)
+	if err != nil {
+		return "", "", err
+	}
+	return secretRef, metadata, nil
+}
+
+// deleteAccountSecretsFromKeyring remove os segredos da conta do keyring.
+func deleteAccountSecretsFromKeyring(accountID int64) error {
+	if err := deleteFromKeyring(keyringSecretName(accountID, "api_secret")); err != nil {
+		return err
+	}
+	return deleteFromKeyring(keyringSecretName(accountID, "passphrase"))
+}
+
+// StoredAPIKey retorna a API Key como deve ser gravada no banco (a referência env:, se for o caso).
+func (acc *BybitAccount) StoredAPIKey() string {
+	if acc.apiKeyRef != "" {
+		return acc.apiKeyRef
+	}
+	return acc.APIKey
+}
+
+// StoredAPISecret retorna o API Secret como deve ser gravado no banco (a referência env:, se for o caso).
+func (acc *BybitAccount) StoredAPISecret() string {
+	if acc.apiSecretRef != "" {
+		return acc.apiSecretRef
+	}
+	return acc.APISecret
+}
+
+// StoredMetadata retorna o metadata como deve ser gravado no banco (com a referência env: do passphrase, se for o caso).
+func (acc *BybitAccount) StoredMetadata() string {
+	if acc.metadataRef != "" {
+		return acc.metadataRef
+	}
+	return acc.Metadata
+}
+
+// SecretError retorna o erro ao carregar os segredos da conta (ex.: keyring indisponível), ou nil.
+func (acc *BybitAccount) SecretError() error {
+	return acc.secretErr
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Envio de panics e erros repetidos para o Sentry (opcional, só com SENTRY_DSN):
+//
+//	SENTRY_DSN=https://chave@o123.ingest.sentry.io/456   DSN do projeto
+//	SENTRY_ENVIRONMENT=producao                          ambiente dos eventos (padrão: production)
+//
+// O cliente fala direto com a API de envelopes do Sentry, sem SDK.
+const (
+	sentryDSNEnv         = "SENTRY_DSN"
+	sentryEnvironmentEnv = "SENTRY_ENVIRONMENT"
+)
+
+const (
+	sentryHTTPTimeout = 10 * time.Second
+	// sentryRepeatedFailures é o número de falhas de conexão seguidas a partir do qual o erro é reportado.
+	sentryRepeatedFailures = 3
+	// sentryErrorInterval evita reportar o mesmo erro da mesma conta mais de uma vez por intervalo.
+	sentryErrorInterval = time.Hour
+)
+
+type sentryReporter struct {
+	envelopeURL string
+	auth        string
+	dsn         string
+	environment string
+	client      *http.Client
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+	pending  sync.WaitGroup
+}
+
+var sentry *sentryReporter
+
+// initSentry ativa o envio ao Sentry se SENTRY_DSN estiver definido.
+func initSentry() error {
+	dsn := strings.TrimSpace(os.Getenv(sentryDSNEnv))
+	if dsn == "" {
+		return nil
+	}
+	reporter, err := newSentryReporter(dsn, os.Getenv(sentryEnvironmentEnv))
+	if err != nil {
+		return err
+	}
+	sentry = reporter
+	return nil
+}
+
+// newSentryReporter interpreta o DSN (https://chave_publica@host/id_do_projeto).
+func newSentryReporter(dsn, environment string) (*sentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("DSN inválido: %s", redactSecrets(dsn))
+	}
+	projectID := path.Base(u.Path)
+	if _, err := strconv.Atoi(projectID); err != nil {
+		return nil, fmt.Errorf("DSN sem o ID do projeto: %s", redactSecrets(dsn))
+	}
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=bybit-notifier/%s, sentry_key=%s", projectVersion, u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	envelopeURL := fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, strings.TrimSuffix(path.Dir(u.Path), "/"), projectID)
+
+	environment = strings.TrimSpace(environment)
+	if environment == "" {
+		environment = "production"
+	}
+	return &sentryReporter{
+		envelopeURL: envelopeURL,
+		auth:        auth,
+		dsn:         dsn,
+		environment: environment,
+		client:      &http.Client{Timeout: sentryHTTPTimeout},
+		lastSent:    make(map[string]time.Time),
+	}, nil
+}
+
+// sentryFrame e sentryEvent seguem o formato de evento do Sentry (só os campos usados).
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace *struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace,omitempty"`
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Release     string            `json:"release"`
+	Environment string            `json:"environment"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+	Tags        map[string]string `json:"tags"`
+	Extra       map[string]string `json:"extra,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+// reportPanic envia ao Sentry o panic recuperado em where, com a pilha de onde ele ocorreu.
+// Deve ser chamado dentro do defer que fez o recover (a pilha ainda inclui o ponto do panic).
+// O panic no mesmo ponto da mesma conta é enviado no máximo uma vez por sentryErrorInterval.
+func reportPanic(accountID int64, accountName, where string, recovered interface{}) {
+	if sentry == nil {
+		return
+	}
+	key := fmt.Sprintf("%d/panic/%s", accountID, where)
+	sentry.mu.Lock()
+	if last, ok := sentry.lastSent[key]; ok && time.Since(last) < sentryErrorInterval {
+		sentry.mu.Unlock()
+		return
+	}
+	sentry.lastSent[key] = time.Now()
+	sentry.mu.Unlock()
+
+	exception := sentryException{Type: "panic em " + where, Value: redactSecrets(fmt.Sprint(recovered))}
+	exception.Stacktrace = &struct {
+		Frames []sentryFrame `json:"frames"`
+	}{Frames: sentryStackFrames(3)}
+	sentry.capture("fatal", accountID, accountName, []string{"panic", where}, exception, nil)
+}
+
+// reportAccountError envia ao Sentry um erro recorrente da conta (kind agrupa os eventos, ex.: "conexao").
+// O mesmo tipo de erro da mesma conta é enviado no máximo uma vez por sentryErrorInterval.
+func reportAccountError(accountID int64, accountName, kind string, err error, extra map[string]string) {
+	if sentry == nil || err == nil {
+		return
+	}
+	key := fmt.Sprintf("%d/%s", accountID, kind)
+	sentry.mu.Lock()
+	if last, ok := sentry.lastSent[key]; ok && time.Since(last) < sentryErrorInterval {
+		sentry.mu.Unlock()
+		return
+	}
+	sentry.lastSent[key] = time.Now()
+	sentry.mu.Unlock()
+
+	exception := sentryException{Type: kind, Value: redactSecrets(err.Error())}
+	sentry.capture("error", accountID, accountName, []string{kind, strconv.FormatInt(accountID, 10)}, exception, extra)
+}
+
+// capture monta o evento e o envia em background.
+func (s *sentryReporter) capture(level string, accountID int64, accountName string, fingerprint []string, exception sentryException, extra map[string]string) {
+	eventID := make([]byte, 16)
+	rand.Read(eventID)
+	hostname, _ := os.Hostname()
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(eventID),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Release:     "bybit-notifier@" + projectVersion,
+		Environment: s.environment,
+		ServerName:  hostname,
+		Fingerprint: fingerprint,
+		Tags:        map[string]string{"account_id": strconv.FormatInt(accountID, 10), "account": accountName},
+		Extra:       extra,
+	}
+	event.Exception.Values = []sentryException{exception}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.send(event); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao enviar evento ao Sentry: %v\n", err)
+		}
+	}()
+}
+
+func (s *sentryReporter) send(event sentryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": event.EventID, "sent_at": event.Timestamp, "dsn": s.dsn})
+	itemHeader, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(payload)})
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(itemHeader)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, s.envelopeURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New(redactSecrets(err.Error()))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// sentryStackFrames retorna a pilha atual no formato do Sentry (do frame mais antigo ao mais recente),
+// sem os frames do runtime e ignorando skip frames a partir de runtime.Callers.
+func sentryStackFrames(skip int) []sentryFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var result []sentryFrame
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			// main.(*WebSocketManager).runConnection.func1 → módulo main, função (*WebSocketManager).runConnection.func1
+			module, function := "", frame.Function
+			pkgStart := strings.LastIndex(function, "/") + 1
+			if dot := strings.Index(function[pkgStart:], "."); dot >= 0 {
+				module, function = function[:pkgStart+dot], function[pkgStart+dot+1:]
+			}
+			result = append([]sentryFrame{{
+				Function: function,
+				Module:   module,
+				Filename: path.Base(frame.File),
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    module == "main",
+			}}, result...)
+		}
+		if !more {
+			break
+		}
+	}
+	return result
+}
+
+// flushSentry aguarda (até timeout) o envio dos eventos pendentes, ao desligar.
+func flushSentry(timeout time.Duration) {
+	if sentry == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		sentry.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
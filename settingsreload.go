@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recarga das configurações globais sem reiniciar o processo. SETTINGS_FILE aponta para um arquivo JSON com as
+// variáveis de ambiente que podem mudar em funcionamento, ex.: {"LOG_LEVEL": "debug", "RECONNECT_MAX_DELAY": "2m"}.
+// Os valores do arquivo têm prioridade sobre o ambiente; tirar uma chave do arquivo volta ao valor do ambiente.
+// A recarga acontece quando o arquivo muda (verificado a cada settingsWatchInterval), com SIGHUP (fora do Windows)
+// ou pelo POST /api/reload, e também relê o cadastro das contas monitoradas (preferências alteradas pelo comando
+// settings, por exemplo) com ReloadAccount.
+const (
+	settingsFileEnv       = "SETTINGS_FILE"
+	settingsWatchInterval = 5 * time.Second
+)
+
+// reloadableSettings são as variáveis aceitas no arquivo: todas são lidas a cada uso (ou reaplicadas na recarga).
+func reloadableSettings() []string {
+	keys := []string{logLevelEnv, debugSampleEnv, connectionAlertsWebhookEnv, heartbeatURLEnv, authFailureLimitEnv,
+		subscriptionMaxAttemptsEnv, apiKeyPolicyEnv, bybitWSURLsEnv}
+	for _, field := range reconnectFields {
+		keys = append(keys, field.env)
+	}
+	return keys
+}
+
+// settingsState guarda os valores do ambiente no início (para voltar a eles) e serializa as recargas.
+var settingsState = struct {
+	mu       sync.Mutex
+	original map[string]*string // nil = variável não definida no ambiente
+	modTime  time.Time
+}{}
+
+// readSettingsFile lê o arquivo de configurações. Valores podem ser texto, número ou booleano.
+func readSettingsFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("JSON inválido: %w", err)
+	}
+	allowed := make(map[string]bool)
+	for _, key := range reloadableSettings() {
+		allowed[key] = true
+	}
+	settings := make(map[string]string, len(raw))
+	var unknown []string
+	for key, value := range raw {
+		if !allowed[key] {
+			unknown = append(unknown, key)
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			settings[key] = v
+		case float64, bool:
+			settings[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("%s: use texto, número ou booleano", key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("chave(s) que não podem ser recarregadas: %s (use o ambiente e reinicie o aplicativo)", strings.Join(unknown, ", "))
+	}
+	return settings, nil
+}
+
+// applySettingsFile aplica o arquivo de SETTINGS_FILE ao ambiente do processo e retorna as variáveis que mudaram.
+// Sem SETTINGS_FILE, não faz nada. Com erro no arquivo, nada é alterado.
+func applySettingsFile() ([]string, error) {
+	path := strings.TrimSpace(os.Getenv(settingsFileEnv))
+	if path == "" {
+		return nil, nil
+	}
+	settingsState.mu.Lock()
+	defer settingsState.mu.Unlock()
+	if info, err := os.Stat(path); err == nil {
+		settingsState.modTime = info.ModTime()
+	}
+	settings, err := readSettingsFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if settingsState.original == nil {
+		settingsState.original = make(map[string]*string)
+		for _, key := range reloadableSettings() {
+			if value, ok := os.LookupEnv(key); ok {
+				settingsState.original[key] = &value
+			} else {
+				settingsState.original[key] = nil
+			}
+		}
+	}
+
+	var changed []string
+	for _, key := range reloadableSettings() {
+		desired, fromFile := settings[key]
+		current, isSet := os.LookupEnv(key)
+		if !fromFile {
+			if original := settingsState.original[key]; original != nil {
+				desired, fromFile = *original, true
+			}
+		}
+		switch {
+		case fromFile && (!isSet || current != desired):
+			os.Setenv(key, desired)
+		case !fromFile && isSet:
+			os.Unsetenv(key)
+		default:
+			continue
+		}
+		changed = append(changed, key)
+	}
+	return changed, nil
+}
+
+// settingsFileChanged indica se o arquivo de configurações foi alterado desde a última leitura.
+func settingsFileChanged() bool {
+	path := strings.TrimSpace(os.Getenv(settingsFileEnv))
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	settingsState.mu.Lock()
+	defer settingsState.mu.Unlock()
+	return !info.ModTime().Equal(settingsState.modTime)
+}
+
+// ReloadSettings relê SETTINGS_FILE e o cadastro das contas monitoradas e aplica as alterações às conexões em
+// andamento. source identifica a origem da recarga nas mensagens (arquivo alterado, SIGHUP, API).
+func (wsm *WebSocketManager) ReloadSettings(source string) error {
+	changed, err := applySettingsFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[AVISO] Recarga das configurações (%s) ignorada: %v\n", source, err)
+		return err
+	}
+	refreshLoggerSampling()
+	loadSharedWebhooksOrWarn(wsm.db)
+
+	wsm.mu.RLock()
+	accountIDs := make([]int64, 0, len(wsm.connections))
+	for accountID := range wsm.connections {
+		accountIDs = append(accountIDs, accountID)
+	}
+	wsm.mu.RUnlock()
+	var problems []string
+	for _, accountID := range accountIDs {
+		if _, err := wsm.ReloadAccount(accountID); err != nil {
+			problems = append(problems, fmt.Sprintf("conta %d: %v", accountID, err))
+		}
+	}
+
+	summary := "sem alterações globais"
+	if len(changed) > 0 {
+		summary = "alterado(s): " + strings.Join(changed, ", ")
+	}
+	fmt.Fprintf(os.Stderr, "Configurações recarregadas (%s): %s; %d conta(s) monitorada(s) atualizada(s)\n", source, summary, len(accountIDs)-len(problems))
+	if len(problems) > 0 {
+		err := errors.New(strings.Join(problems, "; "))
+		fmt.Fprintf(os.Stderr, "[AVISO] Erro ao recarregar contas: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// refreshLoggerSampling reaplica LOG_DEBUG_SAMPLE aos logs já abertos.
+func refreshLoggerSampling() {
+	rate := debugSampleRate()
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+	for _, logger := range loggers {
+		logger.mu.Lock()
+		logger.sampleRate = rate
+		logger.mu.Unlock()
+	}
+}
+
+// watchSettings recarrega as configurações quando SETTINGS_FILE muda ou quando chega SIGHUP, até o aplicativo sair.
+func (wsm *WebSocketManager) watchSettings() {
+	reloadSignals := make(chan os.Signal, 1)
+	notifyReloadSignal(reloadSignals)
+	go func() {
+		ticker := time.NewTicker(settingsWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-wsm.ctx.Done():
+				return
+			case <-reloadSignals:
+				wsm.ReloadSettings("SIGHUP")
+			case <-ticker.C:
+				if settingsFileChanged() {
+					wsm.ReloadSettings("arquivo alterado")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severidade e roteamento: cada notificação tem uma severidade (info, warning ou critical) e a preferência
+// severity_routes da conta define, por severidade, os canais que a recebem, ex.:
+// settings "Minha Conta" severity_routes '{"critical": ["conexao", "discord"], "info": ["discord"], "warning": []}'.
+// Os canais são os webhooks da conta: discord (principal), execucoes e conexao (alertas de conexão). Uma lista vazia
+// suprime a severidade; severidades fora da preferência vão para o canal de sempre do evento. A severidade padrão de
+// cada evento pode ser trocada pela preferência severity_levels, ex.: '{"stop_cancel": "warning"}'. A planilha do
+// Google não entra no roteamento: ela registra dados, não avisa.
+const (
+	severityRoutesSettingKey = "severity_routes"
+	severityLevelsSettingKey = "severity_levels"
+)
+
+const (
+	notifySeverityInfo     = "info"
+	notifySeverityWarning  = "warning"
+	notifySeverityCritical = "critical"
+)
+
+// Eventos além dos de ordem e carteira (templates.go)
+const (
+	eventExecution         = "execution"          // execuções (webhook de execuções)
+	eventConnection        = "connection"         // queda e restabelecimento da conexão
+	eventAlert             = "alert"              // avisos ao operador: inscrição, lacuna, failover, relógio
+	eventMonitoringStopped = "monitoring_stopped" // monitoramento parado por falha de autenticação ou IP
+)
+
+// defaultEventSeverity é a severidade de cada evento sem severity_levels.
+var defaultEventSeverity = map[string]string{
+	templateNewOrder:        notifySeverityInfo,
+	templateOrderMoved:      notifySeverityInfo,
+	templateCancel:          notifySeverityInfo,
+	templateStop:            notifySeverityInfo,
+	templateStopMoved:       notifySeverityInfo,
+	templateStopCancel:      notifySeverityInfo,
+	templatePositionSummary: notifySeverityInfo,
+	eventExecution:          notifySeverityInfo,
+	eventConnection:         notifySeverityCritical,
+	eventAlert:              notifySeverityWarning,
+	eventMonitoringStopped:  notifySeverityCritical,
+}
+
+// notifySeverities é a ordem das severidades para exibição e validação.
+var notifySeverities = []string{notifySeverityInfo, notifySeverityWarning, notifySeverityCritical}
+
+// routableChannels são os canais aceitos em severity_routes.
+var routableChannels = []string{notifyChannelDiscord, notifyChannelExecutions, notifyChannelConnection, notifyChannelGeneric, notifyChannelDiscordBot}
+
+func isSeverity(value string) bool {
+	for _, severity := range notifySeverities {
+		if value == severity {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSeverityRoutesSetting(value json.RawMessage) error {
+	var routes map[string][]string
+	if json.Unmarshal(value, &routes) != nil {
+		return errors.New(`use um objeto {"severidade": ["canal", ...]}, ex.: {"critical": ["conexao", "discord"], "info": ["discord"]}`)
+	}
+	for severity, channels := range routes {
+		if !isSeverity(severity) {
+			return fmt.Errorf("severidade desconhecida: %s (use %s)", severity, strings.Join(notifySeverities, ", "))
+		}
+		for _, channel := range channels {
+			if !isRoutableChannel(channel) {
+				return fmt.Errorf("%s: canal desconhecido: %s (use %s)", severity, channel, strings.Join(routableChannels, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func validateSeverityLevelsSetting(value json.RawMessage) error {
+	var levels map[string]string
+	if json.Unmarshal(value, &levels) != nil {
+		return errors.New(`use um objeto {"evento": "severidade"}, ex.: {"stop_cancel": "warning"}`)
+	}
+	for event, severity := range levels {
+		if _, ok := defaultEventSeverity[event]; !ok {
+			return fmt.Errorf("evento desconhecido: %s (use %s)", event, strings.Join(severityEventNames(), ", "))
+		}
+		if !isSeverity(severity) {
+			return fmt.Errorf("%s: severidade desconhecida: %s (use %s)", event, severity, strings.Join(notifySeverities, ", "))
+		}
+	}
+	return nil
+}
+
+func isRoutableChannel(channel string) bool {
+	for _, c := range routableChannels {
+		if channel == c {
+			return true
+		}
+	}
+	return false
+}
+
+func severityEventNames() []string {
+	names := make([]string, 0, len(defaultEventSeverity))
+	for event := range defaultEventSeverity {
+		names = append(names, event)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// eventSeverity retorna a severidade do evento na conta: a de severity_levels ou a padrão.
+func eventSeverity(account *BybitAccount, event string) string {
+	var levels map[string]string
+	if account.Settings.Decode(severityLevelsSettingKey, &levels) && isSeverity(levels[event]) {
+		return levels[event]
+	}
+	if severity, ok := defaultEventSeverity[event]; ok {
+		return severity
+	}
+	return notifySeverityInfo
+}
+
+// notificationChannels retorna os canais, com webhook configurado, que recebem uma notificação da severidade: os da
+// rota em severity_routes ou, sem rota para a severidade, defaultChannel. Vazio = nenhum canal (ou suprimida).
+func notificationChannels(account *BybitAccount, severity, defaultChannel string) []string {
+	channels := []string{defaultChannel}
+	var routes map[string][]string
+	if account.Settings.Decode(severityRoutesSettingKey, &routes) {
+		if routed, ok := routes[severity]; ok {
+			channels = routed
+		}
+	}
+	// Os webhooks compartilhados recebem tudo o que a conta envia, menos as severidades suprimidas (sharedwebhook.go)
+	if len(channels) > 0 {
+		channels = append(channels, accountSharedChannels(account)...)
+	}
+	configured := make([]string, 0, len(channels))
+	seen := make(map[string]bool)
+	_, hasBot := accountDiscordBot(account)
+	for _, channel := range channels {
+		// Com o bot do Discord (discordbot.go), o canal principal é postado pelo bot em vez do webhook
+		if channel == notifyChannelDiscord && hasBot {
+			channel = notifyChannelDiscordBot
+		}
+		if !seen[channel] && channelWebhookURL(account, channel) != "" {
+			seen[channel] = true
+			configured = append(configured, channel)
+		}
+	}
+	return configured
+}
+
+// channelWebhookURL retorna o webhook atual do canal na conta ("" = não configurado).
+func channelWebhookURL(account *BybitAccount, channel string) string {
+	switch channel {
+	case notifyChannelDiscord:
+		return account.WebhookURL
+	case notifyChannelExecutions:
+		return account.WebhookURLExecutions
+	case notifyChannelConnection:
+		return connectionAlertsWebhook(account)
+	case notifyChannelGeneric:
+		config, _ := accountGenericWebhook(account)
+		return config.URL
+	case notifyChannelDiscordBot:
+		if config, ok := accountDiscordBot(account); ok {
+			return config.messagesURL()
+		}
+	}
+	if name, ok := sharedChannelName(channel); ok {
+		return sharedWebhookURL(name)
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Webhooks compartilhados: um webhook do Discord cadastrado uma vez com um nome (comando shared-webhook) e ligado a
+// várias contas pela preferência shared_webhooks, ex.: settings "Cliente A" shared_webhooks '["gestora"]'. Cada
+// notificação da conta também vai para os webhooks compartilhados dela, com o nome da conta na primeira linha, para
+// acompanhar as contas de vários clientes num único canal. O cadastro fica em app_settings e é lido ao iniciar e na
+// recarga das configurações (settingsreload.go).
+const (
+	sharedWebhookSettingPrefix = "shared_webhook." // app_settings: shared_webhook.<nome> = URL
+	sharedWebhooksSettingKey   = "shared_webhooks" // preferência da conta: nomes dos webhooks compartilhados
+	sharedChannelPrefix        = "compartilhado:"  // canal no histórico: compartilhado:<nome>
+)
+
+const sharedWebhookUsage = "uso: shared-webhook [add <nome> <url do webhook> | remove <nome>]"
+
+// sharedWebhooks é o cadastro dos webhooks compartilhados (nome -> URL).
+var sharedWebhooks = struct {
+	mu     sync.RWMutex
+	byName map[string]string
+}{byName: make(map[string]string)}
+
+// loadSharedWebhooks lê o cadastro do banco.
+func loadSharedWebhooks(db *Database) error {
+	settings, err := db.ListSettingsByPrefix(sharedWebhookSettingPrefix)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]string, len(settings))
+	for key, url := range settings {
+		byName[strings.TrimPrefix(key, sharedWebhookSettingPrefix)] = url
+		registerSecret(url, redactedPlaceholder)
+	}
+	sharedWebhooks.mu.Lock()
+	sharedWebhooks.byName = byName
+	sharedWebhooks.mu.Unlock()
+	return nil
+}
+
+// sharedWebhookURL retorna a URL do webhook compartilhado ("" = não cadastrado).
+func sharedWebhookURL(name string) string {
+	sharedWebhooks.mu.RLock()
+	defer sharedWebhooks.mu.RUnlock()
+	return sharedWebhooks.byName[name]
+}
+
+func validSharedWebhookName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func validateSharedWebhooksSetting(value json.RawMessage) error {
+	var names []string
+	if json.Unmarshal(value, &names) != nil {
+		return errors.New(`use uma lista com os nomes dos webhooks compartilhados, ex.: ["gestora"]`)
+	}
+	for _, name := range names {
+		if !validSharedWebhookName(name) {
+			return fmt.Errorf("nome inválido: %q (letras minúsculas, números, - e _)", name)
+		}
+	}
+	return nil
+}
+
+// accountSharedChannels retorna os canais dos webhooks compartilhados da conta que estão cadastrados.
+func accountSharedChannels(account *BybitAccount) []string {
+	var names []string
+	if !account.Settings.Decode(sharedWebhooksSettingKey, &names) {
+		return nil
+	}
+	var channels []string
+	for _, name := range names {
+		if sharedWebhookURL(name) != "" {
+			channels = append(channels, sharedChannelPrefix+name)
+		}
+	}
+	return channels
+}
+
+// sharedChannelName retorna o nome do webhook compartilhado do canal (false = não é um canal compartilhado).
+func sharedChannelName(channel string) (string, bool) {
+	return strings.CutPrefix(channel, sharedChannelPrefix)
+}
+
+// sharedChannelMessage põe o nome da conta na primeira linha da mensagem enviada ao webhook compartilhado.
+func sharedChannelMessage(account *BybitAccount, message string) string {
+	return fmt.Sprintf("**[%s]**\n%s", account.Name, message)
+}
+
+// runSharedWebhookCommand lista, cadastra ou remove os webhooks compartilhados.
+func runSharedWebhookCommand(db *Database, args []string) error {
+	switch {
+	case len(args) == 0:
+		settings, err := db.ListSettingsByPrefix(sharedWebhookSettingPrefix)
+		if err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			fmt.Println("Nenhum webhook compartilhado cadastrado.")
+			return nil
+		}
+		names := make([]string, 0, len(settings))
+		for key := range settings {
+			names = append(names, strings.TrimPrefix(key, sharedWebhookSettingPrefix))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// Só o ID do webhook: o token fica de fora
+			url := settings[sharedWebhookSettingPrefix+name]
+			if i := strings.LastIndex(strings.TrimSuffix(url, "/"), "/"); i >= 0 {
+				url = url[:i+1] + redactedPlaceholder
+			}
+			fmt.Printf("%-20s %s\n", name, url)
+		}
+		return nil
+	case len(args) == 3 && args[0] == "add":
+		name, url := args[1], strings.TrimSpace(args[2])
+		if !validSharedWebhookName(name) {
+			return fmt.Errorf("nome inválido: %q (letras minúsculas, números, - e _)", name)
+		}
+		if url == "" || !validateDiscordWebhookURL(url) {
+			return errors.New("use a URL de um webhook do Discord")
+		}
+		if err := db.SetSetting(sharedWebhookSettingPrefix+name, url); err != nil {
+			return err
+		}
+		fmt.Printf("Webhook compartilhado '%s' cadastrado. Ligue às contas com: settings <conta> %s '[\"%s\"]'\n", name, sharedWebhooksSettingKey, name)
+	case len(args) == 2 && args[0] == "remove":
+		if _, exists, err := db.GetSetting(sharedWebhookSettingPrefix + args[1]); err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("webhook compartilhado não encontrado: %s", args[1])
+		}
+		if err := db.DeleteSetting(sharedWebhookSettingPrefix + args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Webhook compartilhado '%s' removido.\n", args[1])
+	default:
+		return errors.New(sharedWebhookUsage)
+	}
+	fmt.Println("O aplicativo em execução usa o cadastro novo após reiniciar ou recarregar as configurações.")
+	return loadSharedWebhooks(db)
+}
+
+// loadSharedWebhooksOrWarn lê o cadastro avisando no stderr em caso de erro (sem impedir o início).
+func loadSharedWebhooksOrWarn(db *Database) {
+	if err := loadSharedWebhooks(db); err != nil {
+		fmt.Fprintf(os.Stderr, "[AVISO] Erro ao ler os webhooks compartilhados: %v\n", err)
+	}
+}
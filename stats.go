@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Chaves dos contadores por conta (tabela account_stats)
+const (
+	statNotificationsSent   = "notifications_sent"
+	statWebhookFailures     = "webhook_failures"
+	statReconnects          = "reconnects"
+	statMonitoringStartedAt = "monitoring_started_at" // unix (segundos) do último início do monitoramento
+	statMessagesPrefix      = "messages:"             // mensagens recebidas por tópico, ex.: messages:order
+)
+
+// Chaves só dos agregados diários (tabela account_daily_stats); os contadores de notificações,
+// falhas de webhook e reconexões também entram nos agregados (dailyStatKeys).
+const (
+	statOrdersSeen = "orders_seen"
+	statExecutions = "executions"
+	statVolumeUSD  = "volume_usd" // soma da quantidade executada (contratos inversos, em USD)
+)
+
+// dailyStatKeys são os contadores de account_stats que também são agregados por dia.
+var dailyStatKeys = map[string]bool{
+	statNotificationsSent: true,
+	statWebhookFailures:   true,
+	statReconnects:        true,
+}
+
+// dailyStatsDays é quantos dias o menu de estatísticas mostra.
+const dailyStatsDays = 7
+
+// statsFlushInterval é o intervalo de gravação dos contadores no banco.
+const statsFlushInterval = 30 * time.Second
+
+// statsRecorder acumula os incrementos em memória e grava no banco periodicamente,
+// para não fazer uma escrita no SQLite a cada mensagem recebida.
+type statsRecorder struct {
+	mu      sync.Mutex
+	pending map[int64]map[string]int64
+	daily   map[int64]map[string]map[string]float64 // conta -> dia (UTC) -> chave -> incremento
+	db      *Database
+}
+
+var accountStats = &statsRecorder{pending: make(map[int64]map[string]int64), daily: make(map[int64]map[string]map[string]float64)}
+
+// recordStat incrementa um contador da conta. Sem flusher iniciado (ex.: subcomandos), os valores são descartados.
+func recordStat(accountID int64, key string, delta int64) {
+	accountStats.mu.Lock()
+	defer accountStats.mu.Unlock()
+	if accountStats.db == nil {
+		return
+	}
+	counters, exists := accountStats.pending[accountID]
+	if !exists {
+		counters = make(map[string]int64)
+		accountStats.pending[accountID] = counters
+	}
+	counters[key] += delta
+	if dailyStatKeys[key] {
+		accountStats.addDaily(accountID, key, float64(delta))
+	}
+}
+
+// recordDailyStat incrementa só o agregado diário da conta (ex.: volume executado).
+func recordDailyStat(accountID int64, key string, delta float64) {
+	accountStats.mu.Lock()
+	defer accountStats.mu.Unlock()
+	if accountStats.db == nil {
+		return
+	}
+	accountStats.addDaily(accountID, key, delta)
+}
+
+// addDaily soma ao agregado do dia atual (UTC). Chamar com mu travado.
+func (r *statsRecorder) addDaily(accountID int64, key string, delta float64) {
+	day := time.Now().UTC().Format("2006-01-02")
+	days, exists := r.daily[accountID]
+	if !exists {
+		days = make(map[string]map[string]float64)
+		r.daily[accountID] = days
+	}
+	if days[day] == nil {
+		days[day] = make(map[string]float64)
+	}
+	days[day][key] += delta
+}
+
+// recordNotificationResult contabiliza o resultado de um envio de webhook e grava a notificação no histórico.
+func recordNotificationResult(accountID int64, channel, message string, delivery notificationDelivery) {
+	switch {
+	case delivery.LogOnly:
+	case delivery.Err != nil:
+		recordStat(accountID, statWebhookFailures, 1)
+	default:
+		recordStat(accountID, statNotificationsSent, 1)
+	}
+	recordNotificationHistory(accountID, channel, message, delivery)
+}
+
+// startStatsFlusher passa a gravar os contadores no banco a cada statsFlushInterval.
+func startStatsFlusher(db *Database) {
+	accountStats.mu.Lock()
+	accountStats.db = db
+	accountStats.mu.Unlock()
+	enableNotificationHistory(db)
+	enableStreamCheckpoints(db)
+	enableRawCapture(db)
+
+	go func() {
+		ticker := time.NewTicker(statsFlushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			flushStats()
+		}
+	}()
+}
+
+// flushStats grava no banco os incrementos pendentes (e os checkpoints das conexões).
+func flushStats() {
+	flushStreamCheckpoints()
+	accountStats.mu.Lock()
+	db := accountStats.db
+	pending := accountStats.pending
+	accountStats.pending = make(map[int64]map[string]int64)
+	daily := accountStats.daily
+	accountStats.daily = make(map[int64]map[string]map[string]float64)
+	accountStats.mu.Unlock()
+
+	if db == nil {
+		return
+	}
+	for accountID, counters := range pending {
+		if err := db.AddAccountStats(accountID, counters); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao gravar estatísticas da conta %d: %v\n", accountID, err)
+		}
+	}
+	for accountID, days := range daily {
+		for day, deltas := range days {
+			if err := db.AddDailyStats(accountID, day, deltas); err != nil {
+				fmt.Fprintf(os.Stderr, "Erro ao gravar estatísticas diárias da conta %d: %v\n", accountID, err)
+			}
+		}
+	}
+}
+
+// AccountStatsView reúne os contadores persistidos e o estado atual da conexão para exibição.
+type AccountStatsView struct {
+	MessagesByTopic     map[string]int64
+	NotificationsSent   int64
+	WebhookFailures     int64
+	Reconnects          int64
+	MonitoringStartedAt time.Time
+	UpdatedAt           time.Time
+}
+
+// getAccountStatsView lê os contadores da conta (gravando antes o que estiver pendente em memória).
+func getAccountStatsView(db *Database, accountID int64) (*AccountStatsView, error) {
+	flushStats()
+	values, updatedAt, err := db.GetAccountStats(accountID)
+	if err != nil {
+		return nil, err
+	}
+	view := &AccountStatsView{MessagesByTopic: make(map[string]int64), UpdatedAt: updatedAt}
+	for key, value := range values {
+		switch {
+		case key == statNotificationsSent:
+			view.NotificationsSent = value
+		case key == statWebhookFailures:
+			view.WebhookFailures = value
+		case key == statReconnects:
+			view.Reconnects = value
+		case key == statMonitoringStartedAt:
+			if value > 0 {
+				view.MonitoringStartedAt = time.Unix(value, 0)
+			}
+		case strings.HasPrefix(key, statMessagesPrefix):
+			view.MessagesByTopic[strings.TrimPrefix(key, statMessagesPrefix)] = value
+		}
+	}
+	return view, nil
+}
+
+// sortedTopics retorna os tópicos em ordem alfabética para exibição estável.
+func (v *AccountStatsView) sortedTopics() []string {
+	topics := make([]string, 0, len(v.MessagesByTopic))
+	for topic := range v.MessagesByTopic {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// DailyStats são os agregados de um dia (UTC) da conta.
+type DailyStats struct {
+	Day               string
+	NotificationsSent int64
+	WebhookFailures   int64
+	OrdersSeen        int64
+	Executions        int64
+	VolumeUSD         float64
+	Reconnects        int64
+}
+
+// getDailyStats retorna os agregados dos últimos days dias (do mais recente ao mais antigo), incluindo dias sem atividade.
+func getDailyStats(db *Database, accountID int64, days int) ([]DailyStats, error) {
+	flushStats()
+	today := time.Now().UTC()
+	from := today.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	values, err := db.ListDailyStats(accountID, from)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]DailyStats, 0, days)
+	for i := 0; i < days; i++ {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		v := values[day]
+		result = append(result, DailyStats{
+			Day:               day,
+			NotificationsSent: int64(v[statNotificationsSent]),
+			WebhookFailures:   int64(v[statWebhookFailures]),
+			OrdersSeen:        int64(v[statOrdersSeen]),
+			Executions:        int64(v[statExecutions]),
+			VolumeUSD:         v[statVolumeUSD],
+			Reconnects:        int64(v[statReconnects]),
+		})
+	}
+	return result, nil
+}
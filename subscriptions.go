@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Confirmação das inscrições: cada tópico é inscrito numa requisição própria e fica pendente até o servidor
+// confirmar (ack). Recusa ou falta de confirmação em subscriptionAckTimeout reenviam a inscrição do tópico; depois
+// de SUBSCRIBE_MAX_ATTEMPTS tentativas sem sucesso, um alerta é enviado e o tópico fica de fora até a próxima
+// reconexão, em vez de o fluxo ficar incompleto sem aviso.
+const (
+	subscriptionAckTimeout         = 10 * time.Second
+	subscriptionMaxAttemptsEnv     = "SUBSCRIBE_MAX_ATTEMPTS"
+	subscriptionMaxAttemptsDefault = 3
+)
+
+// bybitSubscribeReqPrefix + tópico é o req_id da inscrição na Bybit, devolvido na resposta para identificar o tópico.
+const bybitSubscribeReqPrefix = "sub-"
+
+// bybitPrivateTopics são os tópicos inscritos na conexão privada da Bybit.
+var bybitPrivateTopics = []string{"order", "execution", "position", "wallet"}
+
+func subscriptionMaxAttempts() int {
+	attempts, err := strconv.Atoi(strings.TrimSpace(os.Getenv(subscriptionMaxAttemptsEnv)))
+	if err != nil || attempts < 1 {
+		return subscriptionMaxAttemptsDefault
+	}
+	return attempts
+}
+
+type subscriptionState struct {
+	acked     bool
+	attempts  int
+	sentAt    time.Time
+	lastError string // motivo da recusa da última tentativa
+	alerted   bool   // tentativas esgotadas
+}
+
+// subscriptionTracker acompanha as inscrições de uma conexão WebSocket (uma por conexão estabelecida).
+type subscriptionTracker struct {
+	wsm         *WebSocketManager
+	wsConn      *WebSocketConnection
+	stream      string                   // conexão (rawStream*), para logs e alertas
+	send        func(topic string) error // envia a inscrição de um tópico
+	maxAttempts int
+
+	mu     sync.Mutex
+	topics map[string]*subscriptionState
+	order  []string
+
+	ctx    context.Context // cancelado por stop ou pelo fim da conexão/monitoramento
+	cancel context.CancelFunc
+}
+
+func newSubscriptionTracker(ctx context.Context, wsm *WebSocketManager, wsConn *WebSocketConnection, stream string, topics []string, send func(topic string) error) *subscriptionTracker {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &subscriptionTracker{
+		wsm:         wsm,
+		wsConn:      wsConn,
+		stream:      stream,
+		send:        send,
+		maxAttempts: subscriptionMaxAttempts(),
+		topics:      make(map[string]*subscriptionState, len(topics)),
+		order:       topics,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for _, topic := range topics {
+		t.topics[topic] = &subscriptionState{}
+	}
+	return t
+}
+
+// start envia a inscrição de todos os tópicos e passa a verificar as confirmações até stop.
+// Só a goroutine de verificação escreve na conexão depois disso (o ping usa WriteControl).
+func (t *subscriptionTracker) start() error {
+	t.wsConn.setSubscriptions(t.stream, t)
+	for _, topic := range t.order {
+		if err := t.sendTopic(topic); err != nil {
+			return err
+		}
+	}
+	t.wsConn.spawn(goroutineSubscribe, t.watch)
+	return nil
+}
+
+func (t *subscriptionTracker) stop() {
+	t.cancel()
+}
+
+func (t *subscriptionTracker) sendTopic(topic string) error {
+	t.mu.Lock()
+	state := t.topics[topic]
+	state.attempts++
+	state.sentAt = time.Now()
+	state.lastError = ""
+	t.mu.Unlock()
+	return t.send(topic)
+}
+
+func (t *subscriptionTracker) watch() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+			t.check()
+		}
+	}
+}
+
+// check reenvia as inscrições recusadas ou sem confirmação no prazo e alerta as que esgotaram as tentativas.
+func (t *subscriptionTracker) check() {
+	type retry struct {
+		topic, reason string
+		attempt       int
+	}
+	var retries []retry
+	var exhausted []retry
+	t.mu.Lock()
+	for _, topic := range t.order {
+		state := t.topics[topic]
+		if state.acked || state.alerted {
+			continue
+		}
+		reason := state.lastError
+		if reason == "" {
+			if time.Since(state.sentAt) < subscriptionAckTimeout {
+				continue
+			}
+			reason = fmt.Sprintf("sem confirmação em %s", subscriptionAckTimeout)
+		}
+		if state.attempts >= t.maxAttempts {
+			state.alerted = true
+			exhausted = append(exhausted, retry{topic, reason, state.attempts})
+			continue
+		}
+		retries = append(retries, retry{topic, reason, state.attempts + 1})
+	}
+	t.mu.Unlock()
+
+	logger, _ := getLogger(t.wsConn.AccountID, t.wsConn.Account().Name)
+	for _, r := range retries {
+		if logger != nil {
+			logger.Log("⚠️ Inscrição em %s (%s) não confirmada: %s; reenviando (tentativa %d/%d)", r.topic, t.stream, r.reason, r.attempt, t.maxAttempts)
+		}
+		if err := t.sendTopic(r.topic); err != nil {
+			// Conexão provavelmente caiu; o loop de leitura trata a reconexão
+			if logger != nil {
+				logger.Log("Erro ao reenviar inscrição em %s: %v", r.topic, err)
+			}
+			return
+		}
+	}
+	for _, r := range exhausted {
+		if logger != nil {
+			logger.Log("❌ Inscrição em %s (%s) não confirmada após %d tentativas: %s", r.topic, t.stream, r.attempt, r.reason)
+		}
+		t.wsm.sendOperatorAlert(t.wsConn, eventAlert, fmt.Sprintf("⚠️ Conta **%s**: a inscrição no tópico `%s` (%s) não foi confirmada pela corretora após %d tentativas (%s).\n"+
+			"As notificações desse tópico não vão chegar até a próxima reconexão.", t.wsConn.Account().Name, r.topic, t.stream, r.attempt, r.reason))
+	}
+}
+
+// ack registra a resposta do servidor à inscrição do tópico (ok = confirmada; reason = motivo da recusa).
+func (t *subscriptionTracker) ack(topic string, ok bool, reason string) {
+	t.mu.Lock()
+	state, exists := t.topics[topic]
+	if !exists || state.acked {
+		t.mu.Unlock()
+		return
+	}
+	attempts := state.attempts
+	if ok {
+		state.acked = true
+		state.lastError = ""
+	} else {
+		if reason == "" {
+			reason = "recusada"
+		}
+		state.lastError = reason
+	}
+	t.mu.Unlock()
+
+	logger, _ := getLogger(t.wsConn.AccountID, t.wsConn.Account().Name)
+	if logger == nil {
+		return
+	}
+	switch {
+	case !ok:
+		logger.Log("⚠️ Inscrição em %s (%s) recusada: %s", topic, t.stream, reason)
+	case attempts > 1:
+		logger.Log("✅ Inscrição em %s (%s) confirmada na tentativa %d", topic, t.stream, attempts)
+	default:
+		logger.Log("[DEBUG] Inscrição em %s (%s) confirmada", topic, t.stream)
+	}
+}
+
+// unacked retorna os tópicos ainda sem confirmação.
+func (t *subscriptionTracker) unacked() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var topics []string
+	for _, topic := range t.order {
+		if !t.topics[topic].acked {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// setSubscriptions registra o acompanhamento de inscrições da conexão stream (substitui o da conexão anterior).
+func (c *WebSocketConnection) setSubscriptions(stream string, t *subscriptionTracker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[string]*subscriptionTracker)
+	}
+	c.subscriptions[stream] = t
+}
+
+// subscriptionsFor retorna o acompanhamento de inscrições atual da conexão stream (nil se não houver).
+func (c *WebSocketConnection) subscriptionsFor(stream string) *subscriptionTracker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscriptions[stream]
+}
+
+// unackedTopics lista, de todas as conexões da conta, os tópicos sem confirmação (ex.: "wallet (bybit-private)").
+// Chamado com c.mu travado.
+func (c *WebSocketConnection) unackedTopics() []string {
+	var topics []string
+	for stream, t := range c.subscriptions {
+		for _, topic := range t.unacked() {
+			topics = append(topics, fmt.Sprintf("%s (%s)", topic, stream))
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
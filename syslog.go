@@ -0,0 +1,84 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+	"strings"
+)
+
+// Saída dos logs das contas para o syslog:
+//
+//	SYSLOG_ADDR=local                     socket local (/dev/log)
+//	SYSLOG_ADDR=udp://logs.exemplo:514    servidor remoto (udp ou tcp)
+//	SYSLOG_FACILITY=local0                facility (padrão: daemon)
+//	SYSLOG_TAG=bybit-notifier             tag das mensagens (padrão: bybit-notifier)
+const (
+	syslogAddrEnv     = "SYSLOG_ADDR"
+	syslogFacilityEnv = "SYSLOG_FACILITY"
+	syslogTagEnv      = "SYSLOG_TAG"
+	syslogDefaultTag  = "bybit-notifier"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL, "daemon": syslog.LOG_DAEMON,
+	"auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG, "lpr": syslog.LOG_LPR, "news": syslog.LOG_NEWS,
+	"uucp": syslog.LOG_UUCP, "cron": syslog.LOG_CRON, "authpriv": syslog.LOG_AUTHPRIV, "ftp": syslog.LOG_FTP,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2, "local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5, "local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+type syslogSink struct {
+	writer *syslog.Writer
+	addr   string
+}
+
+func newSyslogSink(addr, facilityName, tag string) (logSink, error) {
+	facilityName = strings.ToLower(strings.TrimSpace(facilityName))
+	if facilityName == "" {
+		facilityName = "daemon"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, fmt.Errorf("facility desconhecida: %s", facilityName)
+	}
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		tag = syslogDefaultTag
+	}
+
+	network, raddr := "", ""
+	if addr != "local" {
+		u, err := url.Parse(addr)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("endereço inválido: %s (use local, udp://host:porta ou tcp://host:porta)", addr)
+		}
+		network, raddr = u.Scheme, u.Host
+	}
+	writer, err := syslog.Dial(network, raddr, facility|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer, addr: addr}, nil
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog (" + s.addr + ")"
+}
+
+// WriteLog envia a linha com a severidade correspondente (o Writer refaz a conexão se ela cair).
+func (s *syslogSink) WriteLog(entry logEntry) error {
+	message := formatLogEntryMessage(entry)
+	switch entry.Severity {
+	case severityDebug:
+		return s.writer.Debug(message)
+	case severityWarning:
+		return s.writer.Warning(message)
+	case severityError:
+		return s.writer.Err(message)
+	default:
+		return s.writer.Info(message)
+	}
+}
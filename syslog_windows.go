@@ -0,0 +1,13 @@
+package main
+
+import "errors"
+
+const (
+	syslogAddrEnv     = "SYSLOG_ADDR"
+	syslogFacilityEnv = "SYSLOG_FACILITY"
+	syslogTagEnv      = "SYSLOG_TAG"
+)
+
+func newSyslogSink(addr, facilityName, tag string) (logSink, error) {
+	return nil, errors.New("syslog não está disponível no Windows")
+}
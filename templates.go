@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Templates de notificação: a preferência templates da conta troca o texto padrão de cada tipo de evento por um
+// template Go (text/template) com acesso aos campos do evento, ex.:
+// settings "Minha Conta" templates '{"new_order": "{{icon .Side}} {{.Symbol}} {{.Side}} @ {{price .Price}}"}'.
+// Eventos sem template usam o texto padrão, que também fica disponível no template em .Default. Um template vazio
+// (ou que gera texto vazio) suprime a notificação do evento; um erro ao executar usa o texto padrão e vai para o log.
+const templatesSettingKey = "templates"
+
+// Eventos com template
+const (
+	templateNewOrder        = "new_order"        // ordem nova ou grupo de ordens
+	templateOrderMoved      = "order_moved"      // preço da ordem alterado
+	templateCancel          = "cancel"           // ordens canceladas
+	templateStop            = "stop"             // stop criado
+	templateStopMoved       = "stop_moved"       // trigger do stop alterado
+	templateStopCancel      = "stop_cancel"      // stop cancelado
+	templatePositionSummary = "position_summary" // resumo de carteira e posições
+)
+
+// orderTemplateEvents liga os tipos de notificação do buffer de atraso aos eventos.
+var orderTemplateEvents = map[string]string{
+	"orders_group":     templateNewOrder,
+	"simple_order":     templateNewOrder,
+	"order_moved":      templateOrderMoved,
+	"cancelled_order":  templateCancel,
+	"untriggered_stop": templateStop,
+	"stop_moved":       templateStopMoved,
+	"deactivated_stop": templateStopCancel,
+}
+
+// errSummarySuppressed indica que o template position_summary da conta gerou texto vazio.
+var errSummarySuppressed = errors.New("o template position_summary da conta gerou um texto vazio")
+
+// templateFuncs são as funções disponíveis nos templates, além das nativas (printf, len, index, eq...).
+var templateFuncs = template.FuncMap{
+	"price": templatePrice,
+	"icon":  sideIcon,
+	"num":   templateNumber,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// orderTemplateData são os campos dos eventos de ordem e stop. Os preços e a quantidade (USD) já vêm convertidos
+// em número; os campos crus da Bybit ficam em .Order (primeira ordem) e .Orders.
+type orderTemplateData struct {
+	Account      string
+	Event        string
+	Default      string      // texto padrão do aplicativo
+	Orders       []OrderData // todas as ordens do evento (grupo ou cancelamentos)
+	Order        OrderData   // primeira ordem
+	Count        int
+	Symbol       string
+	Coin         string
+	Side         string
+	OrderType    string
+	StopType     string
+	ReduceOnly   bool
+	Price        float64 // preço exibido da primeira ordem (médio, se executada)
+	TriggerPrice float64
+	Qty          float64 // USD, somada no grupo
+	CoinQty      float64 // Qty na moeda, ao preço do evento (0 = desconhecido)
+	MinPrice     float64 // grupo: faixa e preço médio ponderado
+	MaxPrice     float64
+	AvgPrice     float64
+	OldPrice     float64 // ordem ou stop movido
+	NewPrice     float64
+	WalletPct    float64 // % do saldo da moeda (0 = desconhecido)
+	Origin       string  // orderLinkId das ordens, com o nome do prefixo em order_link_names (orderlink.go)
+}
+
+// walletSummaryCoin é o resumo de uma moeda no position_summary.
+type walletSummaryCoin struct {
+	Coin         string
+	Symbol       string
+	Total        float64 // USD
+	Protected    float64
+	Long         float64
+	Exposed      float64
+	ProtectedPct float64
+	LongPct      float64
+	Positions    []walletSummaryPosition // posições abertas, com a distância até a liquidação (liquidation.go)
+	FundingRate  float64                 // taxa de funding atual em fração (funding.go)
+	NextFunding  time.Time               // zero = funding desconhecido
+}
+
+// walletSummaryTemplateData são os campos do position_summary: as moedas e os totais da carteira.
+type walletSummaryTemplateData struct {
+	Account      string
+	Event        string
+	Default      string
+	Coins        []walletSummaryCoin
+	Total        float64
+	Protected    float64
+	Long         float64
+	Exposed      float64
+	ProtectedPct float64
+	LongPct      float64
+}
+
+// templateSamples geram dados de exemplo de cada evento, para validar o template antes de gravar.
+var templateSamples = map[string]func() interface{}{
+	templateNewOrder:   func() interface{} { return sampleOrderTemplateData(templateNewOrder) },
+	templateOrderMoved: func() interface{} { return sampleOrderTemplateData(templateOrderMoved) },
+	templateCancel:     func() interface{} { return sampleOrderTemplateData(templateCancel) },
+	templateStop:       func() interface{} { return sampleOrderTemplateData(templateStop) },
+	templateStopMoved:  func() interface{} { return sampleOrderTemplateData(templateStopMoved) },
+	templateStopCancel: func() interface{} { return sampleOrderTemplateData(templateStopCancel) },
+	templatePositionSummary: func() interface{} {
+		coin := walletSummaryCoin{Coin: "BTC", Symbol: "BTCUSD", Total: 1000, Protected: 600, Exposed: 400, ProtectedPct: 60}
+		return walletSummaryTemplateData{Account: "Exemplo", Event: templatePositionSummary, Coins: []walletSummaryCoin{coin},
+			Total: 1000, Protected: 600, Exposed: 400, ProtectedPct: 60}
+	},
+}
+
+func sampleOrderTemplateData(event string) orderTemplateData {
+	order := OrderData{Category: "inverse", OrderID: "exemplo", Symbol: "BTCUSD", Side: "Buy", OrderType: "Limit",
+		OrderStatus: "New", Price: "60000", Qty: "100", TriggerPrice: "59000", StopOrderType: "StopLoss", OrderLinkID: "grid-8f3a"}
+	data := newOrderTemplateData("Exemplo", event, delayNotificationItem{Data: []OrderData{order}, OldPrice: 60000, NewPrice: 61000}, nil, "")
+	data.Origin = "Bot Grid (grid-8f3a)"
+	return data
+}
+
+func validateTemplatesSetting(value json.RawMessage) error {
+	var templates map[string]string
+	if json.Unmarshal(value, &templates) != nil {
+		return errors.New(`use um objeto {"evento": "template"}, ex.: {"new_order": "{{.Symbol}} {{.Side}} @ {{price .Price}}"}`)
+	}
+	events := make([]string, 0, len(templates))
+	for event := range templates {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		sample, ok := templateSamples[event]
+		if !ok {
+			return fmt.Errorf("evento desconhecido: %s (use %s)", event, strings.Join(templateEventNames(), ", "))
+		}
+		if _, err := renderTemplate(event, templates[event], sample()); err != nil {
+			return fmt.Errorf("%s: %w", event, err)
+		}
+	}
+	return nil
+}
+
+func templateEventNames() []string {
+	names := make([]string, 0, len(templateSamples))
+	for event := range templateSamples {
+		names = append(names, event)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderTemplate executa o template com os dados do evento; o texto sai sem espaços nas pontas.
+func renderTemplate(event, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(event).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// applyNotificationTemplate retorna o texto do evento pelo template da conta ou, sem template (ou com erro ao
+// executar), o texto padrão.
+func applyNotificationTemplate(account *BybitAccount, event string, data interface{}, defaultText string) string {
+	var templates map[string]string
+	if !account.Settings.Decode(templatesSettingKey, &templates) {
+		return defaultText
+	}
+	text, ok := templates[event]
+	if !ok {
+		return defaultText
+	}
+	text, err := renderTemplate(event, text, data)
+	if err != nil {
+		if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+			logger.Log("⚠️ Erro no template %s (usando o texto padrão): %v", event, err)
+		}
+		return defaultText
+	}
+	return text
+}
+
+// formatOrderNotification formata um item do buffer de atraso: texto padrão e, se houver, template da conta.
+func formatOrderNotification(account *BybitAccount, item delayNotificationItem, wallet *WalletData, defaultText string) string {
+	event := orderTemplateEvents[item.NotificationType]
+	data := newOrderTemplateData(account.Name, event, item, wallet, defaultText)
+	data.Origin = accountOrderLinkNames(account).origin(item.Data)
+	return applyNotificationTemplate(account, event, data, defaultText)
+}
+
+func newOrderTemplateData(accountName, event string, item delayNotificationItem, wallet *WalletData, defaultText string) orderTemplateData {
+	first := item.Data[0]
+	minPrice, maxPrice, avgPrice, totalQty := orderGroupPrices(item.Data)
+	price, _ := strconv.ParseFloat(getDisplayPrice(first), 64)
+	trigger, _ := strconv.ParseFloat(first.TriggerPrice, 64)
+	data := orderTemplateData{
+		Account:      accountName,
+		Event:        event,
+		Default:      defaultText,
+		Orders:       item.Data,
+		Order:        first,
+		Count:        len(item.Data),
+		Symbol:       first.Symbol,
+		Coin:         symbolToCoin(first.Symbol),
+		Side:         first.Side,
+		OrderType:    first.OrderType,
+		StopType:     first.StopOrderType,
+		ReduceOnly:   first.ReduceOnly,
+		Price:        price,
+		TriggerPrice: trigger,
+		Qty:          totalQty,
+		MinPrice:     minPrice,
+		MaxPrice:     maxPrice,
+		AvgPrice:     avgPrice,
+		OldPrice:     item.OldPrice,
+		NewPrice:     item.NewPrice,
+	}
+	// Mesmo preço do valor em moeda das mensagens padrão (coinamount.go)
+	coinPrice := avgPrice
+	switch event {
+	case templateStop, templateStopCancel:
+		coinPrice = trigger
+	case templateOrderMoved, templateStopMoved:
+		coinPrice = item.NewPrice
+	}
+	data.CoinQty, _ = coinAmount(totalQty, coinPrice)
+	if usdValue, ok := getCoinUsdValue(wallet, data.Coin); ok && totalQty > 0 {
+		data.WalletPct = totalQty / usdValue * 100
+	}
+	return data
+}
+
+// sideIcon retorna o ícone do lado da ordem (🟢 compra, 🔴 venda).
+func sideIcon(side string) string {
+	if side == "Buy" {
+		return "🟢"
+	}
+	return "🔴"
+}
+
+// templatePrice formata um número (ou texto numérico) como os preços e quantidades das mensagens padrão.
+func templatePrice(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return formatPriceCoin(v)
+	case int:
+		return formatPriceCoin(float64(v))
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return formatPriceCoin(f)
+		}
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// templateNumber converte um campo cru da Bybit (texto) em número, para comparações e printf.
+func templateNumber(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// readSecretLine lê uma linha sem ecoar no terminal (API Secret, passphrase, senhas),
+// para que o valor não fique visível no histórico do terminal ou em compartilhamentos de tela.
+// Quando a entrada não é um terminal (pipe, redirecionamento), lê normalmente pelo scanner.
+func readSecretLine(scanner *bufio.Scanner) string {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		scanner.Scan()
+		return scanner.Text()
+	}
+	value, err := term.ReadPassword(fd)
+	// ReadPassword não imprime a quebra de linha digitada pelo usuário
+	fmt.Println()
+	if err != nil {
+		scanner.Scan()
+		return scanner.Text()
+	}
+	return string(value)
+}
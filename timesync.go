@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sincronização com o horário da Bybit: a assinatura da autenticação (expires) e das chamadas REST usa o relógio
+// da Bybit (GET /v5/market/time) em vez do relógio local, que em VPS costuma se desviar e faz a corretora recusar a
+// autenticação. O desvio é medido antes de autenticar, no máximo a cada bybitTimeSyncInterval (ou de novo depois de
+// uma recusa por expires vencido); acima de bybitClockSkewWarn o operador é avisado uma vez, até o relógio voltar.
+const (
+	bybitTimeSyncInterval = 15 * time.Minute
+	bybitTimeSyncTimeout  = 5 * time.Second
+	bybitClockSkewWarn    = 5 * time.Second
+)
+
+// bybitClock guarda a diferença medida entre o relógio da Bybit e o local (servidor - local).
+var bybitClock = struct {
+	syncMu   sync.Mutex // serializa as medições
+	mu       sync.Mutex
+	offset   time.Duration
+	syncedAt time.Time
+	warned   bool // operador já avisado do desvio atual
+}{}
+
+// bybitNow retorna o horário atual no relógio da Bybit (o local, se a sincronização nunca funcionou).
+func bybitNow() time.Time {
+	bybitClock.mu.Lock()
+	defer bybitClock.mu.Unlock()
+	return time.Now().Add(bybitClock.offset)
+}
+
+// bybitClockOffset retorna o desvio medido (zero se nunca sincronizou).
+func bybitClockOffset() time.Duration {
+	bybitClock.mu.Lock()
+	defer bybitClock.mu.Unlock()
+	return bybitClock.offset
+}
+
+// invalidateBybitClock força uma nova medição na próxima autenticação.
+func invalidateBybitClock() {
+	bybitClock.mu.Lock()
+	bybitClock.syncedAt = time.Time{}
+	bybitClock.mu.Unlock()
+}
+
+// syncBybitClock mede o desvio se a última medição for mais antiga que bybitTimeSyncInterval. As conexões que
+// autenticam ao mesmo tempo esperam a mesma medição. Em caso de falha, mantém o desvio anterior e tenta de novo
+// na próxima autenticação.
+func (wsm *WebSocketManager) syncBybitClock(wsConn *WebSocketConnection) {
+	if mockStreamURL != "" {
+		// Servidor simulado (mockbybit.go): sem API REST
+		return
+	}
+	bybitClock.syncMu.Lock()
+	defer bybitClock.syncMu.Unlock()
+	bybitClock.mu.Lock()
+	fresh := time.Since(bybitClock.syncedAt) < bybitTimeSyncInterval
+	previous := bybitClock.offset
+	bybitClock.mu.Unlock()
+	if fresh {
+		return
+	}
+
+	offset, err := measureBybitClockOffset(wsConn.ctx)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	if err != nil {
+		if logger != nil {
+			logger.Log("⚠️ Não foi possível consultar o horário da Bybit (usando o desvio anterior, %s): %v", formatClockOffset(previous), err)
+		}
+		return
+	}
+	bybitClock.mu.Lock()
+	bybitClock.offset, bybitClock.syncedAt = offset, time.Now()
+	bybitClock.mu.Unlock()
+	if logger != nil {
+		logger.Log("[DEBUG] Horário da Bybit sincronizado: desvio do relógio local %s", formatClockOffset(offset))
+	}
+
+	skewed := offset > bybitClockSkewWarn || offset < -bybitClockSkewWarn
+	switch {
+	case skewed && !bybitClock.warned:
+		bybitClock.warned = true
+		text := fmt.Sprintf("🕒 O relógio desta máquina está %s em relação ao da Bybit. A autenticação usa o horário da Bybit, mas confira a sincronização do relógio (NTP) da máquina: horários de logs e notificações também dependem dele.", formatClockOffset(offset))
+		fmt.Fprintf(os.Stderr, "[AVISO] Relógio local com desvio de %s em relação à Bybit; verifique o NTP da máquina\n", formatClockOffset(offset))
+		wsm.sendOperatorAlert(wsConn, eventAlert, text)
+	case !skewed && bybitClock.warned:
+		bybitClock.warned = false
+		fmt.Fprintf(os.Stderr, "Relógio local voltou a ficar sincronizado com a Bybit (desvio %s)\n", formatClockOffset(offset))
+	}
+}
+
+// measureBybitClockOffset consulta o horário da Bybit e calcula o desvio pelo meio do tempo de ida e volta.
+func measureBybitClockOffset(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, bybitTimeSyncTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bybitRESTURL+"/v5/market/time", nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	received := time.Now()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	var body struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			TimeNano string `json:"timeNano"`
+		} `json:"result"`
+		Time int64 `json:"time"` // ms
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("resposta inválida: %w", err)
+	}
+	if body.RetCode != 0 {
+		return 0, fmt.Errorf("retCode %d: %s", body.RetCode, body.RetMsg)
+	}
+	server := time.UnixMilli(body.Time)
+	if nanos, err := strconv.ParseInt(body.Result.TimeNano, 10, 64); err == nil && nanos > 0 {
+		server = time.Unix(0, nanos)
+	} else if body.Time == 0 {
+		return 0, fmt.Errorf("resposta sem horário")
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	return server.Sub(local), nil
+}
+
+// formatClockOffset descreve o desvio (ex.: "12.3s adiantado", "800ms atrasado").
+func formatClockOffset(offset time.Duration) string {
+	// Relógio local atrás do servidor = desvio positivo
+	switch {
+	case offset > 0:
+		return offset.Round(time.Millisecond).String() + " atrasado"
+	case offset < 0:
+		return (-offset).Round(time.Millisecond).String() + " adiantado"
+	}
+	return "0s"
+}
+
+// isExpiredAuthMessage reconhece a recusa da autenticação por expires vencido (relógio local atrasado ou adiantado).
+func isExpiredAuthMessage(msg string) bool {
+	return strings.Contains(strings.ToLower(msg), "expire")
+}
+
+// Horário da OKX: o login assina o timestamp com o relógio local, corrigido pelo desvio medido em relação à OKX
+// (GET /api/v5/public/time). A medição só acontece depois de uma recusa por timestamp (60004/60006): a próxima
+// tentativa de login já sai com o horário da OKX.
+var okxClock = struct {
+	mu     sync.Mutex
+	offset time.Duration
+	stale  bool // recusa por timestamp desde a última medição
+}{}
+
+// okxTimestampCodes são os códigos do login recusado por timestamp inválido ou vencido (relógio desviado).
+var okxTimestampCodes = map[string]bool{"60004": true, "60006": true}
+
+// okxNow retorna o horário atual no relógio da OKX (o local, se nunca foi medido).
+func okxNow() time.Time {
+	okxClock.mu.Lock()
+	defer okxClock.mu.Unlock()
+	return time.Now().Add(okxClock.offset)
+}
+
+// invalidateOKXClock pede uma nova medição antes do próximo login.
+func invalidateOKXClock() {
+	okxClock.mu.Lock()
+	okxClock.stale = true
+	okxClock.mu.Unlock()
+}
+
+// syncOKXClock mede o desvio do relógio da OKX se o último login foi recusado por timestamp. Em caso de falha,
+// mantém o desvio anterior e tenta de novo no próximo login.
+func syncOKXClock(wsConn *WebSocketConnection) {
+	okxClock.mu.Lock()
+	stale := okxClock.stale
+	okxClock.mu.Unlock()
+	if !stale {
+		return
+	}
+	offset, err := measureOKXClockOffset(wsConn.ctx)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	if err != nil {
+		if logger != nil {
+			logger.Log("⚠️ Não foi possível consultar o horário da OKX: %v", err)
+		}
+		return
+	}
+	okxClock.mu.Lock()
+	okxClock.offset, okxClock.stale = offset, false
+	okxClock.mu.Unlock()
+	if logger != nil {
+		logger.Log("Horário da OKX sincronizado: desvio do relógio local %s", formatClockOffset(offset))
+	}
+}
+
+// measureOKXClockOffset consulta o horário da OKX e calcula o desvio pelo meio do tempo de ida e volta.
+func measureOKXClockOffset(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, bybitTimeSyncTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, okxRESTURL+"/api/v5/public/time", nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	received := time.Now()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	var body struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Ts string `json:"ts"` // ms
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("resposta inválida: %w", err)
+	}
+	if body.Code != "0" {
+		return 0, fmt.Errorf("código %s: %s", body.Code, body.Msg)
+	}
+	if len(body.Data) == 0 {
+		return 0, fmt.Errorf("resposta sem horário")
+	}
+	ms, err := strconv.ParseInt(body.Data[0].Ts, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, fmt.Errorf("resposta sem horário")
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	return time.UnixMilli(ms).Sub(local), nil
+}
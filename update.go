@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Repositório de onde o self-update baixa as releases
+const releaseRepo = "garumam/bybit-inverse-notification"
+
+// releaseChecksumsAsset é o arquivo da release com o SHA-256 de cada binário (formato do sha256sum).
+const releaseChecksumsAsset = "checksums.txt"
+
+const updateHTTPTimeout = 5 * time.Minute
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// releaseAssetName retorna o nome do binário publicado para a plataforma atual (mesmos nomes do build.sh/build.ps1).
+func releaseAssetName() (string, error) {
+	switch {
+	case runtime.GOOS == "linux" && runtime.GOARCH == "amd64":
+		return "bybit-notifier-linux", nil
+	case runtime.GOOS == "windows" && runtime.GOARCH == "amd64":
+		return "bybit-notifier-windows.exe", nil
+	}
+	return "", fmt.Errorf("não há binário publicado para %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+func runSelfUpdateCommand(db *Database, args []string) error {
+	checkOnly := false
+	force := false
+	for _, arg := range args {
+		switch arg {
+		case "--check":
+			checkOnly = true
+		case "--force":
+			force = true
+		default:
+			return fmt.Errorf("opção desconhecida: %s (use --check ou --force)", arg)
+		}
+	}
+
+	client := &http.Client{Timeout: updateHTTPTimeout}
+	release, err := fetchLatestRelease(client)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Versão atual: %s\n", projectVersion)
+	fmt.Printf("Última versão: %s\n", release.TagName)
+	if release.TagName == projectVersion && !force {
+		fmt.Println(colorGreen("Já está na versão mais recente."))
+		return nil
+	}
+	if checkOnly {
+		fmt.Printf("Atualização disponível. Use '%s self-update' para instalar.\n", programName())
+		return nil
+	}
+
+	assetName, err := releaseAssetName()
+	if err != nil {
+		return err
+	}
+	binaryURL := findReleaseAsset(release, assetName)
+	if binaryURL == "" {
+		return fmt.Errorf("a release %s não tem o arquivo %s", release.TagName, assetName)
+	}
+	checksumsURL := findReleaseAsset(release, releaseChecksumsAsset)
+	if checksumsURL == "" {
+		return fmt.Errorf("a release %s não tem %s; atualização cancelada por não ser possível verificar o download", release.TagName, releaseChecksumsAsset)
+	}
+
+	expectedSum, err := fetchExpectedChecksum(client, checksumsURL, assetName)
+	if err != nil {
+		return err
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("não foi possível localizar o executável atual: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+
+	// Baixar no mesmo diretório do executável para que a troca seja um rename (atômico no Linux)
+	fmt.Printf("Baixando %s...\n", assetName)
+	tmpPath, err := downloadToTemp(client, binaryURL, filepath.Dir(exePath), expectedSum)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Checksum SHA-256 verificado.")
+
+	if err := replaceExecutable(exePath, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	fmt.Println(colorGreen(fmt.Sprintf("Atualizado para %s!", release.TagName)))
+	fmt.Println("Reinicie o aplicativo (ou o serviço) para usar a nova versão.")
+	return nil
+}
+
+func fetchLatestRelease(client *http.Client) (*githubRelease, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", releaseRepo), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.New("nenhuma release publicada")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("erro ao consultar releases: status code: %d", resp.StatusCode)
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("resposta inválida do GitHub: %w", err)
+	}
+	return &release, nil
+}
+
+func findReleaseAsset(release *githubRelease, name string) string {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadURL
+		}
+	}
+	return ""
+}
+
+// fetchExpectedChecksum lê o checksums.txt da release e retorna o SHA-256 esperado para o arquivo.
+func fetchExpectedChecksum(client *http.Client, checksumsURL, assetName string) (string, error) {
+	resp, err := client.Get(checksumsURL)
+	if err != nil {
+		return "", fmt.Errorf("erro ao baixar %s: %w", releaseChecksumsAsset, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("erro ao baixar %s: status code: %d", releaseChecksumsAsset, resp.StatusCode)
+	}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, 1<<20))
+	for scanner.Scan() {
+		// Formato do sha256sum: "<hash>  <arquivo>" (o arquivo pode vir com * no modo binário)
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s não contém o checksum de %s", releaseChecksumsAsset, assetName)
+}
+
+// downloadToTemp baixa o binário para um arquivo temporário em dir e confere o SHA-256.
+func downloadToTemp(client *http.Client, url, dir, expectedSum string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("erro ao baixar binário: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("erro ao baixar binário: status code: %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".bybit-notifier-update-*")
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar arquivo temporário (sem permissão de escrita em %s?): %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+
+	hash := sha256.New()
+	_, copyErr := io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	closeErr := tmp.Close()
+	if copyErr != nil || closeErr != nil {
+		os.Remove(tmpPath)
+		if copyErr != nil {
+			return "", fmt.Errorf("erro ao baixar binário: %w", copyErr)
+		}
+		return "", closeErr
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != expectedSum {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("checksum não confere (esperado %s, obtido %s); arquivo descartado", expectedSum, sum)
+	}
+
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// replaceExecutable troca o executável atual pelo novo.
+// No Windows o executável em uso não pode ser sobrescrito, mas pode ser renomeado; a cópia antiga fica como .old.
+func replaceExecutable(exePath, newPath string) error {
+	if runtime.GOOS == "windows" {
+		oldPath := exePath + ".old"
+		os.Remove(oldPath)
+		if err := os.Rename(exePath, oldPath); err != nil {
+			return fmt.Errorf("erro ao renomear executável atual: %w", err)
+		}
+		if err := os.Rename(newPath, exePath); err != nil {
+			// Tentar restaurar o executável original
+			os.Rename(oldPath, exePath)
+			return fmt.Errorf("erro ao instalar novo executável: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(newPath, exePath); err != nil {
+		return fmt.Errorf("erro ao instalar novo executável: %w", err)
+	}
+	return nil
+}
@@ -2,16 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -30,54 +33,197 @@ type delayNotificationItem struct {
 }
 
 type WebSocketManager struct {
-	accountManager               *AccountManager
-	db                           *Database
-	connections      map[int64]*WebSocketConnection
-	mu               sync.RWMutex
+	ctx                       context.Context // contexto raiz (main); cancelado ao encerrar o aplicativo
+	accountManager            Repository
+	db                        *Database
+	connections               map[int64]*WebSocketConnection
+	mu                        sync.RWMutex
 	walletNotificationBuffers map[int64]*WalletNotification
-	delayBuffers     map[int64]*DelayNotificationBuffer
-	bufferMu                     sync.RWMutex
+	delayBuffers              map[int64]*DelayNotificationBuffer
+	bufferMu                  sync.RWMutex
+	stopping                  map[int64]*WebSocketConnection // conexões paradas com buffers sendo descarregados (flush.go; protegido por mu)
+	public                    *PublicStreamManager           // streams públicos compartilhados entre as contas (publicstream.go)
+	funding                   *fundingTracker                // tickers dos símbolos com posição aberta, para o funding (funding.go)
 }
 
 // DelayNotificationBuffer acumula ordens, stops e execuções quando notification_delay_seconds > 0.
 type DelayNotificationBuffer struct {
-	orders      map[string][]OrderData // orderId -> versões ordenadas por updatedTime
-	stops       map[string][]OrderData // orderId -> versões ordenadas por updatedTime
-	executions  []ExecutionData
-	timer       *time.Timer
-	accountID   int64
-	delaySec    int
-	mu          sync.Mutex
+	orders     map[string][]OrderData // orderId -> versões ordenadas por updatedTime
+	stops      map[string][]OrderData // orderId -> versões ordenadas por updatedTime
+	executions []ExecutionData
+	timer      *connTimer
+	accountID  int64
+	delaySec   int
+	mu         sync.Mutex
 }
 
 type WalletNotification struct {
-	discordTimer *time.Timer // 15 min: notificação Discord (wallet)
-	sheetsTimer  *time.Timer // 2 min: notificação Google Sheets
+	discordTimer *connTimer // 15 min: notificação Discord (wallet)
+	sheetsTimer  *connTimer // 2 min: notificação Google Sheets
 	mu           sync.Mutex
 	accountID    int64
 }
 
 type WebSocketConnection struct {
-	AccountID  int64
-	Account    *BybitAccount
-	Conn       *websocket.Conn
-	StopChan   chan struct{}
-	Running    bool
-	mu         sync.Mutex
+	AccountID int64
+	account   atomic.Pointer[BybitAccount] // cadastro da conta; trocado por ReloadAccount com a conexão rodando
+	Conn      *websocket.Conn
+	mu        sync.Mutex
+
+	// Cancelado ao parar o monitoramento da conta ou encerrar o aplicativo; encerra a conexão e todas as
+	// goroutines dela (leitura, ping, inscrições, OKX business, recuperação de lacunas)
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Saúde da conexão (protegidos por mu)
+	Connected      bool
+	ConnectedAt    time.Time
+	LastMessageAt  time.Time // último frame recebido (mensagem ou pong)
+	ReconnectCount int
+	connectedOnce  bool
+
+	// Queda em andamento, para os alertas de conexão (connalerts.go; protegidos por mu)
+	downSince     time.Time
+	downAlertSent bool
+	downTimer     *connTimer
+	lastError     string
+	// Inscrições da conexão atual, por conexão (rawStream*; subscriptions.go)
+	subscriptions map[string]*subscriptionTracker
+	// Restaurada ao abrir o aplicativo: recupera as execuções do período fechado (backfill.go)
+	restored bool
+	// Cadastro ou configurações recarregados (ReloadAccount): a política de reconexão é relida na próxima tentativa
+	reloadPolicy atomic.Bool
+	// Parada pedida pela própria conexão (requestStop): a parada normal roda quando runConnection retorna
+	stopRequested atomic.Bool
+	// Endpoint do stream privado em uso e falhas seguidas de conexão (failover.go)
+	failover endpointFailover
+	// Goroutines e timers vivos da conexão, por tipo (resources.go)
+	resources connResources
+	// Conexão fechada por reconexão programada e última verificação dos horários (scheduledreconnect.go;
+	// scheduleCheckedAt protegido por mu)
+	scheduledReconnect atomic.Bool
+	scheduleCheckedAt  time.Time
+	// Pausa do monitoramento (pause.go; protegidos por mu): resume é fechado ao retomar
+	paused      bool
+	resume      chan struct{}
+	pausedAt    time.Time
+	pausedUntil time.Time // zero = sem prazo
+	// Frames, bytes e mensagens por tópico recebidos e último erro, desde o início do monitoramento (connstats.go)
+	stats connStats
+	// Filas de processamento das mensagens e de envio das notificações da conta (messagequeue.go)
+	queue            chan func()
+	queueCounters    queueCounters
+	queueDrained     chan struct{} // fechado quando o worker de processamento termina, com a fila esvaziada
+	deliveries       chan deliveryJob
+	deliveryCounters queueCounters
+	deliveryPending  atomic.Int64   // envios enfileirados ou em andamento
+	outbox           deliveryOutbox // notificações enfileiradas ou em andamento, para o histórico (outbox.go)
+	// Notificações por minuto de cada canal e as acumuladas acima do limite (channelratelimit.go)
+	rateLimiter channelRateLimiter
+	// Mensagens das ordens abertas, editadas a cada execução ou cancelamento (ordermessages.go)
+	orderMessages      orderMessageTracker
+	deliveryCtx        context.Context
+	stopDeliveryWorker context.CancelFunc
+}
+
+// connectionStaleAfter é o tempo sem receber nenhum frame após o qual a conexão é considerada travada.
+// O ping é enviado a cada 20s, então uma conexão viva recebe pelo menos um pong nesse intervalo.
+const connectionStaleAfter = 90 * time.Second
+
+// connectionHeartbeatInterval é o intervalo de gravação do heartbeat em active_connections.
+const connectionHeartbeatInterval = time.Minute
+
+// connectionHeartbeatStaleAfter é a idade a partir da qual o heartbeat indica um processo que parou sem limpar a linha.
+const connectionHeartbeatStaleAfter = 3 * connectionHeartbeatInterval
+
+// ConnectionHealth é um retrato do estado de uma conexão para exibição.
+type ConnectionHealth struct {
+	Connected      bool
+	ConnectedAt    time.Time
+	LastMessageAt  time.Time
+	ReconnectCount int
+	Stale          bool
+	UnackedTopics  []string // tópicos cuja inscrição ainda não foi confirmada
+	Endpoint       string   // endpoint alternativo em uso (vazio = principal; failover.go)
+	Paused         bool     // monitoramento pausado (pause.go)
+	PausedAt       time.Time
+	PausedUntil    *time.Time // retomada automática, se houver
+}
+
+// errIPNotAllowed indica que a corretora recusou a autenticação porque o IP desta máquina não está liberado
+// na API key. Diferente de credenciais erradas ou falhas de rede, reconectar não resolve.
+var errIPNotAllowed = errors.New("IP desta máquina não está liberado na API key")
+
+// ipRestrictionPattern reconhece as mensagens de IP não liberado (Bybit: "Unmatched IP" / 10010; OKX: 50110).
+var ipRestrictionPattern = regexp.MustCompile(`(?i)unmatched ip|ip .*not .*(allow|whitelist|bound|trusted|link)|invalid ip|\b10010\b|\b50110\b`)
+
+func isIPRestrictionMessage(msg string) bool {
+	return ipRestrictionPattern.MatchString(msg)
+}
+
+// Account retorna o cadastro atual da conta; ReloadAccount pode trocá-lo com as goroutines da conexão rodando.
+func (c *WebSocketConnection) Account() *BybitAccount {
+	return c.account.Load()
+}
+
+// touch registra que um frame foi recebido na conexão.
+func (c *WebSocketConnection) touch() {
+	c.mu.Lock()
+	c.LastMessageAt = time.Now()
+	c.mu.Unlock()
+}
+
+// markConnected registra uma conexão estabelecida; a partir da segunda conta como reconexão.
+func (c *WebSocketConnection) markConnected() {
+	c.mu.Lock()
+	now := time.Now()
+	if c.connectedOnce {
+		c.ReconnectCount++
+		recordStat(c.AccountID, statReconnects, 1)
+	}
+	c.connectedOnce = true
+	c.Connected = true
+	c.ConnectedAt = now
+	c.LastMessageAt = now
+	c.noteUp()
+	c.mu.Unlock()
+}
+
+// markDisconnected registra que a conexão caiu (aguardando reconexão); err é o motivo, se houver.
+func (c *WebSocketConnection) markDisconnected(err error) {
+	c.mu.Lock()
+	c.Connected = false
+	c.noteDown(err)
+	c.mu.Unlock()
+}
+
+// bybitEnvelope é a parte comum das mensagens da Bybit: resposta de controle (op) ou dados de um tópico,
+// com o data ainda cru para ser decodificado no tipo do tópico.
+type bybitEnvelope struct {
+	Op           string          `json:"op"`
+	Success      bool            `json:"success"`
+	ReqID        string          `json:"req_id"`
+	RetMsg       string          `json:"ret_msg"`
+	ID           string          `json:"id"`
+	Topic        string          `json:"topic"`
+	CreationTime int64           `json:"creationTime"`
+	Data         json.RawMessage `json:"data"`
 }
 
 type BybitOrderMessage struct {
-	ID           string      `json:"id"`
-	Topic        string      `json:"topic"`
-	CreationTime int64       `json:"creationTime"`
-	Data         []OrderData `json:"data"`
+	ID            string      `json:"id"`
+	Topic         string      `json:"topic"`
+	CreationTime  int64       `json:"creationTime"`
+	Data          []OrderData `json:"data"`
+	CorrelationID string      `json:"-"` // ID de correlação da mensagem recebida (correlation.go)
 }
 
 type BybitExecutionMessage struct {
-	ID           string          `json:"id"`
-	Topic        string          `json:"topic"`
-	CreationTime int64           `json:"creationTime"`
-	Data         []ExecutionData `json:"data"`
+	ID            string          `json:"id"`
+	Topic         string          `json:"topic"`
+	CreationTime  int64           `json:"creationTime"`
+	Data          []ExecutionData `json:"data"`
+	CorrelationID string          `json:"-"` // ID de correlação da mensagem recebida (correlation.go)
 }
 
 type BybitPositionMessage struct {
@@ -121,21 +267,31 @@ type ExecutionData struct {
 	CreateType    string `json:"createType"`
 	MarkPrice     string `json:"markPrice"`
 	ExecTime      string `json:"execTime"` // timestamp da execução em ms (API Bybit)
+	ExecID        string `json:"execId"`
+	ExecFee       string `json:"execFee"` // positivo = taxa paga, negativo = rebate
+	FeeRate       string `json:"feeRate"`
+	IsMaker       bool   `json:"isMaker"`
+	CorrelationID string `json:"-"` // mensagem de origem; não vai para o banco
 }
 
 type PositionData struct {
-	Symbol          string `json:"symbol"`
-	Side            string `json:"side"`
-	Size            string `json:"size"`
-	EntryPrice      string `json:"entryPrice"`
-	MarkPrice       string `json:"markPrice"`
-	PositionValue   string `json:"positionValue"`
-	PositionIM      string `json:"positionIM"`
-	PositionMM      string `json:"positionMM"`
-	StopLoss        string `json:"stopLoss"`
-	TakeProfit      string `json:"takeProfit"`
-	Category        string `json:"category"`
-	PositionStatus  string `json:"positionStatus"`
+	Symbol         string `json:"symbol"`
+	Side           string `json:"side"`
+	Size           string `json:"size"`
+	EntryPrice     string `json:"entryPrice"`
+	MarkPrice      string `json:"markPrice"`
+	LiqPrice       string `json:"liqPrice"` // vazio ou 0 = sem preço de liquidação (ex.: sem posição)
+	PositionValue  string `json:"positionValue"`
+	PositionIM     string `json:"positionIM"`
+	PositionMM     string `json:"positionMM"`
+	StopLoss       string `json:"stopLoss"`
+	TakeProfit     string `json:"takeProfit"`
+	Category       string `json:"category"`
+	PositionStatus string `json:"positionStatus"`
+	PositionIdx    int    `json:"positionIdx"` // 0 = one-way, 1 = hedge Buy, 2 = hedge Sell
+	CurRealisedPnl string `json:"curRealisedPnl"`
+	CumRealisedPnl string `json:"cumRealisedPnl"`
+	UpdatedTime    string `json:"updatedTime"`
 }
 
 type CoinBalance struct {
@@ -157,21 +313,28 @@ type OrderData struct {
 	Price         string `json:"price"`
 	AvgPrice      string `json:"avgPrice"`
 	Qty           string `json:"qty"`
+	CumExecQty    string `json:"cumExecQty"`
 	CreatedTime   string `json:"createdTime"`
 	UpdatedTime   string `json:"updatedTime"`
 	ReduceOnly    bool   `json:"reduceOnly"`
 	StopOrderType string `json:"stopOrderType"`
 	TriggerPrice  string `json:"triggerPrice"`
 	CreateType    string `json:"createType"`
+	CorrelationID string `json:"-"` // mensagem de origem; não vai para o banco
 }
 
-func NewWebSocketManager(db *Database, accountManager *AccountManager) *WebSocketManager {
+func NewWebSocketManager(ctx context.Context, db *Database, accountManager Repository) *WebSocketManager {
+	public := NewPublicStreamManager(ctx, bybitPublicWSURL)
 	return &WebSocketManager{
-		accountManager:   accountManager,
-		db:               db,
-		connections:      make(map[int64]*WebSocketConnection),
+		ctx:                       ctx,
+		accountManager:            accountManager,
+		db:                        db,
+		connections:               make(map[int64]*WebSocketConnection),
 		walletNotificationBuffers: make(map[int64]*WalletNotification),
-		delayBuffers:     make(map[int64]*DelayNotificationBuffer),
+		delayBuffers:              make(map[int64]*DelayNotificationBuffer),
+		stopping:                  make(map[int64]*WebSocketConnection),
+		public:                    public,
+		funding:                   newFundingTracker(public),
 	}
 }
 
@@ -229,6 +392,12 @@ func orderPctOfWallet(wallet *WalletData, symbol string, orderQtyUSD float64) st
 }
 
 func (wsm *WebSocketManager) StartConnection(accountID int64) error {
+	return wsm.startConnection(accountID, false)
+}
+
+// startConnection inicia o monitoramento da conta; restored = conexão restaurada ao abrir o aplicativo, quando
+// as execuções ocorridas com o aplicativo fechado são recuperadas pela API REST (backfill.go).
+func (wsm *WebSocketManager) startConnection(accountID int64, restored bool) error {
 	wsm.mu.Lock()
 	defer wsm.mu.Unlock()
 
@@ -240,29 +409,43 @@ func (wsm *WebSocketManager) StartConnection(accountID int64) error {
 	if err != nil {
 		return err
 	}
+	if err := account.SecretError(); err != nil {
+		return fmt.Errorf("não foi possível carregar os segredos da conta: %w", err)
+	}
+	// Iniciar de novo indica que a conta foi corrigida: sai do circuit breaker de autenticação
+	wsm.accountManager.ClearAuthFailure(accountID)
 
+	ctx, cancel := context.WithCancel(wsm.ctx)
 	wsConn := &WebSocketConnection{
 		AccountID: accountID,
-		Account:   account,
-		StopChan:  make(chan struct{}),
-		Running:   true,
+		ctx:       ctx,
+		cancel:    cancel,
+		restored:  restored,
 	}
+	wsConn.account.Store(account)
+	wsConn.stats.startedAt = time.Now()
 
 	wsm.connections[accountID] = wsConn
 
+	configureAccountLogger(account)
+
 	// Marcar como ativa no banco
 	if err := wsm.accountManager.SetConnectionActive(accountID, true); err != nil {
 		// Erro silencioso - tentar novamente na próxima vez
 	}
+	wsm.db.SetAccountStat(accountID, statMonitoringStartedAt, time.Now().Unix())
+
+	wsm.startMessageWorker(wsConn)
 
 	// Iniciar conexão em goroutine com tratamento de panic
-	go func() {
+	wsConn.spawn(goroutineConnection, func() {
 		defer func() {
 			if r := recover(); r != nil {
 				// Imprimir no stderr PRIMEIRO (antes de tentar qualquer coisa)
 				fmt.Fprintf(os.Stderr, "\n=== ERRO FATAL ===\n")
 				fmt.Fprintf(os.Stderr, "A aplicação encontrou um erro fatal ao iniciar o monitoramento da conta '%s' (ID: %d)\n", account.Name, accountID)
-				fmt.Fprintf(os.Stderr, "Erro: %v\n", r)
+				fmt.Fprintf(os.Stderr, "Erro: %v\n", redactSecrets(fmt.Sprint(r)))
+				reportPanic(accountID, account.Name, "runConnection (goroutine)", r)
 				
 				// Tentar logar o panic (mas não bloquear se falhar)
 				func() {
@@ -277,94 +460,184 @@ func (wsm *WebSocketManager) StartConnection(accountID int64) error {
 					}
 				}()
 				
-				// Tentar obter caminho do log (usar padrão comum)
-				var logPath string
-				if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
-					logPath = filepath.Join(dataDir, "logs", fmt.Sprintf("account_%d.log", accountID))
-				} else {
-					logPath = filepath.Join("data", "logs", fmt.Sprintf("account_%d.log", accountID))
-				}
-				fmt.Fprintf(os.Stderr, "Verifique os logs em: %s\n", logPath)
+				fmt.Fprintf(os.Stderr, "Verifique os logs em: %s\n", getLogFilePath(accountID))
 				fmt.Fprintf(os.Stderr, "==================\n\n")
 			}
 		}()
 		
+		wsm.trackFundingSnapshots(wsConn)
 		wsm.runConnection(wsConn)
-	}()
+		if wsConn.stopRequested.Load() {
+			// Fora desta goroutine: finishStop espera as goroutines da conexão e verifica vazamentos
+			go wsm.stopRequestedConnection(wsConn)
+		}
+	})
 
 	return nil
 }
 
-func (wsm *WebSocketManager) StopConnection(accountID int64) {
-	wsm.mu.Lock()
-	defer wsm.mu.Unlock()
+// PublicStreams retorna o manager dos streams públicos, compartilhado entre as contas.
+func (wsm *WebSocketManager) PublicStreams() *PublicStreamManager {
+	return wsm.public
+}
 
+// configureAccountLogger aplica ao log da conta o fuso e o nível (preferência log_level) do cadastro.
+func configureAccountLogger(account *BybitAccount) {
+	logger, err := getLogger(account.ID, account.Name)
+	if err != nil {
+		return
+	}
+	logger.SetTimezone(account.Timezone)
+	if err := logger.SetLevel(account.Settings.GetString(logLevelSettingKey, "")); err != nil {
+		logger.Log("⚠️ %v; usando o nível padrão", err)
+	}
+}
+
+// ReloadAccount relê a conta do banco e aplica as alterações ao monitoramento em andamento sem derrubar a
+// conexão: webhooks, preferências, fuso, nome e atraso de agrupamento valem a partir das próximas notificações.
+// Só a troca de credenciais (API key, secret ou passphrase) reconecta; reconnected indica esse caso.
+// Conta que não está sendo monitorada: nada a aplicar.
+func (wsm *WebSocketManager) ReloadAccount(accountID int64) (reconnected bool, err error) {
+	wsm.mu.RLock()
 	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
 	if !exists {
-		return
+		return false, nil
 	}
 
-	conn.mu.Lock()
-	if conn.Running {
-		close(conn.StopChan)
-		conn.Running = false
-		if conn.Conn != nil {
-			conn.Conn.Close()
-		}
+	account, err := wsm.accountManager.GetAccount(accountID)
+	if err != nil {
+		return false, err
+	}
+	if err := account.SecretError(); err != nil {
+		return false, fmt.Errorf("não foi possível carregar os segredos da conta: %w", err)
 	}
-	conn.mu.Unlock()
 
-	delete(wsm.connections, accountID)
+	previous := conn.Account()
+	if accountCredentialsChanged(previous, account) {
+		wsm.StopConnection(accountID)
+		return true, wsm.StartConnection(accountID)
+	}
 
-	// Limpar buffers
-	wsm.bufferMu.Lock()
-	if walletNotificationBuffer, exists := wsm.walletNotificationBuffers[accountID]; exists {
-		walletNotificationBuffer.mu.Lock()
-		if walletNotificationBuffer.discordTimer != nil {
-			walletNotificationBuffer.discordTimer.Stop()
-		}
-		if walletNotificationBuffer.sheetsTimer != nil {
-			walletNotificationBuffer.sheetsTimer.Stop()
+	// As goroutines da conexão leem conn.Account() a cada notificação; a troca do ponteiro vale a partir da próxima
+	conn.account.Store(account)
+	conn.reloadPolicy.Store(true)
+	if account.Name != previous.Name {
+		// O logger continua o mesmo (as goroutines da conexão guardam o *Logger): só os arquivos passam ao nome novo
+		if logger, _ := getLogger(accountID, previous.Name); logger != nil {
+			logger.Rename(account.Name)
+		} else if err := renameAccountLogFiles(accountID, account.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "Aviso: não foi possível renomear os arquivos de log da conta '%s': %v\n", account.Name, err)
 		}
-		walletNotificationBuffer.mu.Unlock()
-		delete(wsm.walletNotificationBuffers, accountID)
 	}
-	if delayBuf, exists := wsm.delayBuffers[accountID]; exists {
-		delayBuf.mu.Lock()
-		if delayBuf.timer != nil {
-			delayBuf.timer.Stop()
-		}
-		delayBuf.mu.Unlock()
-		delete(wsm.delayBuffers, accountID)
+	configureAccountLogger(account)
+	if logger, _ := getLogger(accountID, account.Name); logger != nil {
+		logger.Log("Cadastro da conta recarregado; alterações aplicadas sem reconectar")
 	}
-	wsm.bufferMu.Unlock()
+	return false, nil
+}
 
-	// Fechar logger
-	closeLogger(accountID)
+// accountCredentialsChanged indica se a troca de cadastro exige uma nova conexão (credenciais ou plataforma).
+func accountCredentialsChanged(previous, current *BybitAccount) bool {
+	return previous.Platform != current.Platform ||
+		previous.APIKey != current.APIKey ||
+		previous.APISecret != current.APISecret ||
+		bybitAPIKeyType(previous) != bybitAPIKeyType(current) ||
+		metadataPassphrase(previous.Metadata) != metadataPassphrase(current.Metadata)
+}
 
-	// Remover do banco
-	wsm.accountManager.SetConnectionActive(accountID, false)
+// StopConnection para o monitoramento da conta. As notificações que ainda estavam nos buffers (atraso de
+// agrupamento, resumo da carteira, planilha) são enviadas antes de retornar, em vez de descartadas.
+func (wsm *WebSocketManager) StopConnection(accountID int64) {
+	wsm.mu.Lock()
+	conn, exists := wsm.connections[accountID]
+	if !exists {
+		wsm.mu.Unlock()
+		return
+	}
+	wsm.detachConnection(accountID, conn)
+	wsm.mu.Unlock()
+
+	wsm.finishStop(accountID, conn)
+}
+
+// requestStop pede a parada do monitoramento de dentro da goroutine da conexão (runConnection), que deve retornar
+// em seguida: o contexto é cancelado agora e a parada normal roda quando ela terminar (stopRequestedConnection).
+func (c *WebSocketConnection) requestStop() {
+	c.stopRequested.Store(true)
+	c.cancel()
+}
+
+// stopRequestedConnection para a conexão que pediu a parada (requestStop), se ela ainda é a conexão da conta.
+func (wsm *WebSocketManager) stopRequestedConnection(conn *WebSocketConnection) {
+	wsm.mu.Lock()
+	if wsm.connections[conn.AccountID] != conn {
+		wsm.mu.Unlock()
+		return
+	}
+	wsm.detachConnection(conn.AccountID, conn)
+	wsm.mu.Unlock()
+
+	wsm.finishStop(conn.AccountID, conn)
 }
 
+// StopAll para todas as contas, descarregando os buffers de cada uma em paralelo.
 func (wsm *WebSocketManager) StopAll() {
+	// Cópia das conexões antes de soltá-las: detachConnection apaga as entradas do próprio mapa
 	wsm.mu.Lock()
-	defer wsm.mu.Unlock()
+	conns := make([]*WebSocketConnection, 0, len(wsm.connections))
+	for _, conn := range wsm.connections {
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		wsm.detachConnection(conn.AccountID, conn)
+	}
+	wsm.mu.Unlock()
 
-	for accountID, conn := range wsm.connections {
-		conn.mu.Lock()
-		if conn.Running {
-			close(conn.StopChan)
-			conn.Running = false
-			if conn.Conn != nil {
-				conn.Conn.Close()
-			}
-		}
-		conn.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn *WebSocketConnection) {
+			defer wg.Done()
+			wsm.finishStop(conn.AccountID, conn)
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// detachConnection encerra a conexão e a move de connections para stopping. Chamado com wsm.mu travado.
+// O cancelamento fecha o WebSocket (dialStream) e encerra as goroutines da conta.
+func (wsm *WebSocketManager) detachConnection(accountID int64, conn *WebSocketConnection) {
+	conn.cancel()
+	conn.stopConnectionAlerts()
+
+	delete(wsm.connections, accountID)
+	wsm.stopping[accountID] = conn
+}
 
-		wsm.accountManager.SetConnectionActive(accountID, false)
+// finishStop descarrega os buffers da conta parada, fecha o logger e remove a marcação de conexão ativa.
+func (wsm *WebSocketManager) finishStop(accountID int64, conn *WebSocketConnection) {
+	// As mensagens que já estavam na fila de processamento entram nos buffers antes de descarregá-los
+	if conn.queueDrained != nil {
+		<-conn.queueDrained
 	}
+	wsm.flushBuffers(accountID, conn)
+	conn.stopDeliveries()
 
-	wsm.connections = make(map[int64]*WebSocketConnection)
+	wsm.mu.Lock()
+	if wsm.stopping[accountID] == conn {
+		delete(wsm.stopping, accountID)
+	}
+	wsm.mu.Unlock()
+
+	wsm.funding.releaseAccount(accountID)
+
+	// Fechar logger
+	closeLogger(accountID)
+	wsm.scheduleLeakCheck(accountID, conn)
+
+	// Remover do banco
+	wsm.accountManager.SetConnectionActive(accountID, false)
 }
 
 func (wsm *WebSocketManager) IsConnectionActive(accountID int64) bool {
@@ -372,16 +645,92 @@ func (wsm *WebSocketManager) IsConnectionActive(accountID int64) bool {
 	defer wsm.mu.RUnlock()
 
 	conn, exists := wsm.connections[accountID]
-	return exists && conn.Running
+	return exists && conn.running()
 }
 
+// running informa se o monitoramento da conta continua (não foi parado nem o aplicativo encerrado).
+func (c *WebSocketConnection) running() bool {
+	return c.ctx.Err() == nil
+}
+
+// GetConnectionHealth retorna o estado de saúde da conexão da conta (false se não estiver sendo monitorada).
+func (wsm *WebSocketManager) GetConnectionHealth(accountID int64) (ConnectionHealth, bool) {
+	wsm.mu.RLock()
+	conn, exists := wsm.connections[accountID]
+	wsm.mu.RUnlock()
+	if !exists {
+		return ConnectionHealth{}, false
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	health := ConnectionHealth{
+		Connected:      conn.Connected,
+		ConnectedAt:    conn.ConnectedAt,
+		LastMessageAt:  conn.LastMessageAt,
+		ReconnectCount: conn.ReconnectCount,
+	}
+	health.Stale = !conn.Connected || time.Since(conn.LastMessageAt) > connectionStaleAfter
+	health.Endpoint = conn.streamEndpoint()
+	if conn.Connected {
+		health.UnackedTopics = conn.unackedTopics()
+	}
+	if conn.paused {
+		health.Paused, health.PausedAt = true, conn.pausedAt
+		if !conn.pausedUntil.IsZero() {
+			pausedUntil := conn.pausedUntil
+			health.PausedUntil = &pausedUntil
+		}
+	}
+	return health, true
+}
+
+// startHeartbeat grava periodicamente o heartbeat das conexões deste processo, para que monitoramento
+// externo (e o menu) detecte linhas de active_connections deixadas por um processo que caiu, e chama as URLs de
+// heartbeat configuradas (heartbeat.go).
+func (wsm *WebSocketManager) startHeartbeat() {
+	go func() {
+		ticker := time.NewTicker(connectionHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-wsm.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			wsm.mu.RLock()
+			accountIDs := make([]int64, 0, len(wsm.connections))
+			for id, conn := range wsm.connections {
+				if conn.running() {
+					accountIDs = append(accountIDs, id)
+				}
+			}
+			wsm.mu.RUnlock()
+			if err := wsm.accountManager.TouchConnectionHeartbeat(accountIDs); err != nil {
+				fmt.Fprintf(os.Stderr, "Erro ao gravar heartbeat das conexões: %v\n", err)
+			}
+			wsm.pingHeartbeats()
+			wsm.runScheduledReconnects()
+		}
+	}()
+}
+
+// StartAllConnections inicia as contas ativas. Contas paradas pelo circuit breaker de autenticação ficam de fora:
+// só voltam quando iniciadas individualmente, depois de corrigidas.
 func (wsm *WebSocketManager) StartAllConnections() error {
 	accounts, err := wsm.accountManager.ListAccounts()
 	if err != nil {
 		return err
 	}
+	authFailures, err := wsm.accountManager.GetAuthFailures()
+	if err != nil {
+		return err
+	}
 
 	for _, account := range accounts {
+		if _, failed := authFailures[account.ID]; failed {
+			continue
+		}
 		if account.Active {
 			if err := wsm.StartConnection(account.ID); err != nil {
 				// Erro já será logado pelo logger na função StartConnection
@@ -399,7 +748,16 @@ func (wsm *WebSocketManager) RestoreConnections() error {
 	}
 
 	for _, accountID := range accountIDs {
-		if err := wsm.StartConnection(accountID); err != nil {
+		account, err := wsm.accountManager.GetAccount(accountID)
+		if err != nil {
+			continue
+		}
+		// Conta desativada: não restaurar e limpar a marcação de conexão ativa
+		if !account.Active {
+			wsm.accountManager.SetConnectionActive(accountID, false)
+			continue
+		}
+		if err := wsm.startConnection(accountID, true); err != nil {
 			// Erro já será logado pelo logger na função StartConnection
 		} else {
 			// Conexão restaurada - já será logado pelo logger
@@ -414,7 +772,8 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Imprimir no stderr PRIMEIRO
-			fmt.Fprintf(os.Stderr, "[PANIC] runConnection para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] runConnection para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "runConnection", r)
 			
 			// Tentar logar o panic (mas não bloquear se falhar)
 			func() {
@@ -423,7 +782,7 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar o panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em runConnection: %v", r)
 				}
@@ -435,26 +794,57 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 	}()
 
 	maxRetries := 999999 // Reconexão infinita
-	retryDelay := time.Second * 5
-	maxRetryDelay := time.Minute * 1
-	initialRetryDelay := retryDelay
 
-	logger, err := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, err := getLogger(wsConn.AccountID, wsConn.Account().Name)
 	if err != nil {
 		// Se não conseguir criar logger, pelo menos imprimir no stderr
 		fmt.Fprintf(os.Stderr, "ERRO: Não foi possível criar logger para conta %d: %v\n", wsConn.AccountID, err)
 	}
 
+	// Esperas entre reconexões e limpeza forçada: RECONNECT_* ou a preferência reconnect da conta
+	policy, policyErr := loadReconnectPolicy(wsConn.Account().Settings)
+	if logger != nil {
+		if policyErr != nil {
+			logger.Log("⚠️ Configuração de reconexão inválida (%v); usando o valor anterior nesses campos", policyErr)
+		}
+		if policy != defaultReconnectPolicy {
+			logger.Log("Reconexão: %s", policy)
+		}
+		if isLogOnly(wsConn.Account()) {
+			logger.Log("📝 Modo só registro: as notificações vão só para o histórico, sem envio (preferência %s)", logOnlySettingKey)
+		}
+	}
+	retryDelay := policy.InitialDelay
+
 	consecutiveFailures := 0
-	maxConsecutiveFailures := 10 // Após 10 falhas consecutivas, fazer limpeza forçada
+	var authFailures authBreaker // recusas de autenticação seguidas (authbreaker.go)
 
 	for retry := 0; retry < maxRetries; retry++ {
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return
 		default:
 		}
 
+		// Monitoramento pausado (pause.go): sem conexão até a retomada
+		if !wsConn.waitWhilePaused() {
+			return
+		}
+
+		if wsConn.reloadPolicy.CompareAndSwap(true, false) {
+			previous := policy
+			policy, policyErr = loadReconnectPolicy(wsConn.Account().Settings)
+			if retryDelay > policy.MaxDelay {
+				retryDelay = policy.MaxDelay
+			}
+			if logger != nil && policyErr != nil {
+				logger.Log("⚠️ Configuração de reconexão inválida (%v); usando o valor anterior nesses campos", policyErr)
+			}
+			if logger != nil && policy != previous {
+				logger.Log("Reconexão (recarregada): %s", policy)
+			}
+		}
+
 		// Limpar conexão antiga antes de tentar nova conexão
 		wsConn.mu.Lock()
 		if wsConn.Conn != nil {
@@ -465,38 +855,73 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 		wsConn.mu.Unlock()
 
 		// Se houver muitas falhas consecutivas, fazer uma limpeza mais agressiva
-		if consecutiveFailures >= maxConsecutiveFailures {
+		if policy.CleanupFailures > 0 && consecutiveFailures >= policy.CleanupFailures {
 			if logger != nil {
-				logger.Log("⚠️ Muitas falhas consecutivas (%d), fazendo limpeza forçada e aguardando antes de reconectar...", consecutiveFailures)
+				logger.Log("⚠️ Muitas falhas consecutivas (%d), fazendo limpeza forçada e aguardando %s antes de reconectar...", consecutiveFailures, policy.CleanupPause)
 			}
 			// Resetar delay e aguardar mais tempo
-			retryDelay = initialRetryDelay
+			retryDelay = policy.InitialDelay
 			consecutiveFailures = 0
 			select {
-			case <-wsConn.StopChan:
+			case <-wsConn.ctx.Done():
 				return
-			case <-time.After(30 * time.Second):
+			case <-time.After(policy.CleanupPause):
 			}
 		}
 
+		// Vaga no limite de conexões abertas (MAX_CONNECTIONS, connectgate.go), mantida até a conexão terminar
+		releaseConnection, ok := acquireConnectionSlot(wsConn, logger)
+		if !ok {
+			return
+		}
+
+		// Aguardar a vez na fila de conexões (início escalonado, connectgate.go); a vaga é liberada ao conectar ou falhar
+		release, waited, ok := startupGate().acquire(wsConn.ctx)
+		if !ok {
+			releaseConnection()
+			return
+		}
+		if waited >= time.Second && logger != nil {
+			logger.Log("[DEBUG] Conexão aguardou %s na fila de início escalonado", waited.Round(time.Millisecond))
+		}
+
+		// Início da lacuna de execuções a recuperar pela API REST depois de conectar (backfill.go)
+		gapStart := executionGapStart(wsConn)
+
 		// Canal para receber sinal de sucesso da conexão
 		successChan := make(chan bool, 1)
 		
 		// Iniciar conexão em goroutine para poder receber o sinal de sucesso
 		errChan := make(chan error, 1)
-		go func() {
-			errChan <- wsm.connectAndListen(wsConn, successChan)
-		}()
+		wsConn.spawn(goroutineReader, func() {
+			err := wsm.connectAndListen(wsConn, successChan)
+			releaseConnection()
+			errChan <- err
+		})
 
 		// Aguardar sinal de sucesso ou erro
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
+			release()
 			return
 		case success := <-successChan:
-			if success {
+			release()
+			if success && wsConn.isPaused() {
+				// Pausada durante a conexão: fecha e espera a retomada (a leitura cai e o erro é ignorado abaixo)
+				wsConn.mu.Lock()
+				if wsConn.Conn != nil {
+					wsConn.Conn.Close()
+				}
+				wsConn.mu.Unlock()
+			} else if success {
 				// Conexão estabelecida com sucesso - resetar contadores e delays
+				wsConn.markConnected()
+				if !gapStart.IsZero() {
+					wsConn.spawn(goroutineBackfill, func() { wsm.backfillExecutions(wsConn, gapStart, time.Now()) })
+				}
 				consecutiveFailures = 0
-				retryDelay = initialRetryDelay
+				authFailures.reset()
+				retryDelay = policy.InitialDelay
 				retry = -1 // Resetar para -1 para que após retry++ volte para 0
 				// if logger != nil {
 				// 	logger.Log("✅ Conexão estabelecida com sucesso, retry resetado")
@@ -505,10 +930,16 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 			// Continuar para aguardar erro da conexão (quando ela cair)
 		case err := <-errChan:
 			// Erro antes de estabelecer conexão
+			release()
+			if wsConn.isPaused() && wsConn.ctx.Err() == nil {
+				wsConn.markPaused()
+				continue
+			}
+			wsConn.markDisconnected(err)
 			if err != nil {
 				// Verificar se foi parado manualmente
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return
 				default:
 				}
@@ -517,15 +948,24 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 				if logger != nil {
 					logger.Log("Erro na conexão WebSocket (tentativa %d, falhas consecutivas: %d): %v", retry+1, consecutiveFailures, err)
 				}
+				if consecutiveFailures == sentryRepeatedFailures {
+					reportConnectionFailures(wsConn, consecutiveFailures, err)
+				}
+				if errors.Is(err, errIPNotAllowed) {
+					wsm.stopOnIPRestriction(wsConn, err)
+					return
+				}
+				if authFailures.record(err) {
+					wsm.stopOnAuthFailure(wsConn, &authFailures)
+					return
+				}
 
-				// Exponential backoff com limite máximo
+				// Backoff exponencial com limite máximo (reconnectPolicy)
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return
 				case <-time.After(retryDelay):
-					if retryDelay < maxRetryDelay {
-						retryDelay *= 2
-					}
+					retryDelay = policy.nextDelay(retryDelay)
 				}
 				continue
 			}
@@ -533,13 +973,25 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 
 		// Aguardar erro da conexão (quando ela cair)
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return
 		case err := <-errChan:
+			scheduled := wsConn.scheduledReconnect.Swap(false)
+			if wsConn.isPaused() && wsConn.ctx.Err() == nil {
+				// Fechada pela pausa (pause.go): espera a retomada no início do loop
+				wsConn.markPaused()
+				continue
+			}
+			if scheduled && wsConn.ctx.Err() == nil {
+				// Fechada pela reconexão programada (scheduledreconnect.go): reconecta já, sem contar falha
+				wsConn.markDisconnected(nil)
+				continue
+			}
+			wsConn.markDisconnected(err)
 			if err != nil {
 				// Verificar se foi parado manualmente
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return
 				default:
 				}
@@ -548,46 +1000,91 @@ func (wsm *WebSocketManager) runConnection(wsConn *WebSocketConnection) {
 				if logger != nil {
 					logger.Log("Erro na conexão WebSocket (tentativa %d, falhas consecutivas: %d): %v", retry+1, consecutiveFailures, err)
 				}
+				if consecutiveFailures == sentryRepeatedFailures {
+					reportConnectionFailures(wsConn, consecutiveFailures, err)
+				}
+				if errors.Is(err, errIPNotAllowed) {
+					wsm.stopOnIPRestriction(wsConn, err)
+					return
+				}
 
-				// Exponential backoff com limite máximo
+				// Backoff exponencial com limite máximo (reconnectPolicy)
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return
 				case <-time.After(retryDelay):
-					if retryDelay < maxRetryDelay {
-						retryDelay *= 2
-					}
+					retryDelay = policy.nextDelay(retryDelay)
 				}
 			} else {
 				// Conexão fechada normalmente, verificar se deve reconectar
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return
 				default:
 					// Reconectar após um delay curto
 					if logger != nil {
 						logger.Log("Conexão fechada, tentando reconectar...")
 					}
-					time.Sleep(initialRetryDelay)
+					time.Sleep(policy.InitialDelay)
 				}
 			}
 		}
 	}
 }
 
+// reportConnectionFailures envia ao Sentry o erro de conexão que se repetiu (autenticação ou rede/WebSocket).
+func reportConnectionFailures(wsConn *WebSocketConnection, consecutiveFailures int, err error) {
+	kind := "conexao"
+	if strings.Contains(err.Error(), "autentica") {
+		kind = "autenticacao"
+	}
+	reportAccountError(wsConn.AccountID, wsConn.Account().Name, kind, err, map[string]string{
+		"falhas_consecutivas": strconv.Itoa(consecutiveFailures),
+		"plataforma":          wsConn.Account().Platform,
+	})
+}
+
+// stopOnIPRestriction para o monitoramento da conta e avisa o operador quando a autenticação foi recusada
+// por restrição de IP: continuar tentando só gera mais recusas (e pode levar a corretora a bloquear a key).
+func (wsm *WebSocketManager) stopOnIPRestriction(wsConn *WebSocketConnection, err error) {
+	if logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name); logger != nil {
+		logger.Log("🚫 Autenticação recusada por restrição de IP, monitoramento parado: %v", err)
+	}
+	reportAccountError(wsConn.AccountID, wsConn.Account().Name, "autenticacao_ip", err, map[string]string{"plataforma": wsConn.Account().Platform})
+	wsm.sendNotification(wsConn, eventMonitoringStopped, fmt.Sprintf("🚫 **%s**: a corretora recusou a autenticação porque o IP desta máquina não está na lista de IPs liberados da API key.\n"+
+		"O monitoramento foi parado. Libere o IP da máquina na API key (ou remova a restrição) e inicie o monitoramento novamente.", wsConn.Account().Name))
+	wsConn.requestStop()
+}
+
+// dialStream abre uma conexão WebSocket vinculada a ctx: o cancelamento interrompe o handshake ou fecha a
+// conexão (desbloqueando o ReadMessage). A função retornada fecha a conexão e desfaz o vínculo.
+func dialStream(ctx context.Context, url string) (*websocket.Conn, func(), error) {
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	conn, _, err := dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	unbind := context.AfterFunc(ctx, func() { conn.Close() })
+	return conn, func() {
+		unbind()
+		conn.Close()
+	}, nil
+}
+
 // connectAndListen despacha para a implementação da plataforma (Bybit ou OKX).
 func (wsm *WebSocketManager) connectAndListen(wsConn *WebSocketConnection, successChan chan<- bool) (err error) {
-	if wsConn.Account.Platform == "okx" {
+	if wsConn.Account().Platform == "okx" {
 		return wsm.connectAndListenOKX(wsConn, successChan)
 	}
 	return wsm.connectAndListenBybit(wsConn, successChan)
 }
 
-func (wsm *WebSocketManager) pingLoop(conn *websocket.Conn, stopChan chan struct{}) {
+// pingLoop envia pings na conexão até ctx ser cancelado (fim da conexão ou do monitoramento).
+func pingLoop(ctx context.Context, conn *websocket.Conn) {
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "PANIC em pingLoop: %v\n", r)
+			fmt.Fprintf(os.Stderr, "PANIC em pingLoop: %v\n", redactSecrets(fmt.Sprint(r)))
 		}
 	}()
 
@@ -596,18 +1093,18 @@ func (wsm *WebSocketManager) pingLoop(conn *websocket.Conn, stopChan chan struct
 
 	for {
 		select {
-		case <-stopChan:
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			// Verificar se o canal foi fechado antes de tentar escrever
+			// Verificar se foi cancelado antes de tentar escrever
 			select {
-			case <-stopChan:
+			case <-ctx.Done():
 				return
 			default:
 			}
 			
-			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			// WriteControl pode ser chamado junto com outras escritas (ex.: reenvio de inscrições)
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
 				// Erro ao enviar ping, a conexão será detectada no loop principal
 				// Não fazer nada, apenas retornar para parar o loop
 				return
@@ -616,85 +1113,94 @@ func (wsm *WebSocketManager) pingLoop(conn *websocket.Conn, stopChan chan struct
 	}
 }
 
-func (wsm *WebSocketManager) handleMessage(wsConn *WebSocketConnection, message []byte) {
+func (wsm *WebSocketManager) handleMessage(wsConn *WebSocketConnection, correlationID string, message []byte) {
 	// Capturar panics para evitar crash silencioso
 	defer func() {
 		if r := recover(); r != nil {
-			logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "handleMessage", r)
+			logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 			if logger != nil {
 				logger.Log("PANIC em handleMessage: %v", r)
 			} else {
-				fmt.Fprintf(os.Stderr, "PANIC em handleMessage (conta %d): %v\n", wsConn.AccountID, r)
+				fmt.Fprintf(os.Stderr, "PANIC em handleMessage (conta %d): %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
 			}
 		}
 	}()
 
-	logger, logErr := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, logErr := getLogger(wsConn.AccountID, wsConn.Account().Name)
 	if logErr != nil {
 		fmt.Fprintf(os.Stderr, "ERRO: Não foi possível criar logger em handleMessage para conta %d: %v\n", wsConn.AccountID, logErr)
 	}
 
-	// Tentar parsear como mensagem de controle primeiro
-	var controlMsg map[string]interface{}
-	if err := json.Unmarshal(message, &controlMsg); err == nil {
-		if op, ok := controlMsg["op"].(string); ok {
-			if op == "auth" {
-				if logger != nil {
-					logger.Log("[DEBUG] Resposta de autenticação: %v", controlMsg)
-				}
-				return
-			}
-			if op == "subscribe" {
-				if success, ok := controlMsg["success"].(bool); ok && success {
-					// if logger != nil {
-					// 	logger.Log("✅ Inscrição nos tópicos confirmada!")
-					// }
-				} else {
-					if logger != nil {
-						logger.Log("⚠️ Inscrição pode ter falhado: %v", controlMsg)
-					}
-				}
-				return
-			}
-			if op == "ping" || op == "pong" {
-				// Pings/pongs são normais, não logar
-				return
-			}
-		}
-		// Se tem campo "topic", pode ser uma mensagem de dados
-		if topic, ok := controlMsg["topic"].(string); ok {
-			if logger != nil {
-				logger.Log("[DEBUG] Mensagem com tópico recebida: topic=%s", topic)
-			}
+	// Um único parse do envelope; o campo data só é decodificado no tipo do tópico
+	var envelope bybitEnvelope
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		if logger != nil {
+			logger.Log("[DEBUG] %s Mensagem inválida ignorada: %v", correlationTag(correlationID), err)
 		}
+		return
 	}
 
-	// Tentar parsear como mensagem de order
-	var orderMsg BybitOrderMessage
-	if err := json.Unmarshal(message, &orderMsg); err == nil && orderMsg.Topic == "order" {
-		wsm.handleOrderMessage(wsConn, orderMsg)
+	switch envelope.Op {
+	case "auth":
+		if logger != nil {
+			logger.Log("[DEBUG] Resposta de autenticação: %s", message)
+		}
+		return
+	case "subscribe":
+		tracker := wsConn.subscriptionsFor(rawStreamBybitPrivate)
+		if topic, ok := strings.CutPrefix(envelope.ReqID, bybitSubscribeReqPrefix); ok && tracker != nil {
+			tracker.ack(topic, envelope.Success, envelope.RetMsg)
+		} else if !envelope.Success && logger != nil {
+			logger.Log("⚠️ Inscrição pode ter falhado: %s", message)
+		}
+		return
+	case "ping", "pong":
+		// Pings/pongs são normais, não logar
 		return
 	}
-
-	// Tentar parsear como mensagem de execution
-	var execMsg BybitExecutionMessage
-	if err := json.Unmarshal(message, &execMsg); err == nil && execMsg.Topic == "execution" {
-		wsm.handleExecutionMessage(wsConn, execMsg)
+	if envelope.Topic == "" {
 		return
 	}
 
-	// Tentar parsear como mensagem de position
-	var posMsg BybitPositionMessage
-	if err := json.Unmarshal(message, &posMsg); err == nil && posMsg.Topic == "position" {
-		wsm.handlePositionMessage(wsConn, posMsg)
+	topic := envelope.Topic
+	if !acceptStreamEvent(wsConn.AccountID, topic, envelope.CreationTime) {
+		if logger != nil {
+			logger.Log("[DEBUG] %s Mensagem ignorada - anterior ao último evento processado (topic=%s, creationTime=%d)", correlationTag(correlationID), topic, envelope.CreationTime)
+		}
 		return
 	}
+	recordStat(wsConn.AccountID, statMessagesPrefix+topic, 1)
+	wsConn.countTopic(topic)
+	if logger != nil {
+		logger.Log("[DEBUG] %s Mensagem com tópico recebida: topic=%s", correlationTag(correlationID), topic)
+	}
 
-	// Tentar parsear como mensagem de wallet
-	var walletMsg BybitWalletMessage
-	if err := json.Unmarshal(message, &walletMsg); err == nil && walletMsg.Topic == "wallet" {
-		wsm.handleWalletMessage(wsConn, walletMsg)
-		return
+	var err error
+	switch topic {
+	case "order":
+		orderMsg := BybitOrderMessage{ID: envelope.ID, Topic: topic, CreationTime: envelope.CreationTime, CorrelationID: correlationID}
+		if err = json.Unmarshal(envelope.Data, &orderMsg.Data); err == nil {
+			wsm.handleOrderMessage(wsConn, orderMsg)
+		}
+	case "execution":
+		execMsg := BybitExecutionMessage{ID: envelope.ID, Topic: topic, CreationTime: envelope.CreationTime, CorrelationID: correlationID}
+		if err = json.Unmarshal(envelope.Data, &execMsg.Data); err == nil {
+			wsm.handleExecutionMessage(wsConn, execMsg)
+		}
+	case "position":
+		posMsg := BybitPositionMessage{ID: envelope.ID, Topic: topic, CreationTime: envelope.CreationTime}
+		if err = json.Unmarshal(envelope.Data, &posMsg.Data); err == nil {
+			wsm.handlePositionMessage(wsConn, posMsg)
+		}
+	case "wallet":
+		walletMsg := BybitWalletMessage{ID: envelope.ID, Topic: topic, CreationTime: envelope.CreationTime}
+		if err = json.Unmarshal(envelope.Data, &walletMsg.Data); err == nil {
+			wsm.handleWalletMessage(wsConn, walletMsg)
+		}
+	}
+	if err != nil && logger != nil {
+		logger.Log("%s Erro ao decodificar mensagem de %s: %v", correlationTag(correlationID), topic, err)
 	}
 }
 
@@ -702,14 +1208,15 @@ func (wsm *WebSocketManager) handleOrderMessage(wsConn *WebSocketConnection, ord
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] handleOrderMessage para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] handleOrderMessage para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "handleOrderMessage", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em handleOrderMessage: %v", r)
 				}
@@ -717,23 +1224,28 @@ func (wsm *WebSocketManager) handleOrderMessage(wsConn *WebSocketConnection, ord
 		}
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 
+	if orderMsg.CorrelationID == "" {
+		orderMsg.CorrelationID = newCorrelationID()
+	}
+	tag := correlationTag(orderMsg.CorrelationID)
 	if logger != nil {
-		logger.Log("[DEBUG] Mensagem de order recebida! Total de ordens: %d", len(orderMsg.Data))
+		logger.Log("[DEBUG] %s Mensagem de order recebida! Total de ordens: %d", tag, len(orderMsg.Data))
 	}
 
 	for _, orderData := range orderMsg.Data {
+		orderData.CorrelationID = orderMsg.CorrelationID
 		if logger != nil {
 			jsonData, _ := json.Marshal(orderData)
-			logger.Log("[DEBUG] Processando ordem - Category: %s, Status: %s, Symbol: %s | JSON: %s",
-				orderData.Category, orderData.OrderStatus, orderData.Symbol, string(jsonData))
+			logger.Log("[DEBUG] %s Processando ordem - Category: %s, Status: %s, Symbol: %s | JSON: %s",
+				tag, orderData.Category, orderData.OrderStatus, orderData.Symbol, string(jsonData))
 		}
 
 		// Processar apenas ordens inverse
 		if orderData.Category != "inverse" {
 			if logger != nil {
-				logger.Log("[DEBUG] Ordem ignorada - não é inverse (category: %s)", orderData.Category)
+				logger.Log("[DEBUG] %s Ordem ignorada - não é inverse (category: %s)", tag, orderData.Category)
 			}
 			continue
 		}
@@ -743,26 +1255,50 @@ func (wsm *WebSocketManager) handleOrderMessage(wsConn *WebSocketConnection, ord
 		// e ao mesmo tempo há uma tentativa de cancelamento
 		if orderData.RejectReason != "" && orderData.RejectReason != "EC_NoError" && orderData.RejectReason != "EC_PerCancelRequest" {
 			if logger != nil {
-				logger.Log("[DEBUG] Ordem ignorada - rejectReason diferente de EC_NoError: %s", orderData.RejectReason)
+				logger.Log("[DEBUG] %s Ordem %s ignorada - rejectReason diferente de EC_NoError: %s", tag, orderData.OrderID, orderData.RejectReason)
 			}
 			continue
 		}
 
-		if orderData.OrderStatus == "Untriggered" {
-			wsm.addStopToDelayBuffer(wsConn.AccountID, orderData, wsConn)
+		// Mesma atualização entregue de novo (reconexão, snapshot repetido): não notificar outra vez (dedupe.go)
+		if orderData.OrderID != "" && isDuplicateEvent(wsConn.AccountID, orderFingerprint(orderData)) {
+			if logger != nil {
+				logger.Log("[DEBUG] %s Ordem %s ignorada - atualização duplicada (%s)", tag, orderData.OrderID, orderData.OrderStatus)
+			}
 			continue
 		}
-		if orderData.OrderStatus == "Deactivated" {
+
+		// Histórico de ordens: toda atualização processada, antes dos filtros de notificação
+		if err := wsm.accountManager.RecordOrderEvent(wsConn.AccountID, orderData); err != nil && logger != nil {
+			logger.Log("Erro ao gravar histórico da ordem %s: %v", orderData.OrderID, err)
+		}
+		recordDailyStat(wsConn.AccountID, statOrdersSeen, 1)
+
+		// Regras de notificação da conta (rules.go)
+		if notify, rule := notificationRuleVerdict(wsConn.Account(), orderRuleSubject(orderData)); !notify {
+			if logger != nil {
+				logger.Log("[DEBUG] %s Ordem %s ignorada - regra de notificação %d", tag, orderData.OrderID, rule)
+			}
+			continue
+		}
+
+		if orderData.OrderStatus == "Untriggered" || orderData.OrderStatus == "Deactivated" {
+			if logger != nil {
+				logger.Log("[DEBUG] %s Stop %s (%s, %s) no buffer de delay", tag, orderData.OrderID, orderData.OrderStatus, orderData.Symbol)
+			}
 			wsm.addStopToDelayBuffer(wsConn.AccountID, orderData, wsConn)
 			continue
 		}
 		if orderData.CreateType == "CreateByStopOrder" || orderData.CreateType == "CreateByPartialStopLoss" || orderData.OrderStatus == "Triggered" {
 			if logger != nil {
-				logger.Log("[DEBUG] Ordem ignorada - CreateByStopOrder | CreateByPartialStopLoss | Triggered (status: %s)", orderData.OrderStatus)
+				logger.Log("[DEBUG] %s Ordem %s ignorada - CreateByStopOrder | CreateByPartialStopLoss | Triggered (status: %s)", tag, orderData.OrderID, orderData.OrderStatus)
 			}
 			continue
 		}
 		// Todas as ordens (New, Filled, Cancelled, etc.) vão para o buffer de delay
+		if logger != nil {
+			logger.Log("[DEBUG] %s Ordem %s (%s, %s) no buffer de delay", tag, orderData.OrderID, orderData.OrderStatus, orderData.Symbol)
+		}
 		wsm.addOrderToDelayBuffer(wsConn.AccountID, orderData, wsConn)
 
 	}
@@ -770,7 +1306,7 @@ func (wsm *WebSocketManager) handleOrderMessage(wsConn *WebSocketConnection, ord
 
 // formatOrderGroupMessage formata uma mensagem para um grupo de ordens (uma ou várias). Usado por processDelayBuffer.
 // wallet: última wallet da conta (pode ser nil); se tiver Coin da moeda da ordem, inclui % em relação ao UsdValue da Coin.
-func formatOrderGroupMessage(wallet *WalletData, groupOrders []OrderData) string {
+func formatOrderGroupMessage(icons notificationIcons, links orderLinkNames, wallet *WalletData, groupOrders []OrderData) string {
 	if len(groupOrders) == 0 {
 		return ""
 	}
@@ -779,9 +1315,29 @@ func formatOrderGroupMessage(wallet *WalletData, groupOrders []OrderData) string
 	if firstOrder.ReduceOnly {
 		reducePrefix = "Reduce "
 	}
-	var minPrice, maxPrice float64
-	var totalQty float64
-	var coinQty float64 // para preço médio ponderado: soma(preço * qty)
+	minPrice, maxPrice, avgPrice, totalQty := orderGroupPrices(groupOrders)
+	pctSuffix := orderPctOfWallet(wallet, firstOrder.Symbol, totalQty)
+	displayPrice := getDisplayPrice(firstOrder)
+	orderIcon := icons.side(templateNewOrder, firstOrder.Side)
+	coinSuffix := coinEquivalent(firstOrder.Symbol, totalQty, avgPrice)
+	origin := links.originLine(groupOrders) // orderLinkId das ordens (orderlink.go)
+	if len(groupOrders) == 1 {
+		return fmt.Sprintf("%sNova ordem aberta - %s %s%s %s @ %s (Qty: %s USD%s)%s%s",
+			orderIcon, firstOrder.Symbol, reducePrefix, firstOrder.Side, firstOrder.OrderType, displayPrice, formatPriceCoin(totalQty), coinSuffix, pctSuffix, origin)
+	}
+	if minPrice == maxPrice {
+		return fmt.Sprintf("%s%d ordens %s%s %s agrupadas - %s @ %s (Qty Total: %s USD%s)%s%s",
+			orderIcon, len(groupOrders), reducePrefix, firstOrder.Side, firstOrder.OrderType, firstOrder.Symbol, displayPrice, formatPriceCoin(totalQty), coinSuffix, pctSuffix, origin)
+	}
+
+	return fmt.Sprintf("%s%d ordens %s%s %s agrupadas - %s\n   Range: %s até %s (Preço médio: %s)\n   Qty Total: %s USD%s%s%s",
+		orderIcon, len(groupOrders), reducePrefix, firstOrder.Side, firstOrder.OrderType, firstOrder.Symbol,
+		formatPriceCoin(minPrice), formatPriceCoin(maxPrice), formatPriceCoin(avgPrice), formatPriceCoin(totalQty), coinSuffix, pctSuffix, origin)
+}
+
+// orderGroupPrices calcula a faixa de preços, o preço médio ponderado e a quantidade total (USD) do grupo.
+func orderGroupPrices(groupOrders []OrderData) (minPrice, maxPrice, avgPrice, totalQty float64) {
+	var coinQty float64 // para preço médio ponderado: soma(qty / preço)
 	for i, order := range groupOrders {
 		priceStr := getDisplayPrice(order)
 		price, err := strconv.ParseFloat(priceStr, 64)
@@ -805,70 +1361,45 @@ func formatOrderGroupMessage(wallet *WalletData, groupOrders []OrderData) string
 		totalQty += qty
 		coinQty += qty / price
 	}
-	var avgPrice float64
 	if totalQty > 0 {
 		avgPrice = totalQty / coinQty
 	}
-	pctSuffix := orderPctOfWallet(wallet, firstOrder.Symbol, totalQty)
-	displayPrice := getDisplayPrice(firstOrder)
-	var orderIcon string
-	if firstOrder.Side == "Buy" {
-		orderIcon = "🟢"
-	} else {
-		orderIcon = "🔴"
-	}
-	if len(groupOrders) == 1 {
-		return fmt.Sprintf("%s Nova ordem aberta - %s %s%s %s @ %s (Qty: %s USD)%s",
-			orderIcon, firstOrder.Symbol, reducePrefix, firstOrder.Side, firstOrder.OrderType, displayPrice, formatPriceCoin(totalQty), pctSuffix)
-	}
-	if minPrice == maxPrice {
-		return fmt.Sprintf("%s %d ordens %s%s %s agrupadas - %s @ %s (Qty Total: %s USD)%s",
-			orderIcon, len(groupOrders), reducePrefix, firstOrder.Side, firstOrder.OrderType, firstOrder.Symbol, displayPrice, formatPriceCoin(totalQty), pctSuffix)
-	}
-
-	return fmt.Sprintf("%s %d ordens %s%s %s agrupadas - %s\n   Range: %s até %s (Preço médio: %s)\n   Qty Total: %s USD%s",
-		orderIcon, len(groupOrders), reducePrefix, firstOrder.Side, firstOrder.OrderType, firstOrder.Symbol,
-		formatPriceCoin(minPrice), formatPriceCoin(maxPrice), formatPriceCoin(avgPrice), formatPriceCoin(totalQty), pctSuffix)
+	return minPrice, maxPrice, avgPrice, totalQty
 }
 
 // formatOrderMovedMessage formata mensagem de ordem movida (preço alterado). Usado por processDelayBuffer.
-func formatOrderMovedMessage(order OrderData, oldPrice, newPrice float64, wallet *WalletData) string {
+func formatOrderMovedMessage(icons notificationIcons, links orderLinkNames, order OrderData, oldPrice, newPrice float64, wallet *WalletData) string {
 	reducePrefix := ""
 	if order.ReduceOnly {
 		reducePrefix = "Reduce "
 	}
-	var orderIcon string
-	if order.Side == "Buy" {
-		orderIcon = "🟢"
-	} else {
-		orderIcon = "🔴"
-	}
+	orderIcon := icons.side(templateOrderMoved, order.Side)
 	qty, _ := strconv.ParseFloat(order.Qty, 64)
 	pctSuffix := orderPctOfWallet(wallet, order.Symbol, qty)
-	return fmt.Sprintf("📝 %s Ordem movida - %s %s%s %s\n   Preço: %s → %s (Qty: %s USD)%s",
-		orderIcon, order.Symbol, reducePrefix, order.Side, order.OrderType, formatPriceCoin(oldPrice), formatPriceCoin(newPrice), formatPriceCoin(qty), pctSuffix)
+	return fmt.Sprintf("%s%sOrdem movida - %s %s%s %s\n   Preço: %s → %s (Qty: %s USD%s)%s%s",
+		icons.prefix(templateOrderMoved, "moved"), orderIcon, order.Symbol, reducePrefix, order.Side, order.OrderType, formatPriceCoin(oldPrice), formatPriceCoin(newPrice), formatPriceCoin(qty), coinEquivalent(order.Symbol, qty, newPrice), pctSuffix, links.originLine([]OrderData{order}))
 }
 
 // formatCancelMessage formata mensagem de cancelamentos agrupados.
-func formatCancelMessage(orders []OrderData) string {
+func formatCancelMessage(icons notificationIcons, links orderLinkNames, orders []OrderData) string {
 	if len(orders) == 0 {
 		return ""
 	}
-	parts := []string{fmt.Sprintf("❌ %d ordens canceladas:", len(orders))}
+	parts := []string{fmt.Sprintf("%s%d ordens canceladas:", icons.prefix(templateCancel, "cancel"), len(orders))}
 	for _, order := range orders {
 		reducePrefix := ""
 		if order.ReduceOnly {
 			reducePrefix = "Reduce "
 		}
 		displayPrice := getDisplayPrice(order)
-		parts = append(parts, fmt.Sprintf("  • %s %s%s %s @ %s",
-			order.Symbol, reducePrefix, order.Side, order.OrderType, displayPrice))
+		parts = append(parts, fmt.Sprintf("  • %s %s%s %s @ %s%s",
+			order.Symbol, reducePrefix, order.Side, order.OrderType, displayPrice, links.suffix(order.OrderLinkID)))
 	}
 	return strings.Join(parts, "\n")
 }
 
 // formatStopOrderMessage formata mensagem de stop Untriggered.
-func formatStopOrderMessage(order OrderData, wallet *WalletData) string {
+func formatStopOrderMessage(icons notificationIcons, order OrderData, wallet *WalletData) string {
 	reducePrefix := ""
 	if order.ReduceOnly {
 		reducePrefix = "Reduce "
@@ -886,48 +1417,38 @@ func formatStopOrderMessage(order OrderData, wallet *WalletData) string {
 	}
 
 	formattedQty := formatPriceCoin(qty)
-	mensagemQty := "(Qty: " + formattedQty + " USD)"
+	mensagemQty := "(Qty: " + formattedQty + " USD" + coinEquivalent(order.Symbol, qty, triggerPrice) + ")"
 	if formattedQty == "0" {
 		mensagemQty = "(Qty: 100% da posição)"
 	}
 	pctSuffix := orderPctOfWallet(wallet, order.Symbol, qty)
-	var stopIcon string
-	if order.Side == "Buy" {
-		stopIcon = "🟢"
-	} else {
-		stopIcon = "🔴"
-	}
+	stopIcon := icons.side(templateStop, order.Side)
 	stopTypeSuffix := formatStopOrderTypeSuffix(order.StopOrderType)
-	return fmt.Sprintf("%s Stop %s%s %s - %s @ %s %s%s%s",
+	return fmt.Sprintf("%sStop %s%s %s - %s @ %s %s%s%s",
 		stopIcon, reducePrefix, order.Side, order.OrderType, order.Symbol, formatPriceCoin(triggerPrice), mensagemQty, pctSuffix, stopTypeSuffix)
 }
 
 // formatStopMovedMessage formata mensagem de stop movido (trigger price alterado). Usado por processDelayBuffer.
-func formatStopMovedMessage(order OrderData, oldPrice, newPrice float64, wallet *WalletData) string {
+func formatStopMovedMessage(icons notificationIcons, order OrderData, oldPrice, newPrice float64, wallet *WalletData) string {
 	reducePrefix := ""
 	if order.ReduceOnly {
 		reducePrefix = "Reduce "
 	}
 	qty, _ := strconv.ParseFloat(order.Qty, 64)
 	formattedQty := formatPriceCoin(qty)
-	mensagemQty := "(Qty: " + formattedQty + " USD)"
+	mensagemQty := "(Qty: " + formattedQty + " USD" + coinEquivalent(order.Symbol, qty, newPrice) + ")"
 	if formattedQty == "0" {
 		mensagemQty = "(Qty: 100% da posição)"
 	}
 	pctSuffix := orderPctOfWallet(wallet, order.Symbol, qty)
-	var stopIcon string
-	if order.Side == "Buy" {
-		stopIcon = "🟢"
-	} else {
-		stopIcon = "🔴"
-	}
+	stopIcon := icons.side(templateStopMoved, order.Side)
 	stopTypeSuffix := formatStopOrderTypeSuffix(order.StopOrderType)
-	return fmt.Sprintf("📝 %s Stop movido - %s %s%s %s%s\n   Preço: %s → %s %s%s",
-		stopIcon, order.Symbol, reducePrefix, order.Side, order.OrderType, stopTypeSuffix, formatPriceCoin(oldPrice), formatPriceCoin(newPrice), mensagemQty, pctSuffix)
+	return fmt.Sprintf("%s%sStop movido - %s %s%s %s%s\n   Preço: %s → %s %s%s",
+		icons.prefix(templateStopMoved, "moved"), stopIcon, order.Symbol, reducePrefix, order.Side, order.OrderType, stopTypeSuffix, formatPriceCoin(oldPrice), formatPriceCoin(newPrice), mensagemQty, pctSuffix)
 }
 
 // formatStopCancellationMessage formata mensagem de stop cancelado (Deactivated).
-func formatStopCancellationMessage(order OrderData) string {
+func formatStopCancellationMessage(icons notificationIcons, order OrderData) string {
 	reducePrefix := ""
 	if order.ReduceOnly {
 		reducePrefix = "Reduce "
@@ -945,19 +1466,14 @@ func formatStopCancellationMessage(order OrderData) string {
 	}
 
 	formattedQty := formatPriceCoin(qty)
-	mensagemQty := "(Qty: " + formattedQty + " USD)"
+	mensagemQty := "(Qty: " + formattedQty + " USD" + coinEquivalent(order.Symbol, qty, triggerPrice) + ")"
 	if formattedQty == "0" {
 		mensagemQty = "(Qty: 100% da posição)"
 	}
-	var stopIcon string
-	if order.Side == "Buy" {
-		stopIcon = "🟢"
-	} else {
-		stopIcon = "🔴"
-	}
+	stopIcon := icons.side(templateStopCancel, order.Side)
 	stopTypeSuffix := formatStopOrderTypeSuffix(order.StopOrderType)
-	return fmt.Sprintf("❌ %s Stop %s%s %s **CANCELADO** - %s @ %s %s%s",
-		stopIcon, reducePrefix, order.Side, order.OrderType, order.Symbol, formatPriceCoin(triggerPrice), mensagemQty, stopTypeSuffix)
+	return fmt.Sprintf("%s%sStop %s%s %s **CANCELADO** - %s @ %s %s%s",
+		icons.prefix(templateStopCancel, "cancel"), stopIcon, reducePrefix, order.Side, order.OrderType, order.Symbol, formatPriceCoin(triggerPrice), mensagemQty, stopTypeSuffix)
 }
 
 // formatStopOrderTypeSuffix retorna o sufixo de tipo de stop para mensagem.
@@ -1003,7 +1519,7 @@ func (wsm *WebSocketManager) getOrCreateDelayBuffer(accountID int64, delaySec in
 }
 
 func (wsm *WebSocketManager) addOrderToDelayBuffer(accountID int64, order OrderData, wsConn *WebSocketConnection) {
-	delaySec := wsConn.Account.NotificationDelaySeconds
+	delaySec := wsConn.Account().NotificationDelaySeconds
 	if delaySec <= 0 {
 		delaySec = 2
 	}
@@ -1021,13 +1537,14 @@ func (wsm *WebSocketManager) addOrderToDelayBuffer(accountID int64, order OrderD
 	}
 	if uniqueCount >= delayBufferMaxUniqueItems {
 		buf.mu.Unlock()
-		go wsm.processDelayBuffer(accountID, wsConn)
+		wsConn.spawn(goroutineBuffer, func() { wsm.processDelayBuffer(accountID, wsConn) })
 		return
 	}
-	buf.timer = time.AfterFunc(time.Duration(delaySec)*time.Second, func() {
+	buf.timer = wsConn.afterFunc(timerDelay, time.Duration(delaySec)*time.Second, func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer (timer) para conta %d: %v\n", accountID, r)
+				fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer (timer) para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+				reportPanic(accountID, wsConn.Account().Name, "processDelayBuffer (timer)", r)
 			}
 		}()
 		wsm.processDelayBuffer(accountID, wsConn)
@@ -1036,7 +1553,7 @@ func (wsm *WebSocketManager) addOrderToDelayBuffer(accountID int64, order OrderD
 }
 
 func (wsm *WebSocketManager) addStopToDelayBuffer(accountID int64, order OrderData, wsConn *WebSocketConnection) {
-	delaySec := wsConn.Account.NotificationDelaySeconds
+	delaySec := wsConn.Account().NotificationDelaySeconds
 	if delaySec <= 0 {
 		delaySec = 2
 	}
@@ -1054,13 +1571,14 @@ func (wsm *WebSocketManager) addStopToDelayBuffer(accountID int64, order OrderDa
 	}
 	if uniqueCount >= delayBufferMaxUniqueItems {
 		buf.mu.Unlock()
-		go wsm.processDelayBuffer(accountID, wsConn)
+		wsConn.spawn(goroutineBuffer, func() { wsm.processDelayBuffer(accountID, wsConn) })
 		return
 	}
-	buf.timer = time.AfterFunc(time.Duration(delaySec)*time.Second, func() {
+	buf.timer = wsConn.afterFunc(timerDelay, time.Duration(delaySec)*time.Second, func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer (timer) para conta %d: %v\n", accountID, r)
+				fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer (timer) para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+				reportPanic(accountID, wsConn.Account().Name, "processDelayBuffer (timer)", r)
 			}
 		}()
 		wsm.processDelayBuffer(accountID, wsConn)
@@ -1069,7 +1587,7 @@ func (wsm *WebSocketManager) addStopToDelayBuffer(accountID int64, order OrderDa
 }
 
 func (wsm *WebSocketManager) addExecutionToDelayBuffer(accountID int64, exec ExecutionData, wsConn *WebSocketConnection) {
-	delaySec := wsConn.Account.NotificationDelaySeconds
+	delaySec := wsConn.Account().NotificationDelaySeconds
 	if delaySec <= 0 {
 		delaySec = 2
 	}
@@ -1086,13 +1604,14 @@ func (wsm *WebSocketManager) addExecutionToDelayBuffer(accountID int64, exec Exe
 	}
 	if uniqueCount >= delayBufferMaxUniqueItems {
 		buf.mu.Unlock()
-		go wsm.processDelayBuffer(accountID, wsConn)
+		wsConn.spawn(goroutineBuffer, func() { wsm.processDelayBuffer(accountID, wsConn) })
 		return
 	}
-	buf.timer = time.AfterFunc(time.Duration(delaySec)*time.Second, func() {
+	buf.timer = wsConn.afterFunc(timerDelay, time.Duration(delaySec)*time.Second, func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer (timer) para conta %d: %v\n", accountID, r)
+				fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer (timer) para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+				reportPanic(accountID, wsConn.Account().Name, "processDelayBuffer (timer)", r)
 			}
 		}()
 		wsm.processDelayBuffer(accountID, wsConn)
@@ -1104,18 +1623,24 @@ func (wsm *WebSocketManager) addExecutionToDelayBuffer(accountID int64, exec Exe
 func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSocketConnection) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer para conta %d: %v\n", accountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] processDelayBuffer para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(accountID, wsConn.Account().Name, "processDelayBuffer", r)
 		}
 	}()
 
-	wsm.mu.RLock()
-	conn, exists := wsm.connections[accountID]
-	if !exists || !conn.Running {
-		wsm.mu.RUnlock()
+	conn, ok := wsm.notifyingConnection(accountID)
+	if !ok {
 		return
 	}
 	wsConn = conn
-	wsm.mu.RUnlock()
+
+	// logDebug registra as decisões do agrupamento, com o ID de correlação das mensagens de origem
+	logger, _ := getLogger(accountID, wsConn.Account().Name)
+	logDebug := func(format string, args ...interface{}) {
+		if logger != nil {
+			logger.Log("[DEBUG] "+format, args...)
+		}
+	}
 
 	wsm.bufferMu.Lock()
 	buf, exists := wsm.delayBuffers[accountID]
@@ -1153,6 +1678,10 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 	var preparedOrders []OrderData
 	movedOrderIDs := make(map[string]bool)
 	movedOrderPrices := make(map[string]struct{ Old, New float64 })
+	// Com order_message_edits, execuções e cancelamentos de ordens já notificadas editam a mensagem da abertura; no modo
+	// só registro não há mensagem para editar e eles são registrados como notificações normais
+	trackOrders := orderMessageEditsEnabled(wsConn.Account()) && !isLogOnly(wsConn.Account())
+	var orderMessageUpdates []OrderData
 	for _, versions := range ordersCopy {
 		sortOrderVersionsByUpdatedTime(versions)
 
@@ -1183,6 +1712,7 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 				preparedOrders = append(preparedOrders, newest)
 				continue
 			} else {
+				logDebug("%s Ordem %s sem notificação: criada e cancelada dentro do delay", correlationTag(orderCorrelationIDs(versions)...), newest.OrderID)
 				continue
 			}
 		}
@@ -1219,6 +1749,11 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 
 			if isLimitExecutedQuickly || isLimitMoved {
 				preparedOrders = append(preparedOrders, newest)
+			} else if trackOrders && wsConn.orderMessages.tracks(newest.OrderID) {
+				logDebug("%s Ordem Limit %s executada: mensagem da abertura será editada", correlationTag(orderCorrelationIDs(versions)...), newest.OrderID)
+				orderMessageUpdates = append(orderMessageUpdates, newest)
+			} else {
+				logDebug("%s Ordem Limit %s executada sem notificação (já notificada na abertura)", correlationTag(orderCorrelationIDs(versions)...), newest.OrderID)
 			}
 
 			continue
@@ -1270,6 +1805,7 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 				preparedStops = append(preparedStops, newest)
 				continue
 			} else {
+				logDebug("%s Stop %s sem notificação: criado e cancelado dentro do delay", correlationTag(orderCorrelationIDs(versions)...), newest.OrderID)
 				continue
 			}
 		}
@@ -1279,6 +1815,7 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 		// - se trigger mudou, trata como stop movido
 		if oldest.OrderStatus == "Untriggered" && newest.OrderStatus == "Untriggered" && hasExistingStopOrder {
 			if existingStopOrder.TriggerPrice == newest.TriggerPrice {
+				logDebug("%s Stop %s sem notificação: gatilho não mudou", correlationTag(orderCorrelationIDs(versions)...), newest.OrderID)
 				continue
 			}
 			if o, errO := strconv.ParseFloat(existingStopOrder.TriggerPrice, 64); errO == nil {
@@ -1381,6 +1918,7 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 	for _, stop := range preparedStops {
 		triggerPrice, _ := strconv.ParseFloat(stop.TriggerPrice, 64)
 		if triggerPrice == 0 {
+			logDebug("%s Stop %s sem notificação: triggerPrice 0", correlationTag(stop.CorrelationID), stop.OrderID)
 			continue
 		}
 		uTime, _ := strconv.ParseInt(stop.UpdatedTime, 10, 64)
@@ -1431,12 +1969,11 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 	// Atualizar banco: ordens e stops
 	for _, item := range orderNotifications {
 		for _, o := range item.Data {
-			orderJSON, _ := json.Marshal(o)
 			if item.NotificationType == "cancelled_order" || item.NotificationType == "deactivated_stop" {
 				_ = wsm.accountManager.DeleteOrder(o.OrderID)
 			} else if item.NotificationType == "untriggered_stop" || item.NotificationType == "simple_order" || item.NotificationType == "orders_group" || item.NotificationType == "order_moved" || item.NotificationType == "stop_moved" {
 				if o.OrderStatus != "Filled" && o.OrderStatus != "PartiallyFilled" {
-					_ = wsm.accountManager.SaveOrder(o.OrderID, accountID, string(orderJSON))
+					_ = wsm.accountManager.SaveOrder(accountID, o)
 				} else {
 					_ = wsm.accountManager.DeleteOrder(o.OrderID)
 				}
@@ -1444,6 +1981,24 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 		}
 	}
 
+	// Símbolos silenciados (mute.go): o estado das ordens já foi gravado acima, para a ordem seguir do status certo
+	// depois do silêncio; só as notificações são descartadas
+	unmuted := func(orders []OrderData) []OrderData {
+		var kept []OrderData
+		for _, o := range orders {
+			if isSymbolMuted(accountID, o.Symbol) {
+				logDebug("%s Ordem %s sem notificação: %s silenciado", correlationTag(o.CorrelationID), o.OrderID, o.Symbol)
+				continue
+			}
+			kept = append(kept, o)
+		}
+		return kept
+	}
+	for i := range orderNotifications {
+		orderNotifications[i].Data = unmuted(orderNotifications[i].Data)
+	}
+	orderMessageUpdates = unmuted(orderMessageUpdates)
+
 	// Regra 8 e 9: montar texto e enviar uma mensagem
 	// Buscar última wallet da conta para exibir % da ordem em relação ao saldo da moeda
 	var lastWallet *WalletData
@@ -1451,37 +2006,94 @@ func (wsm *WebSocketManager) processDelayBuffer(accountID int64, wsConn *WebSock
 	if walletRows, err := wsm.db.GetWalletSnapshotsUpdatedSince(accountID, sinceWallet); err == nil && len(walletRows) > 0 {
 		lastWallet = mergeWalletSnapshotRows(walletRows)
 	}
-	var parts []string
+	// Uma mensagem por severidade, para cada uma seguir a sua rota (severity.go); sem roteamento, todas são info
+	var severities []string
+	icons := accountIcons(wsConn.Account())
+	links := accountOrderLinkNames(wsConn.Account())
+	parts := make(map[string][]string)
+	messageIDs := make(map[string][]string)
+	type trackedOrderPart struct {
+		severity, text string
+		orders         []OrderData
+	}
+	var trackedOrders []trackedOrderPart
 	for _, item := range orderNotifications {
 		if len(item.Data) == 0 {
 			continue
 		}
+		severity := eventSeverity(wsConn.Account(), orderTemplateEvents[item.NotificationType])
+		partsBefore := len(parts[severity])
+		// Texto padrão ou template da conta (templates.go); texto vazio suprime o evento
+		partText := func(item delayNotificationItem, defaultText string) string {
+			if event := orderTemplateEvents[item.NotificationType]; isEventMuted(accountID, event) {
+				logDebug("%s Notificação %s silenciada (evento %s)", correlationTag(orderCorrelationIDs(item.Data)...), item.NotificationType, event)
+				return ""
+			}
+			text := formatOrderNotification(wsConn.Account(), item, lastWallet, defaultText)
+			if text == "" {
+				logDebug("%s Notificação %s suprimida pelo template", correlationTag(orderCorrelationIDs(item.Data)...), item.NotificationType)
+			}
+			return text
+		}
+		addPart := func(item delayNotificationItem, defaultText string) {
+			if text := partText(item, defaultText); text != "" {
+				parts[severity] = append(parts[severity], text)
+			}
+		}
 		switch item.NotificationType {
 		case "orders_group", "simple_order":
-			parts = append(parts, formatOrderGroupMessage(lastWallet, item.Data))
+			if trackOrders && hasOpenOrder(item.Data) {
+				// Mensagem própria, editada depois com a execução ou o cancelamento
+				if text := partText(item, formatOrderGroupMessage(icons, links, lastWallet, item.Data)); text != "" {
+					trackedOrders = append(trackedOrders, trackedOrderPart{severity: severity, text: text, orders: item.Data})
+				}
+				continue
+			}
+			addPart(item, formatOrderGroupMessage(icons, links, lastWallet, item.Data))
 		case "order_moved":
-			parts = append(parts, formatOrderMovedMessage(item.Data[0], item.OldPrice, item.NewPrice, lastWallet))
+			addPart(item, formatOrderMovedMessage(icons, links, item.Data[0], item.OldPrice, item.NewPrice, lastWallet))
 		case "cancelled_order":
 			var toNotify []OrderData
 			for _, o := range item.Data {
-				if hasValidDisplayPrice(o) {
+				if trackOrders && wsConn.orderMessages.tracks(o.OrderID) {
+					orderMessageUpdates = append(orderMessageUpdates, o)
+				} else if hasValidDisplayPrice(o) {
 					toNotify = append(toNotify, o)
 				}
 			}
 			if len(toNotify) > 0 {
-				parts = append(parts, formatCancelMessage(toNotify))
+				cancelled := item
+				cancelled.Data = toNotify
+				addPart(cancelled, formatCancelMessage(icons, links, toNotify))
+			} else {
+				logDebug("%s Cancelamento sem notificação: ordens sem preço", correlationTag(orderCorrelationIDs(item.Data)...))
 			}
 		case "untriggered_stop":
-			parts = append(parts, formatStopOrderMessage(item.Data[0], lastWallet))
+			addPart(item, formatStopOrderMessage(icons, item.Data[0], lastWallet))
 		case "stop_moved":
-			parts = append(parts, formatStopMovedMessage(item.Data[0], item.OldPrice, item.NewPrice, lastWallet))
+			addPart(item, formatStopMovedMessage(icons, item.Data[0], item.OldPrice, item.NewPrice, lastWallet))
 		case "deactivated_stop":
-			parts = append(parts, formatStopCancellationMessage(item.Data[0]))
+			addPart(item, formatStopCancellationMessage(icons, item.Data[0]))
 		}
+		if len(parts[severity]) > partsBefore {
+			ids := orderCorrelationIDs(item.Data)
+			logDebug("%s Agrupamento: %s com %d ordem(ns)", correlationTag(ids...), item.NotificationType, len(item.Data))
+			if partsBefore == 0 {
+				severities = append(severities, severity)
+			}
+			messageIDs[severity] = append(messageIDs[severity], ids...)
+		}
+	}
+	for _, severity := range severities {
+		messageText := strings.Join(parts[severity], "\n\n")
+		wsm.sendNotificationWithType(wsConn, severity, messageText, true, false, messageIDs[severity])
 	}
-	if len(parts) > 0 {
-		messageText := strings.Join(parts, "\n\n")
-		wsm.sendNotificationWithType(wsConn, messageText, true, false)
+	for _, part := range trackedOrders {
+		logDebug("%s Mensagem própria para %d ordem(ns), editada a cada execução ou cancelamento", correlationTag(orderCorrelationIDs(part.orders)...), len(part.orders))
+		wsm.sendOrderMessage(wsConn, part.severity, part.text, part.orders)
+	}
+	if len(orderMessageUpdates) > 0 {
+		wsm.editOrderMessages(wsConn, orderMessageUpdates)
 	}
 
 	// Regra 10: execuções (delay para notificação de ordens chegar ao Discord antes)
@@ -1495,14 +2107,15 @@ func (wsm *WebSocketManager) handleExecutionMessage(wsConn *WebSocketConnection,
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] handleExecutionMessage para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] handleExecutionMessage para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "handleExecutionMessage", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em handleExecutionMessage: %v", r)
 				}
@@ -1510,17 +2123,22 @@ func (wsm *WebSocketManager) handleExecutionMessage(wsConn *WebSocketConnection,
 		}
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 
+	if execMsg.CorrelationID == "" {
+		execMsg.CorrelationID = newCorrelationID()
+	}
+	tag := correlationTag(execMsg.CorrelationID)
 	if logger != nil {
-		logger.Log("[DEBUG] Mensagem de execution recebida! Total de execuções: %d", len(execMsg.Data))
+		logger.Log("[DEBUG] %s Mensagem de execution recebida! Total de execuções: %d", tag, len(execMsg.Data))
 	}
 
 	for _, execData := range execMsg.Data {
+		execData.CorrelationID = execMsg.CorrelationID
 		// Processar apenas execuções inverse
 		if execData.Category != "inverse" {
 			if logger != nil {
-				logger.Log("[DEBUG] Execução ignorada - não é inverse (category: %s)", execData.Category)
+				logger.Log("[DEBUG] %s Execução ignorada - não é inverse (category: %s)", tag, execData.Category)
 			}
 			continue
 		}
@@ -1528,7 +2146,7 @@ func (wsm *WebSocketManager) handleExecutionMessage(wsConn *WebSocketConnection,
 		// Processar apenas execuções do tipo Trade
 		if execData.ExecType != "Trade" {
 			if logger != nil {
-				logger.Log("[DEBUG] Execução ignorada - não é Trade (execType: %s)", execData.ExecType)
+				logger.Log("[DEBUG] %s Execução ignorada - não é Trade (execType: %s)", tag, execData.ExecType)
 			}
 			continue
 		}
@@ -1536,13 +2154,31 @@ func (wsm *WebSocketManager) handleExecutionMessage(wsConn *WebSocketConnection,
 		if logger != nil {
 			jsonData, _ := json.Marshal(execData)
 
-			logger.Log("[DEBUG] Processando execução - Symbol: %s, Side: %s, ExecPrice: %s | JSON: %s",
-				execData.Symbol, execData.Side, execData.ExecPrice, string(jsonData))
+			logger.Log("[DEBUG] %s Processando execução - Symbol: %s, Side: %s, ExecPrice: %s | JSON: %s",
+				tag, execData.Symbol, execData.Side, execData.ExecPrice, string(jsonData))
+		}
+
+		if execData.ExecID != "" && isDuplicateEvent(wsConn.AccountID, executionFingerprint(execData)) {
+			if logger != nil {
+				logger.Log("[DEBUG] %s Execução %s ignorada - duplicada", tag, execData.ExecID)
+			}
+			continue
+		}
+
+		if err := wsm.accountManager.RecordExecution(wsConn.AccountID, execData); err != nil && logger != nil {
+			logger.Log("Erro ao gravar execução %s no histórico: %v", execData.ExecID, err)
+		}
+		recordDailyStat(wsConn.AccountID, statExecutions, 1)
+		if qty, err := strconv.ParseFloat(execData.ExecQty, 64); err == nil {
+			recordDailyStat(wsConn.AccountID, statVolumeUSD, qty)
 		}
 
 		// Adicionar ao buffer de execution (inicia/reseta timer de 15 minutos)
 		wsm.addWalletNotificationToBuffer(wsConn.AccountID, wsConn)
 
+		if logger != nil {
+			logger.Log("[DEBUG] %s Execução %s (%s %s) no buffer de delay", tag, execData.ExecID, execData.Side, execData.Symbol)
+		}
 		wsm.addExecutionToDelayBuffer(wsConn.AccountID, execData, wsConn)
 
 	}
@@ -1552,14 +2188,15 @@ func (wsm *WebSocketManager) handlePositionMessage(wsConn *WebSocketConnection,
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] handlePositionMessage para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] handlePositionMessage para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "handlePositionMessage", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em handlePositionMessage: %v", r)
 				}
@@ -1567,10 +2204,10 @@ func (wsm *WebSocketManager) handlePositionMessage(wsConn *WebSocketConnection,
 		}
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 
 	if logger != nil {
-		logger.Log("[DEBUG] Mensagem de position recebida! Total de posições: %d", len(posMsg.Data))
+		logger.LogSampled("[DEBUG] Mensagem de position recebida! Total de posições: %d", len(posMsg.Data))
 	}
 
 	oneWayMode, err := wsm.accountManager.GetOneWayMode(wsConn.AccountID)
@@ -1582,7 +2219,7 @@ func (wsm *WebSocketManager) handlePositionMessage(wsConn *WebSocketConnection,
 	for _, posData := range posMsg.Data {
 		if posData.Category != "inverse" {
 			if logger != nil {
-				logger.Log("[DEBUG] Posição ignorada - não é inverse (category: %s)", posData.Category)
+				logger.LogSampled("[DEBUG] Posição ignorada - não é inverse (category: %s)", posData.Category)
 			}
 			continue
 		}
@@ -1597,6 +2234,25 @@ func (wsm *WebSocketManager) handlePositionMessage(wsConn *WebSocketConnection,
 				logger.Log("Erro ao salvar snapshot de position no banco: %v", err)
 			}
 		}
+		if wsConn.Account().Platform != "okx" {
+			wsm.funding.trackPosition(wsConn.AccountID, posData)
+		}
+
+		// Histórico de posições: abertura e fechamento
+		changes, err := wsm.accountManager.TrackPosition(wsConn.AccountID, posData)
+		if err != nil {
+			if logger != nil {
+				logger.Log("Erro ao gravar histórico da posição %s: %v", posData.Symbol, err)
+			}
+		} else if logger != nil {
+			for _, change := range changes {
+				if change.ClosedAt.IsZero() {
+					logger.Log("Posição aberta: %s %s %s @ %s", change.Symbol, change.Side, change.Size, change.EntryPrice)
+				} else {
+					logger.Log("Posição fechada: %s %s %s, entrada %s, saída %s, PnL realizado %s", change.Symbol, change.Side, change.Size, change.EntryPrice, change.ExitPrice, change.RealizedPnl)
+				}
+			}
+		}
 	}
 }
 
@@ -1604,14 +2260,15 @@ func (wsm *WebSocketManager) handleWalletMessage(wsConn *WebSocketConnection, wa
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] handleWalletMessage para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] handleWalletMessage para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "handleWalletMessage", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em handleWalletMessage: %v", r)
 				}
@@ -1619,17 +2276,17 @@ func (wsm *WebSocketManager) handleWalletMessage(wsConn *WebSocketConnection, wa
 		}
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 
 	if logger != nil {
-		logger.Log("[DEBUG] Mensagem de wallet recebida! Total de wallets: %d", len(walletMsg.Data))
+		logger.LogSampled("[DEBUG] Mensagem de wallet recebida! Total de wallets: %d", len(walletMsg.Data))
 	}
 
 	// Processar apenas wallets UNIFIED
 	for _, walletData := range walletMsg.Data {
 		if walletData.AccountType != "UNIFIED" {
 			if logger != nil {
-				logger.Log("[DEBUG] Wallet ignorado - não é UNIFIED (accountType: %s)", walletData.AccountType)
+				logger.LogSampled("[DEBUG] Wallet ignorado - não é UNIFIED (accountType: %s)", walletData.AccountType)
 			}
 			continue
 		}
@@ -1646,7 +2303,7 @@ func (wsm *WebSocketManager) handleWalletMessage(wsConn *WebSocketConnection, wa
 		}
 
 		// Agendar notificação Google Sheets em 2 minutos (dados lidos do banco na hora)
-		if wsConn.Account.WebhookURLGoogleSheets != "" && wsConn.Account.SheetURLGoogleSheets != "" {
+		if wsConn.Account().WebhookURLGoogleSheets != "" && wsConn.Account().SheetURLGoogleSheets != "" {
 			wsm.resetSheetsTimer(wsConn.AccountID, wsConn)
 		}
 	}
@@ -1667,15 +2324,16 @@ func (wsm *WebSocketManager) addWalletNotificationToBuffer(accountID int64, wsCo
 	if buffer.discordTimer != nil {
 		buffer.discordTimer.Stop()
 	}
-	buffer.discordTimer = time.AfterFunc(15*time.Minute, func() {
+	buffer.discordTimer = wsConn.afterFunc(timerWallet, 15*time.Minute, func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "[PANIC] processWalletNotification (timer) para conta %d: %v\n", accountID, r)
+				fmt.Fprintf(os.Stderr, "[PANIC] processWalletNotification (timer) para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+				reportPanic(accountID, wsConn.Account().Name, "processWalletNotification (timer)", r)
 			}
 		}()
 		wsm.processWalletNotification(accountID, wsConn)
 	})
-	logger, _ := getLogger(accountID, wsConn.Account.Name)
+	logger, _ := getLogger(accountID, wsConn.Account().Name)
 	if logger != nil {
 		logger.Log("[DEBUG] Execução recebida, iniciando/resetando timer de 15 minutos para Discord")
 	}
@@ -1696,10 +2354,11 @@ func (wsm *WebSocketManager) resetSheetsTimer(accountID int64, wsConn *WebSocket
 	if buffer.sheetsTimer != nil {
 		buffer.sheetsTimer.Stop()
 	}
-	buffer.sheetsTimer = time.AfterFunc(2*time.Minute, func() {
+	buffer.sheetsTimer = wsConn.afterFunc(timerSheets, 2*time.Minute, func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "[PANIC] processSheetsNotification (timer) para conta %d: %v\n", accountID, r)
+				fmt.Fprintf(os.Stderr, "[PANIC] processSheetsNotification (timer) para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+				reportPanic(accountID, wsConn.Account().Name, "processSheetsNotification (timer)", r)
 			}
 		}()
 		wsm.processSheetsNotification(accountID)
@@ -1738,7 +2397,11 @@ func mergeWalletSnapshotRows(rows []WalletSnapshotRow) *WalletData {
 }
 
 func (wsm *WebSocketManager) getPositionSnapshotTypes(accountID int64) []string {
-	oneWayMode, err := wsm.accountManager.GetOneWayMode(accountID)
+	return positionSnapshotTypes(wsm.accountManager, accountID)
+}
+
+func positionSnapshotTypes(manager AccountRepo, accountID int64) []string {
+	oneWayMode, err := manager.GetOneWayMode(accountID)
 	if err != nil || oneWayMode {
 		return []string{"position"}
 	}
@@ -1796,14 +2459,15 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] processWalletNotification para conta %d: %v\n", accountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] processWalletNotification para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(accountID, wsConn.Account().Name, "processWalletNotification", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(accountID, wsConn.Account.Name)
+				logger, _ := getLogger(accountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em processWalletNotification: %v", r)
 				}
@@ -1811,37 +2475,70 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 		}
 	}()
 
-	// Verificar se a conexão ainda está ativa
-	wsm.mu.RLock()
-	conn, exists := wsm.connections[accountID]
-	if !exists || !conn.Running {
-		wsm.mu.RUnlock()
+	// Verificar se a conexão ainda está ativa (ou sendo parada, com os buffers sendo descarregados)
+	activeConn, ok := wsm.notifyingConnection(accountID)
+	if !ok {
 		return
 	}
-	activeConn := conn
-	wsm.mu.RUnlock()
-
 	wsConn = activeConn
 
+	if isEventMuted(accountID, templatePositionSummary) {
+		if logger, _ := getLogger(accountID, wsConn.Account().Name); logger != nil {
+			logger.Log("[DEBUG] Resumo de posições sem notificação - evento position_summary silenciado")
+		}
+		return
+	}
+
 	// Buscar wallets atualizadas nos últimos 17 minutos no banco
 	sinceWallet := time.Now().Add(-17 * time.Minute)
-	walletRows, err := wsm.db.GetWalletSnapshotsUpdatedSince(accountID, sinceWallet)
-	if err != nil || len(walletRows) == 0 {
+	messageText, chartURL, err := buildWalletSummaryMessage(wsm.db, wsm.accountManager, accountID, sinceWallet)
+	if err != nil {
 		return
 	}
 
+	// Enviar notificação (carteira)
+	wsm.sendNotificationWithImage(wsConn, eventSeverity(wsConn.Account(), templatePositionSummary), messageText, false, true, nil, chartURL)
+	logger, _ := getLogger(accountID, wsConn.Account().Name)
+	if logger != nil {
+		logger.Log("[DEBUG] Notificação de posição enviada após 15 minutos sem execuções")
+	}
+}
+
+// errNoWalletSnapshot indica que ainda não há mensagem de wallet salva para a conta (ex.: conta OKX ou recém-cadastrada).
+var errNoWalletSnapshot = errors.New("nenhum dado de carteira recebido ainda")
+
+// buildWalletSummaryMessage monta o resumo de carteira/posições a partir dos snapshots salvos no banco e, com a
+// preferência summary_chart, a URL do gráfico do resumo (chart.go; "" = sem gráfico).
+// Considera apenas wallets atualizadas a partir de since (time.Time{} = todas).
+func buildWalletSummaryMessage(db *Database, manager AccountRepo, accountID int64, since time.Time) (string, string, error) {
+	walletRows, err := db.GetWalletSnapshotsUpdatedSince(accountID, since)
+	if err != nil {
+		return "", "", err
+	}
+	if len(walletRows) == 0 {
+		return "", "", errNoWalletSnapshot
+	}
+
 	lastWallet := mergeWalletSnapshotRows(walletRows)
 	if lastWallet == nil {
-		return
+		return "", "", errNoWalletSnapshot
 	}
 
-	positionTypes := wsm.getPositionSnapshotTypes(accountID)
+	positionTypes := positionSnapshotTypes(manager, accountID)
 	oneWayMode := len(positionTypes) == 1 && positionTypes[0] == "position"
-	positionRows, err := wsm.db.GetPositionSnapshotsByTypes(accountID, positionTypes)
+	positionRows, err := db.GetPositionSnapshotsByTypes(accountID, positionTypes)
 	if err != nil {
-		return
+		return "", "", err
 	}
 	positionsBySymbol := buildPositionsBySymbol(positionRows)
+	account, accountErr := manager.GetAccount(accountID)
+	timezone := ""
+	// O funding vem do stream público da Bybit (funding.go): as posições da OKX não são acompanhadas
+	withFunding := true
+	if accountErr == nil {
+		timezone = account.Timezone
+		withFunding = account.Platform != "okx"
+	}
 
 	// Obter valor total da carteira do wallet
 	totalEquity, err := strconv.ParseFloat(lastWallet.TotalEquity, 64)
@@ -1850,7 +2547,7 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 		totalEquity, err = strconv.ParseFloat(lastWallet.TotalWalletBalance, 64)
 		if err != nil {
 			// Não foi possível obter valor da carteira - não processar
-			return
+			return "", "", fmt.Errorf("valor total da carteira indisponível no snapshot")
 		}
 	}
 
@@ -1859,6 +2556,7 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 	var totalLongUSD float64
 	var totalExposicaoUSD float64
 	var coinMessages []string // Mensagens por moeda para usar no else se necessário
+	var coins []walletSummaryCoin // mesmos valores, para o template position_summary (templates.go)
 	var totalValidPositions int = 0
 
 	// Processar todas as posições para calcular totais e criar mensagens
@@ -1874,12 +2572,16 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 		}
 
 		var totalEquityPerCoin float64
+		var coinPrice float64 // cotação do saldo (usdValue / equity), para os valores em moeda
 		for _, coinBalance := range lastWallet.Coin {
 			if coinBalance.Coin == coin {
 				equity, err := strconv.ParseFloat(coinBalance.UsdValue, 64)
 				if err == nil {
 					totalEquityPerCoin = equity
 				}
+				if coinEquity, err := strconv.ParseFloat(coinBalance.Equity, 64); err == nil && coinEquity > 0 {
+					coinPrice = totalEquityPerCoin / coinEquity
+				}
 				break
 			}
 		}
@@ -1912,18 +2614,39 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 		// Criar mensagem da moeda (para usar no else se necessário)
 		var coinMsgParts []string
 		coinMsgParts = append(coinMsgParts, fmt.Sprintf("📌 %s (%s):", coin, symbol))
-		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  💰 Total: $%s USD", formatPriceCoin(totalEquityPerCoin)))
-		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  🛡️ Protegido: $%s USD", formatPriceCoin(protecaoPosUSD)))
+		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  💰 Total: $%s USD%s", formatPriceCoin(totalEquityPerCoin), coinEquivalent(symbol, totalEquityPerCoin, coinPrice)))
+		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  🛡️ Protegido: $%s USD%s", formatPriceCoin(protecaoPosUSD), coinEquivalent(symbol, protecaoPosUSD, coinPrice)))
 		if longPosUSD > 0 {
-			coinMsgParts = append(coinMsgParts, fmt.Sprintf("  📈 Posição Long: $%s USD", formatPriceCoin(longPosUSD)))
+			coinMsgParts = append(coinMsgParts, fmt.Sprintf("  📈 Posição Long: $%s USD%s", formatPriceCoin(longPosUSD), coinEquivalent(symbol, longPosUSD, coinPrice)))
 		}
-		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  ⚠️ Exposto: $%s USD", formatPriceCoin(expostoPosUSD)))
+		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  ⚠️ Exposto: $%s USD%s", formatPriceCoin(expostoPosUSD), coinEquivalent(symbol, expostoPosUSD, coinPrice)))
 		coinMsgParts = append(coinMsgParts, fmt.Sprintf("  📈 %% Protegida: %s%%", formatPriceCoin(percentProtegidaPos)))
 		if longPosUSD > 0 {
 			coinMsgParts = append(coinMsgParts, fmt.Sprintf("  📊 %% Longada: %s%%", formatPriceCoin(percentLongadaPos)))
 		}
+		// Preço de liquidação e distância do mark de cada posição aberta (liquidation.go)
+		var openPositions []walletSummaryPosition
+		for _, pos := range symbolPositions {
+			if p, ok := positionLiquidation(pos); ok {
+				openPositions = append(openPositions, p)
+				if line := formatLiquidationLine(p); line != "" {
+					coinMsgParts = append(coinMsgParts, line)
+				}
+			}
+		}
+		if withFunding {
+			if line := formatFundingLine(symbol, openPositions, timezone); line != "" {
+				coinMsgParts = append(coinMsgParts, line)
+			}
+		}
 		coinMsgParts = append(coinMsgParts, "")
 		coinMessages = append(coinMessages, strings.Join(coinMsgParts, "\n"))
+		summaryCoin := walletSummaryCoin{Coin: coin, Symbol: symbol, Total: totalEquityPerCoin, Protected: protecaoPosUSD,
+			Long: longPosUSD, Exposed: expostoPosUSD, ProtectedPct: percentProtegidaPos, LongPct: percentLongadaPos, Positions: openPositions}
+		if funding, ok := currentFunding(symbol); ok && withFunding {
+			summaryCoin.FundingRate, summaryCoin.NextFunding = funding.Rate, funding.NextAt
+		}
+		coins = append(coins, summaryCoin)
 	}
 
 	// Construir mensagem
@@ -1961,32 +2684,77 @@ func (wsm *WebSocketManager) processWalletNotification(accountID int64, wsConn *
 	}
 
 	messageText := strings.Join(messageParts, "\n")
+	if accountErr != nil {
+		return messageText, "", nil
+	}
+	data := walletSummaryTemplateData{Account: account.Name, Event: templatePositionSummary, Default: messageText, Coins: coins,
+		Total: totalEquity, Protected: totalProtecaoUSD, Long: totalLongUSD, Exposed: totalExposicaoUSD}
+	if totalEquity > 0 {
+		data.ProtectedPct = totalProtecaoUSD / totalEquity * 100
+		data.LongPct = totalLongUSD / totalEquity * 100
+	}
+	if messageText = applyNotificationTemplate(account, templatePositionSummary, data, messageText); messageText == "" {
+		return "", "", errSummarySuppressed
+	}
+	return messageText, walletSummaryChartURL(account, data), nil
+}
 
-	// Enviar notificação (carteira)
-	wsm.sendNotificationWithType(wsConn, messageText, false, true)
-	logger, _ := getLogger(accountID, wsConn.Account.Name)
-	if logger != nil {
-		logger.Log("[DEBUG] Notificação de posição enviada após 15 minutos sem execuções")
+// SendWalletSummaryNow envia o resumo de carteira/posições imediatamente, sem esperar o timer de 15 minutos
+// após a última execução. Retorna o texto do resumo; o envio é síncrono para reportar erros do webhook.
+func (wsm *WebSocketManager) SendWalletSummaryNow(account *BybitAccount) (string, error) {
+	messageText, err := sendWalletSummary(wsm.db, wsm.accountManager, account)
+	if err != nil {
+		return messageText, err
+	}
+
+	// O resumo já foi enviado; cancelar o envio agendado pelo timer
+	wsm.bufferMu.Lock()
+	buffer, exists := wsm.walletNotificationBuffers[account.ID]
+	wsm.bufferMu.Unlock()
+	if exists {
+		buffer.mu.Lock()
+		if buffer.discordTimer != nil {
+			buffer.discordTimer.Stop()
+			buffer.discordTimer = nil
+		}
+		buffer.mu.Unlock()
+	}
+	return messageText, nil
+}
+
+// sendWalletSummary monta o resumo com todos os snapshots salvos e envia para o webhook da conta (se configurado).
+func sendWalletSummary(db *Database, manager AccountRepo, account *BybitAccount) (string, error) {
+	messageText, chartURL, err := buildWalletSummaryMessage(db, manager, account.ID, time.Time{})
+	if err != nil {
+		return "", err
+	}
+	discordMsg := buildDiscordMessage(account, messageText, false, true)
+	for _, channel := range notificationChannels(account, eventSeverity(account, templatePositionSummary), notifyChannelDiscord) {
+		err := deliverNotification(account.ID, channel, discordMsg, nil, channelSender(account, channel, discordMsg, chartURL, nil))
+		if err != nil {
+			return messageText, fmt.Errorf("erro ao enviar webhook (%s): %w", notifyChannelLabel(channel), err)
+		}
 	}
+	if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+		logger.Log("Resumo de carteira enviado sob demanda")
+	}
+	return messageText, nil
 }
 
 func (wsm *WebSocketManager) processSheetsNotification(accountID int64) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] processSheetsNotification para conta %d: %v\n", accountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] processSheetsNotification para conta %d: %v\n", accountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(accountID, "", "processSheetsNotification", r)
 		}
 	}()
 
-	wsm.mu.RLock()
-	conn, exists := wsm.connections[accountID]
-	if !exists || !conn.Running {
-		wsm.mu.RUnlock()
+	wsConn, ok := wsm.notifyingConnection(accountID)
+	if !ok {
 		return
 	}
-	wsConn := conn
-	wsm.mu.RUnlock()
 
-	if wsConn.Account.WebhookURLGoogleSheets == "" || wsConn.Account.SheetURLGoogleSheets == "" {
+	if wsConn.Account().WebhookURLGoogleSheets == "" || wsConn.Account().SheetURLGoogleSheets == "" {
 		return
 	}
 
@@ -2009,8 +2777,8 @@ func (wsm *WebSocketManager) processSheetsNotification(accountID int64) {
 	}
 	positionsBySymbol := buildPositionsBySymbol(positionRows)
 
-	logger, _ := getLogger(accountID, wsConn.Account.Name)
-	now := getBrasiliaTime()
+	logger, _ := getLogger(accountID, wsConn.Account().Name)
+	now := getAccountTime(wsConn.Account().Timezone)
 	dateTimeStr := now.Format("02/01/2006 15:04")
 	headers := []string{"data", "moeda", "total_moeda", "total_dolar", "total_protegido", "total_exposto", "total_long"}
 
@@ -2057,33 +2825,31 @@ func (wsm *WebSocketManager) processSheetsNotification(accountID int64) {
 		}{coin: coinBalance.Coin, columns: columns, headers: headers})
 	}
 
-	// Enviar webhooks em goroutine para não bloquear a thread principal
-	webhookURL := wsConn.Account.WebhookURLGoogleSheets
-	sheetURL := wsConn.Account.SheetURLGoogleSheets
-	go func() {
-		for _, p := range webhookPayloads {
-			if err := sendGoogleSheetsWebhook(webhookURL, sheetURL, p.coin, p.columns, p.headers); err != nil {
+	// Enviar webhooks pela fila de envio para não bloquear a thread principal
+	webhookURL := wsConn.Account().WebhookURLGoogleSheets
+	sheetURL := wsConn.Account().SheetURLGoogleSheets
+	for _, p := range webhookPayloads {
+		message := fmt.Sprintf("Carteira %s: %v", p.coin, p.columns)
+		wsConn.enqueueDelivery(notifyChannelGoogleSheets, message, nil, func() {
+			err := deliverNotification(accountID, notifyChannelGoogleSheets, message, nil, func() error {
+				return sendGoogleSheetsWebhook(webhookURL, sheetURL, p.coin, p.columns, p.headers)
+			})
+			if err != nil {
 				if logger != nil {
 					logger.Log("Erro ao enviar webhook do Google Sheets para %s: %v", p.coin, err)
 				}
 			}
-		}
-	}()
+		})
+	}
 }
 
-// formatExecTimeToBrasilia converte timestamp em ms (string) para data no formato Brasil "DD/MM/YYYY HH:MM". Se inválido, usa time.Now() em Brasília.
-func formatExecTimeToBrasilia(execTimeMs string) string {
+// formatExecTime converte timestamp em ms (string) para data no formato "DD/MM/YYYY HH:MM" no fuso da conta. Se inválido, usa time.Now().
+func formatExecTime(execTimeMs string, tz string) string {
 	ms, err := strconv.ParseInt(execTimeMs, 10, 64)
 	if err != nil {
-		return getBrasiliaTime().Format("02/01/2006 15:04")
+		return getAccountTime(tz).Format("02/01/2006 15:04")
 	}
-	t := time.UnixMilli(ms)
-	if loc, err := time.LoadLocation("America/Sao_Paulo"); err == nil {
-		return t.In(loc).Format("02/01/2006 15:04")
-	}
-	brasiliaOffset := -3 * 60 * 60
-	brasiliaTZ := time.FixedZone("BRT", brasiliaOffset)
-	return t.In(brasiliaTZ).Format("02/01/2006 15:04")
+	return time.UnixMilli(ms).In(loadTimezone(tz)).Format("02/01/2006 15:04")
 }
 
 // formatQtyCoin formata quantidade/valor em moeda sem notação científica e sem casas decimais fixas (remove zeros à direita).
@@ -2118,11 +2884,34 @@ func (wsm *WebSocketManager) flushExecutions(wsConn *WebSocketConnection, execut
 	if len(executions) == 0 {
 		return
 	}
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	if logger != nil {
+		logger.Log("[DEBUG] %s Agrupamento: %d execução(ões)", correlationTag(executionCorrelationIDs(executions)...), len(executions))
+	}
 
-	if wsConn.Account.WebhookURLExecutions != "" {
+	channels := notificationChannels(wsConn.Account(), eventSeverity(wsConn.Account(), eventExecution), notifyChannelExecutions)
+	if len(channels) > 0 && isEventMuted(wsConn.AccountID, eventExecution) {
+		if logger != nil {
+			logger.Log("[DEBUG] %s Execuções sem notificação - evento execution silenciado", correlationTag(executionCorrelationIDs(executions)...))
+		}
+		channels = nil
+	}
+	if len(channels) > 0 {
 		var parts []string
+		links := accountOrderLinkNames(wsConn.Account())
 		for _, e := range executions {
+			if notify, rule := notificationRuleVerdict(wsConn.Account(), executionRuleSubject(e)); !notify {
+				if logger != nil {
+					logger.Log("[DEBUG] %s Execução %s sem notificação - regra de notificação %d", correlationTag(e.CorrelationID), e.ExecID, rule)
+				}
+				continue
+			}
+			if isSymbolMuted(wsConn.AccountID, e.Symbol) {
+				if logger != nil {
+					logger.Log("[DEBUG] %s Execução %s sem notificação - %s silenciado", correlationTag(e.CorrelationID), e.ExecID, e.Symbol)
+				}
+				continue
+			}
 			coin := symbolToCoin(e.Symbol)
 			price, _ := strconv.ParseFloat(e.ExecPrice, 64)
 			qtyUsd, _ := strconv.ParseFloat(e.ExecQty, 64)
@@ -2130,63 +2919,89 @@ func (wsm *WebSocketManager) flushExecutions(wsConn *WebSocketConnection, execut
 			if e.CreateType == "CreateByStopOrder" {
 				stopText = "Stop "
 			}
-			parts = append(parts, fmt.Sprintf("%s - %s %s %s%s | Preço: %s | USD: %s",
-				formatExecTimeToBrasilia(e.ExecTime), coin, e.Side, stopText, e.OrderType, formatPriceCoin(price), formatPriceCoin(qtyUsd)))
+			parts = append(parts, fmt.Sprintf("%s - %s %s %s%s | Preço: %s | USD: %s%s%s",
+				formatExecTime(e.ExecTime, wsConn.Account().Timezone), coin, e.Side, stopText, e.OrderType, formatPriceCoin(price), formatPriceCoin(qtyUsd), coinEquivalent(e.Symbol, qtyUsd, price), links.suffix(e.OrderLinkID)))
+		}
+		if len(parts) > 0 {
+			discordMsg := buildExecutionDiscordMessage(wsConn.Account(), strings.Join(parts, "\n"))
+			ids := executionCorrelationIDs(executions)
+			for _, channel := range channels {
+				channel := channel
+				if !wsm.admitNotification(wsConn, channel, strings.Join(parts, "\n"), ids) {
+					continue
+				}
+				wsConn.enqueueDelivery(channel, discordMsg, ids, func() {
+					wsm.sendExecutionNotification(wsConn, channel, discordMsg, ids)
+				})
+			}
 		}
-		messageText := strings.Join(parts, "\n")
-		go wsm.sendExecutionNotification(wsConn, messageText)
 	}
 
-	if wsConn.Account.WebhookURLGoogleSheets != "" && wsConn.Account.SheetURLGoogleSheetsExecutions != "" {
+	if wsConn.Account().WebhookURLGoogleSheets != "" && wsConn.Account().SheetURLGoogleSheetsExecutions != "" {
 		byCoin := make(map[string][]ExecutionData)
 		for _, e := range executions {
 			coin := symbolToCoin(e.Symbol)
 			byCoin[coin] = append(byCoin[coin], e)
 		}
-		webhookURL := wsConn.Account.WebhookURLGoogleSheets
-		sheetURLExec := wsConn.Account.SheetURLGoogleSheetsExecutions
+		webhookURL := wsConn.Account().WebhookURLGoogleSheets
+		sheetURLExec := wsConn.Account().SheetURLGoogleSheetsExecutions
+		timezone := wsConn.Account().Timezone
 		for coin, execs := range byCoin {
 			coinCopy := coin
 			execsCopy := make([]ExecutionData, len(execs))
 			copy(execsCopy, execs)
-			go func() {
-				if err := wsm.sendGoogleSheetsExecutionWebhook(webhookURL, sheetURLExec, coinCopy, execsCopy); err != nil && logger != nil {
-					logger.Log("Erro ao enviar webhook de execuções para %s: %v", coinCopy, err)
+			ids := executionCorrelationIDs(execsCopy)
+			message := fmt.Sprintf("Execuções %s: %d linha(s)", coinCopy, len(execsCopy))
+			wsConn.enqueueDelivery(notifyChannelGoogleSheets, message, ids, func() {
+				err := deliverNotification(wsConn.AccountID, notifyChannelGoogleSheets, message, ids, func() error {
+					return wsm.sendGoogleSheetsExecutionWebhook(webhookURL, sheetURLExec, timezone, coinCopy, execsCopy)
+				})
+				if err != nil && logger != nil {
+					logger.Log("%s Erro ao enviar webhook de execuções para %s: %v", correlationTag(ids...), coinCopy, err)
 				}
-			}()
+			})
 		}
 	}
 }
 
-func (wsm *WebSocketManager) sendExecutionNotification(wsConn *WebSocketConnection, messageText string) {
+// sendExecutionNotification envia ao canal (o webhook de execuções ou o da rota da severidade) a mensagem já montada
+// por buildExecutionDiscordMessage.
+func (wsm *WebSocketManager) sendExecutionNotification(wsConn *WebSocketConnection, channel, discordMsg string, correlationIDs []string) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] sendExecutionNotification para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] sendExecutionNotification para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "sendExecutionNotification", r)
 		}
 	}()
-	if wsConn.Account.WebhookURLExecutions == "" {
+	if channelWebhookURL(wsConn.Account(), channel) == "" {
 		return
 	}
-	everyoneTag := ""
-	if wsConn.Account.MarkEveryoneExecution {
-		everyoneTag = "@everyone "
-	}
 
-	discordMsg := fmt.Sprintf("%s🔔 Execuções\n%s", everyoneTag, messageText)
-	if err := sendDiscordWebhook(wsConn.Account.WebhookURLExecutions, discordMsg); err != nil {
-		logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
-		if logger != nil {
-			logger.Log("Erro ao enviar webhook de execuções: %v", err)
-		}
+	err := deliverNotification(wsConn.AccountID, channel, discordMsg, correlationIDs, channelSender(wsConn.Account(), channel, discordMsg, "", correlationIDs))
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	if logger == nil {
+		return
+	}
+	if err != nil {
+		logger.Log("%s Erro ao enviar webhook de execuções (%s): %v", correlationTag(correlationIDs...), notifyChannelLabel(channel), err)
+	} else {
+		logger.Log("[DEBUG] %s Notificação enviada (%s)", correlationTag(correlationIDs...), notifyChannelLabel(channel))
 	}
 }
 
+// buildExecutionDiscordMessage monta o texto enviado ao webhook de execuções.
+func buildExecutionDiscordMessage(account *BybitAccount, messageText string) string {
+	everyoneTag := mentionTag(account, mentionExecution, account.MarkEveryoneExecution)
+	icons := accountIcons(account)
+	return icons.strip(fmt.Sprintf("%s%sExecuções\n%s", everyoneTag, icons.prefix(eventExecution, "header"), messageText))
+}
+
 // ExecutionRow representa uma linha no payload de execuções do Google Sheets.
 type ExecutionRow struct {
 	Columns []interface{} `json:"columns"`
 }
 
-func (wsm *WebSocketManager) sendGoogleSheetsExecutionWebhook(webhookURL, sheetURLExecutions, coin string, executions []ExecutionData) error {
+func (wsm *WebSocketManager) sendGoogleSheetsExecutionWebhook(webhookURL, sheetURLExecutions, timezone, coin string, executions []ExecutionData) error {
 	if webhookURL == "" || sheetURLExecutions == "" {
 		return fmt.Errorf("webhook URL ou sheet URL execuções está vazia")
 	}
@@ -2207,7 +3022,7 @@ func (wsm *WebSocketManager) sendGoogleSheetsExecutionWebhook(webhookURL, sheetU
 		qtyUsd, _ := strconv.ParseFloat(e.ExecQty, 64)
 		valCoin, _ := strconv.ParseFloat(e.ExecValue, 64)
 		columns := []interface{}{
-			formatExecTimeToBrasilia(e.ExecTime),
+			formatExecTime(e.ExecTime, timezone),
 			coin,
 			e.Side,
 			stopText + e.OrderType,
@@ -2217,6 +3032,10 @@ func (wsm *WebSocketManager) sendGoogleSheetsExecutionWebhook(webhookURL, sheetU
 		}
 		rows = append(rows, ExecutionRow{Columns: columns})
 	}
+	// Reprodução: as linhas são só impressas, a planilha de produção não recebe nada
+	if interceptReplayNotification(false, webhookURL, fmt.Sprintf("%s_exec %v", coin, rows)) {
+		return nil
+	}
 	payload := map[string]interface{}{
 		"sheet_id": sheetID,
 		"symbol":   coin + "_exec",
@@ -2233,27 +3052,50 @@ func (wsm *WebSocketManager) sendGoogleSheetsExecutionWebhook(webhookURL, sheetU
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("status code: %d", resp.StatusCode)
+		return &webhookStatusError{StatusCode: resp.StatusCode}
 	}
 	return nil
 }
 
-func (wsm *WebSocketManager) sendNotification(wsConn *WebSocketConnection, messageText string) {
-	wsm.sendNotificationWithType(wsConn, messageText, false, false)
+func (wsm *WebSocketManager) sendNotification(wsConn *WebSocketConnection, event, messageText string) {
+	wsm.sendNotificationWithType(wsConn, eventSeverity(wsConn.Account(), event), messageText, false, false, nil)
+}
+
+// sendNotificationWithType envia ao webhook principal ou, com severity_routes, aos canais da rota da severidade
+// (severity.go). correlationIDs são as mensagens do WebSocket que geraram o texto (nil = nenhuma, ex.: resumo da
+// carteira), registradas no histórico e no log.
+func (wsm *WebSocketManager) sendNotificationWithType(wsConn *WebSocketConnection, severity, messageText string, isOrder bool, isWallet bool, correlationIDs []string) {
+	wsm.sendNotificationWithImage(wsConn, severity, messageText, isOrder, isWallet, correlationIDs, "")
+}
+
+// sendNotificationWithImage é o sendNotificationWithType com uma imagem anexada à mensagem do Discord (ex.: gráfico
+// do resumo de carteira; "" = sem imagem).
+func (wsm *WebSocketManager) sendNotificationWithImage(wsConn *WebSocketConnection, severity, messageText string, isOrder bool, isWallet bool, correlationIDs []string, imageURL string) {
+	wsm.deliverToChannels(wsConn, severity, messageText, isOrder, isWallet, correlationIDs, imageURL, nil)
 }
 
-func (wsm *WebSocketManager) sendNotificationWithType(wsConn *WebSocketConnection, messageText string, isOrder bool, isWallet bool) {
+// sendOrderMessage envia a mensagem de nova ordem sozinha, guardando o ID enviado a cada canal do Discord para
+// editá-la quando as ordens forem executadas ou canceladas (ordermessages.go).
+func (wsm *WebSocketManager) sendOrderMessage(wsConn *WebSocketConnection, severity, messageText string, orders []OrderData) {
+	tracked := wsConn.orderMessages.track(orders)
+	wsm.deliverToChannels(wsConn, severity, messageText, true, false, orderCorrelationIDs(orders), "", tracked)
+}
+
+// deliverToChannels enfileira a mensagem para os canais da severidade; com tracked, a mensagem é acompanhada para
+// edição.
+func (wsm *WebSocketManager) deliverToChannels(wsConn *WebSocketConnection, severity, messageText string, isOrder bool, isWallet bool, correlationIDs []string, imageURL string, tracked *orderMessage) {
 	// Capturar panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] sendNotification para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] sendNotification para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "sendNotification", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em sendNotification: %v", r)
 				}
@@ -2261,38 +3103,72 @@ func (wsm *WebSocketManager) sendNotificationWithType(wsConn *WebSocketConnectio
 		}
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
-	
-	alertIcon := "🔔" // Altere aqui para escolher outro ícone
-	
-	// Verificar se deve adicionar @everyone
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+
+	for _, channel := range notificationChannels(wsConn.Account(), severity, notifyChannelDiscord) {
+		// Enviar para Discord pela fila de envio para não bloquear o fluxo principal
+		channel := channel
+		if !wsm.admitNotification(wsConn, channel, messageText, correlationIDs) {
+			if tracked != nil {
+				// Abertura acumulada no resumo: o cancelamento ou a execução sai como notificação normal
+				wsConn.orderMessages.addChannel(tracked, channel, false)
+			}
+			continue
+		}
+		discordMsg := buildDiscordMessage(wsConn.Account(), messageText, isOrder, isWallet)
+		account := wsConn.Account()
+		editable := tracked != nil && channel == notifyChannelDiscordBot && !isLogOnly(account)
+		if tracked != nil {
+			wsConn.orderMessages.addChannel(tracked, channel, editable)
+		}
+		wsConn.enqueueDelivery(channel, discordMsg, correlationIDs, func() {
+			send := channelSender(account, channel, discordMsg, imageURL, correlationIDs)
+			if editable {
+				send = wsConn.orderMessages.sender(account, channel, discordMsg, tracked)
+			}
+			err := deliverNotification(account.ID, channel, discordMsg, correlationIDs, send)
+			if logger == nil {
+				return
+			}
+			tag := ""
+			if len(correlationIDs) > 0 {
+				tag = correlationTag(correlationIDs...) + " "
+			}
+			if err != nil {
+				logger.Log("%sErro ao enviar webhook, notificação: %s", tag, messageText)
+			} else if tag != "" {
+				logger.Log("[DEBUG] %sNotificação enviada (%s)", tag, notifyChannelLabel(channel))
+			}
+		})
+	}
+	// Quando não há webhook (ou a severidade é suprimida), não fazer nada (não logar nem imprimir)
+}
+
+// buildDiscordMessage monta o texto enviado ao webhook principal: @everyone (se configurado), ícone, mensagem e horário.
+func buildDiscordMessage(account *BybitAccount, messageText string, isOrder bool, isWallet bool) string {
+	icons := accountIcons(account)
+
+	// Verificar se deve adicionar @everyone (ou as menções da preferência mention)
 	everyoneTag := ""
-	if isOrder && wsConn.Account.MarkEveryoneOrder {
-		everyoneTag = "@everyone "
-	} else if isWallet && wsConn.Account.MarkEveryoneWallet {
-		everyoneTag = "@everyone "
+	if isOrder {
+		everyoneTag = mentionTag(account, mentionOrder, account.MarkEveryoneOrder)
+	} else if isWallet {
+		everyoneTag = mentionTag(account, mentionWallet, account.MarkEveryoneWallet)
 	}
-	
-	// Obter data/hora atual no horário de Brasília (funciona no Windows e Linux)
-	now := getBrasiliaTime()
-	timeStamp := fmt.Sprintf("🕘  %s - %s (Horário de Brasília)",
-		now.Format("02/01/2006"),
-		now.Format("15:04"))
-	
-	if wsConn.Account.WebhookURL != "" {
-		// Enviar para Discord em goroutine para não bloquear o fluxo principal
-		// Discord remove quebras de linha no início, então precisamos ter conteúdo antes
-		webhookURL := wsConn.Account.WebhookURL
-		discordMsg := fmt.Sprintf("%s%s\n%s\n\n%s", everyoneTag, alertIcon, messageText, timeStamp)
-		go func() {
-			if err := sendDiscordWebhook(webhookURL, discordMsg); err != nil {
-				if logger != nil {
-					logger.Log("Erro ao enviar webhook, notificação: %s", messageText)
-				}
-			}
-		}()
+
+	// Obter data/hora atual no fuso da conta (padrão: horário de Brasília)
+	now := getAccountTime(account.Timezone)
+	timeStamp := strings.TrimSpace(fmt.Sprintf("%s %s - %s (%s)",
+		icons.prefix("", "clock"), now.Format("02/01/2006"),
+		now.Format("15:04"),
+		timezoneLabel(account.Timezone)))
+
+	// Discord remove quebras de linha no início: sem ícone nem menção, a mensagem começa direto no texto
+	header := strings.TrimSpace(everyoneTag + icons.get("", "header"))
+	if header == "" {
+		return icons.strip(fmt.Sprintf("%s\n\n%s", messageText, timeStamp))
 	}
-	// Quando não há webhook, não fazer nada (não logar nem imprimir)
+	return icons.strip(fmt.Sprintf("%s\n%s\n\n%s", header, messageText, timeStamp))
 }
 
 func min(a, b int) int {
@@ -2303,26 +3179,102 @@ func min(a, b int) int {
 }
 
 func sendDiscordWebhook(webhookURL, message string) error {
-	payload := map[string]string{
-		"content": message,
-	}
+	return sendDiscordWebhookWithImage(webhookURL, message, "")
+}
 
-	jsonData, err := json.Marshal(payload)
+// sendDiscordWebhookWithImage envia a mensagem com uma imagem (URL) no embed; sem imageURL, só o texto.
+func sendDiscordWebhookWithImage(webhookURL, message, imageURL string) error {
+	if interceptReplayNotification(true, webhookURL, message) {
+		return nil
+	}
+	jsonData, err := json.Marshal(discordMessagePayload(message, imageURL))
 	if err != nil {
 		return err
 	}
+	_, err = discordWebhookRequest(http.MethodPost, webhookURL, webhookURL, jsonData)
+	return err
+}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewReader(jsonData))
-	if err != nil {
-		return err
+// discordMessagePayload monta o corpo de uma mensagem do Discord (webhook ou bot): o texto e, com imageURL, a imagem
+// no embed.
+func discordMessagePayload(message, imageURL string) map[string]interface{} {
+	payload := map[string]interface{}{
+		"content": message,
 	}
-	defer resp.Body.Close()
+	if imageURL != "" {
+		payload["embeds"] = []interface{}{map[string]interface{}{"image": map[string]string{"url": imageURL}}}
+	}
+	return payload
+}
 
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("status code: %d", resp.StatusCode)
+// discordWebhookTimeout é o prazo de uma chamada ao webhook do Discord; sem ele, uma conexão presa seguraria a fila
+// do webhook (e as outras entregas para ele) indefinidamente.
+const discordWebhookTimeout = 10 * time.Second
+
+// discordWebhookRequest faz a chamada à API do webhook (requestURL: o próprio webhook ou uma mensagem dele) e retorna
+// o corpo da resposta.
+func discordWebhookRequest(method, webhookURL, requestURL string, jsonData []byte) ([]byte, error) {
+	return discordRequest(method, webhookURL, requestURL, "", jsonData, discordWebhookStatusHint)
+}
+
+// discordRequest faz a chamada à API do Discord e retorna o corpo da resposta. bucketKey identifica a fila do limite de
+// envio (o webhook ou o canal do bot); authorization vai no cabeçalho Authorization ("" = webhook, sem cabeçalho) e
+// statusHint explica os status de erro.
+func discordRequest(method, bucketKey, requestURL, authorization string, jsonData []byte, statusHint func(int) string) ([]byte, error) {
+	// Uma chamada por vez ao webhook, respeitando o limite do Discord (discordratelimit.go)
+	bucket := discordBucketFor(bucketKey)
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	client := &http.Client{Timeout: discordWebhookTimeout}
+	for attempt := 1; ; attempt++ {
+		bucket.wait()
+		req, err := http.NewRequest(method, requestURL, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		retryAfter, limited := bucket.update(resp)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if limited && attempt <= discordRateLimitMaxRetries && retryAfter <= discordRateLimitMaxWait {
+			continue
+		}
+
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+			return nil, &webhookStatusError{StatusCode: resp.StatusCode, Hint: statusHint(resp.StatusCode)}
+		}
+		return body, nil
 	}
+}
 
-	return nil
+// discordWebhookStatusHint explica os status mais comuns de um webhook do Discord mal configurado.
+func discordWebhookStatusHint(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return " - token do webhook inválido"
+	case http.StatusNotFound:
+		return " - webhook não existe (foi excluído ou a URL está incompleta)"
+	case http.StatusTooManyRequests:
+		return " - limite de envio do Discord atingido"
+	}
+	return ""
+}
+
+// validateDiscordWebhookURL valida se a URL do webhook do Discord está no formato correto
+func validateDiscordWebhookURL(url string) bool {
+	if url == "" {
+		return true // URL vazia é válida (opcional)
+	}
+	// Validar formato: https://discord.com/api/webhooks/{id}/{token} (aceita discordapp.com, ptb. e canary.)
+	matched, _ := regexp.MatchString(`^https://(?:ptb\.|canary\.)?discord(?:app)?\.com/api(?:/v\d+)?/webhooks/\d+/[A-Za-z0-9_.-]+/?$`, url)
+	return matched
 }
 
 // validateGoogleSheetsWebhookURL valida se a URL do webhook do Google Sheets está no formato correto
@@ -2366,6 +3318,9 @@ func sendGoogleSheetsWebhook(webhookURL, sheetURL, symbol string, columns []inte
 	if webhookURL == "" || sheetURL == "" {
 		return fmt.Errorf("webhook URL ou sheet URL está vazia")
 	}
+	if interceptReplayNotification(false, webhookURL, fmt.Sprintf("%s %v", symbol, columns)) {
+		return nil
+	}
 
 	// Extrair ID da planilha
 	sheetID, err := extractSheetID(sheetURL)
@@ -2397,8 +3352,171 @@ func sendGoogleSheetsWebhook(webhookURL, sheetURL, symbol string, columns []inte
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("status code: %d", resp.StatusCode)
+		return &webhookStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// Canais de notificação (usados no teste, no histórico e no reenvio)
+const (
+	notifyChannelDiscord      = "discord"
+	notifyChannelExecutions   = "execucoes"
+	notifyChannelGoogleSheets = "planilha"
+	notifyChannelConnection   = "conexao" // alertas de conexão (connalerts.go)
+)
+
+// testNotificationChannels retorna os canais configurados na conta, na ordem em que são exibidos.
+func testNotificationChannels(account *BybitAccount) []string {
+	var channels []string
+	if account.WebhookURL != "" {
+		channels = append(channels, notifyChannelDiscord)
+	}
+	if _, ok := accountDiscordBot(account); ok {
+		channels = append(channels, notifyChannelDiscordBot)
+	}
+	if account.WebhookURLExecutions != "" {
+		channels = append(channels, notifyChannelExecutions)
+	}
+	if account.WebhookURLGoogleSheets != "" && account.SheetURLGoogleSheets != "" {
+		channels = append(channels, notifyChannelGoogleSheets)
+	}
+	if connectionAlertsWebhook(account) != "" {
+		channels = append(channels, notifyChannelConnection)
+	}
+	if _, ok := accountGenericWebhook(account); ok {
+		channels = append(channels, notifyChannelGeneric)
+	}
+	return append(channels, accountSharedChannels(account)...)
+}
+
+// notifyChannelLabel retorna o nome do canal para exibição.
+func notifyChannelLabel(channel string) string {
+	switch channel {
+	case notifyChannelDiscord:
+		return "Discord (ordens/carteira)"
+	case notifyChannelExecutions:
+		return "Discord (execuções)"
+	case notifyChannelGoogleSheets:
+		return "Google Planilhas"
+	case notifyChannelConnection:
+		return "Discord (conexão)"
+	case notifyChannelGeneric:
+		return "Webhook genérico"
+	case notifyChannelDiscordBot:
+		return "Discord (bot)"
+	}
+	if name, ok := sharedChannelName(channel); ok {
+		return "Discord (compartilhado " + name + ")"
+	}
+	return channel
+}
+
+// sendTestNotification envia uma mensagem sintética pelo mesmo caminho das notificações reais (deliverNotification:
+// novas tentativas, histórico e arquivo de notificações) e espera o resultado, para que erros de configuração do
+// webhook apareçam na hora. Com a conta monitorada por wsm, a mensagem também passa pelo limite do canal e pela fila
+// de envio da conta (wsm nil = subcomando, sem monitoramento).
+func sendTestNotification(wsm *WebSocketManager, account *BybitAccount, channel string) error {
+	messageText := fmt.Sprintf("🧪 Notificação de teste da conta **%s**\nSe você está vendo esta mensagem, o webhook está configurado corretamente.", account.Name)
+
+	var message string
+	var send func() error
+	switch channel {
+	case notifyChannelDiscord:
+		if account.WebhookURL == "" {
+			return fmt.Errorf("webhook do Discord não configurado")
+		}
+		message = buildDiscordMessage(account, messageText, false, false)
+	case notifyChannelDiscordBot:
+		if _, ok := accountDiscordBot(account); !ok {
+			return fmt.Errorf("bot do Discord não configurado")
+		}
+		message = buildDiscordMessage(account, messageText, false, false)
+	case notifyChannelExecutions:
+		if account.WebhookURLExecutions == "" {
+			return fmt.Errorf("webhook de execuções não configurado")
+		}
+		message = buildExecutionDiscordMessage(account, messageText)
+	case notifyChannelGoogleSheets:
+		now := getAccountTime(account.Timezone)
+		columns := []interface{}{now.Format("02/01/2006 15:04:05"), "Notificação de teste"}
+		headers := []string{"Data", "Mensagem"}
+		message = "Notificação de teste"
+		send = func() error {
+			return sendGoogleSheetsWebhook(account.WebhookURLGoogleSheets, account.SheetURLGoogleSheets, "TESTE", columns, headers)
+		}
+	case notifyChannelConnection:
+		if connectionAlertsWebhook(account) == "" {
+			return fmt.Errorf("webhook de alertas de conexão não configurado")
+		}
+		message = buildDiscordMessage(account, messageText, false, false)
+	case notifyChannelGeneric:
+		if _, ok := accountGenericWebhook(account); !ok {
+			return fmt.Errorf("webhook genérico não configurado")
+		}
+		message = messageText
+	default:
+		if _, shared := sharedChannelName(channel); !shared || channelWebhookURL(account, channel) == "" {
+			return fmt.Errorf("canal desconhecido: %s", channel)
+		}
+		message = buildDiscordMessage(account, messageText, false, false)
+	}
+	if send == nil {
+		send = channelSender(account, channel, message, "", nil)
+	}
+
+	var err error
+	if wsConn := wsm.testNotificationConnection(account.ID); wsConn != nil {
+		err = wsm.deliverTestNotification(wsConn, channel, messageText, message, send)
+	} else {
+		err = deliverNotification(account.ID, channel, message, nil, send)
+	}
+	if err == nil && channel != notifyChannelGoogleSheets && isLogOnly(account) {
+		// Registrada no histórico sem envio (logonly.go): o webhook não foi testado
+		err = errLogOnly
+	}
+
+	if logger, _ := getLogger(account.ID, account.Name); logger != nil {
+		if err != nil {
+			logger.Log("Notificação de teste (%s) falhou: %v", notifyChannelLabel(channel), err)
+		} else {
+			logger.Log("Notificação de teste (%s) enviada", notifyChannelLabel(channel))
+		}
 	}
+	return err
+}
 
+// testNotificationConnection retorna a conexão ativa da conta, pela qual a notificação de teste é enviada (nil = conta
+// não monitorada ou wsm nil).
+func (wsm *WebSocketManager) testNotificationConnection(accountID int64) *WebSocketConnection {
+	if wsm == nil {
+		return nil
+	}
+	wsm.mu.RLock()
+	defer wsm.mu.RUnlock()
+	if conn, exists := wsm.connections[accountID]; exists && conn.running() {
+		return conn
+	}
 	return nil
 }
+
+// deliverTestNotification envia a notificação de teste pelo limite do canal e pela fila de envio da conexão, como
+// deliverToChannels, e espera o resultado da entrega.
+func (wsm *WebSocketManager) deliverTestNotification(wsConn *WebSocketConnection, channel, messageText, message string, send func() error) error {
+	// A planilha não passa pelo limite por canal (como em processSheetsNotification)
+	if channel != notifyChannelGoogleSheets && !wsm.admitNotification(wsConn, channel, messageText, nil) {
+		return fmt.Errorf("limite de %d notificações por minuto do canal atingido; a notificação de teste sairá no resumo", channelRateLimit(wsConn.Account(), channel))
+	}
+	done := make(chan error, 1)
+	if !wsConn.enqueueDelivery(channel, message, nil, func() {
+		done <- deliverNotification(wsConn.AccountID, channel, message, nil, send)
+	}) {
+		return errors.New("descartada pela fila de envio da conta (veja o histórico de notificações)")
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-wsConn.deliveryCtx.Done():
+		return errors.New("monitoramento parado antes do envio")
+	}
+}
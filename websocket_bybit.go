@@ -1,9 +1,7 @@
 package main
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -20,14 +18,15 @@ const bybitWSURL = "wss://stream.bybit.com/v5/private"
 func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection, successChan chan<- bool) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] connectAndListenBybit para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] connectAndListenBybit para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "connectAndListenBybit", r)
 			func() {
 				defer func() {
 					if r2 := recover(); r2 != nil {
 						fmt.Fprintf(os.Stderr, "ERRO ao tentar logar o panic: %v\n", r2)
 					}
 				}()
-				logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
+				logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
 				if logger != nil {
 					logger.Log("PANIC em connectAndListenBybit: %v", r)
 				}
@@ -36,16 +35,16 @@ func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection,
 		}
 	}()
 
-	logger, logErr := getLogger(wsConn.AccountID, wsConn.Account.Name)
+	logger, logErr := getLogger(wsConn.AccountID, wsConn.Account().Name)
 	if logErr != nil {
 		fmt.Fprintf(os.Stderr, "ERRO: Não foi possível criar logger para conta %d: %v\n", wsConn.AccountID, logErr)
 	}
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+	endpoint := wsConn.nextEndpoint()
+	conn, closeConn, err := dialStream(wsConn.ctx, endpoint)
+	if wsConn.ctx.Err() == nil {
+		wsm.noteDialResult(wsConn, endpoint, err)
 	}
-
-	conn, _, err := dialer.Dial(bybitWSURL, nil)
 	if err != nil {
 		if logger != nil {
 			logger.Log("Erro ao conectar: %v", err)
@@ -67,10 +66,12 @@ func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection,
 			wsConn.Conn = nil
 		}
 		wsConn.mu.Unlock()
-		conn.Close()
+		closeConn()
 	}()
 
-	if err := wsm.authenticateBybit(conn, wsConn.Account); err != nil {
+	// expires da autenticação no relógio da Bybit (timesync.go)
+	wsm.syncBybitClock(wsConn)
+	if err := wsm.authenticateBybit(conn, wsConn.Account()); err != nil {
 		if logger != nil {
 			logger.Log("Erro na autenticação: %v", err)
 		}
@@ -79,11 +80,16 @@ func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection,
 
 	time.Sleep(1 * time.Second)
 
-	subscribeMsg := map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{"order", "execution", "position", "wallet"},
-	}
-	if err := conn.WriteJSON(subscribeMsg); err != nil {
+	// Um tópico por requisição, com o tópico no req_id, para saber qual foi confirmado (subscriptions.go)
+	subscriptions := newSubscriptionTracker(wsConn.ctx, wsm, wsConn, rawStreamBybitPrivate, bybitPrivateTopics, func(topic string) error {
+		return conn.WriteJSON(map[string]interface{}{
+			"req_id": bybitSubscribeReqPrefix + topic,
+			"op":     "subscribe",
+			"args":   []string{topic},
+		})
+	})
+	defer subscriptions.stop()
+	if err := subscriptions.start(); err != nil {
 		if logger != nil {
 			logger.Log("Erro ao inscrever: %v", err)
 		}
@@ -100,23 +106,24 @@ func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection,
 	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	conn.SetPongHandler(func(string) error {
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		wsConn.touch()
 		return nil
 	})
 
-	pingStopChan := make(chan struct{})
-	go wsm.pingLoop(conn, pingStopChan)
-	defer close(pingStopChan)
+	pingCtx, stopPing := context.WithCancel(wsConn.ctx)
+	defer stopPing()
+	wsConn.spawn(goroutinePing, func() { pingLoop(pingCtx, conn) })
 
 	for {
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return nil
 		default:
 			conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 			messageType, message, err := conn.ReadMessage()
 			if err != nil {
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return nil
 				default:
 				}
@@ -144,8 +151,11 @@ func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection,
 				wsConn.mu.Unlock()
 				return fmt.Errorf("erro na leitura: %w", err)
 			}
+			wsConn.noteFrame(len(message))
 			if messageType == websocket.TextMessage {
-				wsm.handleMessage(wsConn, message)
+				correlationID := newCorrelationID()
+				captureRawMessage(wsConn.AccountID, rawStreamBybitPrivate, correlationID, message)
+				wsConn.enqueueMessage(func() { wsm.handleMessage(wsConn, correlationID, message) })
 			}
 		}
 	}
@@ -153,12 +163,12 @@ func (wsm *WebSocketManager) connectAndListenBybit(wsConn *WebSocketConnection,
 
 func (wsm *WebSocketManager) authenticateBybit(conn *websocket.Conn, account *BybitAccount) error {
 	apiKey := strings.TrimSpace(account.APIKey)
-	apiSecret := strings.TrimSpace(account.APISecret)
-	expires := time.Now().UnixNano()/1e6 + 10000
-	signatureString := fmt.Sprintf("GET/realtime%d", expires)
-	mac := hmac.New(sha256.New, []byte(apiSecret))
-	mac.Write([]byte(signatureString))
-	signature := hex.EncodeToString(mac.Sum(nil))
+	expires := bybitNow().UnixMilli() + 10000
+	signature, err := bybitSign(account, fmt.Sprintf("GET/realtime%d", expires))
+	if err != nil {
+		// Chave RSA ilegível: repetir não resolve, conta como recusa da autenticação
+		return &authRejectedError{Reason: err.Error(), msg: fmt.Sprintf("autenticação falhou: %v", err)}
+	}
 	authMsg := map[string]interface{}{
 		"req_id": uuid.New().String(),
 		"op":     "auth",
@@ -178,7 +188,15 @@ func (wsm *WebSocketManager) authenticateBybit(conn *websocket.Conn, account *By
 	}
 	if success, ok := authResponse["success"].(bool); ok && !success {
 		retMsg, _ := authResponse["ret_msg"].(string)
-		return fmt.Errorf("autenticação falhou: %s (resposta: %v)", retMsg, authResponse)
+		if isIPRestrictionMessage(retMsg) {
+			return fmt.Errorf("%w (%s)", errIPNotAllowed, retMsg)
+		}
+		if isExpiredAuthMessage(retMsg) {
+			// Relógio desviado desde a última medição: não é problema da key, mede de novo na próxima tentativa
+			invalidateBybitClock()
+			return fmt.Errorf("autenticação recusada por horário (%s); o horário da Bybit será consultado de novo", retMsg)
+		}
+		return &authRejectedError{Reason: retMsg, msg: fmt.Sprintf("autenticação falhou: %s (resposta: %v)", retMsg, authResponse)}
 	}
 	if success, ok := authResponse["success"].(bool); ok && success {
 		logger, _ := getLogger(account.ID, account.Name)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -50,13 +51,14 @@ func getOKXPassphrase(metadata string) (string, error) {
 func (wsm *WebSocketManager) connectAndListenOKX(wsConn *WebSocketConnection, successChan chan<- bool) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "[PANIC] connectAndListenOKX para conta %d: %v\n", wsConn.AccountID, r)
+			fmt.Fprintf(os.Stderr, "[PANIC] connectAndListenOKX para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+			reportPanic(wsConn.AccountID, wsConn.Account().Name, "connectAndListenOKX", r)
 			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
-	passphrase, err := getOKXPassphrase(wsConn.Account.Metadata)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	passphrase, err := getOKXPassphrase(wsConn.Account().Metadata)
 	if err != nil {
 		if logger != nil {
 			logger.Log("OKX passphrase inválido: %v", err)
@@ -64,8 +66,7 @@ func (wsm *WebSocketManager) connectAndListenOKX(wsConn *WebSocketConnection, su
 		return err
 	}
 
-	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
-	conn, _, err := dialer.Dial(okxPrivateWSURL, nil)
+	conn, closeConn, err := dialStream(wsConn.ctx, okxPrivateWSURL)
 	if err != nil {
 		if logger != nil {
 			logger.Log("Erro ao conectar OKX: %v", err)
@@ -87,10 +88,11 @@ func (wsm *WebSocketManager) connectAndListenOKX(wsConn *WebSocketConnection, su
 			wsConn.Conn = nil
 		}
 		wsConn.mu.Unlock()
-		conn.Close()
+		closeConn()
 	}()
 
-	if err := wsm.loginOKX(conn, wsConn.Account, passphrase); err != nil {
+	syncOKXClock(wsConn)
+	if err := wsm.loginOKX(conn, wsConn.Account(), passphrase); err != nil {
 		if logger != nil {
 			logger.Log("Erro no login OKX: %v", err)
 		}
@@ -99,7 +101,11 @@ func (wsm *WebSocketManager) connectAndListenOKX(wsConn *WebSocketConnection, su
 
 	time.Sleep(500 * time.Millisecond)
 
-	if err := wsm.subscribeOKX(conn); err != nil {
+	subscriptions := newSubscriptionTracker(wsConn.ctx, wsm, wsConn, rawStreamOKXPrivate, okxPrivateChannels, func(channel string) error {
+		return subscribeOKX(conn, channel)
+	})
+	defer subscriptions.stop()
+	if err := subscriptions.start(); err != nil {
 		if logger != nil {
 			logger.Log("Erro ao inscrever OKX: %v", err)
 		}
@@ -120,23 +126,24 @@ func (wsm *WebSocketManager) connectAndListenOKX(wsConn *WebSocketConnection, su
 	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	conn.SetPongHandler(func(string) error {
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		wsConn.touch()
 		return nil
 	})
 
-	pingStopChan := make(chan struct{})
-	go wsm.pingLoop(conn, pingStopChan)
-	defer close(pingStopChan)
+	pingCtx, stopPing := context.WithCancel(wsConn.ctx)
+	defer stopPing()
+	wsConn.spawn(goroutinePing, func() { pingLoop(pingCtx, conn) })
 
 	for {
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return nil
 		default:
 			conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 			messageType, message, err := conn.ReadMessage()
 			if err != nil {
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return nil
 				default:
 				}
@@ -153,17 +160,20 @@ func (wsm *WebSocketManager) connectAndListenOKX(wsConn *WebSocketConnection, su
 				wsConn.mu.Unlock()
 				return fmt.Errorf("erro na leitura OKX: %w", err)
 			}
+			wsConn.noteFrame(len(message))
 			if messageType != websocket.TextMessage {
 				continue
 			}
-			wsm.handleOKXMessage(wsConn, message, logger)
+			correlationID := newCorrelationID()
+			captureRawMessage(wsConn.AccountID, rawStreamOKXPrivate, correlationID, message)
+			wsConn.enqueueMessage(func() { wsm.handleOKXMessage(wsConn, correlationID, message, logger) })
 		}
 	}
 }
 
-// loginOKX envia op "login" com apiKey, passphrase, timestamp (segundos), sign (Base64 HMAC-SHA256).
+// loginOKX envia op "login" com apiKey, passphrase, timestamp (segundos, no relógio da OKX), sign (Base64 HMAC-SHA256).
 func (wsm *WebSocketManager) loginOKX(conn *websocket.Conn, account *BybitAccount, passphrase string) error {
-	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	ts := strconv.FormatInt(okxNow().Unix(), 10)
 	prehash := ts + "GET" + "/users/self/verify"
 	sign := signOKX(prehash, strings.TrimSpace(account.APISecret))
 	loginMsg := map[string]interface{}{
@@ -188,7 +198,15 @@ func (wsm *WebSocketManager) loginOKX(conn *websocket.Conn, account *BybitAccoun
 	if event, _ := resp["event"].(string); event == "error" {
 		code, _ := resp["code"].(string)
 		msg, _ := resp["msg"].(string)
-		return fmt.Errorf("login OKX falhou: %s %s", code, msg)
+		if isIPRestrictionMessage(code + " " + msg) {
+			return fmt.Errorf("%w (%s %s)", errIPNotAllowed, code, msg)
+		}
+		if okxTimestampCodes[code] {
+			// Relógio desviado: não é problema da key, o horário da OKX é medido antes da próxima tentativa
+			invalidateOKXClock()
+			return fmt.Errorf("login OKX recusado por horário (%s %s); o horário da OKX será consultado de novo", code, msg)
+		}
+		return &authRejectedError{Reason: strings.TrimSpace(code + " " + msg), msg: fmt.Sprintf("login OKX falhou: %s %s", code, msg)}
 	}
 	if event, _ := resp["event"].(string); event != "login" {
 		return fmt.Errorf("resposta de login inesperada: %v", resp)
@@ -202,29 +220,51 @@ func signOKX(prehash, secret string) string {
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
-// subscribeOKX inscreve em account, positions (SWAP), orders (SWAP). Sem instFamily.
-func (wsm *WebSocketManager) subscribeOKX(conn *websocket.Conn) error {
-	args := []map[string]interface{}{
-		{"channel": "account", "extraParams": "{\"updateInterval\":\"0\"}"},
-		{"channel": "positions", "instType": "SWAP"},
-		{"channel": "orders", "instType": "SWAP"},
-	}
+// okxSubscribeArgs são os argumentos de inscrição de cada canal: account, positions (SWAP) e orders (SWAP) no
+// endpoint privado; orders-algo (trigger/stops) no endpoint business. Sem instFamily.
+var okxSubscribeArgs = map[string]map[string]interface{}{
+	"account":     {"channel": "account", "extraParams": "{\"updateInterval\":\"0\"}"},
+	"positions":   {"channel": "positions", "instType": "SWAP"},
+	"orders":      {"channel": "orders", "instType": "SWAP"},
+	"orders-algo": {"channel": "orders-algo", "instType": "SWAP"},
+}
+
+var (
+	okxPrivateChannels  = []string{"account", "positions", "orders"}
+	okxBusinessChannels = []string{"orders-algo"}
+)
+
+// subscribeOKX inscreve a conexão em um canal (uma requisição por canal, para acompanhar a confirmação de cada um).
+func subscribeOKX(conn *websocket.Conn, channel string) error {
 	msg := map[string]interface{}{
 		"op":   "subscribe",
-		"args": args,
+		"args": []map[string]interface{}{okxSubscribeArgs[channel]},
 	}
 	return conn.WriteJSON(msg)
 }
 
-// subscribeOKXBusiness inscreve no canal orders-algo (trigger/stops) no endpoint business.
-func (wsm *WebSocketManager) subscribeOKXBusiness(conn *websocket.Conn) error {
-	msg := map[string]interface{}{
-		"op": "subscribe",
-		"args": []map[string]interface{}{
-			{"channel": "orders-algo", "instType": "SWAP"},
-		},
+// handleOKXEvent trata as respostas de controle da OKX (login, inscrição, erro). Retorna false se não era uma.
+func handleOKXEvent(wsConn *WebSocketConnection, stream string, generic map[string]interface{}, logger interface{ Log(string, ...interface{}) }) bool {
+	event, _ := generic["event"].(string)
+	switch event {
+	case "subscribe":
+		arg, _ := generic["arg"].(map[string]interface{})
+		channel, _ := arg["channel"].(string)
+		if tracker := wsConn.subscriptionsFor(stream); tracker != nil {
+			tracker.ack(channel, true, "")
+		}
+	case "error":
+		// A OKX não informa o canal no erro de inscrição; a falta de confirmação faz o reenvio
+		code, _ := generic["code"].(string)
+		msg, _ := generic["msg"].(string)
+		if logger != nil {
+			logger.Log("⚠️ Erro da OKX (%s): %s %s", stream, code, msg)
+		}
+	case "login", "unsubscribe", "channel-conn-count", "channel-conn-count-error":
+	default:
+		return false
 	}
-	return conn.WriteJSON(msg)
+	return true
 }
 
 // ensureOKXBusinessConnection inicia a goroutine de conexão business (orders-algo) apenas se ainda não
@@ -239,11 +279,11 @@ func (wsm *WebSocketManager) ensureOKXBusinessConnection(wsConn *WebSocketConnec
 	okxBusinessRunning[wsConn.AccountID] = true
 	okxBusinessMu.Unlock()
 
-	go wsm.runOKXBusinessWithRetry(wsConn, passphrase)
+	wsConn.spawn(goroutineOKXBusiness, func() { wsm.runOKXBusinessWithRetry(wsConn, passphrase) })
 }
 
 // runOKXBusinessWithRetry mantém a conexão OKX business com o mesmo processo de reconexão da principal
-// (backoff exponencial, retries). Sai apenas quando wsConn.StopChan for fechado.
+// (backoff exponencial, retries). Sai apenas quando o contexto da conta for cancelado.
 func (wsm *WebSocketManager) runOKXBusinessWithRetry(wsConn *WebSocketConnection, passphrase string) {
 	defer func() {
 		okxBusinessMu.Lock()
@@ -251,16 +291,15 @@ func (wsm *WebSocketManager) runOKXBusinessWithRetry(wsConn *WebSocketConnection
 		okxBusinessMu.Unlock()
 	}()
 
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
-	retryDelay := 5 * time.Second
-	maxRetryDelay := 1 * time.Minute
-	initialRetryDelay := retryDelay
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	// Mesma política da conexão principal; avisos de configuração inválida já saem no runConnection
+	policy, _ := loadReconnectPolicy(wsConn.Account().Settings)
+	retryDelay := policy.InitialDelay
 	consecutiveFailures := 0
-	const maxConsecutiveFailures = 10
 
 	for {
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return
 		default:
 		}
@@ -269,7 +308,8 @@ func (wsm *WebSocketManager) runOKXBusinessWithRetry(wsConn *WebSocketConnection
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
-					fmt.Fprintf(os.Stderr, "[PANIC] OKX business para conta %d: %v\n", wsConn.AccountID, r)
+					fmt.Fprintf(os.Stderr, "[PANIC] OKX business para conta %d: %v\n", wsConn.AccountID, redactSecrets(fmt.Sprint(r)))
+					reportPanic(wsConn.AccountID, wsConn.Account().Name, "OKX business", r)
 					if logger != nil {
 						logger.Log("PANIC na conexão OKX business (reiniciando fluxo de reconexão): %v", r)
 					}
@@ -284,14 +324,14 @@ func (wsm *WebSocketManager) runOKXBusinessWithRetry(wsConn *WebSocketConnection
 		}
 
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return
 		default:
 		}
 
 		if wasConnected {
 			consecutiveFailures = 0
-			retryDelay = initialRetryDelay
+			retryDelay = policy.InitialDelay
 		} else {
 			consecutiveFailures++
 		}
@@ -299,46 +339,44 @@ func (wsm *WebSocketManager) runOKXBusinessWithRetry(wsConn *WebSocketConnection
 			logger.Log("Conexão OKX business caiu, reconectando em %v (falhas consecutivas: %d)...", retryDelay, consecutiveFailures)
 		}
 
-		if consecutiveFailures >= maxConsecutiveFailures {
+		if policy.CleanupFailures > 0 && consecutiveFailures >= policy.CleanupFailures {
 			if logger != nil {
-				logger.Log("Muitas falhas consecutivas na OKX business (%d), aguardando 30s antes de reconectar...", consecutiveFailures)
+				logger.Log("Muitas falhas consecutivas na OKX business (%d), aguardando %s antes de reconectar...", consecutiveFailures, policy.CleanupPause)
 			}
 			consecutiveFailures = 0
-			retryDelay = initialRetryDelay
+			retryDelay = policy.InitialDelay
 			select {
-			case <-wsConn.StopChan:
+			case <-wsConn.ctx.Done():
 				return
-			case <-time.After(30 * time.Second):
+			case <-time.After(policy.CleanupPause):
 			}
 			continue
 		}
 
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return
 		case <-time.After(retryDelay):
-			if retryDelay < maxRetryDelay {
-				retryDelay *= 2
-			}
+			retryDelay = policy.nextDelay(retryDelay)
 		}
 	}
 }
 
 // connectAndListenOKXBusiness conecta ao WebSocket business da OKX, faz login, inscreve em orders-algo e lê mensagens.
-// Retorna (stopped=true) se saiu por StopChan; (stopped=false, wasConnected=X) se saiu por erro (wasConnected indica se já tinha conectado, para resetar backoff).
+// Retorna (stopped=true) se saiu pelo cancelamento do contexto da conta; (stopped=false, wasConnected=X) se saiu por erro (wasConnected indica se já tinha conectado, para resetar backoff).
 func (wsm *WebSocketManager) connectAndListenOKXBusiness(wsConn *WebSocketConnection, passphrase string) (stopped bool, wasConnected bool) {
-	logger, _ := getLogger(wsConn.AccountID, wsConn.Account.Name)
-	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
-	conn, _, err := dialer.Dial(okxBusinessWSURL, nil)
+	logger, _ := getLogger(wsConn.AccountID, wsConn.Account().Name)
+	conn, closeConn, err := dialStream(wsConn.ctx, okxBusinessWSURL)
 	if err != nil {
 		if logger != nil {
 			logger.Log("Erro ao conectar OKX business: %v", err)
 		}
 		return false, false
 	}
-	defer conn.Close()
+	defer closeConn()
 
-	if err := wsm.loginOKX(conn, wsConn.Account, passphrase); err != nil {
+	syncOKXClock(wsConn)
+	if err := wsm.loginOKX(conn, wsConn.Account(), passphrase); err != nil {
 		if logger != nil {
 			logger.Log("Erro no login OKX business: %v", err)
 		}
@@ -346,7 +384,11 @@ func (wsm *WebSocketManager) connectAndListenOKXBusiness(wsConn *WebSocketConnec
 	}
 	time.Sleep(500 * time.Millisecond)
 
-	if err := wsm.subscribeOKXBusiness(conn); err != nil {
+	subscriptions := newSubscriptionTracker(wsConn.ctx, wsm, wsConn, rawStreamOKXBusiness, okxBusinessChannels, func(channel string) error {
+		return subscribeOKX(conn, channel)
+	})
+	defer subscriptions.stop()
+	if err := subscriptions.start(); err != nil {
 		if logger != nil {
 			logger.Log("Erro ao inscrever OKX business orders-algo: %v", err)
 		}
@@ -358,20 +400,20 @@ func (wsm *WebSocketManager) connectAndListenOKXBusiness(wsConn *WebSocketConnec
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
-	pingStopChan := make(chan struct{})
-	go wsm.pingLoop(conn, pingStopChan)
-	defer close(pingStopChan)
+	pingCtx, stopPing := context.WithCancel(wsConn.ctx)
+	defer stopPing()
+	wsConn.spawn(goroutinePing, func() { pingLoop(pingCtx, conn) })
 
 	for {
 		select {
-		case <-wsConn.StopChan:
+		case <-wsConn.ctx.Done():
 			return true, false
 		default:
 			conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 			messageType, message, err := conn.ReadMessage()
 			if err != nil {
 				select {
-				case <-wsConn.StopChan:
+				case <-wsConn.ctx.Done():
 					return true, false
 				default:
 				}
@@ -386,27 +428,33 @@ func (wsm *WebSocketManager) connectAndListenOKXBusiness(wsConn *WebSocketConnec
 				}
 				return false, true
 			}
+			wsConn.noteBytes(len(message))
 			if messageType != websocket.TextMessage {
 				continue
 			}
-			wsm.handleOKXAlgoMessage(wsConn, message, logger)
+			correlationID := newCorrelationID()
+			captureRawMessage(wsConn.AccountID, rawStreamOKXBusiness, correlationID, message)
+			wsConn.enqueueMessage(func() { wsm.handleOKXAlgoMessage(wsConn, correlationID, message, logger) })
 		}
 	}
 }
 
 // handleOKXMessage processa uma mensagem OKX: log raw, depois normaliza e chama handlers Bybit.
-func (wsm *WebSocketManager) handleOKXMessage(wsConn *WebSocketConnection, raw []byte, logger interface{ Log(string, ...interface{}) }) {
+func (wsm *WebSocketManager) handleOKXMessage(wsConn *WebSocketConnection, correlationID string, raw []byte, logger interface{ Log(string, ...interface{}) }) {
 	var generic map[string]interface{}
 	if err := json.Unmarshal(raw, &generic); err != nil {
 		return
 	}
-	event, _ := generic["event"].(string)
-	if event == "login" || event == "subscribe" || event == "unsubscribe" || event == "error" || event == "channel-conn-count" || event == "channel-conn-count-error" {
+	if handleOKXEvent(wsConn, rawStreamOKXPrivate, generic, logger) {
 		return
 	}
 
 	arg, _ := generic["arg"].(map[string]interface{})
 	channel, _ := arg["channel"].(string)
+	if channel != "" {
+		recordStat(wsConn.AccountID, statMessagesPrefix+channel, 1)
+		wsConn.countTopic(channel)
+	}
 
 	eventType, _ := generic["eventType"].(string)
 	if channel == "account" || channel == "positions" {
@@ -418,7 +466,7 @@ func (wsm *WebSocketManager) handleOKXMessage(wsConn *WebSocketConnection, raw [
 	// Log de todas as mensagens originais válidas da OKX (dados de push)
 	if channel == "account" || channel == "positions" || channel == "orders" {
 		if logger != nil {
-			logger.Log("[OKX raw] %s", string(raw))
+			logger.Log("[OKX raw] %s %s", correlationTag(correlationID), string(raw))
 		}
 	}
 
@@ -433,18 +481,17 @@ func (wsm *WebSocketManager) handleOKXMessage(wsConn *WebSocketConnection, raw [
 	case "positions":
 		wsm.processOKXPositions(wsConn, dataSlice)
 	case "orders":
-		wsm.processOKXOrders(wsConn, dataSlice, logger)
+		wsm.processOKXOrders(wsConn, correlationID, dataSlice, logger)
 	}
 }
 
 // handleOKXAlgoMessage processa mensagens do canal orders-algo (endpoint business). Só envia para handleOrderMessage ordens com state live (Untriggered) ou canceled/order_failed/partially_failed (Deactivated).
-func (wsm *WebSocketManager) handleOKXAlgoMessage(wsConn *WebSocketConnection, raw []byte, logger interface{ Log(string, ...interface{}) }) {
+func (wsm *WebSocketManager) handleOKXAlgoMessage(wsConn *WebSocketConnection, correlationID string, raw []byte, logger interface{ Log(string, ...interface{}) }) {
 	var generic map[string]interface{}
 	if err := json.Unmarshal(raw, &generic); err != nil {
 		return
 	}
-	event, _ := generic["event"].(string)
-	if event == "login" || event == "subscribe" || event == "unsubscribe" || event == "error" || event == "channel-conn-count" || event == "channel-conn-count-error" {
+	if handleOKXEvent(wsConn, rawStreamOKXBusiness, generic, logger) {
 		return
 	}
 	arg, _ := generic["arg"].(map[string]interface{})
@@ -452,12 +499,14 @@ func (wsm *WebSocketManager) handleOKXAlgoMessage(wsConn *WebSocketConnection, r
 	if channel != "orders-algo" {
 		return
 	}
+	recordStat(wsConn.AccountID, statMessagesPrefix+channel, 1)
+	wsConn.countTopic(channel)
 	dataSlice, ok := generic["data"].([]interface{})
 	if !ok || len(dataSlice) == 0 {
 		return
 	}
 	if logger != nil {
-		logger.Log("[OKX raw] orders-algo %s", string(raw))
+		logger.Log("[OKX raw] %s orders-algo %s", correlationTag(correlationID), string(raw))
 	}
 	var orders []OrderData
 	for _, d := range dataSlice {
@@ -476,7 +525,7 @@ func (wsm *WebSocketManager) handleOKXAlgoMessage(wsConn *WebSocketConnection, r
 		orders = append(orders, orderData)
 	}
 	if len(orders) > 0 {
-		msg := BybitOrderMessage{Data: orders}
+		msg := BybitOrderMessage{Data: orders, CorrelationID: correlationID}
 		wsm.handleOrderMessage(wsConn, msg)
 	}
 }
@@ -599,6 +648,15 @@ func okxSideToBybit(side string) string {
 	return side
 }
 
+// okxFeeToBybit inverte o sinal da taxa: na OKX fillFee negativo é taxa paga; na Bybit execFee positivo é taxa paga.
+func okxFeeToBybit(fillFee string) string {
+	f, err := strconv.ParseFloat(fillFee, 64)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatFloat(-f, 'f', -1, 64)
+}
+
 func okxNormalizePositionNumber(pos string) string {
 	clean := strings.ReplaceAll(pos, "-", "")
 	f, err := strconv.ParseFloat(clean, 64)
@@ -702,21 +760,34 @@ func (wsm *WebSocketManager) processOKXPositions(wsConn *WebSocketConnection, da
 		side := okxPositionSideToBybit(posSide, pos)
 		avgPx, _ := obj["avgPx"].(string)
 		markPx, _ := obj["markPx"].(string)
+		liqPx, _ := obj["liqPx"].(string)
+		realizedPnl, _ := obj["realizedPnl"].(string)
+		uTime, _ := obj["uTime"].(string)
+		positionIdx := 0
+		if posSide == "long" {
+			positionIdx = 1
+		} else if posSide == "short" {
+			positionIdx = 2
+		}
 
 		symbol := okxInstIdToSymbol(instId)
 		positions = append(positions, PositionData{
-			Symbol:        symbol,
-			Side:          side,
-			Size:          okxNormalizePositionNumber(pos),
-			EntryPrice:    avgPx,
-			MarkPrice:     markPx,
-			Category:      "inverse",
+			Symbol:         symbol,
+			Side:           side,
+			Size:           okxNormalizePositionNumber(pos),
+			EntryPrice:     avgPx,
+			MarkPrice:      markPx,
+			LiqPrice:       liqPx,
+			Category:       "inverse",
+			PositionIdx:    positionIdx,
+			CurRealisedPnl: realizedPnl,
+			UpdatedTime:    uTime,
 		})
 	}
 	if len(positions) > 0 {
 		_ = wsm.accountManager.UpdateOneWayMode(wsConn.AccountID, oneWayMode)
-		if wsConn.Account != nil {
-			wsConn.Account.OneWayMode = oneWayMode
+		if wsConn.Account() != nil {
+			wsConn.Account().OneWayMode = oneWayMode
 		}
 		msg := BybitPositionMessage{Data: positions}
 		wsm.handlePositionMessage(wsConn, msg)
@@ -726,6 +797,7 @@ func (wsm *WebSocketManager) processOKXPositions(wsConn *WebSocketConnection, da
 // okxOrderToBybit converte uma ordem OKX para o formato Bybit, incluindo mapeamento de stops (source "7").
 func okxOrderToBybit(obj map[string]interface{}, symbol string) (orderData OrderData, isStopTriggeredFill bool) {
 	ordId, _ := obj["ordId"].(string)
+	clOrdId, _ := obj["clOrdId"].(string)
 	state, _ := obj["state"].(string)
 	side, _ := obj["side"].(string)
 	ordType, _ := obj["ordType"].(string)
@@ -788,6 +860,7 @@ func okxOrderToBybit(obj map[string]interface{}, symbol string) (orderData Order
 	orderData = OrderData{
 		Category:      "inverse",
 		OrderID:       ordId,
+		OrderLinkID:   clOrdId,
 		Symbol:        symbol,
 		Side:          okxSideToBybit(side),
 		OrderType:     okxOrdTypeToBybit(ordType),
@@ -806,7 +879,7 @@ func okxOrderToBybit(obj map[string]interface{}, symbol string) (orderData Order
 	return orderData, isStopTriggeredFill
 }
 
-func (wsm *WebSocketManager) processOKXOrders(wsConn *WebSocketConnection, dataSlice []interface{}, logger interface{ Log(string, ...interface{}) }) {
+func (wsm *WebSocketManager) processOKXOrders(wsConn *WebSocketConnection, correlationID string, dataSlice []interface{}, logger interface{ Log(string, ...interface{}) }) {
 	var orders []OrderData
 	var executions []ExecutionData
 	for _, d := range dataSlice {
@@ -826,6 +899,8 @@ func (wsm *WebSocketManager) processOKXOrders(wsConn *WebSocketConnection, dataS
 		fillPx, _ := obj["fillPx"].(string)
 		fillTime, _ := obj["fillTime"].(string)
 		tradeId, _ := obj["tradeId"].(string)
+		fillFee, _ := obj["fillFee"].(string)
+		execType, _ := obj["execType"].(string)
 
 		symbol := okxInstIdToSymbol(instId)
 		orderData, isStopTriggeredFill := okxOrderToBybit(obj, symbol)
@@ -857,27 +932,31 @@ func (wsm *WebSocketManager) processOKXOrders(wsConn *WebSocketConnection, dataS
 			}
 			
 			executions = append(executions, ExecutionData{
-				Category:   "inverse",
-				Symbol:     symbol,
-				ExecType:   "Trade",
-				ExecPrice:  fillPx,
-				ExecQty:    execQty,
-				ExecValue:  execValue,
-				Side:       okxSideToBybit(side),
-				OrderID:    orderData.OrderID,
-				OrderType:  okxOrdTypeToBybit(ordType),
-				ExecTime:   fillTime,
-				CreateType: createType,
+				Category:    "inverse",
+				Symbol:      symbol,
+				ExecType:    "Trade",
+				ExecPrice:   fillPx,
+				ExecQty:     execQty,
+				ExecValue:   execValue,
+				Side:        okxSideToBybit(side),
+				OrderID:     orderData.OrderID,
+				OrderLinkID: orderData.OrderLinkID,
+				OrderType:   okxOrdTypeToBybit(ordType),
+				ExecTime:    fillTime,
+				CreateType:  createType,
+				ExecID:      tradeId,
+				ExecFee:     okxFeeToBybit(fillFee),
+				IsMaker:     execType == "M",
 			})
 		}
 		_ = isStopTriggeredFill
 	}
 	if len(orders) > 0 {
-		msg := BybitOrderMessage{Data: orders}
+		msg := BybitOrderMessage{Data: orders, CorrelationID: correlationID}
 		wsm.handleOrderMessage(wsConn, msg)
 	}
 	for _, exec := range executions {
-		msg := BybitExecutionMessage{Data: []ExecutionData{exec}}
+		msg := BybitExecutionMessage{Data: []ExecutionData{exec}, CorrelationID: correlationID}
 		wsm.handleExecutionMessage(wsConn, msg)
 	}
 }